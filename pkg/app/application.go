@@ -26,6 +26,7 @@ import (
 	"hidevops.io/hiboot/pkg/system"
 	"hidevops.io/hiboot/pkg/utils/cmap"
 	"hidevops.io/hiboot/pkg/utils/io"
+	"hidevops.io/hiboot/pkg/utils/mapstruct"
 	"os"
 	"reflect"
 	"strings"
@@ -50,6 +51,8 @@ type Application interface {
 type ApplicationContext interface {
 	RegisterController(controller interface{}) error
 	Use(handlers ...context.Handler)
+	UseManagement(handlers ...context.Handler)
+	Handle(method, path string, handlers ...context.Handler)
 	GetProperty(name string) (value interface{}, ok bool)
 	GetInstance(params ...interface{}) (instance interface{})
 }
@@ -112,6 +115,13 @@ func (a *BaseApplication) SetProperty(name string, value ...interface{}) Applica
 	return a
 }
 
+// SetInstance set the instance before the application is built, it is got from the factory by its name, e.g.
+// the in-memory listener of the test application
+func (a *BaseApplication) SetInstance(params ...interface{}) {
+	name, inst := factory.ParseParams(params...)
+	a.instances.Set(name, factory.NewMetaData(inst))
+}
+
 // GetProperty get application property
 func (a *BaseApplication) GetProperty(name string) (value interface{}, ok bool) {
 	value, ok = a.properties.Get(name)
@@ -153,8 +163,31 @@ func (a *BaseApplication) Build() {
 
 	a.systemConfig, _ = configurableFactory.BuildSystemConfig()
 
-	// set logging level
+	// set logging level, format and file
 	log.SetLevel(a.systemConfig.Logging.Level)
+	log.SetFormat(a.systemConfig.Logging.Format)
+	log.SetLevels(a.systemConfig.Logging.Levels)
+	a.setLoggingFile(a.systemConfig.Logging.File)
+}
+
+func (a *BaseApplication) setLoggingFile(file system.LoggingFile) {
+	if file.Path == "" {
+		return
+	}
+	maxSize, err := mapstruct.ParseSize(file.MaxSize)
+	if err == nil {
+		err = log.SetFile(log.FileOptions{
+			Path:       file.Path,
+			MaxSize:    maxSize,
+			Interval:   file.Interval,
+			MaxBackups: file.MaxBackups,
+			MaxAge:     file.MaxAge,
+			Compress:   file.Compress,
+		})
+	}
+	if err != nil {
+		log.Errorf("failed to write log to file %v: %v", file.Path, err)
+	}
 }
 
 // SystemConfig returns application config
@@ -182,11 +215,12 @@ func (a *BaseApplication) SystemConfig() *system.Configuration {
 }
 
 // BuildConfigurations get BuildConfigurations
-func (a *BaseApplication) BuildConfigurations() {
+func (a *BaseApplication) BuildConfigurations() (err error) {
 	// build configurations
 	a.configurableFactory.Build(configContainer)
 	// build components
-	a.configurableFactory.BuildComponents()
+	err = a.configurableFactory.BuildComponents()
+	return
 }
 
 // ConfigurableFactory get ConfigurableFactory
@@ -211,6 +245,14 @@ func (a *BaseApplication) RegisterController(controller interface{}) error {
 func (a *BaseApplication) Use(handlers ...context.Handler) {
 }
 
+// UseManagement use middleware handlers for management server
+func (a *BaseApplication) UseManagement(handlers ...context.Handler) {
+}
+
+// Handle register the handlers of the route, the web application serves them through the same middleware as the controllers
+func (a *BaseApplication) Handle(method, path string, handlers ...context.Handler) {
+}
+
 // SetAddCommandLineProperties set add command line properties to be enabled or disabled
 func (a *BaseApplication) SetAddCommandLineProperties(enabled bool) Application {
 	a.addCommandLineProperties = enabled
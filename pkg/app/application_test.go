@@ -18,7 +18,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/log"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -206,3 +208,45 @@ func TestBaseApplication(t *testing.T) {
 	ba.GetInstance("foo")
 
 }
+
+func TestSetInstance(t *testing.T) {
+	type fakeListener struct{ addr string }
+	ba := new(app.BaseApplication)
+	err := ba.Initialize()
+	assert.Equal(t, nil, err)
+
+	lis := &fakeListener{addr: "bufconn"}
+	ba.SetInstance(lis)
+	ba.Build()
+	assert.Equal(t, lis, ba.GetInstance(new(fakeListener)))
+}
+
+func TestLoggingProperties(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logging")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	ba := new(app.BaseApplication)
+	err = ba.Initialize()
+	assert.Equal(t, nil, err)
+	ba.SetProperty("logging.levels.factory", log.DebugLevel).
+		SetProperty("logging.file.path", path).
+		SetProperty("logging.file.maxSize", "1MB")
+	ba.Build()
+	defer func() {
+		log.SetLevels(nil)
+		log.SetFile(log.FileOptions{})
+	}()
+
+	t.Run("should set level overrides", func(t *testing.T) {
+		assert.Equal(t, log.DebugLevel, log.GetLevels()["factory"])
+	})
+
+	t.Run("should write log to file", func(t *testing.T) {
+		log.Error("written to file")
+		b, err := ioutil.ReadFile(path)
+		assert.Equal(t, nil, err)
+		assert.Contains(t, string(b), "written to file")
+	})
+}
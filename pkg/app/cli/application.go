@@ -72,7 +72,9 @@ func (a *application) build() error {
 	f.SetInstance(app.ApplicationContextName, a)
 
 	// build auto configurations
-	a.BuildConfigurations()
+	if err := a.BuildConfigurations(); err != nil {
+		return err
+	}
 
 	// set root command
 	r := f.GetInstance(RootCommandName)
@@ -31,6 +31,16 @@ func (a *ApplicationContext) Use(handlers ...context.Handler) {
 
 }
 
+// UseManagement use middleware for management server
+func (a *ApplicationContext) UseManagement(handlers ...context.Handler) {
+
+}
+
+// Handle register the handlers of the route
+func (a *ApplicationContext) Handle(method, path string, handlers ...context.Handler) {
+
+}
+
 // GetProperty get application property by name
 func (a *ApplicationContext) GetProperty(name string) (value interface{}, ok bool) {
 	return
@@ -22,6 +22,7 @@ import (
 	"hidevops.io/hiboot/pkg/app/web/context"
 	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/system"
 	"hidevops.io/hiboot/pkg/utils/io"
 	"hidevops.io/hiboot/pkg/utils/str"
 	"os"
@@ -47,6 +48,13 @@ func newWebApplication() *webApp {
 	}
 }
 
+// route is the route that is not mapped to the controllers
+type route struct {
+	method   string
+	path     string
+	handlers []context.Handler
+}
+
 // Application is the struct of web Application
 type application struct {
 	app.BaseApplication
@@ -56,6 +64,12 @@ type application struct {
 	//jwtControllers  []interface{}
 	controllers []interface{}
 	dispatcher  *Dispatcher
+	// the routes that are handled before the post processors are done, they are registered after them
+	routes      []route
+	initialized bool
+	// the management server, it is nil unless management.server.port is set
+	managementApp      *webApp
+	managementHandlers []context.Handler
 	//controllerMap map[string][]interface{}
 	startUpTime time.Time
 }
@@ -91,11 +105,24 @@ func (a *application) Run() {
 	if conf != nil && conf.Server.Port != "" {
 		serverPort = fmt.Sprintf(":%v", conf.Server.Port)
 	}
+	var run iris.Runner
 	if err == nil {
-		log.Infof("Hiboot started on port(s) http://localhost%v", serverPort)
+		run, err = runner(serverPort, &conf.Server.SSL)
+	}
+	if err == nil {
+		if a.managementApp != nil {
+			go a.runManagement()
+		}
+		scheme := "http"
+		if conf.Server.SSL.Enabled {
+			scheme = "https"
+		}
+		log.Infof("Hiboot started on port(s) %v://localhost%v", scheme, serverPort)
 		timeDiff := time.Since(a.startUpTime)
 		log.Infof("Started %v in %f seconds", conf.App.Name, timeDiff.Seconds())
-		err = a.webApp.Run(iris.Addr(fmt.Sprintf(serverPort)), iris.WithConfiguration(defaultConfiguration()))
+		err = a.webApp.Run(run, iris.WithConfiguration(defaultConfiguration()))
+	} else {
+		log.Errorf("failed to run web application: %v", err)
 	}
 }
 
@@ -127,7 +154,9 @@ func (a *application) build() (err error) {
 	}
 
 	// build auto configurations
-	a.BuildConfigurations()
+	if err = a.BuildConfigurations(); err != nil {
+		return
+	}
 
 	// create dispatcher
 	a.dispatcher = a.GetInstance(Dispatcher{}).(*Dispatcher)
@@ -135,8 +164,25 @@ func (a *application) build() (err error) {
 	// first register anon controllers
 	a.RegisterController(new(at.RestController))
 
+	// the management controllers are served by the main server unless management.server.port is set
+	separateManagement := a.separateManagement()
+	if !separateManagement {
+		a.RegisterController(new(at.ManagementController))
+	}
+
 	// call AfterInitialization with factory interface
 	a.AfterInitialization()
+
+	// register the routes after post processors, as they may apply middleware, e.g. jwt
+	a.initialized = true
+	for _, r := range a.routes {
+		a.dispatcher.handle(a.webApp, r.method, r.path, r.handlers...)
+	}
+
+	// build management server after post processors, as they may apply middleware to it
+	if separateManagement {
+		err = a.buildManagement()
+	}
 	return err
 }
 
@@ -159,8 +205,24 @@ func (a *application) Use(handlers ...context.Handler) {
 	}
 }
 
+// Handle register the handlers of the route on the main server, e.g. the routes that are not mapped to the controllers,
+// they are registered after the post processors, so that they are served through the same middleware, authorization
+// rules and audit as the controllers
+func (a *application) Handle(method, path string, handlers ...context.Handler) {
+	if !a.initialized {
+		a.routes = append(a.routes, route{method: method, path: path, handlers: handlers})
+		return
+	}
+	a.dispatcher.handle(a.webApp, method, path, handlers...)
+}
+
 func (a *application) initialize(controllers ...interface{}) (err error) {
-	io.EnsureWorkDir(3, "config/application.yml")
+	// the config file can be in any supported format
+	for _, ext := range system.MergeOrder {
+		if io.EnsureWorkDir(3, "config/application."+ext) {
+			break
+		}
+	}
 
 	// new iris app
 	a.webApp = newWebApplication()
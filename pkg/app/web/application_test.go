@@ -748,3 +748,23 @@ func TestAnonymousController(t *testing.T) {
 		assert.NotEqual(t, nil, testApp)
 	})
 }
+
+func TestHandle(t *testing.T) {
+	testApp := web.NewTestApp(new(ExampleController))
+	testApp.(app.ApplicationContext).Handle(http.MethodGet, "/handled/{name}", func(ctx context.Context) {
+		ctx.WriteString("hello " + ctx.Params().Get("name"))
+	})
+
+	testApp.Run(t)
+
+	t.Run("should serve the handled route through the middleware of jwt", func(t *testing.T) {
+		testApp.Get("/handled/foo").Expect().Status(http.StatusUnauthorized)
+	})
+
+	t.Run("should handle the route that is not mapped to the controllers", func(t *testing.T) {
+		token, err := jwt.GenerateTestToken(testApp.(app.ApplicationContext), "johndoe", nil)
+		assert.Equal(t, nil, err)
+		testApp.Get("/handled/foo").WithHeader("Authorization", token).
+			Expect().Status(http.StatusOK).Body().Equal("hello foo")
+	})
+}
@@ -0,0 +1,494 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/reflector"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// AuditLogSink is the name of the built-in sink that writes the audit records to the log
+	AuditLogSink = "log"
+	// AuditFileSink is the name of the built-in sink that writes the audit records to the file in json lines
+	AuditFileSink = "file"
+
+	// AuditMasked is the value of the masked field
+	AuditMasked = "******"
+
+	auditTag       = "audit"
+	auditTagMask   = "mask"
+	auditTruncated = "...(truncated)"
+)
+
+// AuditFile is the properties of the audit file sink
+type AuditFile struct {
+	// the path of the audit file
+	Path string `json:"path" default:"logs/audit.log"`
+	// the max size of the audit file before it is rotated, e.g. 100MB, 0 for no limit
+	MaxSize int64 `json:"max_size" mapstructure:"maxSize" default:"100MB"`
+	// the max number of the rotated audit files to retain, 0 for all
+	MaxBackups int `json:"max_backups" mapstructure:"maxBackups" default:"7"`
+	// compress the rotated audit files with gzip
+	Compress bool `json:"compress"`
+}
+
+// AuditProperties is the properties of audit, e.g. web.audit.enabled: true
+type AuditProperties struct {
+	at.ConfigurationProperties `value:"web.audit"`
+
+	// audit the requests of all controllers, otherwise only the controllers that embedded at.Audit are audited
+	Enabled bool `json:"enabled"`
+	// the max size of the request and response in the record, the exceeded one is truncated on the rune boundary, 0 for no limit
+	MaxBodySize int64 `json:"max_body_size" mapstructure:"maxBodySize" default:"4KB"`
+	// the json paths of the fields to be masked, e.g. password, $.user.password or data.*.token, arrays are traversed
+	MaskPaths []string `json:"mask_paths" mapstructure:"maskPaths"`
+	// the built-in sinks, log and file, the components that embedded at.AuditSink are always used
+	Sinks []string `json:"sinks" default:"log"`
+	// the properties of the file sink
+	File AuditFile `json:"file"`
+}
+
+// AuditRecord is the audit record of a request
+type AuditRecord struct {
+	Time      time.Time     `json:"time"`
+	RequestID string        `json:"request_id,omitempty"`
+	Principal string        `json:"principal,omitempty"`
+	Method    string        `json:"method"`
+	Route     string        `json:"route"`
+	Path      string        `json:"path"`
+	Status    int           `json:"status"`
+	Duration  time.Duration `json:"duration"`
+	Request   string        `json:"request,omitempty"`
+	Response  string        `json:"response,omitempty"`
+}
+
+// AuditSink writes the audit records, the component that embedded at.AuditSink and implemented AuditSink is used by audit
+type AuditSink interface {
+	Write(record *AuditRecord) error
+}
+
+// PrincipalResolver resolves the principal of the audited request,
+// the component that embedded at.AuditPrincipal and implemented PrincipalResolver is used by audit
+type PrincipalResolver interface {
+	Principal(ctx context.Context) string
+}
+
+// LogAuditSink writes the audit records to the log
+type LogAuditSink struct{}
+
+// Write writes the audit record to the log
+func (s *LogAuditSink) Write(record *AuditRecord) error {
+	log.WithFields(log.Fields{
+		log.RequestIDKey: record.RequestID,
+		"principal":      record.Principal,
+		"method":         record.Method,
+		"route":          record.Route,
+		"path":           record.Path,
+		"status":         record.Status,
+		"duration":       record.Duration.String(),
+		"request":        record.Request,
+		"response":       record.Response,
+	}).Info("audit")
+	return nil
+}
+
+// FileAuditSink writes the audit records to the rotating file in json lines
+type FileAuditSink struct {
+	file *log.RotatingFile
+}
+
+// NewFileAuditSink is the constructor of FileAuditSink
+func NewFileAuditSink(options log.FileOptions) (sink *FileAuditSink, err error) {
+	var file *log.RotatingFile
+	file, err = log.NewRotatingFile(options)
+	if err == nil {
+		sink = &FileAuditSink{file: file}
+	}
+	return
+}
+
+// Write writes the audit record to the file
+func (s *FileAuditSink) Write(record *AuditRecord) (err error) {
+	var b []byte
+	b, err = json.Marshal(record)
+	if err == nil {
+		_, err = s.file.Write(append(b, '\n'))
+	}
+	return
+}
+
+// Close closes the audit file
+func (s *FileAuditSink) Close() error {
+	return s.file.Close()
+}
+
+type auditor struct {
+	auditProperties     *AuditProperties
+	configurableFactory factory.ConfigurableFactory
+
+	sinks      []AuditSink
+	principals []PrincipalResolver
+	maskPaths  [][]string
+}
+
+func newAuditProperties() *AuditProperties {
+	return &AuditProperties{}
+}
+
+func newAuditor(properties *AuditProperties, configurableFactory factory.ConfigurableFactory) *auditor {
+	return &auditor{
+		auditProperties:     properties,
+		configurableFactory: configurableFactory,
+	}
+}
+
+func init() {
+	app.Register(newAuditProperties, newAuditor)
+}
+
+// enabled returns true if the requests of the controller should be audited
+func (a *auditor) enabled(controller interface{}) bool {
+	return a != nil && (a.auditProperties.Enabled || reflector.HasEmbeddedFieldType(controller, new(at.Audit)))
+}
+
+// load loads the sinks of audit.sinks and the components, the principal resolvers and the mask paths
+func (a *auditor) load() {
+	for _, name := range a.auditProperties.Sinks {
+		switch strings.TrimSpace(name) {
+		case AuditLogSink:
+			a.sinks = append(a.sinks, new(LogAuditSink))
+		case AuditFileSink:
+			file := a.auditProperties.File
+			sink, err := NewFileAuditSink(log.FileOptions{
+				Path:       file.Path,
+				MaxSize:    file.MaxSize,
+				MaxBackups: file.MaxBackups,
+				Compress:   file.Compress,
+			})
+			if err != nil {
+				log.Errorf("failed to open audit file %v: %v", file.Path, err)
+				continue
+			}
+			a.sinks = append(a.sinks, sink)
+		case "":
+		default:
+			log.Warnf("unknown audit sink: %v", name)
+		}
+	}
+
+	for _, md := range a.configurableFactory.GetInstances(new(at.AuditSink)) {
+		if sink, ok := factory.CastMetaData(md).Instance.(AuditSink); ok {
+			a.sinks = append(a.sinks, sink)
+		}
+	}
+	for _, md := range a.configurableFactory.GetInstances(new(at.AuditPrincipal)) {
+		if resolver, ok := factory.CastMetaData(md).Instance.(PrincipalResolver); ok {
+			a.principals = append(a.principals, resolver)
+		}
+	}
+
+	for _, p := range a.auditProperties.MaskPaths {
+		p = strings.Replace(strings.TrimPrefix(strings.TrimSpace(p), "$."), "[*]", "", -1)
+		if p != "" {
+			a.maskPaths = append(a.maskPaths, strings.Split(p, "."))
+		}
+	}
+}
+
+// audit writes the audit record of the request to the sinks
+func (a *auditor) audit(ctx context.Context, route string, start time.Time, request, response interface{}) {
+	record := &AuditRecord{
+		Time:      start,
+		RequestID: log.RequestID(ctx.Request().Context()),
+		Method:    ctx.Method(),
+		Route:     route,
+		Path:      ctx.Path(),
+		Status:    ctx.GetStatusCode(),
+		Duration:  time.Since(start),
+		Request:   a.body(request),
+		Response:  a.body(response),
+	}
+	for _, resolver := range a.principals {
+		if record.Principal = resolver.Principal(ctx); record.Principal != "" {
+			break
+		}
+	}
+
+	for _, sink := range a.sinks {
+		if err := sink.Write(record); err != nil {
+			log.Errorf("failed to write audit record of %v %v: %v", record.Method, record.Path, err)
+		}
+	}
+}
+
+// body returns the masked json of the request or response, it is truncated on the rune boundary if it exceeds the max body size
+func (a *auditor) body(data interface{}) (retVal string) {
+	if data == nil {
+		return
+	}
+	val := maskValue(reflect.ValueOf(data))
+	for _, p := range a.maskPaths {
+		maskPath(val, p)
+	}
+	w := &auditWriter{limit: int(a.auditProperties.MaxBodySize)}
+	err := w.encode(val)
+	switch err {
+	case nil:
+		retVal = w.buf.String()
+	case errAuditTruncated:
+		retVal = string(trimPartialRune(w.buf.Bytes())) + auditTruncated
+	default:
+		log.Warnf("failed to marshal audit body: %v", err)
+	}
+	return
+}
+
+var errAuditTruncated = errors.New("audit body is truncated")
+
+// auditWriter writes the json of the masked value, it stops once the limit is reached so that
+// the memory of a large body is bounded by the max body size
+type auditWriter struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (w *auditWriter) remaining() int {
+	return w.limit - w.buf.Len()
+}
+
+func (w *auditWriter) write(b []byte) error {
+	if w.limit > 0 && len(b) > w.remaining() {
+		w.buf.Write(b[:w.remaining()])
+		return errAuditTruncated
+	}
+	w.buf.Write(b)
+	return nil
+}
+
+func (w *auditWriter) encode(v interface{}) (err error) {
+	switch n := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(n))
+		for key := range n {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		err = w.write([]byte("{"))
+		for i, key := range keys {
+			if err == nil && i > 0 {
+				err = w.write([]byte(","))
+			}
+			if err == nil {
+				err = w.encode(key)
+			}
+			if err == nil {
+				err = w.write([]byte(":"))
+			}
+			if err == nil {
+				err = w.encode(n[key])
+			}
+		}
+		if err == nil {
+			err = w.write([]byte("}"))
+		}
+	case []interface{}:
+		err = w.write([]byte("["))
+		for i, elem := range n {
+			if err == nil && i > 0 {
+				err = w.write([]byte(","))
+			}
+			if err == nil {
+				err = w.encode(elem)
+			}
+		}
+		if err == nil {
+			err = w.write([]byte("]"))
+		}
+	case string:
+		// the quote is written before the string, so the cut never reaches the end of the shortened one
+		if w.limit > 0 && len(n) > w.remaining() {
+			n = n[:w.remaining()]
+		}
+		err = w.marshal(n)
+	case []byte:
+		// base64 encodes 3 bytes in 4
+		if w.limit > 0 && len(n) > w.remaining()*3/4+3 {
+			n = n[:w.remaining()*3/4+3]
+		}
+		err = w.marshal(n)
+	default:
+		err = w.marshal(n)
+	}
+	return
+}
+
+func (w *auditWriter) marshal(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.write(b)
+}
+
+// trimPartialRune removes the incomplete utf-8 sequence at the end of b
+func trimPartialRune(b []byte) []byte {
+	for i := 0; i < utf8.UTFMax && len(b) > 0; i++ {
+		if r, size := utf8.DecodeLastRune(b); r != utf8.RuneError || size > 1 {
+			break
+		}
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// maskValue converts the value to the generic json value, the struct fields tagged with `audit:"mask"` are masked,
+// the json of json.Marshaler is decoded again so that the mask paths apply to it, but the audit tags inside it do not
+func maskValue(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Implements(errorType) {
+			return v.Interface().(error).Error()
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	if v.CanInterface() && (v.Type().Implements(jsonMarshalerType) || reflect.PtrTo(v.Type()).Implements(jsonMarshalerType)) {
+		return remarshal(v.Interface())
+	}
+	if v.CanInterface() && v.Type().Implements(textMarshalerType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		maskFields(v, m)
+		return m
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		m := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			m[key.String()] = maskValue(v.MapIndex(key))
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		s := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s[i] = maskValue(v.Index(i))
+		}
+		return s
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+	}
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return nil
+}
+
+// remarshal decodes the json of the json.Marshaler into the generic json value
+func remarshal(val interface{}) interface{} {
+	b, err := json.Marshal(val)
+	if err != nil {
+		return val
+	}
+	var retVal interface{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&retVal); err != nil {
+		return val
+	}
+	return retVal
+}
+
+// maskFields puts the fields of struct into m by their json names, the fields of embedded structs are promoted
+func maskFields(v reflect.Value, m map[string]interface{}) {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.SplitN(tag, ",", 2)[0]
+		fv := v.Field(i)
+		if field.Anonymous && name == "" {
+			if ft := reflect.Indirect(fv); ft.IsValid() && ft.Kind() == reflect.Struct {
+				maskFields(ft, m)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if field.Tag.Get(auditTag) == auditTagMask {
+			m[name] = AuditMasked
+		} else {
+			m[name] = maskValue(fv)
+		}
+	}
+}
+
+// maskPath masks the value matched by path, * matches any key, arrays are traversed
+func maskPath(node interface{}, path []string) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key, val := range n {
+			if path[0] == "*" || path[0] == key {
+				if len(path) == 1 {
+					n[key] = AuditMasked
+				} else {
+					maskPath(val, path[1:])
+				}
+			}
+		}
+	case []interface{}:
+		for _, val := range n {
+			maskPath(val, path)
+		}
+	}
+}
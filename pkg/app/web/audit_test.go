@@ -0,0 +1,239 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web_test
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/model"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+type auditSink struct {
+	at.AuditSink
+	sync.Mutex
+	records []*web.AuditRecord
+}
+
+func (s *auditSink) Write(record *web.AuditRecord) error {
+	s.Lock()
+	defer s.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *auditSink) reset() (records []*web.AuditRecord) {
+	s.Lock()
+	defer s.Unlock()
+	records, s.records = s.records, nil
+	return
+}
+
+type auditCard struct {
+	Number string `json:"number"`
+	Holder string `json:"holder"`
+}
+
+type auditRequest struct {
+	at.RequestBody
+	Username string      `json:"username"`
+	Password string      `json:"password" audit:"mask"`
+	Cards    []auditCard `json:"cards"`
+}
+
+type auditResponse struct {
+	Token string `json:"token" audit:"mask"`
+	Name  string `json:"name"`
+}
+
+type auditController struct {
+	at.RestController
+	at.Audit
+}
+
+func newAuditController() *auditController {
+	return &auditController{}
+}
+
+func (c *auditController) Post(request *auditRequest) (response model.Response, err error) {
+	response = new(model.BaseResponse)
+	response.SetData(&auditResponse{Token: "s3cr3t", Name: request.Username})
+	return
+}
+
+func (c *auditController) GetByName(name string) string {
+	return strings.Repeat(name, 50)
+}
+
+func (c *auditController) GetUnicode() string {
+	return strings.Repeat("中", 100)
+}
+
+func (c *auditController) GetRaw() (response model.Response) {
+	response = new(model.BaseResponse)
+	response.SetData(json.RawMessage(`{"name":"john","id":12345678901234567890}`))
+	return
+}
+
+type unauditedController struct {
+	at.RestController
+}
+
+func newUnauditedController() *unauditedController {
+	return &unauditedController{}
+}
+
+func (c *unauditedController) Get() string {
+	return "unaudited"
+}
+
+func newAuditSink() *auditSink {
+	return &auditSink{}
+}
+
+func init() {
+	app.Register(newAuditSink)
+}
+
+func TestAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	auditFile := filepath.Join(dir, "audit.log")
+
+	testApp := web.NewTestApp(newAuditController, newUnauditedController).
+		SetProperty("web.audit.maskPaths", "$.data.name,cards[*].number").
+		SetProperty("web.audit.maxBodySize", "256").
+		SetProperty("web.audit.sinks", "log,file").
+		SetProperty("web.audit.file.path", auditFile).
+		Run(t)
+	sink := testApp.(app.ApplicationContext).GetInstance(auditSink{}).(*auditSink)
+	sink.reset()
+
+	t.Run("should audit request and response with masked fields", func(t *testing.T) {
+		testApp.Post("/audit").
+			WithJSON(map[string]interface{}{
+				"username": "john",
+				"password": "p@ssw0rd",
+				"cards":    []map[string]string{{"number": "4111111111111111", "holder": "john"}},
+			}).
+			Expect().Status(http.StatusOK)
+
+		records := sink.reset()
+		assert.Equal(t, 1, len(records))
+		record := records[0]
+		assert.Equal(t, http.MethodPost, record.Method)
+		assert.Equal(t, "/audit", record.Route)
+		assert.Equal(t, "/audit", record.Path)
+		assert.Equal(t, http.StatusOK, record.Status)
+		assert.Equal(t, true, record.Duration > 0)
+
+		var request map[string]interface{}
+		err := json.Unmarshal([]byte(record.Request), &request)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "john", request["username"])
+		assert.Equal(t, web.AuditMasked, request["password"])
+		card := request["cards"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, web.AuditMasked, card["number"])
+		assert.Equal(t, "john", card["holder"])
+
+		var response map[string]interface{}
+		err = json.Unmarshal([]byte(record.Response), &response)
+		assert.Equal(t, nil, err)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, web.AuditMasked, data["token"])
+		assert.Equal(t, web.AuditMasked, data["name"])
+	})
+
+	t.Run("should audit route template and truncate the exceeded body", func(t *testing.T) {
+		testApp.Get("/audit/name/{name}", "hiboot").
+			Expect().Status(http.StatusOK)
+
+		records := sink.reset()
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, "/audit/name/{name}", records[0].Route)
+		assert.Equal(t, "/audit/name/hiboot", records[0].Path)
+		assert.Equal(t, "", records[0].Request)
+		assert.Equal(t, 256+len("...(truncated)"), len(records[0].Response))
+		assert.Equal(t, true, strings.HasSuffix(records[0].Response, "...(truncated)"))
+	})
+
+	t.Run("should truncate the exceeded body on the rune boundary", func(t *testing.T) {
+		testApp.Get("/audit/unicode").
+			Expect().Status(http.StatusOK)
+
+		records := sink.reset()
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, true, utf8.ValidString(records[0].Response))
+		assert.Equal(t, true, strings.HasSuffix(records[0].Response, "中...(truncated)"))
+		assert.Equal(t, true, len(records[0].Response) <= 256+len("...(truncated)"))
+	})
+
+	t.Run("should apply the mask paths to the json marshaler", func(t *testing.T) {
+		testApp.Get("/audit/raw").
+			Expect().Status(http.StatusOK)
+
+		records := sink.reset()
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, false, strings.Contains(records[0].Response, "john"))
+		assert.Equal(t, true, strings.Contains(records[0].Response, `"id":12345678901234567890`))
+	})
+
+	t.Run("should not audit the controller without at.Audit", func(t *testing.T) {
+		testApp.Get("/unaudited").
+			Expect().Status(http.StatusOK)
+		assert.Equal(t, 0, len(sink.reset()))
+	})
+
+	t.Run("should write audit records to file", func(t *testing.T) {
+		b, err := ioutil.ReadFile(auditFile)
+		assert.Equal(t, nil, err)
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		assert.Equal(t, 4, len(lines))
+
+		var record web.AuditRecord
+		err = json.Unmarshal([]byte(lines[0]), &record)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "/audit", record.Route)
+		assert.Equal(t, false, strings.Contains(lines[0], "p@ssw0rd"))
+	})
+}
+
+func TestAuditAll(t *testing.T) {
+	testApp := web.NewTestApp(newUnauditedController).
+		SetProperty("web.audit.enabled", true).
+		SetProperty("web.audit.sinks", "").
+		Run(t)
+	sink := testApp.(app.ApplicationContext).GetInstance(auditSink{}).(*auditSink)
+	sink.reset()
+
+	t.Run("should audit all controllers if web.audit.enabled is true", func(t *testing.T) {
+		testApp.Get("/unaudited").
+			Expect().Status(http.StatusOK)
+		records := sink.reset()
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, "/unaudited", records[0].Route)
+	})
+}
@@ -0,0 +1,262 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/utils/pathmatch"
+	"hidevops.io/hiboot/pkg/utils/reflector"
+	"net/http"
+	"strings"
+)
+
+const (
+	// LogicalAnd requires all roles or permissions
+	LogicalAnd = "and"
+	// LogicalOr requires any of the roles or permissions
+	LogicalOr = "or"
+
+	wildcard = "*"
+)
+
+// Principal is the authenticated principal of the request
+type Principal struct {
+	Name        string   `json:"name"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// HasRole returns true if the principal has the role
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission returns true if any of the granted permissions implies the permission, e.g. order:* implies order:read
+func (p *Principal) HasPermission(permission string) bool {
+	for _, granted := range p.Permissions {
+		if implies(granted, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// implies returns true if the granted permission implies the required one, the parts are separated by colons,
+// * matches any part, and the missing parts of the granted permission are implied, e.g. order implies order:read
+func implies(granted, required string) bool {
+	g := strings.Split(granted, ":")
+	r := strings.Split(required, ":")
+	for i, part := range g {
+		if i >= len(r) {
+			if part != wildcard {
+				return false
+			}
+			continue
+		}
+		if part != wildcard && part != r[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// PrincipalProvider provides the principal of the request, nil if the request is not authenticated,
+// the component that embedded at.PrincipalProvider and implemented PrincipalProvider is used by authorization
+type PrincipalProvider interface {
+	GetPrincipal(ctx context.Context) *Principal
+}
+
+// AuthorizationRule is the authorization rule of the routes
+type AuthorizationRule struct {
+	// the http method, empty or ANY for all methods
+	Method string `json:"method"`
+	// the route template, e.g. /order/{id}, or the pattern, e.g. /order/*, /order/** matches all routes under /order
+	Path string `json:"path"`
+	// the required roles
+	Roles []string `json:"roles"`
+	// the required permissions
+	Permissions []string `json:"permissions"`
+	// and requires all roles and permissions, or requires any of the roles and any of the permissions
+	Logical string `json:"logical" default:"and"`
+}
+
+// AuthorizationProperties is the properties of authorization, e.g.
+//
+//	web:
+//	  authorization:
+//	    rules:
+//	    - method: DELETE
+//	      path: /order/{id}
+//	      roles: admin
+type AuthorizationProperties struct {
+	at.ConfigurationProperties `value:"web.authorization"`
+
+	// the rules that are checked in addition to at.RequiresRoles and at.RequiresPermissions of the controllers
+	Rules []AuthorizationRule `json:"rules"`
+}
+
+// matches returns true if the rule applies to the route of the method
+func (r *AuthorizationRule) matches(method, route string) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, Any) && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	return pathmatch.Match(r.Path, route)
+}
+
+// requirement is the roles and permissions that are required by the annotation or the rule
+type requirement struct {
+	roles       []string
+	permissions []string
+	any         bool
+}
+
+// satisfied returns true if the principal has the required roles and permissions
+func (r *requirement) satisfied(p *Principal) bool {
+	return r.check(r.roles, p.HasRole) && r.check(r.permissions, p.HasPermission)
+}
+
+func (r *requirement) check(required []string, has func(string) bool) bool {
+	if len(required) == 0 {
+		return true
+	}
+	for _, item := range required {
+		if has(item) == r.any {
+			return r.any
+		}
+	}
+	return !r.any
+}
+
+type authorizer struct {
+	authorizationProperties *AuthorizationProperties
+	configurableFactory     factory.ConfigurableFactory
+
+	providers []PrincipalProvider
+}
+
+func newAuthorizationProperties() *AuthorizationProperties {
+	return &AuthorizationProperties{}
+}
+
+func newAuthorizer(properties *AuthorizationProperties, configurableFactory factory.ConfigurableFactory) *authorizer {
+	return &authorizer{
+		authorizationProperties: properties,
+		configurableFactory:     configurableFactory,
+	}
+}
+
+func init() {
+	app.Register(newAuthorizationProperties, newAuthorizer)
+}
+
+// requirements returns the requirements of the annotations of the controller and the rules of the route
+func (a *authorizer) requirements(controller interface{}, method, route string) (reqs []*requirement) {
+	if a == nil {
+		return
+	}
+	roles, hasRoles := a.annotation(controller, "RequiresRoles")
+	permissions, hasPermissions := a.annotation(controller, "RequiresPermissions")
+	if hasRoles {
+		reqs = append(reqs, roles)
+	}
+	if hasPermissions {
+		reqs = append(reqs, permissions)
+	}
+	for i := range a.authorizationProperties.Rules {
+		rule := &a.authorizationProperties.Rules[i]
+		if rule.matches(method, route) {
+			reqs = append(reqs, &requirement{
+				roles:       split(rule.Roles),
+				permissions: split(rule.Permissions),
+				any:         strings.EqualFold(rule.Logical, LogicalOr),
+			})
+		}
+	}
+	return
+}
+
+// annotation parses the embedded at.RequiresRoles or at.RequiresPermissions of the controller
+func (a *authorizer) annotation(controller interface{}, name string) (req *requirement, ok bool) {
+	var value string
+	value, ok = reflector.FindEmbeddedFieldTag(controller, name, "value")
+	if !ok {
+		return
+	}
+	value = fmt.Sprintf("%v", a.configurableFactory.Replace(value))
+	logical, _ := reflector.FindEmbeddedFieldTag(controller, name, "logical")
+	req = &requirement{any: strings.EqualFold(logical, LogicalOr)}
+	if name == "RequiresRoles" {
+		req.roles = split([]string{value})
+	} else {
+		req.permissions = split([]string{value})
+	}
+	return
+}
+
+// load loads the components that embed at.PrincipalProvider
+func (a *authorizer) load() {
+	for _, md := range a.configurableFactory.GetInstances(new(at.PrincipalProvider)) {
+		if provider, ok := factory.CastMetaData(md).Instance.(PrincipalProvider); ok {
+			a.providers = append(a.providers, provider)
+		}
+	}
+}
+
+// principal returns the principal of the first provider that authenticates the request
+func (a *authorizer) principal(ctx context.Context) *Principal {
+	for _, provider := range a.providers {
+		if p := provider.GetPrincipal(ctx); p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// authorize responds 401 if the request is not authenticated, or 403 if any requirement is not satisfied
+func (a *authorizer) authorize(ctx context.Context, reqs []*requirement) bool {
+	p := a.principal(ctx)
+	if p == nil {
+		ctx.ResponseError("unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	for _, req := range reqs {
+		if !req.satisfied(p) {
+			ctx.ResponseError("forbidden", http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
+
+// split splits the comma separated items and trims the spaces
+func split(items []string) (retVal []string) {
+	for _, item := range items {
+		for _, s := range strings.Split(item, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				retVal = append(retVal, s)
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// headerPrincipalProvider provides the principal from the headers, e.g. set by the gateway
+type headerPrincipalProvider struct {
+	at.PrincipalProvider
+}
+
+func newHeaderPrincipalProvider() *headerPrincipalProvider {
+	return &headerPrincipalProvider{}
+}
+
+func (p *headerPrincipalProvider) GetPrincipal(ctx context.Context) *web.Principal {
+	name := ctx.GetHeader("X-User")
+	if name == "" {
+		return nil
+	}
+	return &web.Principal{
+		Name:        name,
+		Roles:       strings.Split(ctx.GetHeader("X-Roles"), ","),
+		Permissions: strings.Split(ctx.GetHeader("X-Permissions"), ","),
+	}
+}
+
+type inventoryController struct {
+	at.RestController
+	at.RequiresPermissions `value:"inventory:read,inventory:list"`
+}
+
+func newInventoryController() *inventoryController {
+	return &inventoryController{}
+}
+
+func (c *inventoryController) Get() string {
+	return "inventory"
+}
+
+func init() {
+	app.Register(newHeaderPrincipalProvider)
+}
+
+func TestAuthorizationWithPrincipalProvider(t *testing.T) {
+	testApp := web.NewTestApp(newInventoryController).Run(t)
+
+	t.Run("should require all permissions", func(t *testing.T) {
+		testApp.Get("/inventory").
+			WithHeader("X-User", "john").
+			WithHeader("X-Permissions", "inventory:read,inventory:list").
+			Expect().Status(http.StatusOK).Body().Equal("inventory")
+
+		testApp.Get("/inventory").
+			WithHeader("X-User", "john").
+			WithHeader("X-Permissions", "inventory:read").
+			Expect().Status(http.StatusForbidden)
+	})
+
+	t.Run("should respond 401 if the principal is not provided", func(t *testing.T) {
+		testApp.Get("/inventory").
+			Expect().Status(http.StatusUnauthorized)
+	})
+}
+
+func TestPrincipal(t *testing.T) {
+	p := &web.Principal{Roles: []string{"admin"}, Permissions: []string{"order:*", "report", "user:read:*"}}
+	assert.Equal(t, true, p.HasRole("admin"))
+	assert.Equal(t, false, p.HasRole("ops"))
+
+	testCases := []struct {
+		permission string
+		expected   bool
+	}{
+		{"order:read", true},
+		{"order", true},
+		{"report:read:1", true},
+		{"user:read", true},
+		{"user:write", false},
+		{"inventory:read", false},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, p.HasPermission(testCase.permission), testCase.permission)
+	}
+}
@@ -30,6 +30,11 @@ func (c *configuration) Context(app *webApp) context.Context {
 	return NewContext(app)
 }
 
+// ClientCertificate is the verified client certificate of mutual tls
+func (c *configuration) ClientCertificate(ctx context.Context) *ClientCertificate {
+	return newClientCertificate(ctx)
+}
+
 // DefaultView set the default view
 func (c *configuration) DefaultView(app *webApp) {
 
@@ -24,8 +24,10 @@ import (
 	"hidevops.io/hiboot/pkg/utils/reflector"
 	"hidevops.io/hiboot/pkg/utils/str"
 	"net/http"
+	"path"
 	"reflect"
 	"strings"
+	"time"
 )
 
 var httpMethods = []string{
@@ -42,18 +44,35 @@ var httpMethods = []string{
 
 const Any = "ANY"
 
+// Mapping is the request mapping of the controller method
+type Mapping struct {
+	// the http method, or ANY for any method
+	Method string `json:"method"`
+	// the path template, e.g. /foo/name/{name}
+	Path string `json:"path"`
+	// the full name of the controller method
+	Handler string `json:"handler"`
+}
+
 type Dispatcher struct {
 	webApp *webApp
 	// inject context aware dependencies
 	configurableFactory factory.ConfigurableFactory
 
+	mappings []Mapping
+
+	auditor    *auditor
+	authorizer *authorizer
+
 	//contextAwareInstances []interface{}
 }
 
-func newDispatcher(webApp *webApp, configurableFactory factory.ConfigurableFactory) *Dispatcher {
+func newDispatcher(webApp *webApp, configurableFactory factory.ConfigurableFactory, auditor *auditor, authorizer *authorizer) *Dispatcher {
 	d := &Dispatcher{
 		webApp:              webApp,
 		configurableFactory: configurableFactory,
+		auditor:             auditor,
+		authorizer:          authorizer,
 	}
 	return d
 }
@@ -63,6 +82,11 @@ func init() {
 }
 
 func (d *Dispatcher) register(controllers []*factory.MetaData) (err error) {
+	return d.registerOn(d.webApp, controllers)
+}
+
+// registerOn registers the controllers on the web app, e.g. the management server
+func (d *Dispatcher) registerOn(webApp *webApp, controllers []*factory.MetaData) (err error) {
 	for _, metaData := range controllers {
 		c := metaData.Instance
 		field := reflect.ValueOf(c)
@@ -79,8 +103,14 @@ func (d *Dispatcher) register(controllers []*factory.MetaData) (err error) {
 
 		//fieldValue := field.Elem()
 
-		// get context mapping
+		// get context mapping, the property placeholders are resolved, e.g. ${actuator.basePath:}/health
 		contextMapping, ok := reflector.FindEmbeddedFieldTag(controller, "ContextPath", "value")
+		if ok {
+			contextMapping = fmt.Sprintf("%v", d.configurableFactory.Replace(contextMapping))
+		}
+
+		// audit the requests of the controller that embedded at.Audit, or of all controllers if web.audit.enabled is true
+		audited := d.auditor.enabled(controller)
 
 		// parse method
 		fieldNames := camelcase.Split(fieldName)
@@ -105,11 +135,11 @@ func (d *Dispatcher) register(controllers []*factory.MetaData) (err error) {
 			//log.Debug("beforeMethod.Name: ", beforeMethod.Name)
 			hdl := newHandler(d.configurableFactory)
 			hdl.parse(beforeMethod, controller, "")
-			party = d.webApp.Party(contextMapping, Handler(func(c context.Context) {
+			party = webApp.Party(contextMapping, Handler(func(c context.Context) {
 				hdl.call(c)
 			}))
 		} else {
-			party = d.webApp.Party(contextMapping)
+			party = webApp.Party(contextMapping)
 		}
 
 		afterMethod, ok := fieldType.MethodByName(afterMethod)
@@ -151,19 +181,62 @@ func (d *Dispatcher) register(controllers []*factory.MetaData) (err error) {
 				// create new method parser here
 				hdl := newHandler(d.configurableFactory)
 				hdl.parse(method, controller, contextMapping+apiContextMapping)
+				if audited {
+					hdl.auditor = d.auditor
+				}
+				// check the roles and permissions required by the controller annotations and the route rules
+				hdl.requirements = d.authorizer.requirements(controller, httpMethod, hdl.path)
+				if len(hdl.requirements) != 0 {
+					hdl.authorizer = d.authorizer
+				}
 				methodHandler := Handler(func(c context.Context) {
 					hdl.call(c)
 					c.Next()
 				})
 
+				handlerName := fmt.Sprintf("%s/%s.%s", pkgPath, fieldName, methodName)
 				if hasAnyMethod {
 					party.Any(apiContextMapping, methodHandler)
 				} else if hasGenericMethod {
 					route := party.Handle(httpMethod, apiContextMapping, methodHandler)
-					route.MainHandlerName = fmt.Sprintf("%s/%s.%s", pkgPath, fieldName, methodName)
+					route.MainHandlerName = handlerName
 				}
+				d.mappings = append(d.mappings, Mapping{
+					Method:  httpMethod,
+					Path:    path.Join(contextMapping, apiContextMapping),
+					Handler: handlerName,
+				})
 			}
 		}
 	}
 	return nil
 }
+
+// handle registers the handlers of the route that is not mapped to the controllers, the request is authorized by
+// the authorization rules of the route, and audited without the bodies if web.audit.enabled is true
+func (d *Dispatcher) handle(webApp *webApp, method, route string, handlers ...context.Handler) {
+	audited := d.auditor != nil && d.auditor.auditProperties.Enabled
+	requirements := d.authorizer.requirements(nil, method, route)
+	var hdl []iris.Handler
+	if audited || len(requirements) != 0 {
+		hdl = append(hdl, Handler(func(c context.Context) {
+			if audited {
+				start := time.Now()
+				defer d.auditor.audit(c, route, start, nil, nil)
+			}
+			if len(requirements) != 0 && !d.authorizer.authorize(c, requirements) {
+				return
+			}
+			c.Next()
+		}))
+	}
+	for _, h := range handlers {
+		hdl = append(hdl, Handler(h))
+	}
+	webApp.Handle(method, route, hdl...)
+}
+
+// Mappings returns the request mappings of all registered controllers
+func (d *Dispatcher) Mappings() []Mapping {
+	return d.mappings
+}
@@ -28,6 +28,7 @@ import (
 	"net/http"
 	"reflect"
 	"strings"
+	"time"
 )
 
 const (
@@ -76,6 +77,9 @@ type handler struct {
 	runtimeInstance factory.Instance
 	contextName     string
 	dependencies    []*factory.MetaData
+	auditor         *auditor
+	authorizer      *authorizer
+	requirements    []*requirement
 }
 
 type requestSet struct {
@@ -282,6 +286,19 @@ func (h *handler) call(ctx context.Context) {
 	var runtimeInstance factory.Instance
 	//var err error
 
+	// the request and response of the audited handler
+	var auditRequest, auditResponse interface{}
+	if h.auditor != nil {
+		start := time.Now()
+		defer func() {
+			h.auditor.audit(ctx, h.path, start, auditRequest, auditResponse)
+		}()
+	}
+
+	if h.authorizer != nil && !h.authorizer.authorize(ctx, h.requirements) {
+		return
+	}
+
 	if h.lenOfPathParams != 0 {
 		path = ctx.Path()
 		pvs = strings.SplitN(path, "/", -1)
@@ -301,6 +318,7 @@ func (h *handler) call(ctx context.Context) {
 		if req.callback != nil {
 			reqErr = req.callback(ctx, request)
 			inputs[i] = reflect.ValueOf(request)
+			auditRequest = request
 		} else if req.kind == reflect.Interface && model.Context == req.typeName {
 			request = ctx
 			inputs[i] = reflect.ValueOf(request)
@@ -339,5 +357,8 @@ func (h *handler) call(ctx context.Context) {
 		results = h.method.Func.Call(inputs)
 
 		h.responseData(ctx, h.numOut, results)
+		if h.numOut > 0 && results[0].CanInterface() {
+			auditResponse = results[0].Interface()
+		}
 	}
 }
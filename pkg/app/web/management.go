@@ -0,0 +1,80 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/middleware/basicauth"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+)
+
+// UseManagement apply middleware to the management server, it takes no effect unless management.server.port is set
+func (a *application) UseManagement(handlers ...context.Handler) {
+	a.managementHandlers = append(a.managementHandlers, handlers...)
+}
+
+// separateManagement returns true if the management server runs on a port other than the main server
+func (a *application) separateManagement() bool {
+	conf := a.SystemConfig()
+	return conf != nil && conf.Management.Server.Port != "" && conf.Management.Server.Port != conf.Server.Port
+}
+
+// managementAddr returns the address of management server, e.g. 127.0.0.1:8081 or :8081
+func (a *application) managementAddr() string {
+	server := a.SystemConfig().Management.Server
+	return fmt.Sprintf("%v:%v", server.Address, server.Port)
+}
+
+// buildManagement creates the management server with its own middleware, then registers the management controllers on it
+func (a *application) buildManagement() (err error) {
+	a.managementApp = newWebApplication()
+
+	basicAuth := a.SystemConfig().Management.Security.BasicAuth
+	if basicAuth.Username != "" {
+		a.managementApp.Use(basicauth.New(basicauth.Config{
+			Users: map[string]string{basicAuth.Username: basicAuth.Password},
+		}))
+	}
+	for _, hdl := range a.managementHandlers {
+		a.managementApp.Use(Handler(hdl))
+	}
+
+	controllers := a.ConfigurableFactory().GetInstances(new(at.ManagementController))
+	if controllers != nil {
+		err = a.dispatcher.registerOn(a.managementApp, controllers)
+	}
+	return
+}
+
+// runManagement runs the management server, it is served over tls by the certificates of server.ssl if it is enabled
+func (a *application) runManagement() {
+	addr := a.managementAddr()
+	ssl := &a.SystemConfig().Server.SSL
+	run, err := runner(addr, ssl)
+	if err == nil {
+		scheme := "http"
+		if ssl.Enabled {
+			scheme = "https"
+		}
+		log.Infof("Management server started on %v://%v", scheme, addr)
+		err = a.managementApp.Run(run, iris.WithConfiguration(defaultConfiguration()))
+	}
+	if err != nil {
+		log.Errorf("failed to run management server on %v: %v", addr, err)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+)
+
+// postProcessor loads the sinks of the auditor and the principal providers of the authorizer,
+// as the components that embed at.AuditSink, at.AuditPrincipal or at.PrincipalProvider are all instantiated then
+type postProcessor struct {
+	auditor    *auditor
+	authorizer *authorizer
+}
+
+func init() {
+	app.RegisterPostProcessor(newPostProcessor)
+}
+
+func newPostProcessor(auditor *auditor, authorizer *authorizer) *postProcessor {
+	return &postProcessor{
+		auditor:    auditor,
+		authorizer: authorizer,
+	}
+}
+
+// AfterInitialization loads the auditor and the authorizer
+func (p *postProcessor) AfterInitialization() {
+	if p.auditor != nil && p.auditor.auditProperties != nil {
+		p.auditor.load()
+	}
+	if p.authorizer != nil && p.authorizer.configurableFactory != nil {
+		p.authorizer.load()
+	}
+}
@@ -28,6 +28,7 @@ import (
 type TestApplication interface {
 	Initialize() error
 	SetProperty(name string, value ...interface{}) TestApplication
+	SetInstance(params ...interface{}) TestApplication
 	Run(t *testing.T) TestApplication
 	Request(method, path string, pathargs ...interface{}) *httpexpect.Request
 	Post(path string, pathargs ...interface{}) *httpexpect.Request
@@ -36,12 +37,14 @@ type TestApplication interface {
 	Delete(path string, pathargs ...interface{}) *httpexpect.Request
 	Patch(path string, pathargs ...interface{}) *httpexpect.Request
 	Options(path string, pathargs ...interface{}) *httpexpect.Request
+	Management(method, path string, pathargs ...interface{}) *httpexpect.Request
 }
 
 // TestApplication the test web application for unit test only
 type testApplication struct {
 	application
-	expect *httpexpect.Expect
+	expect           *httpexpect.Expect
+	managementExpect *httpexpect.Expect
 }
 
 // RunTestApplication returns the new test application
@@ -72,11 +75,21 @@ func (a *testApplication) SetProperty(name string, value ...interface{}) TestApp
 	return a
 }
 
+// SetInstance set the instance before the application is built
+func (a *testApplication) SetInstance(params ...interface{}) TestApplication {
+	a.BaseApplication.SetInstance(params...)
+	return a
+}
+
 // RunTestServer run the test server
 func (a *testApplication) Run(t *testing.T) TestApplication {
 	err := a.build()
 	assert.Equal(t, nil, err)
 	a.expect = httptest.New(t, a.webApp.Application)
+	a.managementExpect = a.expect
+	if a.managementApp != nil {
+		a.managementExpect = httptest.New(t, a.managementApp.Application)
+	}
 	return a
 }
 
@@ -114,3 +127,8 @@ func (a *testApplication) Delete(path string, pathargs ...interface{}) *httpexpe
 func (a *testApplication) Options(path string, pathargs ...interface{}) *httpexpect.Request {
 	return a.expect.Request(http.MethodOptions, path, pathargs...)
 }
+
+// Management request to the management server, it is the same as Request if management.server.port is not set
+func (a *testApplication) Management(method, path string, pathargs ...interface{}) *httpexpect.Request {
+	return a.managementExpect.Request(method, path, pathargs...)
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/kataras/iris"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/system"
+	"hidevops.io/hiboot/pkg/utils/crypto/tlsconfig"
+	"net/http"
+)
+
+// ClientCertificate is the verified client certificate of mutual tls, it can be injected into the controller method, e.g.
+//
+//	func (c *orderController) Get(cert *web.ClientCertificate) string {
+//		return cert.Name
+//	}
+//
+// it is empty if the request is not over tls or the client certificate is not given
+type ClientCertificate struct {
+	at.ContextAware
+
+	// the common name of the subject
+	Name string `json:"name"`
+	// the distinguished name of the subject
+	Subject string `json:"subject"`
+	// the distinguished name of the issuer
+	Issuer string `json:"issuer"`
+	// the serial number in hex
+	SerialNumber string `json:"serial_number"`
+	// the dns names of the subject alternative name
+	DNSNames []string `json:"dns_names"`
+	// the email addresses of the subject alternative name
+	EmailAddresses []string `json:"email_addresses"`
+	// the uris of the subject alternative name, e.g. the spiffe id
+	URIs []string `json:"uris"`
+	// the leaf certificate
+	Certificate *x509.Certificate `json:"-"`
+}
+
+// newClientCertificate returns the verified client certificate of the request
+func newClientCertificate(ctx context.Context) (c *ClientCertificate) {
+	c = new(ClientCertificate)
+	state := ctx.Request().TLS
+	// the certificates of the verified chains are verified by the client certificate authorities
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return
+	}
+	cert := state.VerifiedChains[0][0]
+	c.Name = cert.Subject.CommonName
+	c.Subject = cert.Subject.String()
+	c.Issuer = cert.Issuer.String()
+	c.SerialNumber = cert.SerialNumber.Text(16)
+	c.DNSNames = cert.DNSNames
+	c.EmailAddresses = cert.EmailAddresses
+	for _, uri := range cert.URIs {
+		c.URIs = append(c.URIs, uri.String())
+	}
+	c.Certificate = cert
+	return
+}
+
+// Verified returns true if the client certificate is given and verified
+func (c *ClientCertificate) Verified() bool {
+	return c != nil && c.Certificate != nil
+}
+
+// newTLSConfig returns the tls configuration of server.ssl and the reloader of its certificate files
+func newTLSConfig(ssl *system.SSL) (cfg *tls.Config, reloader *tlsconfig.Reloader, err error) {
+	cfg, reloader, err = tlsconfig.ServerConfig(ssl.Options())
+	if err != nil {
+		return
+	}
+	if ssl.HTTP2 {
+		cfg.NextProtos = []string{"h2", "http/1.1"}
+	} else {
+		cfg.NextProtos = []string{"http/1.1"}
+	}
+	return
+}
+
+// runner returns the runner of https if server.ssl.enabled is true, or http,
+// the certificate files are reloaded by server.ssl.reloadInterval until the server is closed
+func runner(addr string, ssl *system.SSL) (iris.Runner, error) {
+	if ssl == nil || !ssl.Enabled {
+		return iris.Addr(addr), nil
+	}
+	cfg, reloader, err := newTLSConfig(ssl)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Addr: addr, TLSConfig: cfg}
+	if !ssl.HTTP2 {
+		// the non-nil empty map disables http/2
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	}
+	return func(app *iris.Application) error {
+		if ssl.ReloadInterval > 0 {
+			stop := make(chan struct{})
+			defer close(stop)
+			go reloader.Watch(ssl.ReloadInterval, stop, func(err error) {
+				log.Errorf("failed to reload the certificates of server.ssl: %v", err)
+			})
+		}
+		return app.NewHost(srv).ListenAndServeTLS("", "")
+	}, nil
+}
@@ -0,0 +1,226 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/system"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type tlsController struct {
+	at.RestController
+}
+
+func newTLSController() *tlsController {
+	return &tlsController{}
+}
+
+func (c *tlsController) GetPeer(cert *ClientCertificate) map[string]interface{} {
+	return map[string]interface{}{"verified": cert.Verified(), "name": cert.Name, "uris": cert.URIs}
+}
+
+type tlsManagementController struct {
+	at.ManagementController
+}
+
+func newTLSManagementController() *tlsManagementController {
+	return &tlsManagementController{}
+}
+
+func (c *tlsManagementController) Get() string {
+	return "management"
+}
+
+// writeTestCert writes the pem certificate and key signed by parent, or self signed if parent is nil
+func writeTestCert(t *testing.T, dir, name string, parent *tls.Certificate) (certFile, keyFile string, cert *tls.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Equal(t, nil, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:              []string{name},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	signer, signerKey := template, interface{}(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.Equal(t, nil, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Equal(t, nil, err)
+
+	certFile, keyFile = filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	assert.Equal(t, nil, ioutil.WriteFile(certFile, certPem, 0644))
+	assert.Equal(t, nil, ioutil.WriteFile(keyFile, keyPem, 0600))
+
+	c, err := tls.X509KeyPair(certPem, keyPem)
+	assert.Equal(t, nil, err)
+	c.Leaf, _ = x509.ParseCertificate(der)
+	return certFile, keyFile, &c
+}
+
+func TestTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web-tls")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	caFile, _, ca := writeTestCert(t, dir, "ca", nil)
+	certFile, keyFile, _ := writeTestCert(t, dir, "localhost", ca)
+	_, _, client := writeTestCert(t, dir, "client", ca)
+
+	testApp := NewTestApp(newTLSController).Run(t).(*testApplication)
+	ssl := &system.SSL{
+		Enabled:      true,
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+		ClientAuth:   "optional",
+		HTTP2:        true,
+	}
+	cfg, _, err := newTLSConfig(ssl)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"h2", "http/1.1"}, cfg.NextProtos)
+
+	server := httptest.NewUnstartedServer(testApp.webApp.Application)
+	server.TLS = cfg
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	get := func(certificates ...tls.Certificate) (res map[string]interface{}) {
+		cli := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			ServerName:   "localhost",
+			Certificates: certificates,
+		}}}
+		resp, err := cli.Get(server.URL + "/tls/peer")
+		assert.Equal(t, nil, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, nil, json.NewDecoder(resp.Body).Decode(&res))
+		return
+	}
+
+	t.Run("should inject the verified client certificate", func(t *testing.T) {
+		res := get(*client)
+		assert.Equal(t, true, res["verified"])
+		assert.Equal(t, "client", res["name"])
+	})
+
+	t.Run("should inject the empty client certificate if it is not given", func(t *testing.T) {
+		res := get()
+		assert.Equal(t, false, res["verified"])
+		assert.Equal(t, "", res["name"])
+	})
+
+	t.Run("should inject the empty client certificate over http", func(t *testing.T) {
+		testApp.Get("/tls/peer").
+			Expect().Status(http.StatusOK).
+			JSON().Object().ValueEqual("verified", false)
+	})
+
+	t.Run("should disable http2", func(t *testing.T) {
+		ssl := *ssl
+		ssl.HTTP2 = false
+		cfg, _, err := newTLSConfig(&ssl)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"http/1.1"}, cfg.NextProtos)
+	})
+
+	t.Run("should report invalid ssl properties", func(t *testing.T) {
+		_, err := runner(":0", &system.SSL{Enabled: true, CertFile: filepath.Join(dir, "not-exist.crt"), KeyFile: keyFile})
+		assert.NotEqual(t, nil, err)
+
+		_, err = runner(":0", &system.SSL{Enabled: true, CertFile: certFile, KeyFile: keyFile, ClientAuth: "always"})
+		assert.NotEqual(t, nil, err)
+
+		run, err := runner(":0", &system.SSL{})
+		assert.Equal(t, nil, err)
+		assert.NotNil(t, run)
+	})
+}
+
+func TestManagementTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web-management-tls")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	_, _, ca := writeTestCert(t, dir, "ca", nil)
+	certFile, keyFile, _ := writeTestCert(t, dir, "localhost", ca)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	_, port, _ := net.SplitHostPort(lis.Addr().String())
+	lis.Close()
+
+	testApp := NewTestApp(newTLSManagementController).
+		SetProperty("management.server.address", "127.0.0.1").
+		SetProperty("management.server.port", port).
+		SetProperty("server.ssl.enabled", true).
+		SetProperty("server.ssl.certFile", certFile).
+		SetProperty("server.ssl.keyFile", keyFile).
+		SetProperty("server.ssl.reloadInterval", 0).
+		Run(t).(*testApplication)
+	go testApp.runManagement()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	cli := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "localhost"}}}
+
+	t.Run("should serve the management server over tls by server.ssl", func(t *testing.T) {
+		var resp *http.Response
+		for i := 0; i < 50; i++ {
+			if resp, err = cli.Get("https://127.0.0.1:" + port + "/tlsManagement"); err == nil {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		assert.Equal(t, nil, err)
+		if err == nil {
+			defer resp.Body.Close()
+			b, _ := ioutil.ReadAll(resp.Body)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "management", string(b))
+		}
+	})
+}
@@ -0,0 +1,32 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package at
+
+// RequiresRoles is the annotation that requires the principal to have the roles to access the controller, e.g.
+//
+//	type orderController struct {
+//		at.JwtRestController
+//		at.RequiresRoles `value:"admin,ops" logical:"or"`
+//	}
+//
+// all roles are required by default, logical:"or" requires any of them
+type RequiresRoles interface{}
+
+// RequiresPermissions is the annotation that requires the principal to have the permissions to access the controller,
+// e.g. at.RequiresPermissions `value:"order:read"`, the granted permission order:* implies order:read
+type RequiresPermissions interface{}
+
+// PrincipalProvider is the annotation of the component that provides the principal of the request for authorization
+type PrincipalProvider interface{}
@@ -0,0 +1,26 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package at
+
+// HttpClient is the annotation of the declarative http client, the function fields tagged by path are implemented
+// by the httpclient starter, and the requests are sent to httpclient.clients.<value>.url, e.g.
+//
+//	type userClient struct {
+//		at.HttpClient `value:"user-service"`
+//
+//		GetUser    func(ctx context.Context, req *getUserRequest) (*User, error) `method:"GET" path:"/users/{id}"`
+//		CreateUser func(ctx context.Context, user *User) (*User, error)          `method:"POST" path:"/users"`
+//	}
+type HttpClient interface{}
@@ -0,0 +1,28 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package at
+
+// RateLimit is the annotation that limits the requests to the routes of the controller, e.g.
+//
+//	type orderController struct {
+//		at.RestController
+//		at.RateLimit `value:"100/1m" key:"subject" burst:"20" concurrency:"10"`
+//	}
+//
+// the tags are the same as the properties of ratelimit.limits
+type RateLimit interface{}
+
+// RateLimitStore is the annotation of the component that keeps the rate limit state, e.g. in a shared backend
+type RateLimitStore interface{}
@@ -23,3 +23,7 @@ type JwtRestController interface{}
 
 // ContextPath is the annotation that set the context path of a controller
 type ContextPath interface{}
+
+// ManagementController is the annotation that declare current controller is the management controller, e.g. actuator endpoints,
+// it is served by the management server if management.server.port is set, otherwise by the main server
+type ManagementController interface{}
@@ -106,6 +106,11 @@ func (f *configurableFactory) Configuration(name string) interface{} {
 	return nil
 }
 
+// Configurations returns all configurations by name
+func (f *configurableFactory) Configurations() map[string]interface{} {
+	return f.configurations.Items()
+}
+
 // BuildSystemConfig build system configuration
 func (f *configurableFactory) BuildSystemConfig() (systemConfig *system.Configuration, err error) {
 	systemConfig = f.GetInstance(system.Configuration{}).(*system.Configuration)
@@ -415,6 +415,11 @@ func TestConfigurableFactory(t *testing.T) {
 		assert.Equal(t, nil, nonExist)
 	})
 
+	t.Run("should get all configurations", func(t *testing.T) {
+		configurations := f.Configurations()
+		assert.Equal(t, f.Configuration("fake"), configurations["fake"])
+	})
+
 	t.Run("should add instance to factory at runtime", func(t *testing.T) {
 		fakeInstance := &struct{ Name string }{Name: "fake"}
 		f.SetInstance("autoconfigure_test.fakeInstance", fakeInstance)
@@ -57,6 +57,7 @@ type InstantiateFactory interface {
 	InjectDefaultValue(object interface{}) error
 	InjectIntoObject(object interface{}) error
 	InjectDependency(object interface{}) (err error)
+	InjectConfigurationProperties(object interface{}) error
 	Replace(name string) interface{}
 	InjectContextAwareObjects(ctx context.Context, dps []*MetaData) (runtimeInstance Instance, err error)
 }
@@ -66,6 +67,7 @@ type ConfigurableFactory interface {
 	InstantiateFactory
 	SystemConfiguration() *system.Configuration
 	Configuration(name string) interface{}
+	Configurations() map[string]interface{}
 	BuildSystemConfig() (systemConfig *system.Configuration, err error)
 	Build(configs []*MetaData)
 }
@@ -123,3 +125,25 @@ func CastMetaData(object interface{}) (metaData *MetaData) {
 	}
 	return
 }
+
+// FindInstance finds the first component that embeds the annotation and is assignable to the value that target points to,
+// e.g. the component that embeds at.RateLimitStore and implements ratelimit.Store
+//
+//	var store ratelimit.Store
+//	ok := factory.FindInstance(instantiateFactory, new(at.RateLimitStore), &store)
+func FindInstance(f InstantiateFactory, annotation interface{}, target interface{}) bool {
+	tv := reflect.ValueOf(target)
+	if f == nil || tv.Kind() != reflect.Ptr || tv.IsNil() {
+		return false
+	}
+	for _, md := range f.GetInstances(annotation) {
+		if metaData := CastMetaData(md); metaData != nil && metaData.Instance != nil {
+			iv := reflect.ValueOf(metaData.Instance)
+			if iv.Type().AssignableTo(tv.Elem().Type()) {
+				tv.Elem().Set(iv)
+				return true
+			}
+		}
+	}
+	return false
+}
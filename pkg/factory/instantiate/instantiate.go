@@ -18,6 +18,7 @@ package instantiate
 import (
 	"errors"
 	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/factory"
 	"hidevops.io/hiboot/pkg/factory/depends"
 	"hidevops.io/hiboot/pkg/inject"
@@ -141,6 +142,15 @@ func (f *instantiateFactory) injectDependency(item *factory.MetaData) (err error
 	if inst != nil {
 		// inject into object
 		err = f.inject.IntoObject(inst)
+		if _, ok := err.(*inject.BindingError); ok {
+			return
+		}
+		// bind properties if it is annotated with at.ConfigurationProperties
+		if reflector.HasEmbeddedFieldType(inst, new(at.ConfigurationProperties)) {
+			if err = f.inject.ConfigurationProperties(inst); err != nil {
+				return
+			}
+		}
 		tagName, ok := reflector.FindEmbeddedFieldTag(inst, "Qualifier", "name")
 		if ok {
 			name = tagName
@@ -179,7 +189,11 @@ func (f *instantiateFactory) BuildComponents() (err error) {
 		} else {
 			// inject dependencies into function
 			// components, controllers
-			f.injectDependency(item)
+			// the component that failed to bind its properties fails the build
+			e := f.injectDependency(item)
+			if _, ok := e.(*inject.BindingError); ok && err == nil {
+				err = e
+			}
 		}
 	}
 	if err == nil {
@@ -269,6 +283,11 @@ func (f *instantiateFactory) InjectDefaultValue(object interface{}) error {
 	return f.inject.DefaultValue(object)
 }
 
+// InjectConfigurationProperties bind properties into the object that embedded at.ConfigurationProperties
+func (f *instantiateFactory) InjectConfigurationProperties(object interface{}) error {
+	return f.inject.ConfigurationProperties(object)
+}
+
 // InjectIntoFunc inject into func
 func (f *instantiateFactory) InjectIntoFunc(object interface{}) (retVal interface{}, err error) {
 	return f.inject.IntoFunc(object)
@@ -383,3 +383,43 @@ func TestRuntimeInstance(t *testing.T) {
 	}
 
 }
+
+type greeter interface {
+	Greet() string
+}
+
+type principalGreeter struct {
+	at.PrincipalProvider
+}
+
+func newPrincipalGreeter() *principalGreeter {
+	return &principalGreeter{}
+}
+
+func (g *principalGreeter) Greet() string {
+	return helloWorld
+}
+
+func TestFindInstance(t *testing.T) {
+	instFactory := instantiate.NewInstantiateFactory(cmap.New(), []*factory.MetaData{factory.NewMetaData(newPrincipalGreeter)}, nil)
+	err := instFactory.BuildComponents()
+	assert.Equal(t, nil, err)
+
+	t.Run("should find the component that embeds the annotation and implements the interface", func(t *testing.T) {
+		var g greeter
+		assert.Equal(t, true, factory.FindInstance(instFactory, new(at.PrincipalProvider), &g))
+		assert.Equal(t, helloWorld, g.Greet())
+	})
+
+	t.Run("should not find the component that does not implement the interface", func(t *testing.T) {
+		var s fmt.Stringer
+		assert.Equal(t, false, factory.FindInstance(instFactory, new(at.PrincipalProvider), &s))
+		assert.Equal(t, nil, s)
+	})
+
+	t.Run("should not find the component without the annotation or the factory", func(t *testing.T) {
+		var g greeter
+		assert.Equal(t, false, factory.FindInstance(instFactory, new(at.AuditSink), &g))
+		assert.Equal(t, false, factory.FindInstance(nil, new(at.PrincipalProvider), &g))
+	})
+}
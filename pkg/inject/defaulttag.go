@@ -15,10 +15,13 @@
 package inject
 
 import (
-	"hidevops.io/hiboot/pkg/utils/str"
+	"hidevops.io/hiboot/pkg/utils/replacer"
 	"reflect"
+	"time"
 )
 
+var durationType = reflect.TypeOf(time.Duration(0))
+
 type defaultTag struct {
 	BaseTag
 }
@@ -29,30 +32,17 @@ type defaultTag struct {
 
 func (t *defaultTag) Decode(object reflect.Value, field reflect.StructField, property, tag string) (retVal interface{}) {
 	if tag != "" {
-		//log.Debug(valueTag)
-
-		// check if filed type is slice
-		kind := field.Type.Kind()
-		needConvert := true
-		switch kind {
-		case reflect.Slice:
-			retVal = t.instantiateFactory.Replace(tag)
-			typ := reflect.TypeOf(retVal)
-			if retVal != tag {
-				if typ.Kind() == reflect.Slice {
-					needConvert = false
-				} else if typ.Kind() == reflect.String {
-					needConvert = false
-					retVal = str.Convert(retVal.(string), kind)
-				}
+		// resolve the placeholders then convert the result to the type of the field
+		retVal = t.instantiateFactory.Replace(tag)
+		if s, ok := retVal.(string); ok && field.Type == durationType {
+			// the duration is parsed from the string, e.g. `default:"10s"`
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil
 			}
-		case reflect.String:
-			retVal = t.instantiateFactory.Replace(tag)
-			needConvert = false
-		}
-
-		if needConvert {
-			retVal = str.Convert(tag, kind)
+			retVal = d
+		} else {
+			retVal = replacer.Convert(retVal, field.Type.Kind())
 		}
 
 		if retVal != nil {
@@ -66,6 +66,25 @@ app.Component(newFooService), newFoo is the constructor of the dependency.
 		}
 	}
 
+Configuration properties
+
+A struct that embeds at.ConfigurationProperties with the prefix tag `value:"prefix"` is bound to the properties
+under that prefix, then it can be injected into any component, controller or auto configuration.
+The keys are bound in relaxed way (kebab-case, snake_case or camelCase), time.Duration and size strings like 10MB
+are supported, and the result is validated by the tag `validate:"..."`.
+
+	type serverProperties struct {
+		at.ConfigurationProperties `value:"server"`
+
+		Port        int           `default:"8080" validate:"min=1"`
+		ReadTimeout time.Duration `default:"10s"`
+		MaxBodySize int64         `default:"10MB"`
+	}
+
+	func init() {
+		app.Register(new(serverProperties))
+	}
+
 Auto Configuration
 
 Auto Configuration is another cool feature that comes out of the box with Hiboot,
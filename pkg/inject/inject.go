@@ -17,15 +17,21 @@ package inject
 import (
 	"errors"
 	"fmt"
+	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/factory"
 	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/mapstruct"
 	"hidevops.io/hiboot/pkg/utils/reflector"
 	"hidevops.io/hiboot/pkg/utils/str"
+	"hidevops.io/hiboot/pkg/utils/validator"
 	"reflect"
 )
 
 const (
 	initMethodName = "Init"
+
+	configurationPropertiesName = "ConfigurationProperties"
+	valueTagName                = "value"
 )
 
 var (
@@ -50,12 +56,28 @@ var (
 	// ErrFactoryIsNil factory is invalid
 	ErrFactoryIsNil = errors.New("[inject] factory is nil")
 
+	// ErrInvalidPropertiesPrefix the prefix of configuration properties is invalid
+	ErrInvalidPropertiesPrefix = errors.New("[inject] invalid prefix of configuration properties, e.g. at.ConfigurationProperties `value:\"prefix\"`")
+
 	tagsContainer []Tag
 
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+
 	//instancesMap cmap.ConcurrentMap
 	//appFactory factory.ConfigurableFactory
 )
 
+// BindingError is the error of binding the configuration properties, the component can not be built with it
+type BindingError struct {
+	Prefix string
+	Err    error
+}
+
+// Error returns the error message
+func (e *BindingError) Error() string {
+	return fmt.Sprintf("[inject] failed to bind properties %v: %v", e.Prefix, e.Err)
+}
+
 // Inject is the interface for inject tag
 type Inject interface {
 	DefaultValue(object interface{}) error
@@ -63,6 +85,7 @@ type Inject interface {
 	IntoObjectValue(object reflect.Value, property string, tags ...Tag) error
 	IntoMethod(object interface{}, m interface{}) (retVal interface{}, err error)
 	IntoFunc(object interface{}) (retVal interface{}, err error)
+	ConfigurationProperties(object interface{}) error
 }
 
 type inject struct {
@@ -92,6 +115,38 @@ func (i *inject) DefaultValue(object interface{}) error {
 	return i.IntoObjectValue(reflect.ValueOf(object), "", new(defaultTag))
 }
 
+// ConfigurationProperties binds the properties into the object that embedded at.ConfigurationProperties,
+// the properties are found by the prefix that tagged with `value:"prefix"`, e.g.
+//
+//	type serverProperties struct {
+//		at.ConfigurationProperties `value:"server"`
+//		Port        int           `default:"8080"`
+//		ReadTimeout time.Duration `default:"10s"`
+//		MaxBodySize int64         `default:"10MB"`
+//	}
+//
+// the keys are bound in relaxed way, e.g. read-timeout, read_timeout and readTimeout are all bound to ReadTimeout,
+// then the object is validated by the tag `validate:"..."`
+func (i *inject) ConfigurationProperties(object interface{}) (err error) {
+	prefix, ok := reflector.FindEmbeddedFieldTag(object, configurationPropertiesName, valueTagName)
+	if !ok || prefix == "" {
+		return ErrInvalidPropertiesPrefix
+	}
+
+	properties := i.factory.Builder().GetProperties(prefix)
+	err = mapstruct.DecodeWithDefaults(object, properties, i.factory.Replace)
+	if err == nil {
+		err = validator.Validate.Struct(object)
+	}
+	if err == nil {
+		log.Debugf("Bound properties %v into %v", prefix, reflect.TypeOf(object))
+	} else {
+		log.Errorf("failed to bind properties %v into %v: %v", prefix, reflect.TypeOf(object), err)
+		err = &BindingError{Prefix: prefix, Err: err}
+	}
+	return
+}
+
 // IntoObject injects instance into the tagged field with `inject:"instanceName"`
 func (i *inject) IntoObject(object interface{}) error {
 	return i.IntoObjectValue(reflect.ValueOf(object), "")
@@ -151,7 +206,14 @@ func (i *inject) IntoObjectValue(object reflect.Value, property string, tags ...
 				tag, ok := f.Tag.Lookup(tagName)
 				if ok {
 					tagImpl.Init(i.factory)
-					injectedObject = tagImpl.Decode(object, f, prop, tag)
+					if d, isDecoder := tagImpl.(decoder); isDecoder {
+						injectedObject, err = d.decode(object, f, prop, tag)
+						if err != nil {
+							return err
+						}
+					} else {
+						injectedObject = tagImpl.Decode(object, f, prop, tag)
+					}
 					if injectedObject != nil {
 						break
 					}
@@ -185,13 +247,15 @@ func (i *inject) IntoObjectValue(object reflect.Value, property string, tags ...
 		filedKind := filedObject.Kind()
 		canNested := filedKind == reflect.Struct
 		if canNested && fieldObj.IsValid() && fieldObj.CanSet() && filedObject.Type() != obj.Type() {
-			err = i.IntoObjectValue(fieldObj, prop, tags...)
+			if err = i.IntoObjectValue(fieldObj, prop, tags...); err != nil {
+				return err
+			}
 		}
 	}
 	return err
 }
 
-func (i *inject) parseFuncOrMethodInput(inType reflect.Type) (paramValue reflect.Value, ok bool) {
+func (i *inject) parseFuncOrMethodInput(inType reflect.Type) (paramValue reflect.Value, ok bool, err error) {
 	inType = reflector.IndirectType(inType)
 	inTypeName := inType.Name()
 	inst := i.getInstanceByName(inTypeName, inType)
@@ -209,6 +273,11 @@ func (i *inject) parseFuncOrMethodInput(inType reflect.Type) (paramValue reflect
 			// if it is not found, then create new instance
 			paramValue = reflect.New(inType)
 			inst = paramValue.Interface()
+			if reflector.HasEmbeddedFieldType(inst, new(at.ConfigurationProperties)) {
+				if err = i.ConfigurationProperties(inst); err != nil {
+					return
+				}
+			}
 			// TODO: inTypeName
 			i.factory.SetInstance(inst)
 		}
@@ -220,6 +289,15 @@ func (i *inject) parseFuncOrMethodInput(inType reflect.Type) (paramValue reflect
 	return
 }
 
+// result returns the first result of the func or method, the error of its last result is logged,
+// as the component that depends on it is injected with the nil instance
+func result(results []reflect.Value, err error) (interface{}, error) {
+	if last := results[len(results)-1]; len(results) > 1 && last.Type() == errorType && !last.IsNil() {
+		log.Errorf("failed to instantiate %v: %v", results[0].Type(), last.Interface())
+	}
+	return results[0].Interface(), err
+}
+
 // IntoFunc inject object into func and return instance
 func (i *inject) IntoFunc(object interface{}) (retVal interface{}, err error) {
 	fn := reflect.ValueOf(object)
@@ -231,7 +309,10 @@ func (i *inject) IntoFunc(object interface{}) (retVal interface{}, err error) {
 			fnInType := fn.Type().In(n)
 			//expectedTypName := reflector.GetLowerCamelFullNameByType(fnInType)
 			//log.Debugf("expected: %v", expectedTypName)
-			val, ok := i.parseFuncOrMethodInput(fnInType)
+			val, ok, e := i.parseFuncOrMethodInput(fnInType)
+			if e != nil {
+				return nil, e
+			}
 			if ok {
 
 				inputs[n] = val
@@ -247,8 +328,7 @@ func (i *inject) IntoFunc(object interface{}) (retVal interface{}, err error) {
 		}
 		results := fn.Call(inputs)
 		if len(results) != 0 {
-			retVal = results[0].Interface()
-			return
+			retVal, err = result(results, err)
 		}
 		return
 	}
@@ -268,7 +348,10 @@ func (i *inject) IntoMethod(object interface{}, m interface{}) (retVal interface
 			inputs[0] = reflect.ValueOf(object)
 			for n := 1; n < numIn; n++ {
 				fnInType := method.Type.In(n)
-				val, ok := i.parseFuncOrMethodInput(fnInType)
+				val, ok, e := i.parseFuncOrMethodInput(fnInType)
+				if e != nil {
+					return nil, e
+				}
 				if ok {
 					inputs[n] = val
 				} else {
@@ -282,7 +365,7 @@ func (i *inject) IntoMethod(object interface{}, m interface{}) (retVal interface
 			}
 			results := method.Func.Call(inputs)
 			if len(results) != 0 {
-				retVal = results[0].Interface()
+				retVal, err = result(results, err)
 				return
 			}
 		}
@@ -15,8 +15,10 @@
 package inject_test
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/factory"
 	"hidevops.io/hiboot/pkg/factory/autoconfigure"
 	"hidevops.io/hiboot/pkg/factory/instantiate"
@@ -28,6 +30,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 )
 
 type User struct {
@@ -39,27 +42,28 @@ type fakeRepository struct {
 }
 
 type fakeProperties struct {
-	DefVarSlice   []string `default:"${app.name}"`
-	DefProfiles   []string `default:"${app.profiles.include}"`
-	Name          string   `default:"should not inject this default value as it will inject by system.Builder"`
-	Nickname      string   `default:"should not inject this default value as it will inject by system.Builder"`
-	Username      string   `default:"should not inject this default value as it will inject by system.Builder"`
-	Url           string   `default:"should not inject this default value as it will inject by system.Builder"`
-	DefStrVal     string   `default:"this is default value"`
-	DefIntVal     int      `default:"123"`
-	DefIntVal8    int8     `default:"12"`
-	DefIntVal16   int16    `default:"123"`
-	DefUintVal32  int32    `default:"1234"`
-	DefUintVal64  int64    `default:"12345"`
-	DefIntValU    uint     `default:"123"`
-	DefIntValU8   uint8    `default:"12"`
-	DefIntValU16  uint16   `default:"123"`
-	DefUintValU32 uint32   `default:"1234"`
-	DefUintValU64 uint64   `default:"12345"`
-	DefFloatVal64 float64  `default:"0.1231"`
-	DefFloatVal32 float32  `default:"0.1"`
-	DefBool       bool     `default:"true"`
-	DefSlice      []string `default:"jupiter,mercury,mars,earth,moon"`
+	DefVarSlice   []string      `default:"${app.name}"`
+	DefProfiles   []string      `default:"${app.profiles.include}"`
+	Name          string        `default:"should not inject this default value as it will inject by system.Builder"`
+	Nickname      string        `default:"should not inject this default value as it will inject by system.Builder"`
+	Username      string        `default:"should not inject this default value as it will inject by system.Builder"`
+	Url           string        `default:"should not inject this default value as it will inject by system.Builder"`
+	DefStrVal     string        `default:"this is default value"`
+	DefIntVal     int           `default:"123"`
+	DefIntVal8    int8          `default:"12"`
+	DefIntVal16   int16         `default:"123"`
+	DefUintVal32  int32         `default:"1234"`
+	DefUintVal64  int64         `default:"12345"`
+	DefIntValU    uint          `default:"123"`
+	DefIntValU8   uint8         `default:"12"`
+	DefIntValU16  uint16        `default:"123"`
+	DefUintValU32 uint32        `default:"1234"`
+	DefUintValU64 uint64        `default:"12345"`
+	DefFloatVal64 float64       `default:"0.1231"`
+	DefFloatVal32 float32       `default:"0.1"`
+	DefBool       bool          `default:"true"`
+	DefSlice      []string      `default:"jupiter,mercury,mars,earth,moon"`
+	DefDuration   time.Duration `default:"10s"`
 }
 
 type fooProperties struct {
@@ -156,6 +160,8 @@ type userService struct {
 	DefFloatVal32  float32        `value:"0.1"`
 	DefBool        bool           `value:"true"`
 	DefSlice       []string       `value:"jupiter,mercury,mars,earth,moon"`
+	RefIntVal      int            `value:"${fake.port:${fake.defaultPort:8081}}"`
+	RandomIntVal   int            `value:"${random.int(1,10)}"`
 }
 
 type PropTestUser struct {
@@ -412,6 +418,10 @@ func TestInject(t *testing.T) {
 		assert.Equal(t, float32(0.1), fakeConfig.Properties.DefFloatVal32)
 	})
 
+	t.Run("should inject default duration", func(t *testing.T) {
+		assert.Equal(t, 10*time.Second, fakeConfig.Properties.DefDuration)
+	})
+
 	t.Run("should get config", func(t *testing.T) {
 		fr := cf.GetInstance("inject_test.fakeRepository")
 		assert.NotEqual(t, nil, fr)
@@ -451,6 +461,8 @@ func TestInject(t *testing.T) {
 		//assert.Equal(t, appName, us.FakeUser.App)
 		assert.Equal(t, fakeUrl, us.Url)
 		assert.Equal(t, defaultUrl, us.DefaultUrl)
+		assert.Equal(t, 8081, us.RefIntVal)
+		assert.True(t, us.RandomIntVal >= 1 && us.RandomIntVal < 10)
 		assert.NotEqual(t, (*fakeRepository)(nil), us.FakeRepository)
 	})
 
@@ -676,4 +688,120 @@ func TestInjectIntoFunc(t *testing.T) {
 		assert.Equal(t, nil, err)
 		assert.NotEqual(t, nil, res)
 	})
+
+	t.Run("should inject through func that returns nil error", func(t *testing.T) {
+		res, err := injecting.IntoFunc(func() (*member, error) {
+			return new(member), nil
+		})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, new(member), res)
+	})
+
+	t.Run("should inject through func that returns error", func(t *testing.T) {
+		res, err := injecting.IntoFunc(func() (*member, error) {
+			return nil, errors.New("failed")
+		})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, (*member)(nil), res)
+	})
+}
+
+type endpoint struct {
+	Path    string
+	Enabled bool `default:"true"`
+}
+
+type fakeServerProperties struct {
+	at.ConfigurationProperties `value:"fake.server"`
+
+	Host        string        `default:"localhost"`
+	Port        int           `default:"8080" validate:"min=1"`
+	ReadTimeout time.Duration `default:"10s"`
+	MaxBodySize int64         `default:"10MB"`
+	Endpoints   []endpoint
+	Labels      map[string]string
+	Description string `default:"${app.name} server"`
+}
+
+type invalidProperties struct {
+	at.ConfigurationProperties
+	Name string
+}
+
+type fakeClientProperties struct {
+	at.ConfigurationProperties `value:"fake.client"`
+
+	Retries int `default:"3" validate:"min=0"`
+}
+
+type fakeClientService struct {
+	Properties *fakeClientProperties `inject:""`
+}
+
+func newFakeClientService(properties *fakeClientProperties) *fakeClientService {
+	return &fakeClientService{Properties: properties}
+}
+
+type fakeServerService struct {
+	properties *fakeServerProperties
+}
+
+func newFakeServerService(properties *fakeServerProperties) *fakeServerService {
+	return &fakeServerService{properties: properties}
+}
+
+func TestConfigurationProperties(t *testing.T) {
+	cf := setUp(t)
+	cf.SetProperty("fake.server.port", 8090).
+		SetProperty("fake.server.read-timeout", "1m").
+		SetProperty("fake.server.max_body_size", "2MB").
+		SetProperty("fake.server.endpoints", []interface{}{
+			map[string]interface{}{"path": "/foo"},
+			map[string]interface{}{"path": "/bar", "enabled": false},
+		}).
+		SetProperty("fake.server.labels.tier", "backend")
+
+	t.Run("should bind configuration properties", func(t *testing.T) {
+		p := new(fakeServerProperties)
+		err := cf.InjectConfigurationProperties(p)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "localhost", p.Host)
+		assert.Equal(t, 8090, p.Port)
+		assert.Equal(t, time.Minute, p.ReadTimeout)
+		assert.Equal(t, int64(2<<20), p.MaxBodySize)
+		assert.Equal(t, []endpoint{{Path: "/foo", Enabled: true}, {Path: "/bar", Enabled: false}}, p.Endpoints)
+		assert.Equal(t, "backend", p.Labels["tier"])
+		assert.Equal(t, "hiboot server", p.Description)
+	})
+
+	t.Run("should inject bound configuration properties through func", func(t *testing.T) {
+		svc, err := cf.InjectIntoFunc(newFakeServerService)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 8090, svc.(*fakeServerService).properties.Port)
+	})
+
+	t.Run("should report error if properties is invalid", func(t *testing.T) {
+		cf.SetProperty("fake.server.port", 0)
+		err := cf.InjectConfigurationProperties(new(fakeServerProperties))
+		assert.NotEqual(t, nil, err)
+		cf.SetProperty("fake.server.port", 8090)
+	})
+
+	t.Run("should report binding error instead of injecting the invalid properties", func(t *testing.T) {
+		cf.SetProperty("fake.client.retries", -1)
+
+		svc, err := cf.InjectIntoFunc(newFakeClientService)
+		assert.Equal(t, nil, svc)
+		_, ok := err.(*inject.BindingError)
+		assert.Equal(t, true, ok)
+
+		err = cf.InjectIntoObject(new(fakeClientService))
+		_, ok = err.(*inject.BindingError)
+		assert.Equal(t, true, ok)
+	})
+
+	t.Run("should report error if the prefix is not specified", func(t *testing.T) {
+		err := cf.InjectConfigurationProperties(new(invalidProperties))
+		assert.Equal(t, inject.ErrInvalidPropertiesPrefix, err)
+	})
 }
@@ -15,10 +15,12 @@
 package inject
 
 import (
+	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/factory"
 	"hidevops.io/hiboot/pkg/log"
 	"hidevops.io/hiboot/pkg/utils/io"
 	"hidevops.io/hiboot/pkg/utils/mapstruct"
+	"hidevops.io/hiboot/pkg/utils/reflector"
 	"hidevops.io/hiboot/pkg/utils/str"
 	"reflect"
 )
@@ -38,6 +40,12 @@ func getInstance(cf factory.InstantiateFactory, pkgName, name string) (retVal in
 }
 
 func (t *injectTag) Decode(object reflect.Value, field reflect.StructField, property, tag string) (retVal interface{}) {
+	retVal, _ = t.decode(object, field, property, tag)
+	return
+}
+
+// decode injects the instance, the error of binding the properties of the new instance is returned
+func (t *injectTag) decode(object reflect.Value, field reflect.StructField, property, tag string) (retVal interface{}, err error) {
 	properties := t.ParseProperties(tag)
 
 	// first, find if object is already instantiated
@@ -67,6 +75,11 @@ func (t *injectTag) Decode(object reflect.Value, field reflect.StructField, prop
 		if retVal == nil && field.Type.Kind() != reflect.Interface {
 			o := reflect.New(ft)
 			retVal = o.Interface()
+			if reflector.HasEmbeddedFieldType(retVal, new(at.ConfigurationProperties)) {
+				if err = t.instantiateFactory.InjectConfigurationProperties(retVal); err != nil {
+					return nil, err
+				}
+			}
 		}
 		// inject field value
 		// TODO: do we need this feature?
@@ -75,5 +88,5 @@ func (t *injectTag) Decode(object reflect.Value, field reflect.StructField, prop
 		}
 	}
 	log.Debugf("inject tag: %v ==> %v %v: %v", tag, field.Name, field.Type, retVal)
-	return retVal
+	return
 }
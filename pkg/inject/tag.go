@@ -18,6 +18,7 @@ import (
 	"hidevops.io/hiboot/pkg/factory"
 	"hidevops.io/hiboot/pkg/system"
 	"hidevops.io/hiboot/pkg/utils/cmap"
+	"hidevops.io/hiboot/pkg/utils/replacer"
 	"reflect"
 	"strings"
 )
@@ -34,6 +35,11 @@ type Tag interface {
 	IsSingleton() bool
 }
 
+// decoder is the optional interface of Tag that reports the decode error, the field is not injected then
+type decoder interface {
+	decode(object reflect.Value, field reflect.StructField, property, tag string) (retVal interface{}, err error)
+}
+
 // BaseTag is the base struct of tag
 type BaseTag struct {
 	instantiateFactory factory.InstantiateFactory
@@ -55,7 +61,8 @@ func (t *BaseTag) Init(configurableFactory factory.InstantiateFactory) {
 func (t *BaseTag) ParseProperties(tag string) cmap.ConcurrentMap {
 	t.properties = cmap.New()
 
-	args := strings.Split(tag, ",")
+	// the commas inside the placeholders are not separators, e.g. ${random.int(1,10)}
+	args := replacer.Split(tag, ',')
 	for _, v := range args {
 		//log.Debug(v)
 		n := strings.Index(v, "=")
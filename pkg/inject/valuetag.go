@@ -15,7 +15,7 @@
 package inject
 
 import (
-	"hidevops.io/hiboot/pkg/utils/str"
+	"hidevops.io/hiboot/pkg/utils/replacer"
 	"reflect"
 )
 
@@ -29,25 +29,8 @@ func init() {
 
 func (t *valueTag) Decode(object reflect.Value, field reflect.StructField, property, tag string) (retVal interface{}) {
 	if tag != "" {
-		//log.Debug(valueTag)
-
-		// check if filed type is slice
-		kind := field.Type.Kind()
-		needConvert := true
-		switch kind {
-		case reflect.Slice:
-			retVal = t.instantiateFactory.Replace(tag)
-			if retVal != tag {
-				needConvert = false
-			}
-		case reflect.String:
-			retVal = t.instantiateFactory.Replace(tag)
-			needConvert = false
-		}
-
-		if needConvert {
-			retVal = str.Convert(tag, kind)
-		}
+		// resolve the placeholders then convert the result to the type of the field
+		retVal = replacer.Convert(t.instantiateFactory.Replace(tag), field.Type.Kind())
 	}
 	return retVal
 }
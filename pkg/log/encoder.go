@@ -0,0 +1,173 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/kataras/golog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Available format names are:
+// "text"
+// "json"
+// "logfmt"
+const (
+	TextFormat   = "text"
+	JSONFormat   = "json"
+	LogfmtFormat = "logfmt"
+)
+
+const (
+	// TimeKey is the key of the time of the structured log line
+	TimeKey = "time"
+	// LevelKey is the key of the level of the structured log line
+	LevelKey = "level"
+	// MessageKey is the key of the message of the structured log line
+	MessageKey = "msg"
+)
+
+// Encoder encodes a log line with its fields
+type Encoder interface {
+	Encode(t time.Time, level string, msg string, fields Fields) []byte
+}
+
+type jsonEncoder struct{}
+
+// Encode encodes the log line to a json object, e.g. {"level":"info","msg":"hello","request_id":"1","time":"..."}
+func (e *jsonEncoder) Encode(t time.Time, level string, msg string, fields Fields) []byte {
+	m := make(map[string]interface{}, len(fields)+3)
+	for k, v := range fields {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		m[k] = v
+	}
+	m[TimeKey] = t.Format(time.RFC3339Nano)
+	m[LevelKey] = level
+	m[MessageKey] = msg
+	b, err := json.Marshal(m)
+	if err != nil {
+		b, _ = json.Marshal(map[string]interface{}{
+			TimeKey:    m[TimeKey],
+			LevelKey:   level,
+			MessageKey: msg,
+			"error":    err.Error(),
+		})
+	}
+	return b
+}
+
+type logfmtEncoder struct{}
+
+// Encode encodes the log line to logfmt, e.g. time=... level=info msg=hello request_id=1
+func (e *logfmtEncoder) Encode(t time.Time, level string, msg string, fields Fields) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(TimeKey + "=" + t.Format(time.RFC3339Nano))
+	buf.WriteString(" " + LevelKey + "=" + level)
+	buf.WriteString(" " + MessageKey + "=" + logfmtValue(msg))
+	writeFields(&buf, fields)
+	return buf.Bytes()
+}
+
+func writeFields(buf *bytes.Buffer, fields Fields) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		buf.WriteString(" " + k + "=" + logfmtValue(fields[k]))
+	}
+}
+
+func logfmtValue(v interface{}) string {
+	s := fmt.Sprintf("%v", v)
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+var (
+	encoders = map[string]Encoder{
+		JSONFormat:   new(jsonEncoder),
+		LogfmtFormat: new(logfmtEncoder),
+	}
+	encoder     Encoder
+	encoderLock sync.RWMutex
+)
+
+func init() {
+	golog.Handle(handleEncoding)
+}
+
+// handleEncoding writes the log line with the encoder of current format, the text format is handled by golog
+func handleEncoding(l *golog.Log) bool {
+	enc := currentEncoder()
+	if enc == nil {
+		return false
+	}
+	write(l.Logger, enc.Encode(l.Time, levelName(l.Level), strings.TrimSuffix(l.Message, "\n"), nil))
+	return true
+}
+
+func currentEncoder() Encoder {
+	encoderLock.RLock()
+	defer encoderLock.RUnlock()
+	return encoder
+}
+
+func write(logger *golog.Logger, b []byte) {
+	logger.Printer.Output.Write(append(b, '\n'))
+}
+
+func levelName(level golog.Level) string {
+	if meta, ok := golog.Levels[level]; ok {
+		return meta.Name
+	}
+	return ""
+}
+
+// SetFormat sets the format of log lines, text is the default format,
+// json and logfmt write each log line with its fields as a structured record
+func SetFormat(format string) {
+	encoderLock.Lock()
+	defer encoderLock.Unlock()
+	encoder = encoders[strings.ToLower(format)]
+}
+
+// GetFormat returns the name of current format
+func GetFormat() string {
+	enc := currentEncoder()
+	for name, e := range encoders {
+		if e == enc {
+			return name
+		}
+	}
+	return TextFormat
+}
+
+// IsFormat returns true if format is one of the available format names
+func IsFormat(format string) bool {
+	_, ok := encoders[strings.ToLower(format)]
+	return ok || strings.ToLower(format) == TextFormat
+}
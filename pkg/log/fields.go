@@ -0,0 +1,181 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/kataras/golog"
+	"os"
+	"time"
+)
+
+const (
+	// RequestIDKey is the field name of the request correlation id
+	RequestIDKey = "request_id"
+	// RequestIDHeader is the header that carries the request correlation id
+	RequestIDHeader = "X-Request-ID"
+)
+
+// Fields is the contextual fields of structured log lines
+type Fields map[string]interface{}
+
+type fieldsKey struct{}
+
+// Entry is the logger that writes its fields with each log line, e.g.
+//
+//	log.WithFields(log.Fields{"user": "john"}).Info("logged in")
+//
+// the fields are appended to the text log line as key=value pairs, or encoded with the json or logfmt encoder
+type Entry struct {
+	fields Fields
+}
+
+// WithFields returns a new entry with the fields
+func WithFields(fields Fields) *Entry {
+	return new(Entry).WithFields(fields)
+}
+
+// WithField returns a new entry with the field
+func WithField(key string, value interface{}) *Entry {
+	return WithFields(Fields{key: value})
+}
+
+// WithContext returns a new entry with the fields carried by ctx, e.g. the request id
+func WithContext(ctx context.Context) *Entry {
+	return WithFields(FromContext(ctx))
+}
+
+// NewContext returns a copy of ctx that carries the fields, the fields of ctx are kept unless they are overridden
+func NewContext(ctx context.Context, fields Fields) context.Context {
+	merged := make(Fields)
+	for k, v := range FromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FromContext returns the fields carried by ctx
+func FromContext(ctx context.Context) (fields Fields) {
+	if ctx != nil {
+		fields, _ = ctx.Value(fieldsKey{}).(Fields)
+	}
+	return
+}
+
+// RequestID returns the request correlation id carried by ctx
+func RequestID(ctx context.Context) (id string) {
+	id, _ = FromContext(ctx)[RequestIDKey].(string)
+	return
+}
+
+// WithFields returns a new entry with the fields of e and the fields
+func (e *Entry) WithFields(fields Fields) *Entry {
+	entry := &Entry{fields: make(Fields, len(e.fields)+len(fields))}
+	for k, v := range e.fields {
+		entry.fields[k] = v
+	}
+	for k, v := range fields {
+		entry.fields[k] = v
+	}
+	return entry
+}
+
+// WithField returns a new entry with the fields of e and the field
+func (e *Entry) WithField(key string, value interface{}) *Entry {
+	return e.WithFields(Fields{key: value})
+}
+
+// Fields returns the fields of the entry
+func (e *Entry) Fields() Fields {
+	return e.fields
+}
+
+func (e *Entry) log(level golog.Level, msg string) {
+	logger := callerLogger(2)
+	if logger.Level < level {
+		return
+	}
+
+	if enc := currentEncoder(); enc != nil {
+		write(logger, enc.Encode(time.Now(), levelName(level), msg, e.fields))
+		if level == golog.FatalLevel {
+			os.Exit(1)
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(msg)
+	writeFields(&buf, e.fields)
+	logger.Log(level, buf.String())
+	if level == golog.FatalLevel {
+		os.Exit(1)
+	}
+}
+
+// Fatal prints the log line with fields then `os.Exit(1)`
+func (e *Entry) Fatal(v ...interface{}) {
+	e.log(golog.FatalLevel, fmt.Sprint(v...))
+}
+
+// Fatalf prints the formatted log line with fields then `os.Exit(1)`
+func (e *Entry) Fatalf(format string, args ...interface{}) {
+	e.log(golog.FatalLevel, fmt.Sprintf(format, args...))
+}
+
+// Error prints the log line with fields when logger's Level is error, warn, info or debug.
+func (e *Entry) Error(v ...interface{}) {
+	e.log(golog.ErrorLevel, fmt.Sprint(v...))
+}
+
+// Errorf prints the formatted log line with fields when logger's Level is error, warn, info or debug.
+func (e *Entry) Errorf(format string, args ...interface{}) {
+	e.log(golog.ErrorLevel, fmt.Sprintf(format, args...))
+}
+
+// Warn prints the log line with fields when logger's Level is warn, info or debug.
+func (e *Entry) Warn(v ...interface{}) {
+	e.log(golog.WarnLevel, fmt.Sprint(v...))
+}
+
+// Warnf prints the formatted log line with fields when logger's Level is warn, info or debug.
+func (e *Entry) Warnf(format string, args ...interface{}) {
+	e.log(golog.WarnLevel, fmt.Sprintf(format, args...))
+}
+
+// Info prints the log line with fields when logger's Level is info or debug.
+func (e *Entry) Info(v ...interface{}) {
+	e.log(golog.InfoLevel, fmt.Sprint(v...))
+}
+
+// Infof prints the formatted log line with fields when logger's Level is info or debug.
+func (e *Entry) Infof(format string, args ...interface{}) {
+	e.log(golog.InfoLevel, fmt.Sprintf(format, args...))
+}
+
+// Debug prints the log line with fields when logger's Level is debug.
+func (e *Entry) Debug(v ...interface{}) {
+	e.log(golog.DebugLevel, fmt.Sprint(v...))
+}
+
+// Debugf prints the formatted log line with fields when logger's Level is debug.
+func (e *Entry) Debugf(format string, args ...interface{}) {
+	e.log(golog.DebugLevel, fmt.Sprintf(format, args...))
+}
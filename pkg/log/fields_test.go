@@ -0,0 +1,101 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStructuredLogging(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	SetLevel(DebugLevel)
+	defer func() {
+		SetFormat(TextFormat)
+		SetOutput(os.Stdout)
+	}()
+
+	ctx := NewContext(context.Background(), Fields{RequestIDKey: "1234"})
+
+	t.Run("should carry fields by context", func(t *testing.T) {
+		assert.Equal(t, "1234", RequestID(ctx))
+		c := NewContext(ctx, Fields{"user": "john"})
+		assert.Equal(t, Fields{RequestIDKey: "1234", "user": "john"}, FromContext(c))
+		assert.Equal(t, "", RequestID(context.Background()))
+		assert.Equal(t, Fields{RequestIDKey: "1234"}, FromContext(ctx))
+	})
+
+	t.Run("should write fields in text format", func(t *testing.T) {
+		buf.Reset()
+		SetFormat(TextFormat)
+		WithContext(ctx).WithField("user", "john doe").Info("logged in")
+		assert.Contains(t, buf.String(), `logged in request_id=1234 user="john doe"`)
+	})
+
+	t.Run("should write fields in json format", func(t *testing.T) {
+		buf.Reset()
+		SetFormat(JSONFormat)
+		assert.Equal(t, JSONFormat, GetFormat())
+		WithContext(ctx).WithFields(Fields{"count": 2}).Warnf("hello %v", "world")
+		var line map[string]interface{}
+		err := json.Unmarshal(buf.Bytes(), &line)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "warn", line[LevelKey])
+		assert.Equal(t, "hello world", line[MessageKey])
+		assert.Equal(t, "1234", line[RequestIDKey])
+		assert.Equal(t, float64(2), line["count"])
+	})
+
+	t.Run("should write plain log line in json format", func(t *testing.T) {
+		buf.Reset()
+		SetFormat(JSONFormat)
+		Info("plain")
+		var line map[string]interface{}
+		err := json.Unmarshal(buf.Bytes(), &line)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "info", line[LevelKey])
+		assert.Equal(t, "plain", line[MessageKey])
+	})
+
+	t.Run("should write fields in logfmt format", func(t *testing.T) {
+		buf.Reset()
+		SetFormat(LogfmtFormat)
+		WithContext(ctx).Error("failed to connect")
+		line := buf.String()
+		assert.True(t, strings.HasPrefix(line, "time="))
+		assert.Contains(t, line, ` level=error msg="failed to connect" request_id=1234`)
+	})
+
+	t.Run("should not write the entry below current level", func(t *testing.T) {
+		buf.Reset()
+		SetLevel(InfoLevel)
+		WithField("user", "john").Debug("hidden")
+		assert.Equal(t, "", buf.String())
+		SetLevel(DebugLevel)
+	})
+
+	t.Run("should fallback to text format", func(t *testing.T) {
+		SetFormat("unknown")
+		assert.Equal(t, TextFormat, GetFormat())
+		assert.Equal(t, true, IsFormat(LogfmtFormat))
+		assert.Equal(t, false, IsFormat("xml"))
+	})
+}
@@ -0,0 +1,298 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/kataras/golog"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+)
+
+// FileOptions is the options of the rotating log file
+type FileOptions struct {
+	// the path of the log file
+	Path string
+	// rotate the log file when its size would exceed MaxSize bytes, 0 for no size based rotation
+	MaxSize int64
+	// rotate the log file by Interval, e.g. 24h rotates daily, 0 for no time based rotation
+	Interval time.Duration
+	// the max number of rotated files to retain, 0 to retain all
+	MaxBackups int
+	// the max age of rotated files to retain, 0 to retain all
+	MaxAge time.Duration
+	// compress the rotated files with gzip
+	Compress bool
+}
+
+// RotatingFile is the io.WriteCloser that writes to the log file and rotates it by size or time,
+// the rotated files are named like app-2006-01-02T15-04-05.000.log in the same directory
+type RotatingFile struct {
+	options  FileOptions
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	rotateAt time.Time
+	now      func() time.Time
+}
+
+// NewRotatingFile opens the log file, the directory is created if it does not exist
+func NewRotatingFile(options FileOptions) (f *RotatingFile, err error) {
+	f = &RotatingFile{options: options, now: time.Now}
+	err = f.open()
+	return
+}
+
+// Write writes p to the log file, the file is rotated first if it is due
+func (f *RotatingFile) Write(p []byte) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if err = f.open(); err != nil {
+			return
+		}
+	}
+	if f.due(int64(len(p))) {
+		if err = f.rotate(); err != nil {
+			return
+		}
+	}
+	n, err = f.file.Write(p)
+	f.size += int64(n)
+	return
+}
+
+// Rotate closes the log file, renames it to the backup and opens a new one
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rotate()
+}
+
+// Close closes the log file
+func (f *RotatingFile) Close() (err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	return
+}
+
+func (f *RotatingFile) due(n int64) bool {
+	if f.options.MaxSize > 0 && f.size > 0 && f.size+n > f.options.MaxSize {
+		return true
+	}
+	return !f.rotateAt.IsZero() && !f.now().Before(f.rotateAt)
+}
+
+func (f *RotatingFile) open() (err error) {
+	if err = os.MkdirAll(filepath.Dir(f.options.Path), 0755); err != nil {
+		return
+	}
+	f.file, err = os.OpenFile(f.options.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	var info os.FileInfo
+	if info, err = f.file.Stat(); err == nil {
+		f.size = info.Size()
+	}
+	if f.options.Interval > 0 {
+		f.rotateAt = f.now().Truncate(f.options.Interval).Add(f.options.Interval)
+	}
+	return
+}
+
+func (f *RotatingFile) rotate() (err error) {
+	if f.file != nil {
+		if err = f.file.Close(); err != nil {
+			return
+		}
+		f.file = nil
+	}
+
+	backup := f.backupName(f.now())
+	if err = os.Rename(f.options.Path, backup); err != nil && !os.IsNotExist(err) {
+		return
+	}
+	if err == nil && f.options.Compress {
+		err = compress(backup)
+	}
+	if err == nil {
+		err = f.removeExpired()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to rotate log file %v: %v\n", f.options.Path, err)
+	}
+	return f.open()
+}
+
+func (f *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(f.options.Path)
+	return strings.TrimSuffix(f.options.Path, ext) + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// backups returns the rotated files from the newest to the oldest
+func (f *RotatingFile) backups() (names []string, err error) {
+	dir := filepath.Dir(f.options.Path)
+	ext := filepath.Ext(f.options.Path)
+	prefix := strings.TrimSuffix(filepath.Base(f.options.Path), ext) + "-"
+
+	var files []os.FileInfo
+	files, err = ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimSuffix(name, compressSuffix), ext)[len(prefix):]
+		if _, e := time.Parse(backupTimeFormat, ts); e == nil {
+			names = append(names, filepath.Join(dir, name))
+		}
+	}
+	// the time format sorts in time order
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return
+}
+
+func (f *RotatingFile) removeExpired() (err error) {
+	if f.options.MaxBackups <= 0 && f.options.MaxAge <= 0 {
+		return
+	}
+
+	var names []string
+	names, err = f.backups()
+	if err != nil {
+		return
+	}
+	ext := filepath.Ext(f.options.Path)
+	prefix := strings.TrimSuffix(f.options.Path, ext) + "-"
+	cutoff := f.now().Add(-f.options.MaxAge)
+	for i, name := range names {
+		expired := f.options.MaxBackups > 0 && i >= f.options.MaxBackups
+		if !expired && f.options.MaxAge > 0 {
+			ts := strings.TrimSuffix(strings.TrimSuffix(name, compressSuffix), ext)[len(prefix):]
+			t, _ := time.ParseInLocation(backupTimeFormat, ts, time.Local)
+			expired = t.Before(cutoff)
+		}
+		if expired {
+			if e := os.Remove(name); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return
+}
+
+func compress(name string) (err error) {
+	var src, dst *os.File
+	if src, err = os.Open(name); err != nil {
+		return
+	}
+	defer src.Close()
+
+	if dst, err = os.Create(name + compressSuffix); err != nil {
+		return
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if e := gz.Close(); err == nil {
+		err = e
+	}
+	if e := dst.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(name + compressSuffix)
+		return
+	}
+	return os.Remove(name)
+}
+
+// fileOutput is added to the output of the default logger, so that the log file can be changed at runtime
+type fileOutput struct {
+	mu       sync.RWMutex
+	file     *RotatingFile
+	attached bool
+}
+
+var output = new(fileOutput)
+
+// Write writes p to the log file if it is set
+func (o *fileOutput) Write(p []byte) (n int, err error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if o.file == nil {
+		return len(p), nil
+	}
+	return o.file.Write(p)
+}
+
+// attach adds the file output to the default logger if the log file is set and it is not added yet
+func (o *fileOutput) attach() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.file != nil && !o.attached {
+		golog.AddOutput(o)
+		o.attached = true
+	}
+}
+
+// detach marks the file output as removed, as the output of the default logger is replaced
+func (o *fileOutput) detach() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.attached = false
+}
+
+// SetFile writes the log to the rotating file besides the console, the previous log file is closed,
+// the log file is disabled if the path is empty
+func SetFile(options FileOptions) (err error) {
+	var file *RotatingFile
+	if options.Path != "" {
+		if file, err = NewRotatingFile(options); err != nil {
+			return
+		}
+	}
+
+	output.mu.Lock()
+	previous := output.file
+	output.file = file
+	output.mu.Unlock()
+	output.attach()
+
+	if previous != nil {
+		err = previous.Close()
+	}
+	return
+}
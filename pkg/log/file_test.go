@@ -0,0 +1,116 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2019, 1, 1, 10, 0, 0, 0, time.Local)
+	clock := func() time.Time { return now }
+
+	newFile := func(options FileOptions) *RotatingFile {
+		f := &RotatingFile{options: options, now: clock}
+		assert.Equal(t, nil, f.open())
+		return f
+	}
+
+	t.Run("should rotate by size", func(t *testing.T) {
+		path := filepath.Join(dir, "size", "app.log")
+		f := newFile(FileOptions{Path: path, MaxSize: 10})
+		defer f.Close()
+
+		_, err := f.Write([]byte("12345678\n"))
+		assert.Equal(t, nil, err)
+		now = now.Add(time.Second)
+		_, err = f.Write([]byte("abc\n"))
+		assert.Equal(t, nil, err)
+
+		b, _ := ioutil.ReadFile(path)
+		assert.Equal(t, "abc\n", string(b))
+		names, _ := f.backups()
+		assert.Equal(t, 1, len(names))
+		b, _ = ioutil.ReadFile(names[0])
+		assert.Equal(t, "12345678\n", string(b))
+	})
+
+	t.Run("should rotate by time and compress", func(t *testing.T) {
+		path := filepath.Join(dir, "time", "app.log")
+		f := newFile(FileOptions{Path: path, Interval: time.Hour, Compress: true})
+		defer f.Close()
+
+		_, err := f.Write([]byte("first\n"))
+		assert.Equal(t, nil, err)
+		now = now.Add(time.Hour)
+		_, err = f.Write([]byte("second\n"))
+		assert.Equal(t, nil, err)
+
+		names, _ := f.backups()
+		assert.Equal(t, 1, len(names))
+		assert.Equal(t, compressSuffix, filepath.Ext(names[0]))
+		gz, err := os.Open(names[0])
+		assert.Equal(t, nil, err)
+		defer gz.Close()
+		r, err := gzip.NewReader(gz)
+		assert.Equal(t, nil, err)
+		b, _ := ioutil.ReadAll(r)
+		assert.Equal(t, "first\n", string(b))
+	})
+
+	t.Run("should retain max backups and remove expired backups", func(t *testing.T) {
+		path := filepath.Join(dir, "retention", "app.log")
+		f := newFile(FileOptions{Path: path, MaxBackups: 2, MaxAge: 90 * time.Minute})
+		defer f.Close()
+
+		for i := 0; i < 4; i++ {
+			f.Write([]byte("log\n"))
+			now = now.Add(time.Minute)
+			assert.Equal(t, nil, f.Rotate())
+		}
+		names, _ := f.backups()
+		assert.Equal(t, 2, len(names))
+
+		now = now.Add(2 * time.Hour)
+		assert.Equal(t, nil, f.Rotate())
+		names, _ = f.backups()
+		assert.Equal(t, 1, len(names))
+	})
+
+	t.Run("should write log to file", func(t *testing.T) {
+		path := filepath.Join(dir, "output", "app.log")
+		assert.Equal(t, nil, SetFile(FileOptions{Path: path}))
+		SetLevel(InfoLevel)
+		SetOutput(ioutil.Discard)
+		Info("written to file")
+		SetOutput(os.Stdout)
+		assert.Equal(t, nil, SetFile(FileOptions{}))
+		Info("not written to file")
+
+		b, _ := ioutil.ReadFile(path)
+		assert.Contains(t, string(b), "written to file")
+		assert.NotContains(t, string(b), "not written to file")
+	})
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"github.com/kataras/golog"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+var (
+	// levels is the level overrides by package or logger name
+	levels       = map[string]string{}
+	levelLoggers = map[string]*golog.Logger{}
+	levelsLock   sync.RWMutex
+)
+
+// SetLevels replaces the level overrides of packages or loggers, e.g. {"factory": "debug", "inject": "debug"}
+//
+// The name matches a package if it is one or more trailing or inner segments of the package path,
+// e.g. factory matches hidevops.io/hiboot/pkg/factory and hidevops.io/hiboot/pkg/factory/instantiate,
+// the longest matched name wins. The name matches the logger returned by Child(name) as well.
+func SetLevels(levelNames map[string]string) {
+	levelsLock.Lock()
+	defer levelsLock.Unlock()
+	for name := range levels {
+		if _, ok := levelNames[name]; !ok {
+			golog.Child(name).Level = golog.Default.Level
+		}
+	}
+	levels = map[string]string{}
+	for name, levelName := range levelNames {
+		if levelName != "" {
+			levels[name] = levelName
+		}
+	}
+	refreshLevelLoggers()
+}
+
+// SetLoggerLevel overrides the level of the package or logger by name, the override is removed if levelName is empty
+func SetLoggerLevel(name string, levelName string) {
+	levelsLock.Lock()
+	defer levelsLock.Unlock()
+	if levelName == "" {
+		delete(levels, name)
+		golog.Child(name).Level = golog.Default.Level
+	} else {
+		levels[name] = levelName
+	}
+	refreshLevelLoggers()
+}
+
+// GetLevels returns the level overrides of packages or loggers
+func GetLevels() map[string]string {
+	levelsLock.RLock()
+	defer levelsLock.RUnlock()
+	retVal := make(map[string]string, len(levels))
+	for name, levelName := range levels {
+		retVal[name] = levelName
+	}
+	return retVal
+}
+
+// refreshLevelLoggers clones the default logger for each level override, so that the log lines of the package
+// are filtered by its own level, it should be called with levelsLock held
+func refreshLevelLoggers() {
+	levelLoggers = make(map[string]*golog.Logger, len(levels))
+	for name, levelName := range levels {
+		l := golog.Default.Clone()
+		l.SetLevel(levelName)
+		levelLoggers[name] = l
+		golog.Child(name).SetLevel(levelName)
+	}
+}
+
+func refreshLevels() {
+	levelsLock.Lock()
+	defer levelsLock.Unlock()
+	refreshLevelLoggers()
+}
+
+// callerLogger returns the logger of the caller package, or the default logger if the package has no level override
+func callerLogger(skip int) *golog.Logger {
+	levelsLock.RLock()
+	defer levelsLock.RUnlock()
+	if len(levelLoggers) == 0 {
+		return golog.Default
+	}
+
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return golog.Default
+	}
+	pkg := "/" + packageName(runtime.FuncForPC(pc).Name()) + "/"
+
+	logger, matched := golog.Default, ""
+	for name, l := range levelLoggers {
+		if len(name) > len(matched) && strings.Contains(pkg, "/"+name+"/") {
+			logger, matched = l, name
+		}
+	}
+	return logger
+}
+
+// packageName returns the package path of the function name, e.g. hidevops.io/hiboot/pkg/inject.(*inject).IntoObject
+func packageName(fnName string) string {
+	slash := strings.LastIndex(fnName, "/")
+	if dot := strings.Index(fnName[slash+1:], "."); dot >= 0 {
+		return fnName[:slash+1+dot]
+	}
+	return fnName
+}
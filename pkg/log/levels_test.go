@@ -0,0 +1,80 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"github.com/kataras/golog"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	SetLevel(InfoLevel)
+	defer func() {
+		SetLevels(nil)
+		SetOutput(os.Stdout)
+	}()
+
+	t.Run("should parse package name", func(t *testing.T) {
+		assert.Equal(t, "hidevops.io/hiboot/pkg/inject", packageName("hidevops.io/hiboot/pkg/inject.(*inject).IntoObject"))
+		assert.Equal(t, "main", packageName("main.main"))
+	})
+
+	t.Run("should write debug log of the package with level override", func(t *testing.T) {
+		buf.Reset()
+		Debug("hidden")
+		assert.Equal(t, "", buf.String())
+
+		SetLevels(map[string]string{"log": DebugLevel})
+		assert.Equal(t, map[string]string{"log": DebugLevel}, GetLevels())
+		Debug("shown")
+		Debugf("shown %v", "too")
+		WithField("foo", "bar").Debug("entry")
+		assert.Contains(t, buf.String(), "shown")
+		assert.Contains(t, buf.String(), "shown too")
+		assert.Contains(t, buf.String(), "entry foo=bar")
+		assert.Equal(t, InfoLevel, GetLevel())
+	})
+
+	t.Run("should apply the longest matched name", func(t *testing.T) {
+		buf.Reset()
+		SetLevels(map[string]string{"hiboot": DebugLevel, "pkg/log": ErrorLevel})
+		Info("hidden")
+		Warn("hidden")
+		Error("shown")
+		assert.NotContains(t, buf.String(), "hidden")
+		assert.Contains(t, buf.String(), "shown")
+	})
+
+	t.Run("should not match partial segment", func(t *testing.T) {
+		buf.Reset()
+		SetLevels(map[string]string{"lo": DebugLevel})
+		Debug("hidden")
+		assert.Equal(t, "", buf.String())
+	})
+
+	t.Run("should override level of child logger", func(t *testing.T) {
+		SetLoggerLevel("child", DebugLevel)
+		assert.Equal(t, golog.DebugLevel, Child("child").Level)
+		SetLoggerLevel("child", "")
+		assert.Equal(t, golog.InfoLevel, Child("child").Level)
+		_, ok := GetLevels()["child"]
+		assert.Equal(t, false, ok)
+	})
+}
@@ -50,8 +50,6 @@ func callerInfo(skip int) (file string, line int, fn string) {
 	return
 }
 
-// TODO: logger should be able to filter out package by name
-
 var withCaller = func(fn func(v ...interface{}), v ...interface{}) {
 	argv := make([]interface{}, 1)
 	_, line, fnName := callerInfo(3)
@@ -79,11 +77,18 @@ func NewLine(newLineChar string) {
 // Reset re-sets the default logger to an empty one.
 func Reset() {
 	golog.Reset()
+	golog.Handle(handleEncoding)
+	refreshLevels()
+	output.detach()
+	output.attach()
 }
 
 // SetOutput overrides the golog.Logger's Printer's output with another `io.Writer`.
+// The log file set by SetFile is kept.
 func SetOutput(w io.Writer) {
 	golog.SetOutput(w)
+	output.detach()
+	output.attach()
 }
 
 // AddOutput adds one or more `io.Writer` to the golog.Logger's Printer.
@@ -102,6 +107,7 @@ func AddOutput(writers ...io.Writer) {
 //
 // Returns itself.
 func SetPrefix(s string) *golog.Logger {
+	defer refreshLevels()
 	return golog.SetPrefix(s)
 }
 
@@ -109,6 +115,7 @@ func SetPrefix(s string) *golog.Logger {
 // if "s" is empty then time representation will be off.
 func SetTimeFormat(s string) {
 	golog.SetTimeFormat(s)
+	refreshLevels()
 }
 
 // SetLevel accepts a string representation of
@@ -128,6 +135,21 @@ func SetLevel(levelName string) {
 	golog.SetLevel(levelName)
 }
 
+// GetLevel returns the name of current logging level
+func GetLevel() string {
+	return levelName(golog.Default.Level)
+}
+
+// IsLevel returns true if levelName is one of the available level names
+func IsLevel(levelName string) bool {
+	for _, meta := range golog.Levels {
+		if meta.Name == levelName {
+			return true
+		}
+	}
+	return false
+}
+
 // Print prints a log message without levels and colors.
 func Print(v ...interface{}) {
 	golog.Print(v...)
@@ -150,55 +172,55 @@ func Logf(level golog.Level, format string, args ...interface{}) {
 // If the logger's level is fatal, error, warn, info or debug
 // then it will print the log message too.
 func Fatal(v ...interface{}) {
-	withCaller(golog.Fatal, v...)
+	withCaller(callerLogger(1).Fatal, v...)
 }
 
 // Fatalf will `os.Exit(1)` no matter the level of the logger.
 // If the logger's level is fatal, error, warn, info or debug
 // then it will print the log message too.
 func Fatalf(format string, args ...interface{}) {
-	withCallerf(golog.Fatalf, format, args...)
+	withCallerf(callerLogger(1).Fatalf, format, args...)
 }
 
 // Error will print only when logger's Level is error, warn, info or debug.
 func Error(v ...interface{}) {
-	withCaller(golog.Error, v...)
+	withCaller(callerLogger(1).Error, v...)
 }
 
 // Errorf will print only when logger's Level is error, warn, info or debug.
 func Errorf(format string, args ...interface{}) {
-	withCallerf(golog.Errorf, format, args...)
+	withCallerf(callerLogger(1).Errorf, format, args...)
 }
 
 // Warn will print when logger's Level is warn, info or debug.
 func Warn(v ...interface{}) {
-	golog.Warn(v...)
+	callerLogger(1).Warn(v...)
 }
 
 // Warnf will print when logger's Level is warn, info or debug.
 func Warnf(format string, args ...interface{}) {
-	golog.Warnf(format, args...)
+	callerLogger(1).Warnf(format, args...)
 }
 
 // Info will print when logger's Level is info or debug.
 func Info(v ...interface{}) {
-	golog.Info(v...)
+	callerLogger(1).Info(v...)
 }
 
 // Infof will print when logger's Level is info or debug.
 func Infof(format string, args ...interface{}) {
-	golog.Infof(format, args...)
+	callerLogger(1).Infof(format, args...)
 }
 
 // Debug will print when logger's Level is debug.
 func Debug(v ...interface{}) {
-	withCaller(golog.Debug, v...)
+	withCaller(callerLogger(1).Debug, v...)
 }
 
 // Debugf will print when logger's Level is debug.
 func Debugf(format string, args ...interface{}) {
 
-	withCallerf(golog.Debugf, format, args...)
+	withCallerf(callerLogger(1).Debugf, format, args...)
 }
 
 // Install receives  an external logger
@@ -246,6 +268,7 @@ func InstallStd(logger golog.StdLogger) {
 // The `Log` value holds the level of the print operation as well.
 func Handle(handler golog.Handler) {
 	golog.Handle(handler)
+	refreshLevels()
 }
 
 // Hijack adds a hijacker to the low-level logger's Printer.
@@ -265,7 +288,7 @@ func Scan(r io.Reader) (cancel func()) {
 // Child (creates if not exists and) returns a new child
 // Logger based on the default package-level logger instance.
 //
-// Can be used to separate logs by category.
+// Can be used to separate logs by category, its level can be overridden by SetLevels or SetLoggerLevel.
 func Child(name string) *golog.Logger {
 	return golog.Child(name)
 }
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"github.com/kataras/golog"
 	"github.com/kataras/pio"
+	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
 	"time"
@@ -86,6 +87,13 @@ func TestLogging(t *testing.T) {
 		Logf(golog.DebugLevel, "testing %v", "...")
 	})
 
+	t.Run("should get logging level", func(t *testing.T) {
+		SetLevel(WarnLevel)
+		assert.Equal(t, WarnLevel, GetLevel())
+		assert.Equal(t, true, IsLevel(WarnLevel))
+		assert.Equal(t, false, IsLevel("verbose"))
+	})
+
 	t.Run("should pass log.Debug() test", func(t *testing.T) {
 		SetLevel(DebugLevel)
 		Debug("testing ...")
@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package actuator provide the health check endpoint for web application
+// Package actuator provide the health, info, env, configprops, mappings and loggers endpoints for web application
 package actuator
 
 import (
@@ -25,13 +25,8 @@ const (
 	Profile = "actuator"
 )
 
-type Properties struct {
-}
-
 type configuration struct {
 	at.AutoConfiguration
-
-	Properties Properties `mapstructure:"actuator"`
 }
 
 func newConfiguration() *configuration {
@@ -0,0 +1,86 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/utils/reflector"
+	"hidevops.io/hiboot/pkg/utils/str"
+	"reflect"
+)
+
+type configPropsController struct {
+	at.ManagementController
+	at.ContextPath `value:"${actuator.basePath:}/configprops"`
+	endpoint
+
+	configurableFactory factory.ConfigurableFactory
+}
+
+func init() {
+	app.Register(newConfigPropsController)
+}
+
+func newConfigPropsController(configurableFactory factory.ConfigurableFactory, properties *Properties) *configPropsController {
+	return &configPropsController{
+		endpoint:            endpoint{name: ConfigPropsEndpoint, properties: properties},
+		configurableFactory: configurableFactory,
+	}
+}
+
+// GET /configprops returns the bound properties of all configurations and configuration properties by name,
+// e.g. logging.configuration.properties, the secrets are masked
+func (c *configPropsController) Get() map[string]interface{} {
+	configProps := make(map[string]interface{})
+
+	for _, cfg := range c.configurableFactory.Configurations() {
+		cv := reflector.Indirect(reflect.ValueOf(cfg))
+		if cv.Kind() != reflect.Struct {
+			continue
+		}
+		name := reflector.GetLowerCamelFullName(cfg)
+		for i := 0; i < cv.NumField(); i++ {
+			field := cv.Type().Field(i)
+			prefix, ok := field.Tag.Lookup("mapstructure")
+			if ok && field.PkgPath == "" {
+				configProps[name+"."+str.ToLowerCamel(field.Name)] = c.bean(prefix, cv.Field(i).Interface())
+			}
+		}
+	}
+
+	for _, md := range c.configurableFactory.GetInstances(new(at.ConfigurationProperties)) {
+		metaData := factory.CastMetaData(md)
+		if metaData.Instance == nil {
+			continue
+		}
+		prefix, _ := reflector.FindEmbeddedFieldTag(metaData.Instance, "ConfigurationProperties", "value")
+		configProps[metaData.Name] = c.bean(prefix, metaData.Instance)
+	}
+
+	return configProps
+}
+
+func (c *configPropsController) bean(prefix string, properties interface{}) map[string]interface{} {
+	bean := map[string]interface{}{"prefix": prefix}
+	props, err := toMap(properties)
+	if err != nil {
+		bean["error"] = err.Error()
+	} else {
+		bean["properties"] = c.sanitize(props)
+	}
+	return bean
+}
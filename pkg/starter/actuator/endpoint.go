@@ -0,0 +1,95 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator
+
+import (
+	"encoding/json"
+	"fmt"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"net/http"
+)
+
+const (
+	// HealthEndpoint is the name of health endpoint
+	HealthEndpoint = "health"
+	// InfoEndpoint is the name of info endpoint
+	InfoEndpoint = "info"
+	// EnvEndpoint is the name of env endpoint
+	EnvEndpoint = "env"
+	// ConfigPropsEndpoint is the name of configprops endpoint
+	ConfigPropsEndpoint = "configprops"
+	// MappingsEndpoint is the name of mappings endpoint
+	MappingsEndpoint = "mappings"
+	// LoggersEndpoint is the name of loggers endpoint
+	LoggersEndpoint = "loggers"
+
+	sanitizedValue = "******"
+)
+
+// endpoint is embedded into the actuator controllers, it responds 404 if the endpoint is disabled
+type endpoint struct {
+	name       string
+	properties *Properties
+}
+
+// Before is the middleware of the endpoint, it is called by the dispatcher before each request
+func (e *endpoint) Before(ctx context.Context) {
+	if !e.properties.Enabled(e.name) {
+		ctx.ResponseError(fmt.Sprintf("actuator endpoint %v is disabled", e.name), http.StatusNotFound)
+		return
+	}
+	ctx.Next()
+}
+
+// sanitize masks the values of the properties that should be sanitized, the nil values are omitted
+func (e *endpoint) sanitize(properties map[string]interface{}) map[string]interface{} {
+	retVal := make(map[string]interface{}, len(properties))
+	for name, value := range properties {
+		switch {
+		case value == nil:
+			continue
+		case e.properties.Sanitize(name):
+			retVal[name] = sanitizedValue
+		default:
+			retVal[name] = e.sanitizeValue(value)
+		}
+	}
+	return retVal
+}
+
+// sanitizeValue masks the properties of the nested maps, including the maps in the lists
+func (e *endpoint) sanitizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return e.sanitize(v)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = e.sanitizeValue(item)
+		}
+		return items
+	}
+	return value
+}
+
+// toMap converts the properties struct to map by its json tags
+func toMap(properties interface{}) (retVal map[string]interface{}, err error) {
+	var b []byte
+	b, err = json.Marshal(properties)
+	if err == nil {
+		err = json.Unmarshal(b, &retVal)
+	}
+	return
+}
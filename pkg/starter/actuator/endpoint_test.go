@@ -0,0 +1,152 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/log"
+	"net/http"
+	"testing"
+)
+
+func TestEndpoints(t *testing.T) {
+	testApp := web.NewTestApp().
+		SetProperty("actuator.basePath", "/actuator").
+		SetProperty("actuator.endpoints.env.enabled", false).
+		SetProperty("actuator.endpoints.configprops.enabled", true).
+		SetProperty("actuator.endpoints.loggers.enabled", true).
+		SetProperty("info.team", "devops").
+		Run(t)
+
+	t.Run("should get info with base path", func(t *testing.T) {
+		info := testApp.Get("/actuator/info").Expect().Status(http.StatusOK).JSON().Object()
+		info.ValueEqual("team", "devops")
+		info.Value("app").Object().ContainsKey("name")
+		info.Value("build").Object().Value("go").String().NotEmpty()
+	})
+
+	t.Run("should get health with base path", func(t *testing.T) {
+		testApp.Get("/actuator/health").Expect().Status(http.StatusOK)
+	})
+
+	t.Run("should not get disabled endpoint", func(t *testing.T) {
+		testApp.Get("/actuator/env").Expect().Status(http.StatusNotFound)
+	})
+
+	t.Run("should get configuration properties", func(t *testing.T) {
+		props := testApp.Get("/actuator/configprops").Expect().Status(http.StatusOK).JSON().Object()
+		actuatorProps := props.Value("actuator.properties").Object()
+		actuatorProps.ValueEqual("prefix", "actuator")
+		actuatorProps.Value("properties").Object().ValueEqual("base_path", "/actuator")
+		props.Value("actuator.healthProperties").Object().ValueEqual("prefix", "actuator.health")
+	})
+
+	t.Run("should get mappings", func(t *testing.T) {
+		mappings := testApp.Get("/actuator/mappings").Expect().Status(http.StatusOK).JSON().Object().Value("mappings").Array()
+		mappings.Contains(map[string]interface{}{
+			"method":  http.MethodGet,
+			"path":    "/actuator/info",
+			"handler": "hidevops.io/hiboot/pkg/starter/actuator/infoController.Get",
+		})
+	})
+
+	t.Run("should change logging level at runtime", func(t *testing.T) {
+		level := log.GetLevel()
+		defer log.SetLevel(level)
+
+		testApp.Get("/actuator/loggers").Expect().Status(http.StatusOK).JSON().Object().ValueEqual("level", level)
+
+		testApp.Post("/actuator/loggers").WithJSON(map[string]string{"level": log.DebugLevel}).
+			Expect().Status(http.StatusOK).JSON().Object().ValueEqual("level", log.DebugLevel)
+		assert.Equal(t, log.DebugLevel, log.GetLevel())
+
+		testApp.Post("/actuator/loggers").WithJSON(map[string]string{"level": "verbose"}).
+			Expect().Status(http.StatusBadRequest)
+		assert.Equal(t, log.DebugLevel, log.GetLevel())
+	})
+
+	t.Run("should change logging level of package at runtime", func(t *testing.T) {
+		testApp.Post("/actuator/loggers").WithJSON(map[string]string{"name": "inject", "level": log.DebugLevel}).
+			Expect().Status(http.StatusOK).JSON().Object().
+			Value("loggers").Object().ValueEqual("inject", log.DebugLevel)
+		assert.Equal(t, map[string]string{"inject": log.DebugLevel}, log.GetLevels())
+
+		testApp.Post("/actuator/loggers").WithJSON(map[string]string{"name": "inject"}).
+			Expect().Status(http.StatusOK).JSON().Object().
+			Value("loggers").Object().Empty()
+		assert.Equal(t, map[string]string{}, log.GetLevels())
+	})
+}
+
+func TestSensitiveEndpoints(t *testing.T) {
+	testApp := web.NewTestApp().Run(t)
+
+	t.Run("should not expose the sensitive endpoints unless they are enabled explicitly", func(t *testing.T) {
+		testApp.Get("/env").Expect().Status(http.StatusNotFound)
+		testApp.Get("/configprops").Expect().Status(http.StatusNotFound)
+		testApp.Get("/loggers").Expect().Status(http.StatusNotFound)
+		testApp.Post("/loggers").WithJSON(map[string]string{"level": log.DebugLevel}).
+			Expect().Status(http.StatusNotFound)
+	})
+
+	t.Run("should expose the other endpoints by default", func(t *testing.T) {
+		testApp.Get("/info").Expect().Status(http.StatusOK)
+		testApp.Get("/mappings").Expect().Status(http.StatusOK)
+	})
+}
+
+func TestEnvEndpoint(t *testing.T) {
+	testApp := web.NewTestApp().
+		SetProperty("actuator.endpoints.env.enabled", true).
+		SetProperty("fake.name", "foo").
+		SetProperty("fake.password", "s3cr3t").
+		SetProperty("fake.users", []interface{}{
+			map[string]interface{}{"name": "bar", "token": "t0k3n"},
+		}).
+		Run(t)
+
+	t.Run("should get env with secrets masked", func(t *testing.T) {
+		env := testApp.Get("/env").Expect().Status(http.StatusOK).JSON().Object()
+		env.Value("profiles").Object().ContainsKey("active")
+		fake := env.Value("properties").Object().Value("fake").Object()
+		fake.ValueEqual("name", "foo")
+		fake.ValueEqual("password", "******")
+		fake.Value("users").Array().Element(0).Object().ValueEqual("token", "******")
+	})
+}
+
+func TestManagementServer(t *testing.T) {
+	testApp := web.NewTestApp().
+		SetProperty("management.server.port", "8081").
+		SetProperty("management.security.basicAuth.username", "admin").
+		SetProperty("management.security.basicAuth.password", "s3cr3t").
+		Run(t)
+
+	t.Run("should not serve actuator endpoints on main server", func(t *testing.T) {
+		testApp.Get("/health").Expect().Status(http.StatusNotFound)
+	})
+
+	t.Run("should serve actuator endpoints on management server", func(t *testing.T) {
+		testApp.Management(http.MethodGet, "/health").WithBasicAuth("admin", "s3cr3t").
+			Expect().Status(http.StatusOK)
+	})
+
+	t.Run("should protect management server by basic auth", func(t *testing.T) {
+		testApp.Management(http.MethodGet, "/health").Expect().Status(http.StatusUnauthorized)
+		testApp.Management(http.MethodGet, "/health").WithBasicAuth("admin", "wrong").
+			Expect().Status(http.StatusUnauthorized)
+	})
+}
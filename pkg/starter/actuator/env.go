@@ -0,0 +1,52 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+)
+
+type envController struct {
+	at.ManagementController
+	at.ContextPath `value:"${actuator.basePath:}/env"`
+	endpoint
+
+	configurableFactory factory.ConfigurableFactory
+}
+
+func init() {
+	app.Register(newEnvController)
+}
+
+func newEnvController(configurableFactory factory.ConfigurableFactory, properties *Properties) *envController {
+	return &envController{
+		endpoint:            endpoint{name: EnvEndpoint, properties: properties},
+		configurableFactory: configurableFactory,
+	}
+}
+
+// GET /env returns the active profiles and the effective properties, the secrets are masked
+func (c *envController) Get() map[string]interface{} {
+	profiles := c.configurableFactory.SystemConfiguration().App.Profiles
+	return map[string]interface{}{
+		"profiles": map[string]interface{}{
+			"active":  profiles.Active,
+			"include": profiles.Include,
+		},
+		"properties": c.sanitize(c.configurableFactory.Builder().GetProperties("")),
+	}
+}
@@ -16,58 +16,186 @@
 package actuator
 
 import (
+	"fmt"
 	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
 	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/starter/actuator/healthcheck"
+	"hidevops.io/hiboot/pkg/utils/str"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// StatusUp the service is functioning as expected
+	StatusUp = healthcheck.StatusUp
+	// StatusDown the service is suffering unexpected failures
+	StatusDown = healthcheck.StatusDown
+	// StatusOutOfService the service is taken out of service and should not be used
+	StatusOutOfService = healthcheck.StatusOutOfService
+	// StatusUnknown the status of the service is unknown, e.g. the timed out health check if actuator.health.timeoutStatus is UNKNOWN
+	StatusUnknown = healthcheck.StatusUnknown
 )
 
 // HealthService is the interface for health check
-type HealthService interface {
-	Name() string
-	Status() bool
-}
+type HealthService = healthcheck.HealthService
+
+// HealthStatus is the optional interface of HealthService that reports the status other than UP and DOWN,
+// e.g. OUT_OF_SERVICE, it takes precedence over Status()
+type HealthStatus = healthcheck.HealthStatus
+
+// HealthDetails is the optional interface of HealthService that provides the details of the health check
+type HealthDetails = healthcheck.HealthDetails
+
+// Health is the health check result
+type Health = healthcheck.Health
 
-// Health is the health check struct
-type Health struct {
-	Status string `json:"status"`
+type cachedHealth struct {
+	health  Health
+	expires time.Time
 }
 
 type healthController struct {
-	at.RestController
+	at.ManagementController
+	at.ContextPath `value:"${actuator.basePath:}/health"`
+	endpoint
 
 	configurableFactory factory.ConfigurableFactory
+	healthProperties    *HealthProperties
+
+	mutex sync.Mutex
+	cache map[string]cachedHealth
 }
 
 func init() {
 	app.Register(newHealthController)
 }
 
-func newHealthController(configurableFactory factory.ConfigurableFactory) *healthController {
-	return &healthController{configurableFactory: configurableFactory}
+func newHealthController(configurableFactory factory.ConfigurableFactory, properties *Properties, healthProperties *HealthProperties) *healthController {
+	return &healthController{
+		endpoint:            endpoint{name: HealthEndpoint, properties: properties},
+		configurableFactory: configurableFactory,
+		healthProperties:    healthProperties,
+		cache:               make(map[string]cachedHealth),
+	}
 }
 
-// GET /health
-func (c *healthController) Get() map[string]interface{} {
-	healthServices := c.configurableFactory.GetInstances(new(at.HealthCheckService))
-	healthCheckProfiles := make(map[string]interface{})
+// AggregateStatus returns the status of the highest severity, the order is DOWN, OUT_OF_SERVICE, UP, UNKNOWN,
+// it is UP if there is no status, as the application itself is running
+func AggregateStatus(statuses ...string) (retVal string) {
+	return healthcheck.AggregateStatus(statuses...)
+}
 
-	healthCheckProfiles["status"] = "Up"
-
-	if healthServices != nil {
-		for _, md := range healthServices {
-			metaData := factory.CastMetaData(md)
-			if metaData.Instance != nil {
-				healthService := metaData.Instance.(HealthService)
-				status := "Down"
-				if healthService.Status() {
-					status = "Up"
-				}
-				healthCheckProfiles[healthService.Name()] = Health{
-					Status: status,
-				}
-			}
+// HTTPStatus returns the http status code of the health status, 503 for DOWN and OUT_OF_SERVICE, otherwise 200
+func HTTPStatus(status string) int {
+	if !healthcheck.Available(status) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+func (c *healthController) healthServices(include []string) (services []HealthService) {
+	all := str.InSlice(IncludeAll, include)
+	for _, md := range c.configurableFactory.GetInstances(new(at.HealthCheckService)) {
+		metaData := factory.CastMetaData(md)
+		if metaData.Instance == nil {
+			continue
+		}
+		healthService, ok := metaData.Instance.(HealthService)
+		if ok && (all || str.InSlice(healthService.Name(), include)) {
+			services = append(services, healthService)
 		}
 	}
+	return
+}
+
+// check the health service with timeout, the result is cached for actuator.health.cacheTTL
+func (c *healthController) check(hs HealthService) (health Health) {
+	name := hs.Name()
+	c.mutex.Lock()
+	cached, ok := c.cache[name]
+	c.mutex.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.health
+	}
+
+	timeout := c.healthProperties.Timeout
+	if t, ok := c.healthProperties.Timeouts[name]; ok {
+		timeout = t
+	}
 
+	health = healthcheck.Check(hs, timeout, c.healthProperties.TimeoutStatus)
+	if !c.healthProperties.ShowDetails {
+		health.Details = nil
+	}
+	if c.healthProperties.CacheTTL > 0 {
+		c.mutex.Lock()
+		c.cache[name] = cachedHealth{health: health, expires: time.Now().Add(c.healthProperties.CacheTTL)}
+		c.mutex.Unlock()
+	}
+	return
+}
+
+// health checks the included health services in parallel, then aggregates the status
+func (c *healthController) health(ctx context.Context, include []string) map[string]interface{} {
+	services := c.healthServices(include)
+	results := make([]Health, len(services))
+	var wg sync.WaitGroup
+	for i, hs := range services {
+		wg.Add(1)
+		go func(i int, hs HealthService) {
+			defer wg.Done()
+			results[i] = c.check(hs)
+		}(i, hs)
+	}
+	wg.Wait()
+
+	healthCheckProfiles := make(map[string]interface{})
+	statuses := make([]string, len(services))
+	for i, hs := range services {
+		healthCheckProfiles[hs.Name()] = results[i]
+		statuses[i] = results[i].Status
+	}
+	status := AggregateStatus(statuses...)
+	healthCheckProfiles["status"] = status
+
+	ctx.StatusCode(HTTPStatus(status))
 	return healthCheckProfiles
 }
+
+// GET /health
+func (c *healthController) Get(ctx context.Context) map[string]interface{} {
+	return c.health(ctx, []string{IncludeAll})
+}
+
+// GET /health/liveness for kubernetes liveness probe
+func (c *healthController) GetLiveness(ctx context.Context) map[string]interface{} {
+	return c.GetByGroup(LivenessGroup, ctx)
+}
+
+// GET /health/readiness for kubernetes readiness probe
+func (c *healthController) GetReadiness(ctx context.Context) map[string]interface{} {
+	return c.GetByGroup(ReadinessGroup, ctx)
+}
+
+// GET /health/group/{group} for the health groups configured by actuator.health.groups.<group>.include
+func (c *healthController) GetByGroup(group string, ctx context.Context) map[string]interface{} {
+	g, ok := c.healthProperties.Groups[group]
+	if !ok {
+		switch group {
+		case LivenessGroup:
+			g = HealthGroup{}
+		case ReadinessGroup:
+			g = HealthGroup{Include: []string{IncludeAll}}
+		default:
+			ctx.StatusCode(http.StatusNotFound)
+			return map[string]interface{}{
+				"status":  StatusUnknown,
+				"message": fmt.Sprintf("health group %v is not found", group),
+			}
+		}
+	}
+	return c.health(ctx, g.Include)
+}
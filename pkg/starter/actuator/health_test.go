@@ -15,11 +15,13 @@
 package actuator
 
 import (
+	"github.com/stretchr/testify/assert"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/app/web"
 	"hidevops.io/hiboot/pkg/at"
 	"net/http"
 	"testing"
+	"time"
 )
 
 type fakeHealthCheckService struct {
@@ -38,8 +40,52 @@ func newFakeHealthCheckService() HealthService {
 	return &fakeHealthCheckService{}
 }
 
+// the status of fakeStatusHealthService that can be changed by test cases
+var fakeStatus = StatusUp
+
+type fakeStatusHealthService struct {
+	at.HealthCheckService
+}
+
+func (s *fakeStatusHealthService) Name() string {
+	return "fake-status"
+}
+
+func (s *fakeStatusHealthService) Status() bool {
+	return fakeStatus == StatusUp
+}
+
+func (s *fakeStatusHealthService) HealthStatus() string {
+	return fakeStatus
+}
+
+func (s *fakeStatusHealthService) Details() map[string]interface{} {
+	return map[string]interface{}{"version": "v1"}
+}
+
+func newFakeStatusHealthService() *fakeStatusHealthService {
+	return &fakeStatusHealthService{}
+}
+
+type slowHealthCheckService struct {
+	at.HealthCheckService
+}
+
+func (s *slowHealthCheckService) Name() string {
+	return "slow"
+}
+
+func (s *slowHealthCheckService) Status() bool {
+	time.Sleep(100 * time.Millisecond)
+	return true
+}
+
+func newSlowHealthCheckService() *slowHealthCheckService {
+	return &slowHealthCheckService{}
+}
+
 func init() {
-	app.Register(newFakeHealthCheckService)
+	app.Register(newFakeHealthCheckService, newFakeStatusHealthService, newSlowHealthCheckService)
 }
 
 func TestHealthController(t *testing.T) {
@@ -47,3 +93,126 @@ func TestHealthController(t *testing.T) {
 		Get("/health").
 		Expect().Status(http.StatusOK)
 }
+
+func TestAggregateStatus(t *testing.T) {
+	assert.Equal(t, StatusUp, AggregateStatus())
+	// the timed out health check is DOWN by default, it is UNKNOWN and ignored only if actuator.health.timeoutStatus is UNKNOWN
+	assert.Equal(t, StatusDown, AggregateStatus(StatusUp, StatusDown))
+	assert.Equal(t, StatusUp, AggregateStatus(StatusUp, StatusUnknown))
+	assert.Equal(t, StatusUnknown, AggregateStatus(StatusUnknown))
+	assert.Equal(t, StatusOutOfService, AggregateStatus(StatusUp, StatusOutOfService, StatusUnknown))
+	assert.Equal(t, StatusDown, AggregateStatus(StatusOutOfService, StatusDown, StatusUp))
+
+	assert.Equal(t, http.StatusOK, HTTPStatus(StatusUp))
+	assert.Equal(t, http.StatusOK, HTTPStatus(StatusUnknown))
+	assert.Equal(t, http.StatusServiceUnavailable, HTTPStatus(StatusDown))
+	assert.Equal(t, http.StatusServiceUnavailable, HTTPStatus(StatusOutOfService))
+}
+
+func TestHealthStatus(t *testing.T) {
+	testApp := web.NewTestApp().
+		SetProperty("actuator.health.cache-ttl", "0s").
+		SetProperty("actuator.health.timeouts.slow", "10ms").
+		SetProperty("actuator.health.timeout-status", StatusUnknown).
+		Run(t)
+
+	t.Run("should report UP with details and UNKNOWN for timed out health check", func(t *testing.T) {
+		fakeStatus = StatusUp
+		body := testApp.Get("/health").
+			Expect().Status(http.StatusOK).JSON().Object()
+		body.ValueEqual("status", StatusUp)
+		body.Value("fake").Object().ValueEqual("status", StatusUp)
+		body.Value("fake-status").Object().Value("details").Object().ValueEqual("version", "v1")
+		body.Value("slow").Object().ValueEqual("status", StatusUnknown)
+	})
+
+	t.Run("should report DOWN with 503", func(t *testing.T) {
+		fakeStatus = StatusDown
+		testApp.Get("/health").
+			Expect().Status(http.StatusServiceUnavailable).
+			JSON().Object().ValueEqual("status", StatusDown)
+	})
+
+	t.Run("should report OUT_OF_SERVICE with 503", func(t *testing.T) {
+		fakeStatus = StatusOutOfService
+		testApp.Get("/health").
+			Expect().Status(http.StatusServiceUnavailable).
+			JSON().Object().ValueEqual("status", StatusOutOfService)
+	})
+
+	t.Run("should report liveness UP without any health check", func(t *testing.T) {
+		fakeStatus = StatusDown
+		body := testApp.Get("/health/liveness").
+			Expect().Status(http.StatusOK).JSON().Object()
+		body.ValueEqual("status", StatusUp)
+		body.NotContainsKey("fake")
+	})
+
+	t.Run("should report readiness with all health checks", func(t *testing.T) {
+		fakeStatus = StatusDown
+		testApp.Get("/health/readiness").
+			Expect().Status(http.StatusServiceUnavailable).
+			JSON().Object().ContainsKey("fake")
+	})
+
+	t.Run("should report 404 for unknown group", func(t *testing.T) {
+		testApp.Get("/health/group/unknown").
+			Expect().Status(http.StatusNotFound)
+	})
+	fakeStatus = StatusUp
+}
+
+func TestHealthTimeoutStatus(t *testing.T) {
+	fakeStatus = StatusUp
+	testApp := web.NewTestApp().
+		SetProperty("actuator.health.cache-ttl", "0s").
+		SetProperty("actuator.health.timeouts.slow", "10ms").
+		Run(t)
+
+	t.Run("should report DOWN with 503 for timed out health check by default", func(t *testing.T) {
+		body := testApp.Get("/health").
+			Expect().Status(http.StatusServiceUnavailable).JSON().Object()
+		body.ValueEqual("status", StatusDown)
+		body.Value("fake").Object().ValueEqual("status", StatusUp)
+		body.Value("slow").Object().ValueEqual("status", StatusDown)
+		body.Value("slow").Object().Value("details").Object().ContainsKey("error")
+	})
+
+	t.Run("should report readiness DOWN with 503 for timed out health check by default", func(t *testing.T) {
+		testApp.Get("/health/readiness").
+			Expect().Status(http.StatusServiceUnavailable).
+			JSON().Object().ValueEqual("status", StatusDown)
+	})
+}
+
+func TestHealthGroupsAndCache(t *testing.T) {
+	fakeStatus = StatusUp
+	testApp := web.NewTestApp().
+		SetProperty("actuator.health.cache-ttl", "1m").
+		SetProperty("actuator.health.show-details", false).
+		SetProperty("actuator.health.groups.readiness.include", "fake").
+		SetProperty("actuator.health.groups.custom.include", "fake-status").
+		Run(t)
+
+	t.Run("should report configured readiness group", func(t *testing.T) {
+		fakeStatus = StatusDown
+		body := testApp.Get("/health/readiness").
+			Expect().Status(http.StatusOK).JSON().Object()
+		body.ContainsKey("fake")
+		body.NotContainsKey("fake-status")
+	})
+
+	t.Run("should report custom group without details", func(t *testing.T) {
+		fakeStatus = StatusUp
+		body := testApp.Get("/health/group/custom").
+			Expect().Status(http.StatusOK).JSON().Object()
+		body.Value("fake-status").Object().NotContainsKey("details")
+	})
+
+	t.Run("should report cached result", func(t *testing.T) {
+		fakeStatus = StatusDown
+		testApp.Get("/health/group/custom").
+			Expect().Status(http.StatusOK)
+	})
+	fakeStatus = StatusUp
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package healthcheck provides the health statuses and the health check of actuator, it is shared by the
+// starters that report the health without the actuator endpoints, e.g. grpc
+package healthcheck
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// StatusUp the service is functioning as expected
+	StatusUp = "UP"
+	// StatusDown the service is suffering unexpected failures
+	StatusDown = "DOWN"
+	// StatusOutOfService the service is taken out of service and should not be used
+	StatusOutOfService = "OUT_OF_SERVICE"
+	// StatusUnknown the status of the service is unknown, e.g. the timed out health check if actuator.health.timeoutStatus is UNKNOWN
+	StatusUnknown = "UNKNOWN"
+)
+
+// the status order by severity
+var statusOrder = []string{StatusDown, StatusOutOfService, StatusUp, StatusUnknown}
+
+// HealthService is the interface for health check
+type HealthService interface {
+	Name() string
+	Status() bool
+}
+
+// HealthStatus is the optional interface of HealthService that reports the status other than UP and DOWN,
+// e.g. OUT_OF_SERVICE, it takes precedence over Status()
+type HealthStatus interface {
+	HealthStatus() string
+}
+
+// HealthDetails is the optional interface of HealthService that provides the details of the health check
+type HealthDetails interface {
+	Details() map[string]interface{}
+}
+
+// Health is the health check result
+type Health struct {
+	Status  string                 `json:"status"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Check checks the health service, the status is timeoutStatus if it is timed out, DOWN if timeoutStatus is empty
+func Check(hs HealthService, timeout time.Duration, timeoutStatus string) (health Health) {
+	result := make(chan Health, 1)
+	go func() {
+		result <- doCheck(hs)
+	}()
+	select {
+	case health = <-result:
+	case <-time.After(timeout):
+		if timeoutStatus == "" {
+			timeoutStatus = StatusDown
+		}
+		health = Health{
+			Status:  timeoutStatus,
+			Details: map[string]interface{}{"error": fmt.Sprintf("health check is timed out after %v", timeout)},
+		}
+	}
+	return
+}
+
+func doCheck(hs HealthService) (health Health) {
+	if s, ok := hs.(HealthStatus); ok {
+		health.Status = s.HealthStatus()
+	} else if hs.Status() {
+		health.Status = StatusUp
+	} else {
+		health.Status = StatusDown
+	}
+	if d, ok := hs.(HealthDetails); ok {
+		health.Details = d.Details()
+	}
+	return
+}
+
+// AggregateStatus returns the status of the highest severity, the order is DOWN, OUT_OF_SERVICE, UP, UNKNOWN,
+// it is UP if there is no status, as the application itself is running
+func AggregateStatus(statuses ...string) (retVal string) {
+	if len(statuses) == 0 {
+		return StatusUp
+	}
+	idx := len(statusOrder) - 1
+	for _, status := range statuses {
+		for i, s := range statusOrder {
+			if s == status && i < idx {
+				idx = i
+			}
+		}
+	}
+	return statusOrder[idx]
+}
+
+// Available returns false if the status is DOWN or OUT_OF_SERVICE
+func Available(status string) bool {
+	return status != StatusDown && status != StatusOutOfService
+}
@@ -0,0 +1,57 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package healthcheck
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type fakeHealthService struct {
+	delay time.Duration
+}
+
+func (s *fakeHealthService) Name() string {
+	return "fake"
+}
+
+func (s *fakeHealthService) Status() bool {
+	time.Sleep(s.delay)
+	return true
+}
+
+func TestCheck(t *testing.T) {
+	t.Run("should report UP", func(t *testing.T) {
+		assert.Equal(t, StatusUp, Check(&fakeHealthService{}, time.Second, "").Status)
+	})
+
+	t.Run("should report DOWN if it is timed out", func(t *testing.T) {
+		health := Check(&fakeHealthService{delay: time.Second}, 10*time.Millisecond, "")
+		assert.Equal(t, StatusDown, health.Status)
+		assert.Contains(t, health.Details, "error")
+	})
+
+	t.Run("should report the timeout status if it is timed out", func(t *testing.T) {
+		assert.Equal(t, StatusUnknown, Check(&fakeHealthService{delay: time.Second}, 10*time.Millisecond, StatusUnknown).Status)
+	})
+}
+
+func TestAvailable(t *testing.T) {
+	assert.True(t, Available(StatusUp))
+	assert.True(t, Available(StatusUnknown))
+	assert.False(t, Available(StatusDown))
+	assert.False(t, Available(StatusOutOfService))
+}
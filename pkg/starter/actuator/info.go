@@ -0,0 +1,73 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"runtime"
+)
+
+// the build info can be set by ldflags, e.g.
+//
+//	go build -ldflags "-X hidevops.io/hiboot/pkg/starter/actuator.BuildVersion=v1.0.0 -X hidevops.io/hiboot/pkg/starter/actuator.BuildCommit=$(git rev-parse HEAD)"
+var (
+	// BuildVersion is the version of the build
+	BuildVersion string
+	// BuildCommit is the commit of the build
+	BuildCommit string
+	// BuildTime is the time of the build
+	BuildTime string
+)
+
+type infoController struct {
+	at.ManagementController
+	at.ContextPath `value:"${actuator.basePath:}/info"`
+	endpoint
+
+	configurableFactory factory.ConfigurableFactory
+}
+
+func init() {
+	app.Register(newInfoController)
+}
+
+func newInfoController(configurableFactory factory.ConfigurableFactory, properties *Properties) *infoController {
+	return &infoController{
+		endpoint:            endpoint{name: InfoEndpoint, properties: properties},
+		configurableFactory: configurableFactory,
+	}
+}
+
+// GET /info returns the app and build info, the properties under info, e.g. info.team: devops, are included as well
+func (c *infoController) Get() map[string]interface{} {
+	info := c.configurableFactory.Builder().GetProperties(InfoEndpoint)
+
+	appInfo := c.configurableFactory.SystemConfiguration().App
+	info["app"] = map[string]interface{}{
+		"project":     appInfo.Project,
+		"name":        appInfo.Name,
+		"description": appInfo.Description,
+		"version":     appInfo.Version,
+	}
+	info["build"] = map[string]interface{}{
+		"version": BuildVersion,
+		"commit":  BuildCommit,
+		"time":    BuildTime,
+		"go":      runtime.Version(),
+	}
+	return info
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator
+
+import (
+	"fmt"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+	"net/http"
+)
+
+// the available logging levels in order of verbosity
+var loggingLevels = []string{log.DebugLevel, log.InfoLevel, log.WarnLevel, log.ErrorLevel, log.FatalLevel, log.Disable}
+
+type loggerRequest struct {
+	at.RequestBody
+	// the package or logger name, the global level is changed if it is empty
+	Name  string `json:"name"`
+	Level string `json:"level"`
+}
+
+type loggersController struct {
+	at.ManagementController
+	at.ContextPath `value:"${actuator.basePath:}/loggers"`
+	endpoint
+}
+
+func init() {
+	app.Register(newLoggersController)
+}
+
+func newLoggersController(properties *Properties) *loggersController {
+	return &loggersController{
+		endpoint: endpoint{name: LoggersEndpoint, properties: properties},
+	}
+}
+
+// GET /loggers returns the current logging level, the level overrides of packages or loggers and the available levels
+func (c *loggersController) Get() map[string]interface{} {
+	return map[string]interface{}{
+		"level":   log.GetLevel(),
+		"loggers": log.GetLevels(),
+		"levels":  loggingLevels,
+	}
+}
+
+// POST /loggers changes the logging level at runtime, e.g. {"level": "debug"},
+// or the level of the package or logger, e.g. {"name": "inject", "level": "debug"}, the empty level removes the override
+func (c *loggersController) Post(request *loggerRequest, ctx context.Context) map[string]interface{} {
+	if request.Name != "" && request.Level == "" {
+		log.SetLoggerLevel(request.Name, "")
+		log.Infof("logging level of %v is reset", request.Name)
+		return c.Get()
+	}
+
+	if !log.IsLevel(request.Level) {
+		ctx.StatusCode(http.StatusBadRequest)
+		return map[string]interface{}{
+			"message": fmt.Sprintf("invalid logging level %q, it should be one of %v", request.Level, loggingLevels),
+		}
+	}
+	if request.Name != "" {
+		log.SetLoggerLevel(request.Name, request.Level)
+		log.Infof("logging level of %v is changed to %v", request.Name, request.Level)
+		return c.Get()
+	}
+	log.SetLevel(request.Level)
+	log.Infof("logging level is changed to %v", request.Level)
+	return c.Get()
+}
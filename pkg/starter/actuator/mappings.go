@@ -0,0 +1,47 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/at"
+)
+
+type mappingsController struct {
+	at.ManagementController
+	at.ContextPath `value:"${actuator.basePath:}/mappings"`
+	endpoint
+
+	dispatcher *web.Dispatcher
+}
+
+func init() {
+	app.Register(newMappingsController)
+}
+
+func newMappingsController(dispatcher *web.Dispatcher, properties *Properties) *mappingsController {
+	return &mappingsController{
+		endpoint:   endpoint{name: MappingsEndpoint, properties: properties},
+		dispatcher: dispatcher,
+	}
+}
+
+// GET /mappings returns the http method, path and handler name of all routes
+func (c *mappingsController) Get() map[string]interface{} {
+	return map[string]interface{}{
+		"mappings": c.dispatcher.Mappings(),
+	}
+}
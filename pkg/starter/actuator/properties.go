@@ -0,0 +1,112 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/utils/str"
+	"strings"
+	"time"
+)
+
+const (
+	// IncludeAll includes all health services into the health group
+	IncludeAll = "*"
+
+	// LivenessGroup is the health group for liveness probe
+	LivenessGroup = "liveness"
+	// ReadinessGroup is the health group for readiness probe
+	ReadinessGroup = "readiness"
+)
+
+// sensitiveEndpoints expose the configuration or change the application at runtime,
+// they are disabled unless they are enabled explicitly, e.g. actuator.endpoints.loggers.enabled: true
+var sensitiveEndpoints = []string{EnvEndpoint, ConfigPropsEndpoint, LoggersEndpoint}
+
+// Endpoint is the properties of actuator endpoint, e.g. actuator.endpoints.env.enabled: false
+type Endpoint struct {
+	// enable the endpoint, the disabled endpoint responds 404
+	Enabled bool `json:"enabled" default:"true"`
+}
+
+// Properties is the properties of actuator
+type Properties struct {
+	at.ConfigurationProperties `value:"actuator"`
+
+	// the base path of the actuator endpoints, e.g. /actuator for /actuator/health
+	BasePath string `json:"base_path" mapstructure:"basePath"`
+	// the endpoints by name, health, info and mappings are enabled by default, env, configprops and loggers are opt-in
+	Endpoints map[string]Endpoint `json:"endpoints"`
+	// the values of the properties whose names contain any of the keys are masked by env and configprops
+	KeysToSanitize []string `json:"keys_to_sanitize" mapstructure:"keysToSanitize" default:"password,secret,key,token,credential"`
+}
+
+// Enabled returns true if the endpoint is not disabled, the sensitive endpoint should be enabled explicitly
+func (p *Properties) Enabled(name string) bool {
+	endpoint, ok := p.Endpoints[name]
+	if !ok {
+		return !str.InSlice(name, sensitiveEndpoints)
+	}
+	return endpoint.Enabled
+}
+
+// Sanitize returns true if the value of the property should be masked
+func (p *Properties) Sanitize(name string) bool {
+	name = strings.ToLower(name)
+	for _, key := range p.KeysToSanitize {
+		if key != "" && strings.Contains(name, strings.ToLower(key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthGroup is the group of health services, e.g. actuator.health.groups.readiness.include: [db, redis]
+type HealthGroup struct {
+	// the names of the health services, * for all, the group is always UP if it is empty
+	Include []string `json:"include"`
+}
+
+// HealthProperties is the properties of health check
+type HealthProperties struct {
+	at.ConfigurationProperties `value:"actuator.health"`
+
+	// the timeout of each health check, the status is timeoutStatus if it is timed out
+	Timeout time.Duration `json:"timeout" default:"3s"`
+	// the status of the timed out health check, DOWN by default so that the hung service fails the probes,
+	// e.g. UNKNOWN to ignore the timed out health checks
+	TimeoutStatus string `json:"timeout_status" mapstructure:"timeoutStatus" default:"DOWN"`
+	// the timeouts that override the timeout of the named health services
+	Timeouts map[string]time.Duration `json:"timeouts"`
+	// the time to live of cached health check result, 0 for no cache
+	CacheTTL time.Duration `json:"cache_ttl" mapstructure:"cacheTTL" default:"1s"`
+	// show the details of health services
+	ShowDetails bool `json:"show_details" default:"true"`
+	// the health groups, liveness includes nothing and readiness includes all by default
+	Groups map[string]HealthGroup `json:"groups"`
+}
+
+func newProperties() *Properties {
+	return &Properties{}
+}
+
+func newHealthProperties() *HealthProperties {
+	return &HealthProperties{}
+}
+
+func init() {
+	app.Register(newProperties, newHealthProperties)
+}
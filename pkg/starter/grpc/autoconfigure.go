@@ -16,12 +16,16 @@
 package grpc
 
 import (
+	"fmt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	pb "google.golang.org/grpc/health/grpc_health_v1"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/system"
 	"hidevops.io/hiboot/pkg/utils/cmap"
+	"hidevops.io/hiboot/pkg/utils/crypto/tlsconfig"
 	"hidevops.io/hiboot/pkg/utils/reflector"
 	"reflect"
 )
@@ -29,6 +33,9 @@ import (
 const (
 	// Profile is the profile of grpc, it should be as same as the package name
 	Profile = "grpc"
+
+	// defaultPort is the default port of the server and the clients
+	defaultPort = "7575"
 )
 
 type configuration struct {
@@ -36,6 +43,9 @@ type configuration struct {
 	Properties properties `mapstructure:"grpc"`
 
 	instantiateFactory factory.InstantiateFactory
+	interceptors       *interceptors
+	// the reloader of the certificate files of server.ssl, it is watched until the server is stopped
+	reloader *tlsconfig.Reloader
 }
 
 type grpcService struct {
@@ -123,6 +133,7 @@ func newConfiguration(instantiateFactory factory.InstantiateFactory) *configurat
 	c := &configuration{
 		instantiateFactory: instantiateFactory,
 	}
+	c.interceptors = newInterceptors(instantiateFactory, &c.Properties.Interceptors)
 
 	// we need to specify dependencies for runtime dependency injection
 	var dep []string
@@ -139,7 +150,7 @@ func newConfiguration(instantiateFactory factory.InstantiateFactory) *configurat
 // ClientConnector is the interface that connect to grpc client
 // it can be injected to struct at runtime
 func (c *configuration) ClientConnector() ClientConnector {
-	return newClientConnector(c.instantiateFactory)
+	return newClientConnector(c.instantiateFactory, c.interceptors, &c.Properties)
 }
 
 // GrpcClientFactory create gRPC Clients that registered by application
@@ -147,17 +158,68 @@ func (c *configuration) ClientFactory(cc ClientConnector) ClientFactory {
 	return newClientFactory(c.instantiateFactory, c.Properties, cc)
 }
 
-// GrpcServer create new gRpc Server
+// GrpcServer create new gRpc Server, it serves tls by server.ssl if server.ssl.enabled is true
 func (c *configuration) Server() (grpcServer *grpc.Server) {
 	// just return if grpc server is not enabled
 	if c.Properties.Server.Enabled {
-		grpcServer = grpc.NewServer()
+		opts := append([]grpc.ServerOption{
+			grpc.UnaryInterceptor(c.interceptors.unaryServerInterceptor),
+			grpc.StreamInterceptor(c.interceptors.streamServerInterceptor),
+		}, serverOptions(&c.Properties.Server)...)
+		if ssl := c.ssl(); ssl != nil && ssl.Enabled {
+			creds, reloader, err := serverCredentials(ssl)
+			if err != nil {
+				log.Errorf("failed to create the tls credentials of gRPC server: %v", err)
+				return
+			}
+			opts = append(opts, grpc.Creds(creds))
+			if ssl.ReloadInterval > 0 {
+				c.reloader = reloader
+			}
+		}
+		grpcServer = grpc.NewServer(opts...)
 	}
 	return
 }
 
-// GrpcServerFactory create gRPC servers that registered by application
+// GrpcServerFactory create gRPC servers that registered by application, the error is returned if the server
+// fails to listen, so that the application fails to start
 // go:depends
-func (c *configuration) ServerFactory(grpcServer *grpc.Server) ServerFactory {
-	return newServerFactory(c.instantiateFactory, c.Properties, grpcServer)
+func (c *configuration) ServerFactory(grpcServer *grpc.Server) (ServerFactory, error) {
+	sf, err := newServerFactory(c.instantiateFactory, c.Properties, grpcServer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start gRPC server: %v", err)
+	}
+	if c.reloader != nil {
+		sf.watchCertificates(c.reloader, c.ssl().ReloadInterval)
+	}
+	return sf, nil
+}
+
+// Gateway transcodes the REST requests to the gRPC services if grpc.gateway.enabled is true,
+// it depends on ServerFactory as the services should be registered before the server is served in process
+func (c *configuration) Gateway(serverFactory ServerFactory, grpcServer *grpc.Server) (gateway *Gateway) {
+	if c.Properties.Gateway.Enabled && grpcServer != nil {
+		var err error
+		gateway, err = newGateway(c.instantiateFactory, &c.Properties.Gateway, grpcServer)
+		if err != nil {
+			log.Errorf("failed to create gRPC gateway: %v", err)
+			gateway = nil
+		}
+	}
+	return
+}
+
+// ssl returns the server.ssl properties of the system configuration
+func (c *configuration) ssl() *system.SSL {
+	return serverSSL(c.instantiateFactory)
+}
+
+// serverSSL returns the server.ssl properties of the system configuration, or nil
+func serverSSL(instantiateFactory factory.InstantiateFactory) *system.SSL {
+	systemConfig, ok := instantiateFactory.GetInstance(system.Configuration{}).(*system.Configuration)
+	if !ok || systemConfig == nil {
+		return nil
+	}
+	return &systemConfig.Server.SSL
 }
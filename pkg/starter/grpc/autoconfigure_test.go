@@ -18,11 +18,14 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
+	gogrpc "google.golang.org/grpc"
 	"google.golang.org/grpc/examples/helloworld/helloworld"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/log"
 	"hidevops.io/hiboot/pkg/starter/grpc"
 	"hidevops.io/hiboot/pkg/starter/grpc/mockgrpc"
 	"testing"
@@ -60,9 +63,34 @@ func (s *greeterClientService) SayHello(name string) (*helloworld.HelloReply, er
 	return response, err
 }
 
+// interceptor that counts the calls of both server and client
+type fakeInterceptor struct {
+	at.GrpcServerInterceptor
+	at.GrpcClientInterceptor
+
+	serverMethods    []string
+	clientMethods    []string
+	serverRequestIDs []string
+}
+
+func newFakeInterceptor() *fakeInterceptor {
+	return &fakeInterceptor{}
+}
+
+func (i *fakeInterceptor) UnaryServerInterceptor(ctx context.Context, req interface{}, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (interface{}, error) {
+	i.serverMethods = append(i.serverMethods, info.FullMethod)
+	i.serverRequestIDs = append(i.serverRequestIDs, log.RequestID(ctx))
+	return handler(ctx, req)
+}
+
+func (i *fakeInterceptor) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *gogrpc.ClientConn, invoker gogrpc.UnaryInvoker, opts ...gogrpc.CallOption) error {
+	i.clientMethods = append(i.clientMethods, method)
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
 func TestGrpcServerAndClient(t *testing.T) {
 
-	app.Register(newGreeterClientService)
+	app.Register(newGreeterClientService, newFakeInterceptor)
 
 	grpc.Server(helloworld.RegisterGreeterServer, newGreeterServerService)
 	grpc.Client("greeter-service", helloworld.NewGreeterClient)
@@ -81,6 +109,27 @@ func TestGrpcServerAndClient(t *testing.T) {
 		}
 	})
 
+	t.Run("should call gRpc service through interceptors", func(t *testing.T) {
+		greeterCliSvc := applicationContext.GetInstance(greeterClientService{}).(*greeterClientService)
+		resp, err := greeterCliSvc.SayHello("Steve")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Hello Steve", resp.Message)
+
+		interceptor := applicationContext.GetInstance(fakeInterceptor{}).(*fakeInterceptor)
+		assert.Equal(t, []string{"/helloworld.Greeter/SayHello"}, interceptor.serverMethods)
+		assert.Equal(t, []string{"/helloworld.Greeter/SayHello"}, interceptor.clientMethods)
+	})
+
+	t.Run("should pass request id to gRpc service", func(t *testing.T) {
+		greeterCliSvc := applicationContext.GetInstance(greeterClientService{}).(*greeterClientService)
+		ctx := log.NewContext(context.Background(), log.Fields{log.RequestIDKey: "1234"})
+		_, err := greeterCliSvc.greeterClient.SayHello(ctx, &helloworld.HelloRequest{Name: "Steve"})
+		assert.Equal(t, nil, err)
+
+		interceptor := applicationContext.GetInstance(fakeInterceptor{}).(*fakeInterceptor)
+		assert.Equal(t, []string{"", "1234"}, interceptor.serverRequestIDs)
+	})
+
 	t.Run("should connect to gRpc service at runtime", func(t *testing.T) {
 		cc := applicationContext.GetInstance(new(grpc.ClientConnector)).(grpc.ClientConnector)
 		f := applicationContext.GetInstance(new(factory.InstantiateFactory)).(factory.InstantiateFactory)
@@ -0,0 +1,153 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"errors"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/balancer/roundrobin" // the round_robin balancer
+	pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"hidevops.io/hiboot/pkg/log"
+	"sync"
+	"time"
+)
+
+// ErrInvalidBalancer the balancer is not registered, it should be pick_first or round_robin
+var ErrInvalidBalancer = errors.New("[grpc] invalid balancer")
+
+// healthFilter passes the addresses that are serving to the client connection, the addresses are checked by the
+// health service that is registered on each server, all the addresses are passed until they are checked
+type healthFilter struct {
+	resolver.ClientConn
+
+	properties  *healthCheck
+	dialOptions []grpc.DialOption
+
+	mutex     sync.Mutex
+	addresses []resolver.Address
+	conns     map[string]*grpc.ClientConn
+
+	update    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newHealthFilter(cc resolver.ClientConn, properties *healthCheck, dialOptions []grpc.DialOption) *healthFilter {
+	h := &healthFilter{
+		ClientConn:  cc,
+		properties:  properties,
+		dialOptions: dialOptions,
+		conns:       make(map[string]*grpc.ClientConn),
+		update:      make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+// NewAddress passes the addresses at once, then checks them
+func (h *healthFilter) NewAddress(addresses []resolver.Address) {
+	h.mutex.Lock()
+	h.addresses = addresses
+	h.mutex.Unlock()
+	h.ClientConn.NewAddress(addresses)
+	select {
+	case h.update <- struct{}{}:
+	default:
+	}
+}
+
+func (h *healthFilter) run() {
+	interval := h.properties.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.done:
+			for _, conn := range h.conns {
+				conn.Close()
+			}
+			return
+		case <-ticker.C:
+		case <-h.update:
+		}
+		h.check()
+	}
+}
+
+// check passes the serving addresses to the client connection, the connections of the removed addresses are closed
+func (h *healthFilter) check() {
+	h.mutex.Lock()
+	addresses := h.addresses
+	h.mutex.Unlock()
+
+	var serving []resolver.Address
+	current := make(map[string]bool)
+	for _, address := range addresses {
+		current[address.Addr] = true
+		if h.serving(address.Addr) {
+			serving = append(serving, address)
+		}
+	}
+	for addr, conn := range h.conns {
+		if !current[addr] {
+			conn.Close()
+			delete(h.conns, addr)
+		}
+	}
+	select {
+	case <-h.done:
+	default:
+		h.ClientConn.NewAddress(serving)
+	}
+}
+
+// serving returns true if the health service of the address responds SERVING
+func (h *healthFilter) serving(addr string) bool {
+	conn, ok := h.conns[addr]
+	if !ok {
+		var err error
+		conn, err = grpc.Dial(addr, h.dialOptions...)
+		if err != nil {
+			log.Warnf("gRPC health check of %v: %v", addr, err)
+			return false
+		}
+		h.conns[addr] = conn
+	}
+	timeout := h.properties.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resp, err := pb.NewHealthClient(conn).Check(ctx, &pb.HealthCheckRequest{Service: h.properties.Service})
+	if err != nil {
+		log.Debugf("gRPC health check of %v: %v", addr, err)
+		return false
+	}
+	return resp.Status == pb.HealthCheckResponse_SERVING
+}
+
+// Close stops the health check
+func (h *healthFilter) Close() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+	})
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"hidevops.io/hiboot/pkg/log"
+	"runtime/debug"
+	"time"
+)
+
+// recoveryInterceptor recovers the panics of the server handlers, the panic is logged with the stack,
+// and the call fails with codes.Internal
+type recoveryInterceptor struct{}
+
+func (i *recoveryInterceptor) recover(ctx context.Context, method string, err *error) {
+	if r := recover(); r != nil {
+		log.WithContext(ctx).Errorf("gRPC server %v panic: %v\n%s", method, r, debug.Stack())
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+// UnaryServerInterceptor recovers the panic of the unary handler
+func (i *recoveryInterceptor) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer i.recover(ctx, info.FullMethod, &err)
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor recovers the panic of the stream handler
+func (i *recoveryInterceptor) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer i.recover(ss.Context(), info.FullMethod, &err)
+	return handler(srv, ss)
+}
+
+// loggingInterceptor logs the method, the status code and the duration of the calls at debug level,
+// the failed calls are logged as warnings
+type loggingInterceptor struct{}
+
+func (i *loggingInterceptor) log(ctx context.Context, side, method string, start time.Time, err error) {
+	code := status.Code(err)
+	entry := log.WithContext(ctx).WithFields(log.Fields{"method": method, "code": code.String(), "duration": time.Since(start).String()})
+	if err != nil {
+		entry.Warnf("gRPC %v %v %v: %v", side, method, code, status.Convert(err).Message())
+		return
+	}
+	entry.Debugf("gRPC %v %v %v", side, method, code)
+}
+
+// UnaryServerInterceptor logs the unary server call
+func (i *loggingInterceptor) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	start := time.Now()
+	resp, err = handler(ctx, req)
+	i.log(ctx, "server", info.FullMethod, start, err)
+	return
+}
+
+// StreamServerInterceptor logs the stream server call when the stream ends
+func (i *loggingInterceptor) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	start := time.Now()
+	err = handler(srv, ss)
+	i.log(ss.Context(), "server", info.FullMethod, start, err)
+	return
+}
+
+// UnaryClientInterceptor logs the unary client call
+func (i *loggingInterceptor) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+	start := time.Now()
+	err = invoker(ctx, method, req, reply, cc, opts...)
+	i.log(ctx, "client", method, start, err)
+	return
+}
+
+// deadlineInterceptor propagates the deadlines of the calls, the server rejects the calls whose deadline is exceeded
+// before the handler is called, and limits the deadline to serverTimeout, the client applies clientTimeout to the
+// unary calls without deadline, the deadline of the context is sent to the server by gRPC itself
+type deadlineInterceptor struct {
+	clientTimeout time.Duration
+	serverTimeout time.Duration
+}
+
+// serverContext returns the context of the server call with the limited deadline
+func (i *deadlineInterceptor) serverContext(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, nil, status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+	}
+	if i.serverTimeout > 0 {
+		if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > i.serverTimeout {
+			ctx, cancel := context.WithTimeout(ctx, i.serverTimeout)
+			return ctx, cancel, nil
+		}
+	}
+	return ctx, func() {}, nil
+}
+
+// UnaryServerInterceptor limits the deadline of the unary server call
+func (i *deadlineInterceptor) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, cancel, err := i.serverContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor limits the deadline of the stream server call
+func (i *deadlineInterceptor) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, cancel, err := i.serverContext(ss.Context())
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	if ctx != ss.Context() {
+		ss = &serverStream{ServerStream: ss, ctx: ctx}
+	}
+	return handler(srv, ss)
+}
+
+// UnaryClientInterceptor applies clientTimeout to the unary client call without deadline
+func (i *deadlineInterceptor) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if _, ok := ctx.Deadline(); !ok && i.clientTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.clientTimeout)
+		defer cancel()
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,160 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestBuiltInInterceptors(t *testing.T) {
+	unaryInfo := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Unary"}
+	streamInfo := &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}
+
+	t.Run("should recover the panic of unary handler", func(t *testing.T) {
+		_, err := new(recoveryInterceptor).UnaryServerInterceptor(context.Background(), nil, unaryInfo,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				panic("unexpected")
+			})
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	t.Run("should recover the panic of stream handler", func(t *testing.T) {
+		err := new(recoveryInterceptor).StreamServerInterceptor(nil, &testServerStream{ctx: context.Background()}, streamInfo,
+			func(srv interface{}, ss grpc.ServerStream) error {
+				panic("unexpected")
+			})
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	t.Run("should return the result of handler through recovery and logging", func(t *testing.T) {
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return req, nil
+		}
+		resp, err := new(recoveryInterceptor).UnaryServerInterceptor(context.Background(), "hello", unaryInfo, handler)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "hello", resp)
+
+		resp, err = new(loggingInterceptor).UnaryServerInterceptor(context.Background(), "hello", unaryInfo, handler)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "hello", resp)
+
+		failed := status.Error(codes.NotFound, "not found")
+		err = new(loggingInterceptor).UnaryClientInterceptor(context.Background(), "/test.Service/Unary", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				return failed
+			})
+		assert.Equal(t, failed, err)
+	})
+
+	t.Run("should reject the server call whose deadline is exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+		defer cancel()
+		called := false
+		_, err := new(deadlineInterceptor).UnaryServerInterceptor(ctx, nil, unaryInfo,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return nil, nil
+			})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		assert.Equal(t, false, called)
+
+		err = new(deadlineInterceptor).StreamServerInterceptor(nil, &testServerStream{ctx: ctx}, streamInfo,
+			func(srv interface{}, ss grpc.ServerStream) error {
+				called = true
+				return nil
+			})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		assert.Equal(t, false, called)
+	})
+
+	t.Run("should limit the deadline of server call to server timeout", func(t *testing.T) {
+		interceptor := &deadlineInterceptor{serverTimeout: time.Second}
+		remaining := func(ctx context.Context) (d time.Duration) {
+			deadline, ok := ctx.Deadline()
+			assert.Equal(t, true, ok)
+			return time.Until(deadline)
+		}
+
+		interceptor.UnaryServerInterceptor(context.Background(), nil, unaryInfo,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				assert.True(t, remaining(ctx) <= time.Second)
+				return nil, nil
+			})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		interceptor.StreamServerInterceptor(nil, &testServerStream{ctx: ctx}, streamInfo,
+			func(srv interface{}, ss grpc.ServerStream) error {
+				assert.True(t, remaining(ss.Context()) <= time.Second)
+				return nil
+			})
+
+		ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		interceptor.UnaryServerInterceptor(ctx, nil, unaryInfo,
+			func(c context.Context, req interface{}) (interface{}, error) {
+				assert.Equal(t, ctx, c)
+				return nil, nil
+			})
+	})
+
+	t.Run("should apply client timeout to the call without deadline", func(t *testing.T) {
+		interceptor := &deadlineInterceptor{clientTimeout: time.Second}
+		var deadline time.Time
+		var ok bool
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			deadline, ok = ctx.Deadline()
+			return nil
+		}
+
+		interceptor.UnaryClientInterceptor(context.Background(), "/test.Service/Unary", nil, nil, nil, invoker)
+		assert.Equal(t, true, ok)
+		assert.True(t, time.Until(deadline) <= time.Second)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		interceptor.UnaryClientInterceptor(ctx, "/test.Service/Unary", nil, nil, nil, invoker)
+		assert.True(t, time.Until(deadline) > time.Second)
+
+		new(deadlineInterceptor).UnaryClientInterceptor(context.Background(), "/test.Service/Unary", nil, nil, nil, invoker)
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("should enable the built-in interceptors by properties", func(t *testing.T) {
+		i := newInterceptors(nil, &interceptorProperties{Recovery: true, Deadline: true})
+		builtIn := i.builtIn()
+		assert.Equal(t, 2, len(builtIn))
+		assert.IsType(t, new(recoveryInterceptor), builtIn[0])
+		assert.IsType(t, new(deadlineInterceptor), builtIn[1])
+
+		i = newInterceptors(nil, &interceptorProperties{})
+		assert.Equal(t, 0, len(i.builtIn()))
+	})
+}
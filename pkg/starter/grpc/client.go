@@ -20,8 +20,7 @@ func newClientFactory(instantiateFactory factory.InstantiateFactory, properties
 	clientProps := properties.Client
 	var gRPCCli interface{}
 	for _, cli := range grpcClients {
-		prop := new(ClientProperties)
-		err := mapstruct.Decode(prop, clientProps[cli.name])
+		prop, err := decodeClientProperties(instantiateFactory, clientProps[cli.name])
 		if err == nil {
 			gRPCCli, err = cc.Connect(cli.name, cli.cb, prop)
 			if err == nil {
@@ -36,3 +35,15 @@ func newClientFactory(instantiateFactory factory.InstantiateFactory, properties
 
 	return cf
 }
+
+// decodeClientProperties decodes the properties of the client as the configuration properties,
+// the default values are applied, and the keys are bound in relaxed way, the client that is not configured
+// is of the default values
+func decodeClientProperties(instantiateFactory factory.InstantiateFactory, from interface{}) (prop *ClientProperties, err error) {
+	prop = new(ClientProperties)
+	err = mapstruct.DecodeWithDefaults(prop, from, instantiateFactory.Replace)
+	if err == nil && !prop.PlainText {
+		prop.TLS.Enabled = true
+	}
+	return
+}
@@ -2,9 +2,12 @@ package grpc
 
 import (
 	"google.golang.org/grpc"
+	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/factory"
 	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/crypto/tlsconfig"
 	"hidevops.io/hiboot/pkg/utils/reflector"
+	"time"
 )
 
 // ClientConnector interface is response for creating grpc client connection
@@ -15,32 +18,79 @@ type ClientConnector interface {
 
 type clientConnector struct {
 	instantiateFactory factory.InstantiateFactory
+	interceptors       *interceptors
+	properties         *properties
+	inProcess          *InProcessServer
 }
 
-func newClientConnector(instantiateFactory factory.InstantiateFactory) ClientConnector {
+func newClientConnector(instantiateFactory factory.InstantiateFactory, interceptors *interceptors, properties *properties) ClientConnector {
 	cc := &clientConnector{
 		instantiateFactory: instantiateFactory,
+		interceptors:       interceptors,
+		properties:         properties,
+		inProcess:          inProcessServerOf(instantiateFactory),
 	}
 	return cc
 }
 
+// defaultTimeout returns grpc.timeoutSecond, the default deadline of the unary calls
+func (c *clientConnector) defaultTimeout() time.Duration {
+	if c.properties == nil {
+		return 0
+	}
+	return c.properties.TimeoutSecond
+}
+
+// registry returns the ServiceRegistry component of the scheme, or nil
+func (c *clientConnector) registry(scheme string) ServiceRegistry {
+	for _, md := range c.instantiateFactory.GetInstances(new(at.GrpcServiceRegistry)) {
+		if registry, ok := factory.CastMetaData(md).Instance.(ServiceRegistry); ok && registry.Scheme() == scheme {
+			return registry
+		}
+	}
+	return nil
+}
+
 // Connect connect to grpc server from client
 // name: client name
 // clientConstructor: client constructor
 // properties: properties for configuring
 func (c *clientConnector) Connect(name string, clientConstructor interface{}, properties *ClientProperties) (gRPCCli interface{}, err error) {
-	host := properties.Host
-	if host == "" {
-		host = name
-	}
-	address := host + ":" + properties.Port
 	conn := c.instantiateFactory.GetInstance(name)
 	if conn == nil {
+		// the clients of the test application connect to the in-process server whatever the address is
+		if c.inProcess != nil {
+			properties = c.inProcess.clientProperties(properties, serverSSL(c.instantiateFactory))
+		}
+		var opts []grpc.DialOption
+		opts, err = dialOptions(properties, c.defaultTimeout(), c.interceptors)
+		if err != nil {
+			return
+		}
+		// the health checks are of the same transport as the client
+		var transport grpc.DialOption
+		var reloader *tlsconfig.Reloader
+		transport, reloader, err = transportOption(properties)
+		if err != nil {
+			return
+		}
+		opts = append(opts, transport)
+		target := inProcessTarget
+		if c.inProcess != nil {
+			opts = append(opts, c.inProcess.dialer())
+		} else {
+			target = clientTarget(name, properties, c.registry, []grpc.DialOption{transport})
+		}
 		// connect to grpc server
-		conn, err = grpc.Dial(address, grpc.WithInsecure())
+		var cc *grpc.ClientConn
+		cc, err = grpc.Dial(target, opts...)
+		conn = cc
 		c.instantiateFactory.SetInstance(name, conn)
 		if err == nil {
-			log.Infof("gRPC client connected to: %v", address)
+			log.Infof("gRPC client connected to: %v", target)
+			if properties.TLS.CertFile != "" {
+				go watchClientCertificate(cc, reloader)
+			}
 		}
 	}
 	if err == nil && clientConstructor != nil {
@@ -0,0 +1,517 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"golang.org/x/net/context"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"hidevops.io/hiboot/pkg/app"
+	webctx "hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/reflector"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// gatewayBufferSize is the buffer size of the in-process connection between the gateway and the server
+	gatewayBufferSize = 1024 * 1024
+
+	// metadataHeaderPrefix is the prefix of the http headers that are passed to the server as metadata,
+	// e.g. Grpc-Metadata-Tenant: foo is passed as tenant: foo
+	metadataHeaderPrefix = "Grpc-Metadata-"
+)
+
+// Gateway transcodes the REST requests to the gRPC services that are registered by grpc.Server, the routes are
+// mapped by the google.api.http annotations of the rpc methods or by grpc.gateway.rules, the messages are mapped
+// to and from JSON, and the status codes of the calls are mapped to the http status codes
+type Gateway struct {
+	routes []*gatewayRoute
+	conn   *grpc.ClientConn
+}
+
+// gatewayParam is the path parameter of the route that is mapped to the field of the request message
+type gatewayParam struct {
+	name  string
+	field string
+}
+
+// gatewayRoute is the REST route of the rpc method
+type gatewayRoute struct {
+	method       string
+	path         string
+	params       []gatewayParam
+	body         string
+	responseBody string
+	fullMethod   string
+	request      reflect.Type
+	response     reflect.Type
+}
+
+var (
+	gatewayMarshaler   = &jsonpb.Marshaler{OrigName: true, EmitDefaults: true}
+	gatewayUnmarshaler = &jsonpb.Unmarshaler{AllowUnknownFields: true}
+)
+
+func newGateway(instantiateFactory factory.InstantiateFactory, properties *gateway, grpcServer *grpc.Server) (g *Gateway, err error) {
+	g = new(Gateway)
+	rules := make(map[string]*annotations.HttpRule)
+	for _, rule := range properties.Rules {
+		rules[rule.Selector] = rule.httpRule()
+	}
+	for _, srv := range grpcServers {
+		svc := instantiateFactory.GetInstance(srv.name)
+		if svc == nil {
+			continue
+		}
+		// register the service on a server that is not served to find out its name and methods
+		probe := grpc.NewServer()
+		reflector.CallFunc(srv.cb, probe, svc)
+		infos := probe.GetServiceInfo()
+		var names []string
+		for name := range infos {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			info := infos[name]
+			file, _ := info.Metadata.(string)
+			annotated := annotatedRules(file)
+			for _, m := range info.Methods {
+				selector := name + "." + m.Name
+				rule, ok := rules[selector]
+				if !ok {
+					rule, ok = annotated[selector]
+				}
+				if !ok {
+					continue
+				}
+				if m.IsClientStream || m.IsServerStream {
+					log.Warnf("gRPC gateway does not support the streaming method %v", selector)
+					continue
+				}
+				var routes []*gatewayRoute
+				routes, err = newGatewayRoutes(rule, "/"+name+"/"+m.Name, reflect.ValueOf(svc).MethodByName(m.Name))
+				if err != nil {
+					return nil, fmt.Errorf("invalid http rule of %v: %v", selector, err)
+				}
+				g.routes = append(g.routes, routes...)
+			}
+		}
+	}
+	if len(g.routes) != 0 {
+		g.conn, err = dialInProcess(grpcServer)
+	}
+	return
+}
+
+// dialInProcess serves the server on the in-process listener and dials it, so that the calls of the gateway are
+// intercepted by the server interceptors as the calls of the remote clients, the in-process connections are not
+// secured by tls as they are trusted by the server credentials, the listener is closed as the server is stopped
+func dialInProcess(grpcServer *grpc.Server) (*grpc.ClientConn, error) {
+	lis := bufconn.Listen(gatewayBufferSize)
+	go func() {
+		if err := grpcServer.Serve(inProcessListener{lis}); err != nil {
+			log.Errorf("failed to serve gRPC gateway in process: %v", err)
+		}
+	}()
+	return grpc.Dial("bufconn", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return lis.Dial()
+	}))
+}
+
+// httpRule returns the google.api.http annotation of the rule
+func (r *gatewayRule) httpRule() *annotations.HttpRule {
+	rule := &annotations.HttpRule{Selector: r.Selector, Body: r.Body, ResponseBody: r.ResponseBody}
+	switch {
+	case r.Get != "":
+		rule.Pattern = &annotations.HttpRule_Get{Get: r.Get}
+	case r.Put != "":
+		rule.Pattern = &annotations.HttpRule_Put{Put: r.Put}
+	case r.Post != "":
+		rule.Pattern = &annotations.HttpRule_Post{Post: r.Post}
+	case r.Delete != "":
+		rule.Pattern = &annotations.HttpRule_Delete{Delete: r.Delete}
+	case r.Patch != "":
+		rule.Pattern = &annotations.HttpRule_Patch{Patch: r.Patch}
+	}
+	return rule
+}
+
+// annotatedRules returns the google.api.http annotations of the rpc methods in the proto file that is registered
+// by the generated code, the rules are mapped by the full name of the method, e.g. helloworld.Greeter.SayHello
+func annotatedRules(file string) (rules map[string]*annotations.HttpRule) {
+	rules = make(map[string]*annotations.HttpRule)
+	gz := proto.FileDescriptor(file)
+	if gz == nil {
+		return
+	}
+	r, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		return
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return
+	}
+	fd := new(descriptor.FileDescriptorProto)
+	if err = proto.Unmarshal(b, fd); err != nil {
+		log.Warnf("failed to parse the descriptor of %v: %v", file, err)
+		return
+	}
+	for _, svc := range fd.Service {
+		name := svc.GetName()
+		if fd.GetPackage() != "" {
+			name = fd.GetPackage() + "." + name
+		}
+		for _, m := range svc.Method {
+			if m.Options == nil || !proto.HasExtension(m.Options, annotations.E_Http) {
+				continue
+			}
+			ext, err := proto.GetExtension(m.Options, annotations.E_Http)
+			if err == nil {
+				rules[name+"."+m.GetName()] = ext.(*annotations.HttpRule)
+			}
+		}
+	}
+	return
+}
+
+// newGatewayRoutes returns the routes of the rule and its additional bindings, the message types are of the method
+// of the service, e.g. SayHello(context.Context, *HelloRequest) (*HelloReply, error)
+func newGatewayRoutes(rule *annotations.HttpRule, fullMethod string, method reflect.Value) (routes []*gatewayRoute, err error) {
+	if !method.IsValid() || method.Type().NumIn() != 2 || method.Type().NumOut() != 2 {
+		return nil, fmt.Errorf("the service does not implement %v", fullMethod)
+	}
+	bindings := append([]*annotations.HttpRule{rule}, rule.AdditionalBindings...)
+	for _, binding := range bindings {
+		var httpMethod, template string
+		switch pattern := binding.Pattern.(type) {
+		case *annotations.HttpRule_Get:
+			httpMethod, template = http.MethodGet, pattern.Get
+		case *annotations.HttpRule_Put:
+			httpMethod, template = http.MethodPut, pattern.Put
+		case *annotations.HttpRule_Post:
+			httpMethod, template = http.MethodPost, pattern.Post
+		case *annotations.HttpRule_Delete:
+			httpMethod, template = http.MethodDelete, pattern.Delete
+		case *annotations.HttpRule_Patch:
+			httpMethod, template = http.MethodPatch, pattern.Patch
+		case *annotations.HttpRule_Custom:
+			httpMethod, template = strings.ToUpper(pattern.Custom.Kind), pattern.Custom.Path
+		default:
+			return nil, fmt.Errorf("the http method is not specified")
+		}
+		route := &gatewayRoute{
+			method:       httpMethod,
+			body:         binding.Body,
+			responseBody: binding.ResponseBody,
+			fullMethod:   fullMethod,
+			request:      method.Type().In(1).Elem(),
+			response:     method.Type().Out(0).Elem(),
+		}
+		route.path, route.params, err = parseTemplate(template)
+		if err != nil {
+			return
+		}
+		routes = append(routes, route)
+	}
+	return
+}
+
+// parseTemplate converts the path template to the route path, e.g. /v1/{name}/{path=**} to /v1/{p0}/{p1:path}
+func parseTemplate(template string) (path string, params []gatewayParam, err error) {
+	if !strings.HasPrefix(template, "/") {
+		return "", nil, fmt.Errorf("the path template %v should start with /", template)
+	}
+	var buf bytes.Buffer
+	for {
+		start := strings.Index(template, "{")
+		if start < 0 {
+			buf.WriteString(template)
+			break
+		}
+		end := strings.Index(template, "}")
+		if end < start {
+			return "", nil, fmt.Errorf("the path template %v is not closed", template)
+		}
+		buf.WriteString(template[:start])
+		field, pattern := template[start+1:end], "*"
+		if n := strings.Index(field, "="); n >= 0 {
+			field, pattern = field[:n], field[n+1:]
+		}
+		param := gatewayParam{name: "p" + strconv.Itoa(len(params)), field: field}
+		switch pattern {
+		case "*":
+			buf.WriteString("{" + param.name + "}")
+		case "**":
+			buf.WriteString("{" + param.name + ":path}")
+		default:
+			return "", nil, fmt.Errorf("the pattern %v of the path template is not supported", pattern)
+		}
+		params = append(params, param)
+		template = template[end+1:]
+	}
+	return buf.String(), params, nil
+}
+
+// decode maps the request body, the query and the path parameters to the request message
+func (r *gatewayRoute) decode(ctx webctx.Context, req proto.Message) (err error) {
+	fields := make(map[string]interface{})
+	// the numbers are kept as json.Number, as the int64 fields lose the precision of float64
+	decoder := json.NewDecoder(ctx.Request().Body)
+	decoder.UseNumber()
+	switch r.body {
+	case "":
+	case "*":
+		err = decoder.Decode(&fields)
+	default:
+		var body interface{}
+		err = decoder.Decode(&body)
+		if err == nil {
+			err = setField(fields, r.request, strings.Split(r.body, "."), body)
+		}
+	}
+	if err != nil && err != io.EOF {
+		return
+	}
+	// the fields that are not mapped to the body are mapped to the query parameters
+	if r.body != "*" {
+		for key, values := range ctx.Request().URL.Query() {
+			for _, value := range values {
+				if err = setField(fields, r.request, strings.Split(key, "."), value); err != nil {
+					return
+				}
+			}
+		}
+	}
+	for _, param := range r.params {
+		if err = setField(fields, r.request, strings.Split(param.field, "."), ctx.Params().Get(param.name)); err != nil {
+			return
+		}
+	}
+	b, err := json.Marshal(fields)
+	if err == nil {
+		err = gatewayUnmarshaler.Unmarshal(bytes.NewReader(b), req)
+	}
+	return
+}
+
+// setField sets the value of the field of the message type to the JSON object, the string value is converted to
+// the type of the field, the value of the repeated field is appended
+func setField(fields map[string]interface{}, typ reflect.Type, path []string, value interface{}) error {
+	field, ok := messageField(typ, path[0])
+	if !ok {
+		// the unknown fields are ignored as the unknown JSON fields
+		return nil
+	}
+	if len(path) > 1 {
+		nested, _ := fields[path[0]].(map[string]interface{})
+		if nested == nil {
+			nested = make(map[string]interface{})
+			fields[path[0]] = nested
+		}
+		return setField(nested, reflector.IndirectType(field.Type), path[1:], value)
+	}
+	s, ok := value.(string)
+	if !ok {
+		fields[path[0]] = value
+		return nil
+	}
+	typ = field.Type
+	repeated := typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8
+	if repeated {
+		typ = typ.Elem()
+	}
+	// the optional fields of proto2 are pointers
+	kind := reflector.IndirectType(typ).Kind()
+	var v interface{} = s
+	switch kind {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid value %v of %v", s, path[0])
+		}
+		v = b
+	case reflect.Int32, reflect.Int64, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		// the enums are of int32, they are mapped by the names or the numbers
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			v = json.Number(s)
+		}
+	}
+	if repeated {
+		values, _ := fields[path[0]].([]interface{})
+		fields[path[0]] = append(values, v)
+	} else {
+		fields[path[0]] = v
+	}
+	return nil
+}
+
+// messageField returns the struct field of the message by the proto name or the JSON name
+func messageField(typ reflect.Type, name string) (field reflect.StructField, ok bool) {
+	if typ.Kind() != reflect.Struct {
+		return
+	}
+	props := proto.GetProperties(typ)
+	for i, prop := range props.Prop {
+		if prop.OrigName != "" && (prop.OrigName == name || prop.JSONName == name) {
+			return typ.Field(i), true
+		}
+	}
+	return
+}
+
+// messageValue returns the value of the field of the message by the path, e.g. data.name
+func messageValue(message reflect.Value, path string) (reflect.Value, error) {
+	for _, name := range strings.Split(path, ".") {
+		message = reflect.Indirect(message)
+		f, ok := messageField(message.Type(), name)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("field %v is not found", path)
+		}
+		message = message.FieldByIndex(f.Index)
+	}
+	return message, nil
+}
+
+// handler calls the rpc method by the in-process connection and writes the response message in JSON
+func (g *Gateway) handler(r *gatewayRoute) webctx.Handler {
+	return func(ctx webctx.Context) {
+		req := reflect.New(r.request).Interface().(proto.Message)
+		if err := r.decode(ctx, req); err != nil {
+			writeStatus(ctx, status.New(codes.InvalidArgument, err.Error()))
+			return
+		}
+		resp := reflect.New(r.response).Interface().(proto.Message)
+		if err := g.conn.Invoke(outgoingContext(ctx), r.fullMethod, req, resp); err != nil {
+			writeStatus(ctx, status.Convert(err))
+			return
+		}
+		var err error
+		if r.responseBody != "" {
+			var v reflect.Value
+			v, err = messageValue(reflect.ValueOf(resp), r.responseBody)
+			if err == nil {
+				err = writeJSON(ctx, http.StatusOK, v.Interface())
+			}
+		} else {
+			err = writeJSON(ctx, http.StatusOK, resp)
+		}
+		if err != nil {
+			log.Errorf("failed to write the response of %v: %v", r.fullMethod, err)
+		}
+		ctx.Next()
+	}
+}
+
+// outgoingContext passes the Authorization and Grpc-Metadata-* headers to the server by metadata
+func outgoingContext(ctx webctx.Context) context.Context {
+	md := metadata.MD{}
+	for key, values := range ctx.Request().Header {
+		if strings.HasPrefix(key, metadataHeaderPrefix) {
+			md.Append(key[len(metadataHeaderPrefix):], values...)
+		} else if key == "Authorization" {
+			md.Append(key, values...)
+		}
+	}
+	return outgoingRequestID(metadata.NewOutgoingContext(ctx.Request().Context(), md))
+}
+
+// writeJSON writes the message in JSON, the messages are marshaled by the proto names and the default values
+func writeJSON(ctx webctx.Context, code int, v interface{}) (err error) {
+	var b []byte
+	if message, ok := v.(proto.Message); ok {
+		var buf bytes.Buffer
+		err = gatewayMarshaler.Marshal(&buf, message)
+		b = buf.Bytes()
+	} else {
+		b, err = json.Marshal(v)
+	}
+	if err != nil {
+		return
+	}
+	ctx.ContentType("application/json")
+	ctx.StatusCode(code)
+	_, err = ctx.Write(b)
+	return
+}
+
+// writeStatus writes the status of the failed call as google.rpc.Status with the mapped http status code
+func writeStatus(ctx webctx.Context, st *status.Status) {
+	if err := writeJSON(ctx, httpStatus(st.Code()), st.Proto()); err != nil {
+		log.Errorf("failed to write the status %v: %v", st.Code(), err)
+	}
+}
+
+// httpStatus maps the status code of the call to the http status code
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		// the client closed the request
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// mount registers the routes on the web application, they are served through the same middleware and authorization
+// rules as the controllers, e.g. jwt and security, but the annotations of the controllers, e.g. at.RequiresRoles, do
+// not apply, so the routes of the gRPC methods should be protected by web.authorization.rules
+func (g *Gateway) mount(applicationContext app.ApplicationContext) {
+	for _, r := range g.routes {
+		applicationContext.Handle(r.method, r.path, g.handler(r))
+		log.Infof("Mapped %v %v to gRPC method %v", r.method, r.path, r.fullMethod)
+	}
+}
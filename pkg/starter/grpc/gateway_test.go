@@ -0,0 +1,313 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"hidevops.io/hiboot/pkg/app/fake"
+	"hidevops.io/hiboot/pkg/app/web"
+	webctx "hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/factory"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const echoProtoFile = "hiboot/gateway_test.proto"
+
+// echoServer is the service of hiboot/gateway_test.proto, it is written as the generated code
+type echoServer interface {
+	Echo(context.Context, *helloworld.HelloRequest) (*helloworld.HelloReply, error)
+	Fail(context.Context, *helloworld.HelloRequest) (*helloworld.HelloReply, error)
+}
+
+func echoHandler(method string) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := new(helloworld.HelloRequest)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		results := reflect.ValueOf(srv).MethodByName(method).Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(in)})
+		err, _ := results[1].Interface().(error)
+		return results[0].Interface(), err
+	}
+}
+
+var echoServiceDesc = grpc.ServiceDesc{
+	ServiceName: "hiboot.test.Echo",
+	HandlerType: (*echoServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Echo", Handler: echoHandler("Echo")},
+		{MethodName: "Fail", Handler: echoHandler("Fail")},
+	},
+	Metadata: echoProtoFile,
+}
+
+func registerEchoServer(s *grpc.Server, srv echoServer) {
+	s.RegisterService(&echoServiceDesc, srv)
+}
+
+type echoServerService struct{}
+
+func (s *echoServerService) Echo(ctx context.Context, in *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return &helloworld.HelloReply{Message: in.Name + strings.Join(md["tenant"], "") + strings.Join(md["authorization"], "")}, nil
+}
+
+func (s *echoServerService) Fail(ctx context.Context, in *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	return nil, status.Error(codes.NotFound, in.Name+" is not found")
+}
+
+type gatewayGreeterService struct{}
+
+func (s *gatewayGreeterService) SayHello(ctx context.Context, in *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	return &helloworld.HelloReply{Message: "Hello " + in.Name}, nil
+}
+
+// registers the annotated descriptor of hiboot/gateway_test.proto as the generated code
+func init() {
+	method := func(name string, rule *annotations.HttpRule) *descriptor.MethodDescriptorProto {
+		options := new(descriptor.MethodOptions)
+		proto.SetExtension(options, annotations.E_Http, rule)
+		return &descriptor.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".helloworld.HelloRequest"),
+			OutputType: proto.String(".helloworld.HelloReply"),
+			Options:    options,
+		}
+	}
+	fd := &descriptor.FileDescriptorProto{
+		Name:    proto.String(echoProtoFile),
+		Package: proto.String("hiboot.test"),
+		Service: []*descriptor.ServiceDescriptorProto{{
+			Name: proto.String("Echo"),
+			Method: []*descriptor.MethodDescriptorProto{
+				method("Echo", &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Get{Get: "/v1/echo/{name}"},
+					AdditionalBindings: []*annotations.HttpRule{
+						{Pattern: &annotations.HttpRule_Post{Post: "/v1/echo"}, Body: "*"},
+						{Pattern: &annotations.HttpRule_Get{Get: "/v1/files/{name=**}"}},
+					},
+				}),
+				method("Fail", &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Delete{Delete: "/v1/echo/{name}"},
+				}),
+			},
+		}},
+	}
+	b, _ := proto.Marshal(fd)
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(b)
+	w.Close()
+	proto.RegisterFile(echoProtoFile, buf.Bytes())
+}
+
+// instanceFactory returns the instances by name
+type instanceFactory struct {
+	factory.InstantiateFactory
+	instances map[string]interface{}
+}
+
+func (f *instanceFactory) GetInstance(params ...interface{}) interface{} {
+	name, _ := factory.ParseParams(params...)
+	return f.instances[name]
+}
+
+// irisContext mounts the routes on the iris application
+type irisContext struct {
+	fake.ApplicationContext
+	app *iris.Application
+}
+
+func (a *irisContext) Handle(method, path string, handlers ...webctx.Handler) {
+	a.app.Handle(method, path, web.Handler(handlers[0]))
+}
+
+func TestGateway(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	registerEchoServer(grpcServer, new(echoServerService))
+	helloworld.RegisterGreeterServer(grpcServer, new(gatewayGreeterService))
+
+	saved := grpcServers
+	defer func() { grpcServers = saved }()
+	grpcServers = []*grpcService{
+		{name: "grpc.echoServerService", cb: registerEchoServer},
+		{name: "grpc.gatewayGreeterService", cb: helloworld.RegisterGreeterServer},
+		{name: "grpc.notFoundService", cb: helloworld.RegisterGreeterServer},
+	}
+	f := &instanceFactory{instances: map[string]interface{}{
+		"grpc.echoServerService":     new(echoServerService),
+		"grpc.gatewayGreeterService": new(gatewayGreeterService),
+	}}
+
+	gw, err := newGateway(f, &gateway{
+		Enabled: true,
+		Rules: []gatewayRule{
+			{Selector: "helloworld.Greeter.SayHello", Post: "/v1/greeter/{name}", ResponseBody: "message"},
+		},
+	}, grpcServer)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 5, len(gw.routes))
+
+	irisApp := iris.New()
+	gw.mount(&irisContext{app: irisApp})
+	e := httptest.New(t, irisApp)
+
+	t.Run("should transcode the annotated route", func(t *testing.T) {
+		e.GET("/v1/echo/foo").
+			WithHeader("Grpc-Metadata-Tenant", "-bar").
+			WithHeader("Authorization", "-baz").
+			Expect().Status(http.StatusOK).
+			JSON().Object().ValueEqual("message", "foo-bar-baz")
+	})
+
+	t.Run("should transcode the additional binding with the body", func(t *testing.T) {
+		e.POST("/v1/echo").
+			WithHeader("Grpc-Metadata-Tenant", "-bar").
+			WithHeader("Authorization", "-baz").
+			WithJSON(map[string]interface{}{"name": "foo"}).
+			Expect().Status(http.StatusOK).
+			JSON().Object().ValueEqual("message", "foo-bar-baz")
+	})
+
+	t.Run("should map the rest of the path to the field", func(t *testing.T) {
+		e.GET("/v1/files/foo/bar.txt").Expect().Status(http.StatusOK).
+			JSON().Object().ValueEqual("message", "foo/bar.txt")
+	})
+
+	t.Run("should map the query parameters to the fields", func(t *testing.T) {
+		e.POST("/v1/greeter/foo").WithQuery("unknown", "bar").Expect().Status(http.StatusOK)
+		e.DELETE("/v1/echo/foo").WithQuery("name", "bar").Expect().Status(http.StatusNotFound).
+			JSON().Object().ValueEqual("message", "foo is not found")
+	})
+
+	t.Run("should map the status code to the http status code", func(t *testing.T) {
+		obj := e.DELETE("/v1/echo/foo").Expect().Status(http.StatusNotFound).JSON().Object()
+		obj.ValueEqual("code", int(codes.NotFound))
+		obj.ValueEqual("message", "foo is not found")
+	})
+
+	t.Run("should transcode the configured route with the response body", func(t *testing.T) {
+		e.POST("/v1/greeter/foo").Expect().Status(http.StatusOK).JSON().String().Equal("Hello foo")
+	})
+
+	t.Run("should report the invalid request", func(t *testing.T) {
+		e.POST("/v1/echo").WithBytes([]byte("{")).
+			Expect().Status(http.StatusBadRequest).
+			JSON().Object().ValueEqual("code", int(codes.InvalidArgument))
+	})
+
+	t.Run("should report the invalid rule", func(t *testing.T) {
+		_, err := newGateway(f, &gateway{
+			Enabled: true,
+			Rules:   []gatewayRule{{Selector: "helloworld.Greeter.SayHello"}},
+		}, grpcServer)
+		assert.Contains(t, err.Error(), "helloworld.Greeter.SayHello")
+	})
+}
+
+func TestParseTemplate(t *testing.T) {
+	t.Run("should parse the path template", func(t *testing.T) {
+		path, params, err := parseTemplate("/v1/{parent.name}/items/{id=*}/{path=**}")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "/v1/{p0}/items/{p1}/{p2:path}", path)
+		assert.Equal(t, []gatewayParam{{"p0", "parent.name"}, {"p1", "id"}, {"p2", "path"}}, params)
+	})
+
+	t.Run("should report the invalid path template", func(t *testing.T) {
+		_, _, err := parseTemplate("v1/items")
+		assert.NotEqual(t, nil, err)
+		_, _, err = parseTemplate("/v1/{name")
+		assert.NotEqual(t, nil, err)
+		_, _, err = parseTemplate("/v1/{name=items/*}")
+		assert.NotEqual(t, nil, err)
+	})
+}
+
+func TestSetField(t *testing.T) {
+	fields := make(map[string]interface{})
+	typ := reflect.TypeOf(descriptor.DescriptorProto{})
+
+	t.Run("should convert the value to the type of the field", func(t *testing.T) {
+		assert.Equal(t, nil, setField(fields, typ, []string{"name"}, "foo"))
+		assert.Equal(t, nil, setField(fields, typ, []string{"options", "mapEntry"}, "true"))
+		assert.Equal(t, nil, setField(fields, typ, []string{"reserved_name"}, "a"))
+		assert.Equal(t, nil, setField(fields, typ, []string{"reserved_name"}, "b"))
+		assert.Equal(t, nil, setField(fields, typ, []string{"unknown"}, "c"))
+
+		msg := new(descriptor.DescriptorProto)
+		b, _ := json.Marshal(fields)
+		assert.Equal(t, nil, gatewayUnmarshaler.Unmarshal(bytes.NewReader(b), msg))
+		assert.Equal(t, "foo", msg.GetName())
+		assert.Equal(t, true, msg.GetOptions().GetMapEntry())
+		assert.Equal(t, []string{"a", "b"}, msg.ReservedName)
+	})
+
+	t.Run("should convert the value of the optional proto2 field", func(t *testing.T) {
+		fields := make(map[string]interface{})
+		typ := reflect.TypeOf(descriptor.FieldDescriptorProto{})
+		assert.Equal(t, nil, setField(fields, typ, []string{"number"}, "1"))
+		assert.Equal(t, nil, setField(fields, typ, []string{"label"}, "LABEL_REPEATED"))
+		assert.Equal(t, map[string]interface{}{"number": json.Number("1"), "label": "LABEL_REPEATED"}, fields)
+	})
+
+	t.Run("should report the invalid value", func(t *testing.T) {
+		assert.NotEqual(t, nil, setField(fields, typ, []string{"options", "map_entry"}, "yes"))
+	})
+}
+
+func TestHttpStatus(t *testing.T) {
+	assert.Equal(t, http.StatusOK, httpStatus(codes.OK))
+	assert.Equal(t, http.StatusBadRequest, httpStatus(codes.InvalidArgument))
+	assert.Equal(t, http.StatusUnauthorized, httpStatus(codes.Unauthenticated))
+	assert.Equal(t, http.StatusForbidden, httpStatus(codes.PermissionDenied))
+	assert.Equal(t, http.StatusNotFound, httpStatus(codes.NotFound))
+	assert.Equal(t, http.StatusServiceUnavailable, httpStatus(codes.Unavailable))
+	assert.Equal(t, http.StatusInternalServerError, httpStatus(codes.Unknown))
+}
+
+func TestGatewayDecode(t *testing.T) {
+	irisApp := iris.New()
+	r := &gatewayRoute{body: "*", request: reflect.TypeOf(new(descriptor.UninterpretedOption))}
+	var req descriptor.UninterpretedOption
+	irisApp.Post("/", web.Handler(func(ctx webctx.Context) {
+		if err := r.decode(ctx, &req); err != nil {
+			ctx.StatusCode(http.StatusBadRequest)
+		}
+	}))
+	e := httptest.New(t, irisApp)
+
+	t.Run("should decode the int64 field without losing the precision", func(t *testing.T) {
+		e.POST("/").WithBytes([]byte(`{"negative_int_value": -9007199254740993}`)).
+			Expect().Status(http.StatusOK)
+		assert.Equal(t, int64(-9007199254740993), req.GetNegativeIntValue())
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grpctest provides the web test application whose gRPC server is served in memory, and the assertions of
+// the unary and streaming calls of the generated gRPC clients
+package grpctest
+
+import (
+	gogrpc "google.golang.org/grpc"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/starter/grpc"
+	"testing"
+)
+
+// TestApplication is the web test application whose gRPC server is served in memory, the clients registered
+// by grpc.Client connect to the in-process server whatever their addresses are, e.g.
+//
+//	testApp := grpctest.NewTestApp().
+//		FakeServer(helloworld.RegisterGreeterServer, new(fakeGreeterServer)).
+//		Run(t)
+type TestApplication struct {
+	web.TestApplication
+	server *grpc.InProcessServer
+}
+
+// NewTestApp returns the new test application
+func NewTestApp(controllers ...interface{}) *TestApplication {
+	return &TestApplication{
+		TestApplication: web.NewTestApp(controllers...),
+		server:          grpc.NewInProcessServer(),
+	}
+}
+
+// RunTestApplication returns the new test application that is running
+func RunTestApplication(t *testing.T, controllers ...interface{}) *TestApplication {
+	return NewTestApp(controllers...).Run(t)
+}
+
+// SetProperty set application property
+func (a *TestApplication) SetProperty(name string, value ...interface{}) *TestApplication {
+	a.TestApplication.SetProperty(name, value...)
+	return a
+}
+
+// FakeServer replaces the server that is registered by grpc.Server with the same register function,
+// e.g. helloworld.RegisterGreeterServer, the fake server should implement the service interface
+func (a *TestApplication) FakeServer(register interface{}, server interface{}) *TestApplication {
+	a.server.FakeServer(register, server)
+	return a
+}
+
+// Run runs the test application with the gRPC server enabled
+func (a *TestApplication) Run(t *testing.T) *TestApplication {
+	a.TestApplication.SetProperty("grpc.server.enabled", true)
+	a.TestApplication.SetInstance(a.server)
+	a.TestApplication.Run(t)
+	return a
+}
+
+// Dial returns a new connection to the in-process server, it is insecure if there is no option,
+// the connection should be closed by the caller
+func (a *TestApplication) Dial(opts ...gogrpc.DialOption) (*gogrpc.ClientConn, error) {
+	return a.server.Dial(opts...)
+}
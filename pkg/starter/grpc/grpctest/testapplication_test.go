@@ -0,0 +1,148 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpctest_test
+
+import (
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/examples/route_guide/routeguide"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/starter/grpc"
+	"hidevops.io/hiboot/pkg/starter/grpc/grpctest"
+	"io"
+	"testing"
+	"time"
+)
+
+// routeGuideServer implements routeguide.RouteGuideServer, the feature name is prefixed by the name of the server
+type routeGuideServer struct {
+	name string
+}
+
+func newRouteGuideServer() *routeGuideServer {
+	return &routeGuideServer{name: "route"}
+}
+
+func (s *routeGuideServer) feature(point *routeguide.Point) *routeguide.Feature {
+	return &routeguide.Feature{
+		Name:     fmt.Sprintf("%v %v,%v", s.name, point.Latitude, point.Longitude),
+		Location: point,
+	}
+}
+
+func (s *routeGuideServer) GetFeature(ctx context.Context, point *routeguide.Point) (*routeguide.Feature, error) {
+	return s.feature(point), nil
+}
+
+func (s *routeGuideServer) ListFeatures(rect *routeguide.Rectangle, stream routeguide.RouteGuide_ListFeaturesServer) error {
+	for _, point := range []*routeguide.Point{rect.Lo, rect.Hi} {
+		if err := stream.Send(s.feature(point)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *routeGuideServer) RecordRoute(stream routeguide.RouteGuide_RecordRouteServer) error {
+	var count int32
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&routeguide.RouteSummary{PointCount: count})
+		}
+		if err != nil {
+			return err
+		}
+		count++
+	}
+}
+
+func (s *routeGuideServer) RouteChat(stream routeguide.RouteGuide_RouteChatServer) error {
+	for {
+		note, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		note.Message = s.name + " " + note.Message
+		if err := stream.Send(note); err != nil {
+			return err
+		}
+	}
+}
+
+func TestTestApplication(t *testing.T) {
+	grpc.Server(routeguide.RegisterRouteGuideServer, newRouteGuideServer)
+	grpc.Client("route-guide", routeguide.NewRouteGuideClient)
+
+	testApp := grpctest.RunTestApplication(t)
+	routeGuideClient := testApp.TestApplication.(app.ApplicationContext).GetInstance(new(routeguide.RouteGuideClient)).(routeguide.RouteGuideClient)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lo := &routeguide.Point{Latitude: 1, Longitude: 2}
+	hi := &routeguide.Point{Latitude: 3, Longitude: 4}
+
+	t.Run("should connect the client to the in-process server", func(t *testing.T) {
+		grpctest.AssertUnary(t, ctx, routeGuideClient.GetFeature, lo,
+			&routeguide.Feature{Name: "route 1,2", Location: lo})
+	})
+
+	t.Run("should assert the server stream", func(t *testing.T) {
+		grpctest.AssertServerStream(t, ctx, routeGuideClient.ListFeatures, &routeguide.Rectangle{Lo: lo, Hi: hi},
+			&routeguide.Feature{Name: "route 1,2", Location: lo},
+			&routeguide.Feature{Name: "route 3,4", Location: hi})
+	})
+
+	t.Run("should assert the client stream", func(t *testing.T) {
+		grpctest.AssertClientStream(t, ctx, routeGuideClient.RecordRoute, []proto.Message{lo, hi, lo},
+			&routeguide.RouteSummary{PointCount: 3})
+	})
+
+	t.Run("should assert the bidirectional stream", func(t *testing.T) {
+		grpctest.AssertBidiStream(t, ctx, routeGuideClient.RouteChat,
+			[]proto.Message{
+				&routeguide.RouteNote{Location: lo, Message: "first"},
+				&routeguide.RouteNote{Location: hi, Message: "second"},
+			},
+			&routeguide.RouteNote{Location: lo, Message: "route first"},
+			&routeguide.RouteNote{Location: hi, Message: "route second"})
+	})
+
+	t.Run("should dial the in-process server", func(t *testing.T) {
+		conn, err := testApp.Dial()
+		assert.Equal(t, nil, err)
+		defer conn.Close()
+		grpctest.AssertUnary(t, ctx, routeguide.NewRouteGuideClient(conn).GetFeature, hi,
+			&routeguide.Feature{Name: "route 3,4", Location: hi})
+	})
+
+	t.Run("should replace the server with the fake", func(t *testing.T) {
+		fakeApp := grpctest.NewTestApp().
+			FakeServer(routeguide.RegisterRouteGuideServer, &routeGuideServer{name: "fake"}).
+			Run(t)
+		fakeClient := fakeApp.TestApplication.(app.ApplicationContext).GetInstance(new(routeguide.RouteGuideClient)).(routeguide.RouteGuideClient)
+		grpctest.AssertUnary(t, ctx, fakeClient.GetFeature, lo,
+			&routeguide.Feature{Name: "fake 1,2", Location: lo})
+		grpctest.AssertServerStream(t, ctx, fakeClient.ListFeatures, &routeguide.Rectangle{Lo: lo, Hi: hi},
+			&routeguide.Feature{Name: "fake 1,2", Location: lo},
+			&routeguide.Feature{Name: "fake 3,4", Location: hi})
+	})
+}
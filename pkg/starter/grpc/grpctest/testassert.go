@@ -0,0 +1,202 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpctest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"io"
+	"reflect"
+	"testing"
+)
+
+var (
+	// ErrInvalidMethod the method is not a method of the generated gRPC client
+	ErrInvalidMethod = errors.New("[grpctest] invalid method")
+
+	// ErrInvalidStream the stream is not a stream of the generated gRPC client
+	ErrInvalidStream = errors.New("[grpctest] invalid stream")
+)
+
+// AssertUnary calls the unary method of the client, and asserts the reply equals to the expected one, e.g.
+//
+//	grpctest.AssertUnary(t, ctx, greeterClient.SayHello, &helloworld.HelloRequest{Name: "Steve"},
+//		&helloworld.HelloReply{Message: "Hello Steve"})
+func AssertUnary(t *testing.T, ctx context.Context, method interface{}, request, expected proto.Message) bool {
+	reply, err := call(method, ctx, request)
+	if !assert.NoError(t, err) {
+		return false
+	}
+	return assertMessages(t, []proto.Message{expected}, []proto.Message{reply.(proto.Message)})
+}
+
+// AssertServerStream calls the server streaming method of the client, and asserts the received messages equal to
+// the expected ones, e.g.
+//
+//	grpctest.AssertServerStream(t, ctx, routeGuideClient.ListFeatures, rect, feature1, feature2)
+func AssertServerStream(t *testing.T, ctx context.Context, method interface{}, request proto.Message, expected ...proto.Message) bool {
+	stream, err := call(method, ctx, request)
+	if !assert.NoError(t, err) {
+		return false
+	}
+	replies, err := recvAll(stream)
+	if !assert.NoError(t, err) {
+		return false
+	}
+	return assertMessages(t, expected, replies)
+}
+
+// AssertClientStream calls the client streaming method of the client, sends the requests, and asserts the reply
+// equals to the expected one, e.g.
+//
+//	grpctest.AssertClientStream(t, ctx, routeGuideClient.RecordRoute, []proto.Message{point1, point2}, summary)
+func AssertClientStream(t *testing.T, ctx context.Context, method interface{}, requests []proto.Message, expected proto.Message) bool {
+	stream, err := call(method, ctx)
+	if !assert.NoError(t, err) {
+		return false
+	}
+	if !assert.NoError(t, sendAll(stream, requests)) {
+		return false
+	}
+	reply, err := call(methodOf(stream, "CloseAndRecv"))
+	if !assert.NoError(t, err) {
+		return false
+	}
+	return assertMessages(t, []proto.Message{expected}, []proto.Message{reply.(proto.Message)})
+}
+
+// AssertBidiStream calls the bidirectional streaming method of the client, sends the requests while receiving,
+// and asserts the received messages equal to the expected ones, e.g.
+//
+//	grpctest.AssertBidiStream(t, ctx, routeGuideClient.RouteChat, []proto.Message{note1, note2}, note1)
+func AssertBidiStream(t *testing.T, ctx context.Context, method interface{}, requests []proto.Message, expected ...proto.Message) bool {
+	stream, err := call(method, ctx)
+	if !assert.NoError(t, err) {
+		return false
+	}
+	sent := make(chan error, 1)
+	go func() {
+		err := sendAll(stream, requests)
+		if err == nil {
+			err = stream.(grpc.ClientStream).CloseSend()
+		}
+		sent <- err
+	}()
+	replies, err := recvAll(stream)
+	if !assert.NoError(t, <-sent) || !assert.NoError(t, err) {
+		return false
+	}
+	return assertMessages(t, expected, replies)
+}
+
+// methodOf returns the method of the stream, or nil if it is not found
+func methodOf(stream interface{}, name string) interface{} {
+	method := reflect.ValueOf(stream).MethodByName(name)
+	if !method.IsValid() {
+		return nil
+	}
+	return method.Interface()
+}
+
+// call calls the method of the generated client that returns the reply or the stream, and the error
+func call(method interface{}, args ...interface{}) (interface{}, error) {
+	fn := reflect.ValueOf(method)
+	if fn.Kind() != reflect.Func || fn.Type().NumOut() != 2 {
+		return nil, ErrInvalidMethod
+	}
+	typ := fn.Type()
+	numIn := typ.NumIn()
+	if typ.IsVariadic() {
+		// the call options are not passed
+		numIn--
+	}
+	if len(args) != numIn {
+		return nil, ErrInvalidMethod
+	}
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		in[i] = reflect.ValueOf(arg)
+		if !in[i].IsValid() || !in[i].Type().AssignableTo(typ.In(i)) {
+			return nil, ErrInvalidMethod
+		}
+	}
+	out := fn.Call(in)
+	err, _ := out[1].Interface().(error)
+	return out[0].Interface(), err
+}
+
+// sendAll sends the messages to the stream, the sending stops if the stream is closed by the server,
+// then the status is returned by the receiving
+func sendAll(stream interface{}, messages []proto.Message) error {
+	cs, ok := stream.(grpc.ClientStream)
+	if !ok {
+		return ErrInvalidStream
+	}
+	for _, message := range messages {
+		if err := cs.SendMsg(message); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// recvAll receives the messages from the stream until it is closed by the server
+func recvAll(stream interface{}) (messages []proto.Message, err error) {
+	recv := methodOf(stream, "Recv")
+	if recv == nil {
+		return nil, ErrInvalidStream
+	}
+	for {
+		var message interface{}
+		message, err = call(recv)
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return
+		}
+		messages = append(messages, message.(proto.Message))
+	}
+}
+
+// assertMessages asserts the messages are equal one by one
+func assertMessages(t *testing.T, expected, actual []proto.Message) bool {
+	if len(expected) == len(actual) {
+		equal := true
+		for i := range expected {
+			equal = equal && proto.Equal(expected[i], actual[i])
+		}
+		if equal {
+			return true
+		}
+	}
+	return assert.Fail(t, "Not equal messages",
+		fmt.Sprintf("expected: %v\nactual  : %v", messageTexts(expected), messageTexts(actual)))
+}
+
+// messageTexts returns the compact texts of the messages
+func messageTexts(messages []proto.Message) (texts []string) {
+	for _, message := range messages {
+		texts = append(texts, proto.CompactTextString(message))
+	}
+	return
+}
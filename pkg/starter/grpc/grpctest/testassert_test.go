@@ -0,0 +1,80 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpctest
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/examples/helloworld/helloworld"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	greeterClient := helloworld.NewGreeterClient(new(grpc.ClientConn))
+
+	t.Run("should report the invalid method", func(t *testing.T) {
+		_, err := call("SayHello", context.Background(), &helloworld.HelloRequest{})
+		assert.Equal(t, ErrInvalidMethod, err)
+
+		_, err = call(func() error { return nil })
+		assert.Equal(t, ErrInvalidMethod, err)
+	})
+
+	t.Run("should report the invalid arguments", func(t *testing.T) {
+		_, err := call(greeterClient.SayHello, context.Background())
+		assert.Equal(t, ErrInvalidMethod, err)
+
+		_, err = call(greeterClient.SayHello, context.Background(), &helloworld.HelloReply{})
+		assert.Equal(t, ErrInvalidMethod, err)
+
+		_, err = call(greeterClient.SayHello, context.Background(), nil)
+		assert.Equal(t, ErrInvalidMethod, err)
+	})
+
+	t.Run("should return the reply and the error", func(t *testing.T) {
+		reply, err := call(func(ctx context.Context, req *helloworld.HelloRequest, opts ...grpc.CallOption) (*helloworld.HelloReply, error) {
+			return &helloworld.HelloReply{Message: "Hello " + req.Name}, nil
+		}, context.Background(), &helloworld.HelloRequest{Name: "Steve"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Hello Steve", reply.(*helloworld.HelloReply).Message)
+	})
+}
+
+func TestStream(t *testing.T) {
+	t.Run("should report the invalid stream", func(t *testing.T) {
+		_, err := recvAll(new(helloworld.HelloReply))
+		assert.Equal(t, ErrInvalidStream, err)
+
+		err = sendAll(new(helloworld.HelloReply), []proto.Message{new(helloworld.HelloRequest)})
+		assert.Equal(t, ErrInvalidStream, err)
+	})
+}
+
+func TestAssertMessages(t *testing.T) {
+	t.Run("should assert the equal messages", func(t *testing.T) {
+		assert.Equal(t, true, assertMessages(t,
+			[]proto.Message{&helloworld.HelloReply{Message: "Hello"}},
+			[]proto.Message{&helloworld.HelloReply{Message: "Hello"}}))
+	})
+
+	t.Run("should compose the texts of the messages", func(t *testing.T) {
+		assert.Equal(t, []string{`message:"Hello" `, `name:"Steve" `}, messageTexts([]proto.Message{
+			&helloworld.HelloReply{Message: "Hello"},
+			&helloworld.HelloRequest{Name: "Steve"},
+		}))
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/system"
+	"net"
+	"reflect"
+	"time"
+)
+
+const (
+	// inProcessTarget is the target of the clients that connect to the in-process server
+	inProcessTarget = "bufconn"
+
+	// inProcessBufferSize is the buffer size of the in-process connections
+	inProcessBufferSize = 1024 * 1024
+)
+
+// InProcessServer is the in-memory listener of the gRPC server, and the fake servers that replace the ones registered
+// by grpc.Server, the server and the clients are served in memory if it is set to the factory, e.g. by grpctest.NewTestApp
+type InProcessServer struct {
+	listener *bufconn.Listener
+	fakes    map[uintptr]interface{}
+}
+
+// NewInProcessServer returns the new in-process server
+func NewInProcessServer() *InProcessServer {
+	return &InProcessServer{
+		listener: bufconn.Listen(inProcessBufferSize),
+		fakes:    make(map[uintptr]interface{}),
+	}
+}
+
+// inProcessServerOf returns the in-process server that is set to the factory, or nil
+func inProcessServerOf(instantiateFactory factory.InstantiateFactory) (s *InProcessServer) {
+	s, _ = instantiateFactory.GetInstance(new(InProcessServer)).(*InProcessServer)
+	return
+}
+
+// FakeServer replaces the server that is registered by grpc.Server with the same register function,
+// e.g. helloworld.RegisterGreeterServer, the fake server should implement the service interface
+func (s *InProcessServer) FakeServer(register interface{}, server interface{}) {
+	s.fakes[reflect.ValueOf(register).Pointer()] = server
+}
+
+// Dial returns a new connection to the in-process server, it is insecure if there is no option,
+// the connection should be closed by the caller
+func (s *InProcessServer) Dial(opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if len(opts) == 0 {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	return grpc.Dial(inProcessTarget, append(opts, s.dialer())...)
+}
+
+// fake returns the fake server of the register function
+func (s *InProcessServer) fake(register interface{}) (server interface{}, ok bool) {
+	if s != nil {
+		server, ok = s.fakes[reflect.ValueOf(register).Pointer()]
+	}
+	return
+}
+
+// listen returns the in-memory listener, or listens on the address if the server is not in process
+func (s *InProcessServer) listen(network, address string) (net.Listener, error) {
+	if s == nil {
+		return net.Listen(network, address)
+	}
+	return s.listener, nil
+}
+
+// dialer returns the dial option that connects to the in-memory listener
+func (s *InProcessServer) dialer() grpc.DialOption {
+	return grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return s.listener.Dial()
+	})
+}
+
+// clientProperties returns the copy of the client properties whose transport is the one of the in-process server,
+// the certificate of the server is not verified as the connection is in memory
+func (s *InProcessServer) clientProperties(properties *ClientProperties, ssl *system.SSL) *ClientProperties {
+	p := *properties
+	p.TLS.Enabled = ssl != nil && ssl.Enabled
+	p.TLS.InsecureSkipVerify = true
+	p.TLS.CAFile = ""
+	return &p
+}
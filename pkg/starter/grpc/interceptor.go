@@ -0,0 +1,212 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/reflector"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// UnaryServerInterceptor is the component annotated with at.GrpcServerInterceptor that intercepts the unary calls
+type UnaryServerInterceptor interface {
+	UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error)
+}
+
+// StreamServerInterceptor is the component annotated with at.GrpcServerInterceptor that intercepts the stream calls
+type StreamServerInterceptor interface {
+	StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error
+}
+
+// UnaryClientInterceptor is the component annotated with at.GrpcClientInterceptor that intercepts the unary calls
+type UnaryClientInterceptor interface {
+	UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error
+}
+
+// StreamClientInterceptor is the component annotated with at.GrpcClientInterceptor that intercepts the stream calls
+type StreamClientInterceptor interface {
+	StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error)
+}
+
+// interceptors chains the built-in interceptors and the interceptor components, the components are looked up
+// at the first call, as they may be instantiated after the server or the client connection.
+//
+// The built-in interceptors are called first, in the order of recovery, logging and deadline,
+// then the components in the ascending order of the order tag of the annotation, e.g.
+//
+//	type authInterceptor struct {
+//		at.GrpcServerInterceptor `order:"10"`
+//	}
+//
+// the components without order tag are of order 0, the components of the same order are called in registration order
+type interceptors struct {
+	instantiateFactory factory.InstantiateFactory
+	properties         *interceptorProperties
+
+	serverOnce   sync.Once
+	unaryServer  []UnaryServerInterceptor
+	streamServer []StreamServerInterceptor
+
+	clientOnce   sync.Once
+	unaryClient  []UnaryClientInterceptor
+	streamClient []StreamClientInterceptor
+}
+
+func newInterceptors(instantiateFactory factory.InstantiateFactory, properties *interceptorProperties) *interceptors {
+	return &interceptors{instantiateFactory: instantiateFactory, properties: properties}
+}
+
+// instances returns the components of the annotation in the ascending order of the order tag
+func (i *interceptors) instances(annotation interface{}, annotationName string) (retVal []interface{}) {
+	if i.instantiateFactory == nil {
+		return
+	}
+	var orders []int
+	for _, md := range i.instantiateFactory.GetInstances(annotation) {
+		inst := factory.CastMetaData(md).Instance
+		if inst == nil {
+			continue
+		}
+		order := 0
+		if tag, ok := reflector.FindEmbeddedFieldTag(inst, annotationName, "order"); ok {
+			var err error
+			order, err = strconv.Atoi(fmt.Sprintf("%v", i.instantiateFactory.Replace(tag)))
+			if err != nil {
+				log.Errorf("invalid order %v of gRPC interceptor %v", tag, md.Name)
+			}
+		}
+		retVal = append(retVal, inst)
+		orders = append(orders, order)
+	}
+	idx := make([]int, len(retVal))
+	for n := range idx {
+		idx[n] = n
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return orders[idx[a]] < orders[idx[b]]
+	})
+	sorted := make([]interface{}, len(retVal))
+	for n, k := range idx {
+		sorted[n] = retVal[k]
+	}
+	return sorted
+}
+
+// builtIn returns the enabled built-in interceptors in order
+func (i *interceptors) builtIn() (retVal []interface{}) {
+	p := i.properties
+	if p == nil {
+		p = new(interceptorProperties)
+	}
+	if p.Recovery {
+		retVal = append(retVal, new(recoveryInterceptor))
+	}
+	if p.Logging {
+		retVal = append(retVal, new(loggingInterceptor))
+	}
+	if p.Deadline {
+		retVal = append(retVal, &deadlineInterceptor{clientTimeout: p.ClientTimeout, serverTimeout: p.ServerTimeout})
+	}
+	return
+}
+
+func (i *interceptors) loadServer() {
+	for _, inst := range append(i.builtIn(), i.instances(new(at.GrpcServerInterceptor), "GrpcServerInterceptor")...) {
+		if u, ok := inst.(UnaryServerInterceptor); ok {
+			i.unaryServer = append(i.unaryServer, u)
+		}
+		if s, ok := inst.(StreamServerInterceptor); ok {
+			i.streamServer = append(i.streamServer, s)
+		}
+	}
+}
+
+func (i *interceptors) loadClient() {
+	for _, inst := range append(i.builtIn(), i.instances(new(at.GrpcClientInterceptor), "GrpcClientInterceptor")...) {
+		if u, ok := inst.(UnaryClientInterceptor); ok {
+			i.unaryClient = append(i.unaryClient, u)
+		}
+		if s, ok := inst.(StreamClientInterceptor); ok {
+			i.streamClient = append(i.streamClient, s)
+		}
+	}
+}
+
+// unaryServerInterceptor calls the unary server interceptors in order, then the handler,
+// the request id of the incoming metadata is stored in the context
+func (i *interceptors) unaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	i.serverOnce.Do(i.loadServer)
+	ctx = incomingRequestID(ctx)
+	next := handler
+	for n := len(i.unaryServer) - 1; n >= 0; n-- {
+		interceptor, h := i.unaryServer[n], next
+		next = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return interceptor.UnaryServerInterceptor(ctx, req, info, h)
+		}
+	}
+	return next(ctx, req)
+}
+
+// streamServerInterceptor calls the stream server interceptors in order, then the handler
+func (i *interceptors) streamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	i.serverOnce.Do(i.loadServer)
+	if ctx := incomingRequestID(ss.Context()); ctx != ss.Context() {
+		ss = &serverStream{ServerStream: ss, ctx: ctx}
+	}
+	next := handler
+	for n := len(i.streamServer) - 1; n >= 0; n-- {
+		interceptor, h := i.streamServer[n], next
+		next = func(srv interface{}, ss grpc.ServerStream) error {
+			return interceptor.StreamServerInterceptor(srv, ss, info, h)
+		}
+	}
+	return next(srv, ss)
+}
+
+// unaryClientInterceptor calls the unary client interceptors in order, then the invoker,
+// the request id carried by the context is passed by the outgoing metadata
+func (i *interceptors) unaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	i.clientOnce.Do(i.loadClient)
+	ctx = outgoingRequestID(ctx)
+	next := invoker
+	for n := len(i.unaryClient) - 1; n >= 0; n-- {
+		interceptor, inv := i.unaryClient[n], next
+		next = func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return interceptor.UnaryClientInterceptor(ctx, method, req, reply, cc, inv, opts...)
+		}
+	}
+	return next(ctx, method, req, reply, cc, opts...)
+}
+
+// streamClientInterceptor calls the stream client interceptors in order, then the streamer
+func (i *interceptors) streamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	i.clientOnce.Do(i.loadClient)
+	ctx = outgoingRequestID(ctx)
+	next := streamer
+	for n := len(i.streamClient) - 1; n >= 0; n-- {
+		interceptor, s := i.streamClient[n], next
+		next = func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return interceptor.StreamClientInterceptor(ctx, desc, cc, method, s, opts...)
+		}
+	}
+	return next(ctx, desc, cc, method, opts...)
+}
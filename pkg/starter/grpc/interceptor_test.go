@@ -0,0 +1,95 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"strings"
+	"testing"
+)
+
+// stubFactory returns the instances in registration order
+type stubFactory struct {
+	factory.InstantiateFactory
+	instances []interface{}
+}
+
+func (f *stubFactory) GetInstances(params ...interface{}) (retVal []*factory.MetaData) {
+	for _, inst := range f.instances {
+		retVal = append(retVal, &factory.MetaData{Instance: inst})
+	}
+	return
+}
+
+func (f *stubFactory) Replace(source string) interface{} {
+	return strings.Replace(source, "${grpc.test.order:10}", "10", -1)
+}
+
+type orderedInterceptor struct {
+	name  string
+	calls *[]string
+}
+
+func (i *orderedInterceptor) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	*i.calls = append(*i.calls, i.name)
+	return handler(ctx, req)
+}
+
+type firstInterceptor struct {
+	at.GrpcServerInterceptor `order:"-10"`
+	orderedInterceptor
+}
+
+type defaultInterceptor struct {
+	at.GrpcServerInterceptor
+	orderedInterceptor
+}
+
+type lastInterceptor struct {
+	at.GrpcServerInterceptor `order:"${grpc.test.order:10}"`
+	orderedInterceptor
+}
+
+type invalidOrderInterceptor struct {
+	at.GrpcServerInterceptor `order:"first"`
+	orderedInterceptor
+}
+
+func TestInterceptorOrder(t *testing.T) {
+	var calls []string
+	stub := &stubFactory{instances: []interface{}{
+		&lastInterceptor{orderedInterceptor: orderedInterceptor{"last", &calls}},
+		&defaultInterceptor{orderedInterceptor: orderedInterceptor{"default", &calls}},
+		&firstInterceptor{orderedInterceptor: orderedInterceptor{"first", &calls}},
+		&invalidOrderInterceptor{orderedInterceptor: orderedInterceptor{"invalid", &calls}},
+	}}
+
+	t.Run("should call the interceptors in the order of the order tag", func(t *testing.T) {
+		i := newInterceptors(stub, &interceptorProperties{Recovery: true, Logging: true, Deadline: true})
+		resp, err := i.unaryServerInterceptor(context.Background(), "hello", &grpc.UnaryServerInfo{FullMethod: "/test.Service/Unary"},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				calls = append(calls, "handler")
+				return req, nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "hello", resp)
+		assert.Equal(t, []string{"first", "default", "invalid", "last", "handler"}, calls)
+		assert.Equal(t, 3+4, len(i.unaryServer))
+	})
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip" // the gzip compressor of the requests and the responses
+	"google.golang.org/grpc/keepalive"
+	"hidevops.io/hiboot/pkg/utils/crypto/tlsconfig"
+	"time"
+)
+
+// serverOptions returns the options of the server properties
+func serverOptions(properties *server) (opts []grpc.ServerOption) {
+	if properties.MaxReceiveMessageSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(properties.MaxReceiveMessageSize))
+	}
+	if properties.MaxSendMessageSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(properties.MaxSendMessageSize))
+	}
+	if properties.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(properties.MaxConcurrentStreams))
+	}
+	if properties.ConnectionTimeout > 0 {
+		opts = append(opts, grpc.ConnectionTimeout(properties.ConnectionTimeout))
+	}
+	ka := properties.KeepAlive
+	opts = append(opts,
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:              ka.Time,
+			Timeout:           ka.Timeout,
+			MaxConnectionIdle: ka.MaxConnectionIdle,
+			MaxConnectionAge:  ka.MaxConnectionAge,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             ka.MinTime,
+			PermitWithoutStream: ka.PermitWithoutStream,
+		}),
+	)
+	return
+}
+
+// transportOption returns the option of the tls credentials and the reloader of the client certificate,
+// or the plain text
+func transportOption(properties *ClientProperties) (opt grpc.DialOption, reloader *tlsconfig.Reloader, err error) {
+	if !properties.TLS.Enabled {
+		return grpc.WithInsecure(), nil, nil
+	}
+	var creds credentials.TransportCredentials
+	creds, reloader, err = clientCredentials(&properties.TLS)
+	if err == nil {
+		opt = grpc.WithTransportCredentials(creds)
+	}
+	return
+}
+
+// timeoutInterceptor applies the timeout to the unary call without deadline before the interceptors are called,
+// so that the timeout is applied whether the deadline interceptor is enabled or not
+func timeoutInterceptor(timeout time.Duration, next grpc.UnaryClientInterceptor) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return next(ctx, method, req, reply, cc, invoker, opts...)
+	}
+}
+
+// dialOptions returns the options of the client properties except the transport option, the unary calls without
+// deadline are limited to the timeout of the properties, or defaultTimeout
+func dialOptions(properties *ClientProperties, defaultTimeout time.Duration, interceptors *interceptors) (opts []grpc.DialOption, err error) {
+	unaryInterceptor := interceptors.unaryClientInterceptor
+	timeout := properties.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if timeout > 0 {
+		unaryInterceptor = timeoutInterceptor(timeout, unaryInterceptor)
+	}
+	opts = append(opts,
+		grpc.WithUnaryInterceptor(unaryInterceptor),
+		grpc.WithStreamInterceptor(interceptors.streamClientInterceptor),
+	)
+
+	if properties.Balancer != "" {
+		if balancer.Get(properties.Balancer) == nil {
+			err = fmt.Errorf("%v: %v", ErrInvalidBalancer, properties.Balancer)
+			return
+		}
+		opts = append(opts, grpc.WithBalancerName(properties.Balancer))
+	}
+
+	if properties.KeepAlive.Enabled {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                time.Duration(properties.KeepAlive.Delay) * time.Second,
+			Timeout:             time.Duration(properties.KeepAlive.Timeout) * time.Second,
+			PermitWithoutStream: properties.KeepAlive.PermitWithoutStream,
+		}))
+	}
+	if properties.Backoff.MaxDelay > 0 {
+		opts = append(opts, grpc.WithBackoffMaxDelay(properties.Backoff.MaxDelay))
+	}
+
+	var callOpts []grpc.CallOption
+	if properties.MaxReceiveMessageSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(properties.MaxReceiveMessageSize))
+	}
+	if properties.MaxSendMessageSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(properties.MaxSendMessageSize))
+	}
+	if properties.Compression != "" {
+		callOpts = append(callOpts, grpc.UseCompressor(properties.Compression))
+	}
+	if properties.WaitForReady {
+		callOpts = append(callOpts, grpc.FailFast(false))
+	}
+	if len(callOpts) != 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+	return
+}
@@ -0,0 +1,249 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"hidevops.io/hiboot/pkg/factory/instantiate"
+	"hidevops.io/hiboot/pkg/system"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type optionsGreeterService struct{}
+
+func (s *optionsGreeterService) SayHello(ctx context.Context, req *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	switch {
+	case req.Name == "slow":
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	case strings.HasPrefix(req.Name, "big:"):
+		return &helloworld.HelloReply{Message: strings.Repeat("x", 1024)}, nil
+	}
+	return &helloworld.HelloReply{Message: "Hello " + req.Name}, nil
+}
+
+// serveBufconn serves the greeter by the server properties over bufconn
+func serveBufconn(properties *server) (lis *bufconn.Listener, stop func()) {
+	lis = bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer(serverOptions(properties)...)
+	helloworld.RegisterGreeterServer(s, new(optionsGreeterService))
+	go s.Serve(lis)
+	return lis, s.Stop
+}
+
+// dialBufconn dials by the client properties, the dialer fails until ready returns true
+func dialBufconn(t *testing.T, lis *bufconn.Listener, properties *ClientProperties, defaultTimeout time.Duration, ready func() bool) helloworld.GreeterClient {
+	// the built-in interceptors are disabled, the timeouts are applied without the deadline interceptor
+	opts, err := dialOptions(properties, defaultTimeout, newInterceptors(nil, nil))
+	assert.Equal(t, nil, err)
+	opts = append(opts, grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		if ready != nil && !ready() {
+			return nil, errors.New("connection refused")
+		}
+		return lis.Dial()
+	}))
+	conn, err := grpc.Dial("bufconn", opts...)
+	assert.Equal(t, nil, err)
+	return helloworld.NewGreeterClient(conn)
+}
+
+func TestOptions(t *testing.T) {
+	lis, stop := serveBufconn(&server{MaxReceiveMessageSize: 512, MaxConcurrentStreams: 10})
+	defer stop()
+	sayHello := func(cli helloworld.GreeterClient, name string) (*helloworld.HelloReply, error) {
+		return cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: name})
+	}
+
+	t.Run("should call over bufconn", func(t *testing.T) {
+		reply, err := sayHello(dialBufconn(t, lis, &ClientProperties{}, 0, nil), "Steve")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Hello Steve", reply.Message)
+	})
+
+	t.Run("should limit the size of the message received by server", func(t *testing.T) {
+		_, err := sayHello(dialBufconn(t, lis, &ClientProperties{}, 0, nil), strings.Repeat("x", 1024))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("should limit the size of the message sent and received by client", func(t *testing.T) {
+		cli := dialBufconn(t, lis, &ClientProperties{MaxSendMessageSize: 256, MaxReceiveMessageSize: 256}, 0, nil)
+		_, err := sayHello(cli, strings.Repeat("x", 300))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		_, err = sayHello(cli, "big:reply")
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("should apply the default deadline of client", func(t *testing.T) {
+		_, err := sayHello(dialBufconn(t, lis, &ClientProperties{Timeout: 50 * time.Millisecond}, 0, nil), "slow")
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+		_, err = sayHello(dialBufconn(t, lis, &ClientProperties{}, 50*time.Millisecond, nil), "slow")
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("should keep the deadline of the call", func(t *testing.T) {
+		cli := dialBufconn(t, lis, &ClientProperties{Timeout: 50 * time.Millisecond}, 0, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		reply, err := cli.SayHello(ctx, &helloworld.HelloRequest{Name: "slow"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Hello slow", reply.Message)
+	})
+
+	t.Run("should compress the requests", func(t *testing.T) {
+		reply, err := sayHello(dialBufconn(t, lis, &ClientProperties{Compression: "gzip"}, 0, nil), "Steve")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Hello Steve", reply.Message)
+
+		_, err = sayHello(dialBufconn(t, lis, &ClientProperties{Compression: "unknown"}, 0, nil), "Steve")
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	t.Run("should wait for the server to be ready", func(t *testing.T) {
+		var ready int32
+		isReady := func() bool { return atomic.LoadInt32(&ready) == 1 }
+
+		_, err := sayHello(dialBufconn(t, lis, &ClientProperties{}, 0, isReady), "Steve")
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+
+		cli := dialBufconn(t, lis, &ClientProperties{WaitForReady: true, Backoff: backoff{MaxDelay: 50 * time.Millisecond}}, 0, isReady)
+		time.AfterFunc(100*time.Millisecond, func() { atomic.StoreInt32(&ready, 1) })
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		reply, err := cli.SayHello(ctx, &helloworld.HelloRequest{Name: "Steve"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Hello Steve", reply.Message)
+	})
+
+	t.Run("should report invalid tls properties", func(t *testing.T) {
+		_, _, err := transportOption(&ClientProperties{TLS: ClientTLS{Enabled: true, MinVersion: "2.0"}})
+		assert.NotEqual(t, nil, err)
+	})
+}
+
+func TestDecodeClientProperties(t *testing.T) {
+	f := instantiate.NewInstantiateFactory(nil, nil, nil)
+
+	t.Run("should apply the default values", func(t *testing.T) {
+		prop, err := decodeClientProperties(f, map[string]interface{}{"host": "localhost"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "localhost", prop.Host)
+		assert.Equal(t, "7575", prop.Port)
+		assert.Equal(t, true, prop.PlainText)
+		assert.Equal(t, false, prop.TLS.Enabled)
+		assert.Equal(t, true, prop.KeepAlive.Enabled)
+		assert.Equal(t, uint64(10), prop.KeepAlive.Delay)
+		assert.Equal(t, 120*time.Second, prop.Backoff.MaxDelay)
+		assert.Equal(t, "pick_first", prop.Balancer)
+		assert.Equal(t, 30*time.Second, prop.RefreshInterval)
+		assert.Equal(t, false, prop.HealthCheck.Enabled)
+		assert.Equal(t, 10*time.Second, prop.HealthCheck.Interval)
+		assert.Equal(t, time.Second, prop.HealthCheck.Timeout)
+	})
+
+	t.Run("should apply the default values to the client that is not configured", func(t *testing.T) {
+		prop, err := decodeClientProperties(f, nil)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "7575", prop.Port)
+		assert.Equal(t, true, prop.PlainText)
+		assert.Equal(t, "pick_first", prop.Balancer)
+	})
+
+	t.Run("should bind the balancing properties", func(t *testing.T) {
+		prop, err := decodeClientProperties(f, map[string]interface{}{
+			"address":          "static:///host1:7575,host2:7575",
+			"balancer":         "round_robin",
+			"refresh_interval": "5s",
+			"health-check":     map[string]interface{}{"enabled": true, "interval": "2s"},
+		})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "static:///host1:7575,host2:7575", prop.Address)
+		assert.Equal(t, "round_robin", prop.Balancer)
+		assert.Equal(t, 5*time.Second, prop.RefreshInterval)
+		assert.Equal(t, true, prop.HealthCheck.Enabled)
+		assert.Equal(t, 2*time.Second, prop.HealthCheck.Interval)
+		assert.Equal(t, time.Second, prop.HealthCheck.Timeout)
+	})
+
+	t.Run("should bind the keys in relaxed way", func(t *testing.T) {
+		prop, err := decodeClientProperties(f, map[string]interface{}{
+			"plain_text":               false,
+			"keep-alive":               map[string]interface{}{"enabled": false},
+			"timeout":                  "3s",
+			"max_receive_message_size": "16MB",
+			"maxSendMessageSize":       1024,
+			"wait-for-ready":           true,
+			"backoff":                  map[string]interface{}{"max_delay": "5s"},
+		})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, prop.TLS.Enabled)
+		assert.Equal(t, false, prop.KeepAlive.Enabled)
+		assert.Equal(t, 3*time.Second, prop.Timeout)
+		assert.Equal(t, 16*1024*1024, prop.MaxReceiveMessageSize)
+		assert.Equal(t, 1024, prop.MaxSendMessageSize)
+		assert.Equal(t, true, prop.WaitForReady)
+		assert.Equal(t, 5*time.Second, prop.Backoff.MaxDelay)
+	})
+}
+
+func TestClientTimeoutSeconds(t *testing.T) {
+	f := instantiate.NewInstantiateFactory(nil, nil, nil)
+
+	t.Run("should decode the bare number of timeout as seconds", func(t *testing.T) {
+		prop, err := decodeClientProperties(f, map[string]interface{}{"timeout": 5})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 5*time.Second, prop.Timeout)
+
+		prop, err = decodeClientProperties(f, map[string]interface{}{"timeout": "500ms"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 500*time.Millisecond, prop.Timeout)
+	})
+
+	t.Run("should bind grpc.timeoutSecond: 5 as seconds", func(t *testing.T) {
+		b := system.NewBuilder(new(configuration), os.TempDir(), "grpc-timeout-second", "yaml",
+			map[string]interface{}{"grpc.timeoutSecond": 5})
+		conf, err := b.Build()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 5*time.Second, conf.(*configuration).Properties.TimeoutSecond)
+	})
+}
+
+func TestServerOptions(t *testing.T) {
+	t.Run("should build the options of server properties", func(t *testing.T) {
+		assert.Equal(t, 2, len(serverOptions(&server{})))
+		assert.Equal(t, 6, len(serverOptions(&server{
+			MaxReceiveMessageSize: 1024,
+			MaxSendMessageSize:    1024,
+			MaxConcurrentStreams:  10,
+			ConnectionTimeout:     time.Second,
+		})))
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+)
+
+// postProcessor mounts the routes of the gateway, then reports the server is serving, as the application is initialized
+type postProcessor struct {
+	applicationContext app.ApplicationContext
+	serverFactory      ServerFactory
+	gateway            *Gateway
+}
+
+func init() {
+	app.RegisterPostProcessor(newPostProcessor)
+}
+
+func newPostProcessor(applicationContext app.ApplicationContext, serverFactory ServerFactory, gateway *Gateway) *postProcessor {
+	return &postProcessor{
+		applicationContext: applicationContext,
+		serverFactory:      serverFactory,
+		gateway:            gateway,
+	}
+}
+
+// AfterInitialization mounts the routes of the gateway and opens the readiness gate of the server
+func (p *postProcessor) AfterInitialization() {
+	if p.gateway != nil {
+		p.gateway.mount(p.applicationContext)
+	}
+	if p.serverFactory != nil {
+		p.serverFactory.Ready()
+	}
+}
@@ -20,24 +20,200 @@ type server struct {
 	Host string `json:"host"`
 	// server port, default is 7575
 	Port string `json:"port" default:"7575"`
+
+	// the maximum size of the received message, e.g. 16MB, 0 for the default 4MB
+	MaxReceiveMessageSize int `json:"max_receive_message_size" mapstructure:"maxReceiveMessageSize"`
+	// the maximum size of the sent message, 0 for no limit
+	MaxSendMessageSize int `json:"max_send_message_size" mapstructure:"maxSendMessageSize"`
+	// the maximum number of the concurrent streams of each connection, 0 for no limit
+	MaxConcurrentStreams uint32 `json:"max_concurrent_streams" mapstructure:"maxConcurrentStreams"`
+	// the timeout of establishing the connection including the tls handshake, 0 for the default 120s
+	ConnectionTimeout time.Duration `json:"connection_timeout" mapstructure:"connectionTimeout"`
+	// the keepalive and the keepalive enforcement of the server
+	KeepAlive serverKeepAlive `json:"keep_alive" mapstructure:"keepAlive"`
+	// the server reflection service
+	Reflection serverReflection `json:"reflection"`
+	// the timeout of stopping the server gracefully, the pending calls are canceled after it
+	ShutdownTimeout time.Duration `json:"shutdown_timeout" mapstructure:"shutdownTimeout" default:"30s"`
+	// the health statuses of the server and the registered services
+	Health serverHealth `json:"health"`
+}
+
+type serverReflection struct {
+	// register the reflection service on the server, e.g. for grpcurl
+	Enabled bool `json:"enabled" default:"true"`
+}
+
+// serviceHealth is the health indicators of the registered service, e.g.
+//
+//	grpc:
+//	  server:
+//	    health:
+//	      services:
+//	      - name: helloworld.Greeter
+//	        include: [db, redis]
+type serviceHealth struct {
+	// the full name of the service, e.g. helloworld.Greeter
+	Name string `json:"name"`
+	// the names of the health indicators, e.g. db
+	Include []string `json:"include"`
+}
+
+type serverHealth struct {
+	// the interval of updating the statuses by the health indicators, 0 to update them once the application is initialized
+	Interval time.Duration `json:"interval" default:"10s"`
+	// the timeout of each health indicator, the indicator that is timed out is DOWN and the service is NOT_SERVING
+	Timeout time.Duration `json:"timeout" default:"3s"`
+	// the health indicators of the services, the status of the service that is not listed is aggregated by all indicators
+	Services []serviceHealth `json:"services"`
+}
+
+type serverKeepAlive struct {
+	// the duration of no activity after which the server pings the client, 0 for the default 2h
+	Time time.Duration `json:"time"`
+	// the duration the server waits for the ping ack before the connection is closed, 0 for the default 20s
+	Timeout time.Duration `json:"timeout"`
+	// the duration of idle after which the connection is closed, 0 for infinity
+	MaxConnectionIdle time.Duration `json:"max_connection_idle" mapstructure:"maxConnectionIdle"`
+	// the maximum age of the connection, 0 for infinity
+	MaxConnectionAge time.Duration `json:"max_connection_age" mapstructure:"maxConnectionAge"`
+	// the minimum interval of the client pings, the connection of the client that pings more often is closed,
+	// it is less than the delay of the client keepalive by default
+	MinTime time.Duration `json:"min_time" mapstructure:"minTime" default:"5s"`
+	// allow the client pings without active calls
+	PermitWithoutStream bool `json:"permit_without_stream" mapstructure:"permitWithoutStream" default:"true"`
 }
 
 type keepAlive struct {
-	Enabled bool   `json:"enabled" default:"true"`
-	Delay   uint64 `json:"delay" default:"10"`
+	// ping the server if there is no activity
+	Enabled bool `json:"enabled" default:"true"`
+	// the seconds of no activity after which the client pings the server, at least 10
+	Delay uint64 `json:"delay" default:"10"`
+	// the seconds the client waits for the ping ack before the connection is closed
 	Timeout uint64 `json:"timeout" default:"120"`
+	// ping even if there is no active call
+	PermitWithoutStream bool `json:"permit_without_stream" mapstructure:"permitWithoutStream"`
+}
+
+type healthCheck struct {
+	// exclude the addresses that are not serving by the health service of the server
+	Enabled bool `json:"enabled"`
+	// the service name of the health check request, the health of the server is checked if it is empty
+	Service string `json:"service"`
+	// the interval of the health check
+	Interval time.Duration `json:"interval" default:"10s"`
+	// the timeout of each health check
+	Timeout time.Duration `json:"timeout" default:"1s"`
+}
+
+type backoff struct {
+	// the maximum delay of reconnecting, the delay grows exponentially from 1s
+	MaxDelay time.Duration `json:"max_delay" mapstructure:"maxDelay" default:"120s"`
+}
+
+// ClientTLS is the tls properties of grpc client
+type ClientTLS struct {
+	// connect by tls instead of plain text
+	Enabled bool `json:"enabled"`
+	// the pem certificate authorities that verify the server certificate, the system roots are used if it is empty,
+	// they are loaded once as the client is created, unlike the client certificate that is reloaded
+	CAFile string `json:"ca_file" mapstructure:"caFile"`
+	// the pem client certificate file of mutual tls
+	CertFile string `json:"cert_file" mapstructure:"certFile"`
+	// the pem client private key file of mutual tls
+	KeyFile string `json:"key_file" mapstructure:"keyFile"`
+	// the server name that the server certificate is verified by, the host is used if it is empty
+	ServerName string `json:"server_name" mapstructure:"serverName"`
+	// the minimum tls version, 1.0, 1.1, 1.2 or 1.3, 1.2 if it is empty
+	MinVersion string `json:"min_version" mapstructure:"minVersion"`
+	// skip the verification of the server certificate, it is for testing only
+	InsecureSkipVerify bool `json:"insecure_skip_verify" mapstructure:"insecureSkipVerify"`
 }
 
 // ClientProperties used for grpc client injection
 type ClientProperties struct {
-	Host      string    `json:"host"`
-	Port      string    `json:"port" default:"7575"`
+	Host string `json:"host"`
+	Port string `json:"port" default:"7575"`
+	// the target of the resolver, e.g. dns:///greeter:7575, static:///host1:7575,host2:7575,
+	// or the scheme of the ServiceRegistry component, e.g. consul:///greeter, host:port is used if it is empty
+	Address string `json:"address"`
+	// the balancing policy, pick_first or round_robin
+	Balancer string `json:"balancer" default:"pick_first"`
+	// the health check based balancing
+	HealthCheck healthCheck `json:"health_check" mapstructure:"healthCheck"`
+	// the interval of resolving the addresses by the ServiceRegistry component
+	RefreshInterval time.Duration `json:"refresh_interval" mapstructure:"refreshInterval" default:"30s"`
+	// connect by tls with the system roots if it is false, the same as tls.enabled: true
 	PlainText bool      `json:"plain_text" default:"true"`
 	KeepAlive keepAlive `json:"keep_alive"`
+	// the tls properties, e.g. grpc.client.hello-world-service.tls.enabled: true
+	TLS ClientTLS `json:"tls"`
+	// the default deadline of the unary calls without deadline, grpc.timeoutSecond is used if it is 0,
+	// e.g. 5 or 5s, the bare number is of seconds
+	Timeout time.Duration `json:"timeout" unit:"s"`
+	// the maximum size of the received message, e.g. 16MB, 0 for the default 4MB
+	MaxReceiveMessageSize int `json:"max_receive_message_size" mapstructure:"maxReceiveMessageSize"`
+	// the maximum size of the sent message, 0 for no limit
+	MaxSendMessageSize int `json:"max_send_message_size" mapstructure:"maxSendMessageSize"`
+	// the compressor of the requests, e.g. gzip, the requests are not compressed if it is empty
+	Compression string `json:"compression"`
+	// wait for the connection to be ready instead of failing at once when the server is unavailable
+	WaitForReady bool `json:"wait_for_ready" mapstructure:"waitForReady"`
+	// the backoff of reconnecting
+	Backoff backoff `json:"backoff"`
+}
+
+// interceptorProperties is the properties of the built-in interceptors, e.g. grpc.interceptors.logging: false
+type interceptorProperties struct {
+	// recover the panics of the server handlers, the calls fail with codes.Internal
+	Recovery bool `json:"recovery" default:"true"`
+	// log the method, the status code and the duration of the calls at debug level, and the failed calls as warnings
+	Logging bool `json:"logging" default:"true"`
+	// reject the server calls whose deadline is exceeded and apply the timeouts below
+	Deadline bool `json:"deadline" default:"true"`
+	// the timeout of the unary client calls without deadline, 0 for no timeout, grpc.timeoutSecond and
+	// grpc.client.<name>.timeout are applied whether the deadline interceptor is enabled or not, and they take
+	// precedence over it
+	ClientTimeout time.Duration `json:"client_timeout" mapstructure:"clientTimeout"`
+	// the maximum timeout of the server calls, 0 for no limit
+	ServerTimeout time.Duration `json:"server_timeout" mapstructure:"serverTimeout"`
+}
+
+// gatewayRule maps the rpc method to the REST route, it overrides the google.api.http annotation of the method, e.g.
+//
+//	grpc:
+//	  gateway:
+//	    enabled: true
+//	    rules:
+//	    - selector: helloworld.Greeter.SayHello
+//	      get: /v1/greeter/{name}
+type gatewayRule struct {
+	// the full name of the rpc method, e.g. helloworld.Greeter.SayHello
+	Selector string `json:"selector"`
+	// the path template of the http method, e.g. /v1/greeter/{name}, one of them should be set
+	Get    string `json:"get"`
+	Put    string `json:"put"`
+	Post   string `json:"post"`
+	Delete string `json:"delete"`
+	Patch  string `json:"patch"`
+	// the field of the request message that the request body is mapped to, * for the whole message
+	Body string `json:"body"`
+	// the field of the response message that the response body is mapped from, the whole message if it is empty
+	ResponseBody string `json:"response_body" mapstructure:"responseBody"`
+}
+
+type gateway struct {
+	// transcode the REST requests to the gRPC services that are registered by grpc.Server
+	Enabled bool `json:"enabled"`
+	// the rules of the rpc methods in addition to the google.api.http annotations
+	Rules []gatewayRule `json:"rules"`
 }
 
 type properties struct {
-	TimeoutSecond time.Duration          `json:"timeout_second"`
+	// the default deadline of the unary client calls, e.g. 5 or 5s, the bare number is of seconds
+	TimeoutSecond time.Duration          `json:"timeout_second" unit:"s"`
 	Server        server                 `json:"server"`
 	Client        map[string]interface{} `json:"client"`
+	Interceptors  interceptorProperties  `json:"interceptors"`
+	Gateway       gateway                `json:"gateway"`
 }
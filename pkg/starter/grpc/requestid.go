@@ -0,0 +1,63 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"hidevops.io/hiboot/pkg/log"
+	"strings"
+)
+
+// requestIDMetadataKey is the metadata key that carries the request correlation id, the keys of metadata are lower case
+var requestIDMetadataKey = strings.ToLower(log.RequestIDHeader)
+
+// outgoingRequestID passes the request id carried by ctx to the server by metadata x-request-id
+func outgoingRequestID(ctx context.Context) context.Context {
+	id := log.RequestID(ctx)
+	if id == "" {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(requestIDMetadataKey)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, requestIDMetadataKey, id)
+}
+
+// incomingRequestID stores the request id of metadata x-request-id in ctx, so that it is written with the log lines
+// by log.WithContext(ctx) and passed on to the downstream services
+func incomingRequestID(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	ids := md.Get(requestIDMetadataKey)
+	if len(ids) == 0 || ids[0] == "" {
+		return ctx
+	}
+	return log.NewContext(ctx, log.Fields{log.RequestIDKey: ids[0]})
+}
+
+// serverStream overrides the context of the server stream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context with the request id
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
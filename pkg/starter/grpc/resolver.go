@@ -0,0 +1,275 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+	"hidevops.io/hiboot/pkg/log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// StaticScheme is the scheme of the static addresses, e.g. static:///host1:7575,host2:7575
+	StaticScheme = "static"
+
+	// clientScheme is the scheme of the resolver builder of the clients, the authority of the target is the client name
+	clientScheme = "hiboot"
+)
+
+// ServiceRegistry is the component annotated with at.GrpcServiceRegistry that discovers the addresses of the services,
+// the client of address consul:///greeter is resolved by the component of scheme consul, e.g.
+//
+//	type consulRegistry struct {
+//		at.GrpcServiceRegistry
+//	}
+//
+//	func (r *consulRegistry) Scheme() string {
+//		return "consul"
+//	}
+//
+//	func (r *consulRegistry) Resolve(service string) ([]string, error) {
+//		// query the addresses of the service
+//	}
+//
+// the addresses are resolved by grpc.client.<name>.refreshInterval
+type ServiceRegistry interface {
+	// Scheme returns the scheme of the target
+	Scheme() string
+	// Resolve returns the addresses host:port of the service
+	Resolve(service string) (addresses []string, err error)
+}
+
+// ErrUnknownClient is returned if the client of the target is not configured
+var ErrUnknownClient = errors.New("[grpc] client of the target is not configured")
+
+// clients is the resolver builder of the clients
+var clients = &clientBuilder{configs: make(map[string]*clientConfig)}
+
+func init() {
+	resolver.Register(new(staticBuilder))
+	resolver.Register(clients)
+}
+
+// toAddresses returns the resolver addresses of host:port, the empty ones are skipped
+func toAddresses(hosts []string) (addresses []resolver.Address) {
+	for _, host := range hosts {
+		if host = strings.TrimSpace(host); host != "" {
+			addresses = append(addresses, resolver.Address{Addr: host})
+		}
+	}
+	return
+}
+
+// parseTarget parses the target scheme://authority/endpoint, the target without scheme is of the default scheme
+func parseTarget(target string) resolver.Target {
+	if n := strings.Index(target, "://"); n >= 0 {
+		if m := strings.Index(target[n+3:], "/"); m >= 0 {
+			return resolver.Target{Scheme: target[:n], Authority: target[n+3 : n+3+m], Endpoint: target[n+3+m+1:]}
+		}
+	}
+	return resolver.Target{Scheme: resolver.GetDefaultScheme(), Endpoint: target}
+}
+
+// nopResolver is the resolver of the addresses that never change
+type nopResolver struct{}
+
+func (nopResolver) ResolveNow(resolver.ResolveNowOption) {}
+
+func (nopResolver) Close() {}
+
+// staticBuilder resolves the comma separated addresses of the endpoint
+type staticBuilder struct{}
+
+func (b *staticBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOption) (resolver.Resolver, error) {
+	cc.NewAddress(toAddresses(strings.Split(target.Endpoint, ",")))
+	return nopResolver{}, nil
+}
+
+func (b *staticBuilder) Scheme() string {
+	return StaticScheme
+}
+
+// registryResolver resolves the addresses of the service by the ServiceRegistry component periodically,
+// the component is looked up at each resolution, as it may be instantiated after the client connection
+type registryResolver struct {
+	target     resolver.Target
+	cc         resolver.ClientConn
+	lookup     func(scheme string) ServiceRegistry
+	interval   time.Duration
+	resolveNow chan struct{}
+	done       chan struct{}
+}
+
+func newRegistryResolver(target resolver.Target, cc resolver.ClientConn, lookup func(scheme string) ServiceRegistry, interval time.Duration) *registryResolver {
+	r := &registryResolver{
+		target:     target,
+		cc:         cc,
+		lookup:     lookup,
+		interval:   interval,
+		resolveNow: make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+func (r *registryResolver) run() {
+	if r.interval <= 0 {
+		r.interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.resolve()
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		case <-r.resolveNow:
+		}
+	}
+}
+
+func (r *registryResolver) resolve() {
+	registry := r.lookup(r.target.Scheme)
+	if registry == nil {
+		log.Warnf("gRPC service registry of scheme %v is not found", r.target.Scheme)
+		return
+	}
+	addresses, err := registry.Resolve(r.target.Endpoint)
+	if err != nil {
+		log.Errorf("failed to resolve gRPC service %v by %v: %v", r.target.Endpoint, r.target.Scheme, err)
+		return
+	}
+	r.cc.NewAddress(toAddresses(addresses))
+}
+
+// ResolveNow resolves the addresses at once
+func (r *registryResolver) ResolveNow(resolver.ResolveNowOption) {
+	select {
+	case r.resolveNow <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops resolving
+func (r *registryResolver) Close() {
+	close(r.done)
+}
+
+// clientResolver closes the resolver of the target and the health filter
+type clientResolver struct {
+	resolver.Resolver
+	health *healthFilter
+}
+
+func (r *clientResolver) Close() {
+	r.Resolver.Close()
+	if r.health != nil {
+		r.health.Close()
+	}
+}
+
+// clientConfig is the resolution config of the client
+type clientConfig struct {
+	target      resolver.Target
+	properties  *ClientProperties
+	lookup      func(scheme string) ServiceRegistry
+	dialOptions []grpc.DialOption
+}
+
+// clientBuilder is the resolver builder of scheme hiboot, it resolves the target by the config of the client of
+// the target authority, by the builder of the target scheme or by the ServiceRegistry component, then the addresses
+// are filtered by health check
+type clientBuilder struct {
+	sync.RWMutex
+	configs map[string]*clientConfig
+}
+
+func (b *clientBuilder) config(name string) *clientConfig {
+	b.RLock()
+	defer b.RUnlock()
+	return b.configs[name]
+}
+
+func (b *clientBuilder) setConfig(name string, config *clientConfig) {
+	b.Lock()
+	defer b.Unlock()
+	b.configs[name] = config
+}
+
+func (b *clientBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOption) (resolver.Resolver, error) {
+	c := b.config(target.Authority)
+	if c == nil {
+		return nil, fmt.Errorf("%v: %v", ErrUnknownClient, target.Authority)
+	}
+	var health *healthFilter
+	if c.properties.HealthCheck.Enabled {
+		health = newHealthFilter(cc, &c.properties.HealthCheck, c.dialOptions)
+		cc = health
+	}
+	var r resolver.Resolver
+	if builder := resolver.Get(c.target.Scheme); builder != nil {
+		var err error
+		r, err = builder.Build(c.target, cc, opts)
+		if err != nil {
+			if health != nil {
+				health.Close()
+			}
+			return nil, err
+		}
+	} else {
+		r = newRegistryResolver(c.target, cc, c.lookup, c.properties.RefreshInterval)
+	}
+	return &clientResolver{Resolver: r, health: health}, nil
+}
+
+func (b *clientBuilder) Scheme() string {
+	return clientScheme
+}
+
+// clientTarget returns the target of the client to dial, the target is resolved by the config of the client if
+// it is resolved by the ServiceRegistry component or the health check is enabled
+func clientTarget(name string, properties *ClientProperties, lookup func(scheme string) ServiceRegistry, dialOptions []grpc.DialOption) string {
+	address := properties.Address
+	if address == "" {
+		host := properties.Host
+		if host == "" {
+			host = name
+		}
+		port := properties.Port
+		if port == "" {
+			port = defaultPort
+		}
+		address = host + ":" + port
+	}
+	target := parseTarget(address)
+	if !properties.HealthCheck.Enabled && resolver.Get(target.Scheme) != nil {
+		return address
+	}
+	clients.setConfig(name, &clientConfig{
+		target:      target,
+		properties:  properties,
+		lookup:      lookup,
+		dialOptions: dialOptions,
+	})
+	// keep the endpoint as the authority of the connection
+	return fmt.Sprintf("%v://%v/%v", clientScheme, name, target.Endpoint)
+}
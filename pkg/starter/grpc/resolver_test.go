@@ -0,0 +1,232 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/health"
+	pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type idGreeterService struct {
+	id string
+}
+
+func (s *idGreeterService) SayHello(ctx context.Context, req *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	return &helloworld.HelloReply{Message: s.id}, nil
+}
+
+type testBackend struct {
+	addr   string
+	health *health.Server
+	stop   func()
+}
+
+func startBackend(t *testing.T, id string) *testBackend {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	s := grpc.NewServer()
+	helloworld.RegisterGreeterServer(s, &idGreeterService{id: id})
+	hs := health.NewServer()
+	pb.RegisterHealthServer(s, hs)
+	go s.Serve(lis)
+	return &testBackend{addr: lis.Addr().String(), health: hs, stop: s.Stop}
+}
+
+type testRegistry struct {
+	sync.Mutex
+	addresses []string
+	err       error
+}
+
+func (r *testRegistry) Scheme() string {
+	return "test"
+}
+
+func (r *testRegistry) Resolve(service string) ([]string, error) {
+	r.Lock()
+	defer r.Unlock()
+	if service != "greeter" {
+		return nil, errors.New("unknown service")
+	}
+	return r.addresses, r.err
+}
+
+// dialTarget dials the client properties as clientConnector.Connect does, the connection should be closed
+// in order to stop the resolver and the health check
+func dialTarget(t *testing.T, name string, properties *ClientProperties, lookup func(scheme string) ServiceRegistry) (helloworld.GreeterClient, *grpc.ClientConn) {
+	opts, err := dialOptions(properties, 0, newInterceptors(nil, nil))
+	assert.Equal(t, nil, err)
+	opts = append(opts, grpc.WithInsecure())
+	conn, err := grpc.Dial(clientTarget(name, properties, lookup, []grpc.DialOption{grpc.WithInsecure()}), opts...)
+	assert.Equal(t, nil, err)
+	return helloworld.NewGreeterClient(conn), conn
+}
+
+// tryCallIDs returns the ids of the backends that responded n calls, or the error of the first failed call
+func tryCallIDs(cli helloworld.GreeterClient, n int) (ids map[string]int, err error) {
+	ids = make(map[string]int)
+	for i := 0; i < n; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		var reply *helloworld.HelloReply
+		reply, err = cli.SayHello(ctx, &helloworld.HelloRequest{}, grpc.FailFast(false))
+		cancel()
+		if err != nil {
+			return
+		}
+		ids[reply.Message]++
+	}
+	return
+}
+
+// callIDs returns the ids of the backends that responded n calls
+func callIDs(t *testing.T, cli helloworld.GreeterClient, n int) map[string]int {
+	ids, err := tryCallIDs(cli, n)
+	assert.Equal(t, nil, err)
+	return ids
+}
+
+// eventually retries the condition until it is true or timed out
+func eventually(condition func() bool) bool {
+	for deadline := time.Now().Add(3 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if condition() {
+			return true
+		}
+	}
+	return false
+}
+
+func TestResolver(t *testing.T) {
+	b1, b2 := startBackend(t, "b1"), startBackend(t, "b2")
+	defer b1.stop()
+	defer b2.stop()
+	static := StaticScheme + ":///" + b1.addr + "," + b2.addr
+
+	t.Run("should parse target", func(t *testing.T) {
+		assert.Equal(t, resolver.Target{Scheme: "dns", Authority: "", Endpoint: "greeter:7575"}, parseTarget("dns:///greeter:7575"))
+		assert.Equal(t, resolver.Target{Scheme: "dns", Authority: "8.8.8.8", Endpoint: "greeter:7575"}, parseTarget("dns://8.8.8.8/greeter:7575"))
+		assert.Equal(t, resolver.Target{Scheme: resolver.GetDefaultScheme(), Endpoint: "localhost:7575"}, parseTarget("localhost:7575"))
+	})
+
+	t.Run("should dial host and port if address is empty", func(t *testing.T) {
+		assert.Equal(t, "localhost:7575", clientTarget("greeter", &ClientProperties{Host: "localhost"}, nil, nil))
+		assert.Equal(t, "greeter:7576", clientTarget("greeter", &ClientProperties{Port: "7576"}, nil, nil))
+		assert.Equal(t, "dns:///greeter:7575", clientTarget("greeter", &ClientProperties{Address: "dns:///greeter:7575"}, nil, nil))
+	})
+
+	t.Run("should report unknown client", func(t *testing.T) {
+		_, err := clients.Build(resolver.Target{Scheme: clientScheme, Authority: "unknown"}, nil, resolver.BuildOption{})
+		assert.Contains(t, err.Error(), ErrUnknownClient.Error())
+	})
+
+	t.Run("should balance the static addresses by round robin", func(t *testing.T) {
+		cli, conn := dialTarget(t, "round-robin", &ClientProperties{Address: static, Balancer: "round_robin"}, nil)
+		defer conn.Close()
+		// the calls are balanced once both sub connections are ready
+		assert.True(t, eventually(func() bool {
+			ids, err := tryCallIDs(cli, 10)
+			return err == nil && ids["b1"] == 5 && ids["b2"] == 5
+		}))
+	})
+
+	t.Run("should pick the first address", func(t *testing.T) {
+		cli, conn := dialTarget(t, "pick-first", &ClientProperties{Address: static, Balancer: "pick_first"}, nil)
+		defer conn.Close()
+		ids := callIDs(t, cli, 10)
+		assert.Equal(t, 1, len(ids))
+	})
+
+	t.Run("should report invalid balancer", func(t *testing.T) {
+		_, err := dialOptions(&ClientProperties{Balancer: "random"}, 0, newInterceptors(nil, nil))
+		assert.Contains(t, err.Error(), ErrInvalidBalancer.Error())
+	})
+
+	t.Run("should resolve the addresses by service registry", func(t *testing.T) {
+		registry := &testRegistry{}
+		var mutex sync.Mutex
+		var found ServiceRegistry
+		lookup := func(scheme string) ServiceRegistry {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if found != nil && found.Scheme() == scheme {
+				return found
+			}
+			return nil
+		}
+		target := clientTarget("registry", &ClientProperties{Address: "test:///greeter"}, lookup, nil)
+		assert.True(t, strings.HasPrefix(target, clientScheme+"://registry/"))
+
+		cli, conn := dialTarget(t, "registry", &ClientProperties{
+			Address:         "test:///greeter",
+			Balancer:        "round_robin",
+			RefreshInterval: 50 * time.Millisecond,
+		}, lookup)
+		defer conn.Close()
+
+		// the registry is instantiated after the client connection
+		registry.addresses = []string{b1.addr}
+		mutex.Lock()
+		found = registry
+		mutex.Unlock()
+		assert.Equal(t, map[string]int{"b1": 4}, callIDs(t, cli, 4))
+
+		registry.Lock()
+		registry.addresses = []string{b1.addr, b2.addr}
+		registry.Unlock()
+		assert.True(t, eventually(func() bool {
+			ids, err := tryCallIDs(cli, 4)
+			return err == nil && len(ids) == 2
+		}))
+
+		registry.Lock()
+		registry.err = errors.New("unavailable")
+		registry.Unlock()
+		assert.Equal(t, 2, len(callIDs(t, cli, 4)))
+	})
+
+	t.Run("should balance the serving addresses by health check", func(t *testing.T) {
+		// the overall status of the health server is always SERVING, so the named service is checked
+		service := "helloworld.Greeter"
+		b1.health.SetServingStatus(service, pb.HealthCheckResponse_SERVING)
+		b2.health.SetServingStatus(service, pb.HealthCheckResponse_NOT_SERVING)
+		cli, conn := dialTarget(t, "health-check", &ClientProperties{
+			Address:     static,
+			Balancer:    "round_robin",
+			HealthCheck: healthCheck{Enabled: true, Service: service, Interval: 50 * time.Millisecond},
+		}, nil)
+		defer conn.Close()
+		assert.True(t, eventually(func() bool {
+			ids, err := tryCallIDs(cli, 4)
+			return err == nil && ids["b1"] == 4
+		}))
+
+		b2.health.SetServingStatus(service, pb.HealthCheckResponse_SERVING)
+		b1.health.SetServingStatus(service, pb.HealthCheckResponse_NOT_SERVING)
+		assert.True(t, eventually(func() bool {
+			ids, err := tryCallIDs(cli, 4)
+			return err == nil && ids["b2"] == 4
+		}))
+	})
+}
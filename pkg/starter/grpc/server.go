@@ -15,50 +15,237 @@
 package grpc
 
 import (
+	"github.com/kataras/iris"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	pb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/factory"
 	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/starter/actuator/healthcheck"
+	"hidevops.io/hiboot/pkg/utils/crypto/tlsconfig"
 	"hidevops.io/hiboot/pkg/utils/reflector"
-	"net"
+	"hidevops.io/hiboot/pkg/utils/str"
+	"sort"
+	"sync"
+	"time"
 )
 
 // ServerFactory build grpc servers
 type ServerFactory interface {
+	// Ready reports SERVING for the server and the registered services, then their statuses are updated by the
+	// health indicators, it is called once the application is initialized
+	Ready()
+	// Stop stops the server gracefully, the pending calls are canceled after grpc.server.shutdownTimeout
+	Stop()
 }
 
 type serverFactory struct {
+	instantiateFactory factory.InstantiateFactory
+	properties         *server
+	grpcServer         *grpc.Server
+	healthServer       *health.Server
+	// the names of the services that are registered on the server
+	services []string
+
+	mutex     sync.Mutex
+	watching  sync.WaitGroup
+	readyOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
 }
 
-func newServerFactory(instantiateFactory factory.InstantiateFactory, properties properties, grpcServer *grpc.Server) ServerFactory {
-	sf := &serverFactory{}
+func newServerFactory(instantiateFactory factory.InstantiateFactory, properties properties, grpcServer *grpc.Server) (*serverFactory, error) {
+	sf := &serverFactory{
+		instantiateFactory: instantiateFactory,
+		properties:         &properties.Server,
+		done:               make(chan struct{}),
+	}
 
 	// just return if grpc server is not enabled
-	if properties.Server.Enabled && grpcServer != nil {
-		address := properties.Server.Host + ":" + properties.Server.Port
-		lis, err := net.Listen(properties.Server.Network, address)
-		if err == nil {
-			// register server
-			// Register reflection service on gRPC server.
-			chn := make(chan bool)
-			go func() {
-				for _, srv := range grpcServers {
-					svc := instantiateFactory.GetInstance(srv.name)
-					reflector.CallFunc(srv.cb, grpcServer, svc)
-					if err == nil {
-						log.Infof("Registered %v on gRPC server", srv.name)
-					}
-				}
-				reflection.Register(grpcServer)
-				chn <- true
-				grpcServer.Serve(lis)
-			}()
-			<-chn
-
-			log.Infof("gRPC server listening on: localhost%v", address)
+	if !properties.Server.Enabled || grpcServer == nil {
+		return sf, nil
+	}
+	sf.grpcServer = grpcServer
+
+	// the server of the test application is served in memory
+	inProcess := inProcessServerOf(instantiateFactory)
+	for _, srv := range grpcServers {
+		svc := instantiateFactory.GetInstance(srv.name)
+		if fake, ok := inProcess.fake(srv.cb); ok {
+			svc = fake
+		}
+		reflector.CallFunc(srv.cb, grpcServer, svc)
+		if hs, ok := svc.(*health.Server); ok {
+			sf.healthServer = hs
+		}
+		log.Infof("Registered %v on gRPC server", srv.name)
+	}
+	if properties.Server.Reflection.Enabled {
+		reflection.Register(grpcServer)
+	}
+	for name := range grpcServer.GetServiceInfo() {
+		sf.services = append(sf.services, name)
+	}
+	sort.Strings(sf.services)
+	// the server and the services are not serving until the application is initialized
+	if sf.healthServer != nil {
+		sf.healthServer.SetServingStatus("", pb.HealthCheckResponse_NOT_SERVING)
+		for _, name := range sf.services {
+			sf.healthServer.SetServingStatus(name, pb.HealthCheckResponse_NOT_SERVING)
+		}
+	}
+
+	address := properties.Server.Host + ":" + properties.Server.Port
+	lis, err := inProcess.listen(properties.Server.Network, address)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Errorf("gRPC server on %v is stopped: %v", address, err)
+		}
+	}()
+	// stop the server before the web application is shut down
+	iris.RegisterOnInterrupt(sf.Stop)
+	log.Infof("gRPC server listening on: %v", lis.Addr())
+
+	return sf, nil
+}
+
+// Ready reports SERVING for the server and the registered services, then their statuses are updated by the
+// health indicators every grpc.server.health.interval
+func (sf *serverFactory) Ready() {
+	if sf.healthServer == nil {
+		return
+	}
+	sf.readyOnce.Do(func() {
+		sf.updateHealth()
+		if sf.properties.Health.Interval > 0 {
+			sf.watching.Add(1)
+			go sf.watchHealth()
+		}
+		log.Infof("gRPC server is serving")
+	})
+}
+
+func (sf *serverFactory) watchHealth() {
+	defer sf.watching.Done()
+	ticker := time.NewTicker(sf.properties.Health.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sf.done:
+			return
+		case <-ticker.C:
+			sf.updateHealth()
+		}
+	}
+}
+
+// updateHealth sets the status of the server by all health indicators, and the status of each service by
+// the health indicators of grpc.server.health.services
+func (sf *serverFactory) updateHealth() {
+	statuses := sf.checkIndicators()
+	status := func(all bool, include []string) pb.HealthCheckResponse_ServingStatus {
+		var included []string
+		for name, s := range statuses {
+			if all || str.InSlice(name, include) {
+				included = append(included, s)
+			}
+		}
+		if healthcheck.Available(healthcheck.AggregateStatus(included...)) {
+			return pb.HealthCheckResponse_SERVING
+		}
+		return pb.HealthCheckResponse_NOT_SERVING
+	}
+
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+	select {
+	case <-sf.done:
+		// the server is stopping, keep it NOT_SERVING
+		return
+	default:
+	}
+	sf.healthServer.SetServingStatus("", status(true, nil))
+	for _, name := range sf.services {
+		listed := false
+		var include []string
+		for _, svc := range sf.properties.Health.Services {
+			if svc.Name == name {
+				listed = true
+				include = append(include, svc.Include...)
+			}
 		}
+		sf.healthServer.SetServingStatus(name, status(!listed, include))
+	}
+}
+
+// checkIndicators checks the health indicators in parallel, the health indicator of gRPC itself is skipped
+// as it checks this server, the indicator that is timed out is DOWN
+func (sf *serverFactory) checkIndicators() (statuses map[string]string) {
+	statuses = make(map[string]string)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, md := range sf.instantiateFactory.GetInstances(new(at.HealthCheckService)) {
+		indicator, ok := factory.CastMetaData(md).Instance.(healthcheck.HealthService)
+		if !ok || indicator.Name() == Profile {
+			continue
+		}
+		wg.Add(1)
+		go func(indicator healthcheck.HealthService) {
+			defer wg.Done()
+			health := healthcheck.Check(indicator, sf.properties.Health.Timeout, healthcheck.StatusDown)
+			if !healthcheck.Available(health.Status) {
+				log.Warnf("health indicator %v is %v: %v", indicator.Name(), health.Status, health.Details)
+			}
+			mutex.Lock()
+			statuses[indicator.Name()] = health.Status
+			mutex.Unlock()
+		}(indicator)
+	}
+	wg.Wait()
+	return
+}
+
+// watchCertificates reloads the certificate files of server.ssl by the interval until the server is stopped
+func (sf *serverFactory) watchCertificates(reloader *tlsconfig.Reloader, interval time.Duration) {
+	go reloader.Watch(interval, sf.done, func(err error) {
+		log.Errorf("failed to reload the certificates of gRPC server: %v", err)
+	})
+}
 
+// Stop reports NOT_SERVING, then stops the server gracefully, the pending calls are canceled after
+// grpc.server.shutdownTimeout
+func (sf *serverFactory) Stop() {
+	if sf.grpcServer == nil {
+		return
 	}
+	sf.stopOnce.Do(func() {
+		sf.mutex.Lock()
+		close(sf.done)
+		if sf.healthServer != nil {
+			sf.healthServer.SetServingStatus("", pb.HealthCheckResponse_NOT_SERVING)
+			for _, name := range sf.services {
+				sf.healthServer.SetServingStatus(name, pb.HealthCheckResponse_NOT_SERVING)
+			}
+		}
+		sf.mutex.Unlock()
+		sf.watching.Wait()
 
-	return sf
+		stopped := make(chan struct{})
+		go func() {
+			sf.grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			log.Infof("gRPC server is stopped")
+		case <-time.After(sf.properties.ShutdownTimeout):
+			log.Warnf("gRPC server is not stopped in %v, the pending calls are canceled", sf.properties.ShutdownTimeout)
+			sf.grpcServer.Stop()
+		}
+	})
 }
@@ -0,0 +1,260 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/health"
+	pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeIndicator struct {
+	at.HealthCheckService
+	sync.Mutex
+	name   string
+	up     bool
+	status string
+	delay  time.Duration
+}
+
+func (i *fakeIndicator) Name() string {
+	return i.name
+}
+
+func (i *fakeIndicator) Status() bool {
+	time.Sleep(i.delay)
+	i.Lock()
+	defer i.Unlock()
+	return i.up
+}
+
+func (i *fakeIndicator) setUp(up bool) {
+	i.Lock()
+	i.up = up
+	i.Unlock()
+}
+
+type fakeStatusIndicator struct {
+	fakeIndicator
+}
+
+func (i *fakeStatusIndicator) HealthStatus() string {
+	return i.status
+}
+
+// serverTestFactory returns the instances by name, and the health indicators
+type serverTestFactory struct {
+	instanceFactory
+	indicators []interface{}
+}
+
+func (f *serverTestFactory) GetInstances(params ...interface{}) (retVal []*factory.MetaData) {
+	for _, inst := range f.indicators {
+		retVal = append(retVal, &factory.MetaData{Instance: inst})
+	}
+	return
+}
+
+type slowGreeterService struct {
+	called chan struct{}
+}
+
+func (s *slowGreeterService) SayHello(ctx context.Context, in *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	close(s.called)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(10 * time.Second):
+	}
+	return &helloworld.HelloReply{Message: "Hello " + in.Name}, nil
+}
+
+func newTestServerProperties() properties {
+	return properties{Server: server{
+		Enabled:         true,
+		Network:         "tcp",
+		Host:            "127.0.0.1",
+		Port:            "0",
+		Reflection:      serverReflection{Enabled: true},
+		ShutdownTimeout: 100 * time.Millisecond,
+		Health:          serverHealth{Timeout: 50 * time.Millisecond},
+	}}
+}
+
+func checkHealth(hs *health.Server, service string) pb.HealthCheckResponse_ServingStatus {
+	resp, err := hs.Check(context.Background(), &pb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return pb.HealthCheckResponse_UNKNOWN
+	}
+	return resp.Status
+}
+
+func TestServerFactory(t *testing.T) {
+	saved := grpcServers
+	defer func() { grpcServers = saved }()
+	grpcServers = []*grpcService{
+		{name: "grpc.healthServer", cb: pb.RegisterHealthServer},
+		{name: "grpc.greeterService", cb: helloworld.RegisterGreeterServer},
+	}
+	newFactory := func(indicators ...interface{}) *serverTestFactory {
+		return &serverTestFactory{
+			instanceFactory: instanceFactory{instances: map[string]interface{}{
+				"grpc.healthServer":   health.NewServer(),
+				"grpc.greeterService": &slowGreeterService{called: make(chan struct{})},
+			}},
+			indicators: indicators,
+		}
+	}
+
+	t.Run("should report the listen error", func(t *testing.T) {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Equal(t, nil, err)
+		defer lis.Close()
+		prop := newTestServerProperties()
+		_, prop.Server.Port, _ = net.SplitHostPort(lis.Addr().String())
+		_, err = newServerFactory(newFactory(), prop, grpc.NewServer())
+		assert.NotEqual(t, nil, err)
+
+		c := &configuration{Properties: prop, instantiateFactory: newFactory()}
+		sf, err := c.ServerFactory(grpc.NewServer())
+		assert.NotEqual(t, nil, err)
+		assert.True(t, sf == nil)
+	})
+
+	t.Run("should not register reflection if it is disabled", func(t *testing.T) {
+		prop := newTestServerProperties()
+		prop.Server.Reflection.Enabled = false
+		grpcServer := grpc.NewServer()
+		sf, err := newServerFactory(newFactory(), prop, grpcServer)
+		assert.Equal(t, nil, err)
+		defer sf.Stop()
+		_, ok := grpcServer.GetServiceInfo()["grpc.reflection.v1alpha.ServerReflection"]
+		assert.Equal(t, false, ok)
+		_, ok = grpcServer.GetServiceInfo()["helloworld.Greeter"]
+		assert.Equal(t, true, ok)
+	})
+
+	t.Run("should report serving once the application is ready", func(t *testing.T) {
+		f := newFactory()
+		sf, err := newServerFactory(f, newTestServerProperties(), grpc.NewServer())
+		assert.Equal(t, nil, err)
+		defer sf.Stop()
+		hs := f.instances["grpc.healthServer"].(*health.Server)
+		assert.Equal(t, pb.HealthCheckResponse_NOT_SERVING, checkHealth(hs, "helloworld.Greeter"))
+		assert.Equal(t, pb.HealthCheckResponse_UNKNOWN, checkHealth(hs, "unknown.Service"))
+
+		sf.Ready()
+		assert.Equal(t, pb.HealthCheckResponse_SERVING, checkHealth(hs, "helloworld.Greeter"))
+		assert.Equal(t, pb.HealthCheckResponse_SERVING, checkHealth(hs, "grpc.health.v1.Health"))
+	})
+
+	t.Run("should update the statuses by the health indicators", func(t *testing.T) {
+		db := &fakeIndicator{name: "db", up: true}
+		f := newFactory(
+			db,
+			&fakeIndicator{name: "cache", up: true},
+			&fakeIndicator{name: Profile, up: false},
+			&fakeIndicator{name: "slow", up: true, delay: time.Second},
+			&fakeStatusIndicator{fakeIndicator: fakeIndicator{name: "queue", status: "OUT_OF_SERVICE"}},
+		)
+		prop := newTestServerProperties()
+		prop.Server.Health.Interval = 20 * time.Millisecond
+		prop.Server.Health.Services = []serviceHealth{
+			{Name: "helloworld.Greeter", Include: []string{"db", "cache"}},
+		}
+		sf, err := newServerFactory(f, prop, grpc.NewServer())
+		assert.Equal(t, nil, err)
+		defer sf.Stop()
+		hs := f.instances["grpc.healthServer"].(*health.Server)
+
+		sf.Ready()
+		// queue is out of service and the slow indicator is timed out, grpc itself is ignored
+		assert.Equal(t, pb.HealthCheckResponse_NOT_SERVING, checkHealth(hs, "grpc.health.v1.Health"))
+		assert.Equal(t, pb.HealthCheckResponse_SERVING, checkHealth(hs, "helloworld.Greeter"))
+
+		db.setUp(false)
+		assert.True(t, eventually(func() bool {
+			return checkHealth(hs, "helloworld.Greeter") == pb.HealthCheckResponse_NOT_SERVING
+		}))
+	})
+
+	t.Run("should report not serving for the health indicator that is timed out", func(t *testing.T) {
+		f := newFactory(
+			&fakeIndicator{name: "db", up: true},
+			&fakeIndicator{name: "slow", up: true, delay: time.Second},
+		)
+		prop := newTestServerProperties()
+		prop.Server.Health.Services = []serviceHealth{
+			{Name: "helloworld.Greeter", Include: []string{"db"}},
+		}
+		sf, err := newServerFactory(f, prop, grpc.NewServer())
+		assert.Equal(t, nil, err)
+		defer sf.Stop()
+		hs := f.instances["grpc.healthServer"].(*health.Server)
+
+		sf.Ready()
+		assert.Equal(t, pb.HealthCheckResponse_NOT_SERVING, checkHealth(hs, "grpc.health.v1.Health"))
+		assert.Equal(t, pb.HealthCheckResponse_SERVING, checkHealth(hs, "helloworld.Greeter"))
+	})
+
+	t.Run("should stop the server gracefully in time", func(t *testing.T) {
+		f := newFactory()
+		grpcServer := grpc.NewServer()
+		sf, err := newServerFactory(f, newTestServerProperties(), grpcServer)
+		assert.Equal(t, nil, err)
+		sf.Ready()
+
+		lis := bufconn.Listen(gatewayBufferSize)
+		go grpcServer.Serve(lis)
+		conn, err := grpc.Dial("bufconn", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+			return lis.Dial()
+		}))
+		assert.Equal(t, nil, err)
+		defer conn.Close()
+
+		result := make(chan error, 1)
+		go func() {
+			_, err := helloworld.NewGreeterClient(conn).SayHello(context.Background(), &helloworld.HelloRequest{Name: "foo"})
+			result <- err
+		}()
+		<-f.instances["grpc.greeterService"].(*slowGreeterService).called
+
+		start := time.Now()
+		sf.Stop()
+		assert.True(t, time.Since(start) < 5*time.Second)
+		assert.Equal(t, codes.Unavailable, status.Code(<-result))
+		hs := f.instances["grpc.healthServer"].(*health.Server)
+		assert.Equal(t, pb.HealthCheckResponse_NOT_SERVING, checkHealth(hs, "helloworld.Greeter"))
+	})
+
+	t.Run("should do nothing if the server is disabled", func(t *testing.T) {
+		sf, err := newServerFactory(newFactory(), properties{}, nil)
+		assert.Equal(t, nil, err)
+		sf.Ready()
+		sf.Stop()
+	})
+}
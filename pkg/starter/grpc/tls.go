@@ -0,0 +1,126 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"crypto/x509"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/system"
+	"hidevops.io/hiboot/pkg/utils/crypto/tlsconfig"
+	"net"
+	"time"
+)
+
+// clientReloadInterval is the interval to check if the client certificate files are changed
+const clientReloadInterval = time.Minute
+
+// serverCredentials returns the tls credentials of server.ssl and the reloader of its certificate files
+func serverCredentials(ssl *system.SSL) (creds credentials.TransportCredentials, reloader *tlsconfig.Reloader, err error) {
+	cfg, reloader, err := tlsconfig.ServerConfig(ssl.Options())
+	if err != nil {
+		return
+	}
+	cfg.NextProtos = []string{"h2"}
+	return inProcessCredentials{credentials.NewTLS(cfg)}, reloader, nil
+}
+
+// inProcessConn is the connection that is accepted by the in-process listener
+type inProcessConn struct {
+	net.Conn
+}
+
+// inProcessListener is the in-memory listener of the server, its connections are trusted without tls handshake
+type inProcessListener struct {
+	net.Listener
+}
+
+func (l inProcessListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return inProcessConn{conn}, nil
+}
+
+// inProcessCredentials is the tls credentials of the server that skips the handshake of the in-process connections,
+// so that the gateway calls the server in memory whatever the client authentication is
+type inProcessCredentials struct {
+	credentials.TransportCredentials
+}
+
+func (c inProcessCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	if _, ok := conn.(inProcessConn); ok {
+		return conn, nil, nil
+	}
+	return c.TransportCredentials.ServerHandshake(conn)
+}
+
+func (c inProcessCredentials) Clone() credentials.TransportCredentials {
+	return inProcessCredentials{c.TransportCredentials.Clone()}
+}
+
+// clientCredentials returns the tls credentials of the client and the reloader of its certificate files
+func clientCredentials(properties *ClientTLS) (creds credentials.TransportCredentials, reloader *tlsconfig.Reloader, err error) {
+	cfg, reloader, err := tlsconfig.ClientConfig(&tlsconfig.Options{
+		CertFile:           properties.CertFile,
+		KeyFile:            properties.KeyFile,
+		CAFile:             properties.CAFile,
+		MinVersion:         properties.MinVersion,
+		ServerName:         properties.ServerName,
+		InsecureSkipVerify: properties.InsecureSkipVerify,
+	})
+	if err != nil {
+		return
+	}
+	return credentials.NewTLS(cfg), reloader, nil
+}
+
+// watchClientCertificate reloads the client certificate every minute until the connection is closed
+func watchClientCertificate(conn *grpc.ClientConn, reloader *tlsconfig.Reloader) {
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for state := conn.GetState(); state != connectivity.Shutdown; state = conn.GetState() {
+			conn.WaitForStateChange(context.Background(), state)
+		}
+	}()
+	reloader.Watch(clientReloadInterval, closed, func(err error) {
+		log.Errorf("failed to reload the client certificate of gRPC client: %v", err)
+	})
+}
+
+// PeerCertificate returns the verified client certificate of the mutual tls call, or nil, e.g.
+//
+//	func (s *greeterService) SayHello(ctx context.Context, req *HelloRequest) (*HelloReply, error) {
+//		if cert := grpc.PeerCertificate(ctx); cert != nil {
+//			log.Infof("called by %v", cert.Subject.CommonName)
+//		}
+//	}
+func PeerCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return info.State.VerifiedChains[0][0]
+}
@@ -0,0 +1,209 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/examples/helloworld/helloworld"
+	"hidevops.io/hiboot/pkg/factory/instantiate"
+	"hidevops.io/hiboot/pkg/system"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type tlsGreeterService struct{}
+
+func (s *tlsGreeterService) SayHello(ctx context.Context, req *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	name := "anonymous"
+	if cert := PeerCertificate(ctx); cert != nil {
+		name = cert.Subject.CommonName
+	}
+	return &helloworld.HelloReply{Message: "Hello " + name}, nil
+}
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// writeTestCert writes the pem certificate and key signed by parent, or self signed if parent is nil
+func writeTestCert(t *testing.T, dir, name string, parent *testCert) (certFile, keyFile string, c *testCert) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Equal(t, nil, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:              []string{name},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.Equal(t, nil, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Equal(t, nil, err)
+
+	certFile, keyFile = filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	assert.Equal(t, nil, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+	assert.Equal(t, nil, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	c = &testCert{key: key}
+	c.cert, _ = x509.ParseCertificate(der)
+	return
+}
+
+func TestTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grpc-tls")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	caFile, _, ca := writeTestCert(t, dir, "ca", nil)
+	certFile, keyFile, _ := writeTestCert(t, dir, "localhost", ca)
+	clientCertFile, clientKeyFile, _ := writeTestCert(t, dir, "client", ca)
+
+	serverCreds, _, err := serverCredentials(&system.SSL{
+		Enabled:      true,
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+		ClientAuth:   "optional",
+	})
+	assert.Equal(t, nil, err)
+	server := grpc.NewServer(grpc.Creds(serverCreds))
+	helloworld.RegisterGreeterServer(server, new(tlsGreeterService))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	go server.Serve(ln)
+	defer server.Stop()
+
+	sayHello := func(properties *ClientTLS) (reply *helloworld.HelloReply, err error) {
+		creds, _, err := clientCredentials(properties)
+		if err != nil {
+			return
+		}
+		conn, err := grpc.Dial(ln.Addr().String(), grpc.WithTransportCredentials(creds))
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		return helloworld.NewGreeterClient(conn).SayHello(ctx, &helloworld.HelloRequest{})
+	}
+
+	t.Run("should call by mutual tls", func(t *testing.T) {
+		reply, err := sayHello(&ClientTLS{
+			Enabled:    true,
+			CAFile:     caFile,
+			CertFile:   clientCertFile,
+			KeyFile:    clientKeyFile,
+			ServerName: "localhost",
+		})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Hello client", reply.Message)
+	})
+
+	t.Run("should call by tls without client certificate", func(t *testing.T) {
+		reply, err := sayHello(&ClientTLS{Enabled: true, CAFile: caFile, ServerName: "localhost"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Hello anonymous", reply.Message)
+	})
+
+	t.Run("should stop watching the client certificate when the connection is closed", func(t *testing.T) {
+		creds, reloader, err := clientCredentials(&ClientTLS{
+			Enabled:    true,
+			CAFile:     caFile,
+			CertFile:   clientCertFile,
+			KeyFile:    clientKeyFile,
+			ServerName: "localhost",
+		})
+		assert.Equal(t, nil, err)
+		conn, err := grpc.Dial(ln.Addr().String(), grpc.WithTransportCredentials(creds))
+		assert.Equal(t, nil, err)
+		stopped := make(chan struct{})
+		go func() {
+			watchClientCertificate(conn, reloader)
+			close(stopped)
+		}()
+		conn.Close()
+		select {
+		case <-stopped:
+		case <-time.After(3 * time.Second):
+			t.Error("the client certificate is still watched after the connection is closed")
+		}
+	})
+
+	t.Run("should report invalid client tls properties", func(t *testing.T) {
+		_, err := sayHello(&ClientTLS{Enabled: true, CAFile: filepath.Join(dir, "not-exist.crt")})
+		assert.NotEqual(t, nil, err)
+
+		_, _, err = serverCredentials(&system.SSL{Enabled: true, CertFile: certFile, KeyFile: keyFile, MinVersion: "2.0"})
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should call in process without client certificate if client authentication is required", func(t *testing.T) {
+		creds, _, err := serverCredentials(&system.SSL{
+			Enabled:      true,
+			CertFile:     certFile,
+			KeyFile:      keyFile,
+			ClientCAFile: caFile,
+			ClientAuth:   "required",
+		})
+		assert.Equal(t, nil, err)
+		server := grpc.NewServer(grpc.Creds(creds))
+		helloworld.RegisterGreeterServer(server, new(tlsGreeterService))
+		defer server.Stop()
+		conn, err := dialInProcess(server)
+		assert.Equal(t, nil, err)
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		reply, err := helloworld.NewGreeterClient(conn).SayHello(ctx, &helloworld.HelloRequest{}, grpc.FailFast(false))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Hello anonymous", reply.Message)
+	})
+
+	t.Run("should return nil peer certificate without tls", func(t *testing.T) {
+		assert.Nil(t, PeerCertificate(context.Background()))
+	})
+}
+
+func TestServerSSL(t *testing.T) {
+	t.Run("should read server.ssl of the system configuration", func(t *testing.T) {
+		c := &configuration{instantiateFactory: instantiate.NewInstantiateFactory(nil, nil, nil)}
+		ssl := c.ssl()
+		assert.NotNil(t, ssl)
+		assert.Equal(t, false, ssl.Enabled)
+	})
+}
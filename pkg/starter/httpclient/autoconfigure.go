@@ -3,6 +3,7 @@ package httpclient
 import (
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
 )
 
 const (
@@ -12,17 +13,32 @@ const (
 
 type configuration struct {
 	at.AutoConfiguration
+
+	Properties         properties `mapstructure:"httpclient"`
+	instantiateFactory factory.InstantiateFactory
 }
 
 func init() {
 	app.Register(newConfiguration)
 }
 
-func newConfiguration() *configuration {
-	return &configuration{}
+func newConfiguration(instantiateFactory factory.InstantiateFactory) *configuration {
+	return &configuration{
+		instantiateFactory: instantiateFactory,
+	}
+}
+
+// Observers is the observers of the clients of the application, e.g. for metrics and tracing
+func (c *configuration) Observers() *Observers {
+	return new(Observers)
 }
 
 // client returns an instance of Client
-func (c *configuration) Client() Client {
-	return NewClient()
+func (c *configuration) Client(observers *Observers) Client {
+	return NewClient(WithObservers(observers))
+}
+
+// ClientFactory implements the declarative clients that are annotated with at.HttpClient
+func (c *configuration) ClientFactory(observers *Observers) ClientFactory {
+	return newClientFactory(c.instantiateFactory, &c.Properties, observers)
 }
@@ -7,10 +7,10 @@ import (
 )
 
 func TestConfiguration(t *testing.T) {
-	c := newConfiguration()
+	c := newConfiguration(nil)
 
 	t.Run("should get a struct", func(t *testing.T) {
-		client:=c.Client()
+		client:=c.Client(c.Observers())
 		assert.IsType(t, reflect.Struct, reflect.TypeOf(client).Kind())
 	})
 
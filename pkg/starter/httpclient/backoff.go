@@ -34,7 +34,7 @@ func (cb *constantBackoff) Next(retry int) time.Duration {
 		return 0 * time.Millisecond
 	}
 
-	return (time.Duration(cb.backoffInterval) * time.Millisecond) + (time.Duration(rand.Int63n(cb.maximumJitterInterval)) * time.Millisecond)
+	return (time.Duration(cb.backoffInterval) * time.Millisecond) + (time.Duration(jitter(cb.maximumJitterInterval)) * time.Millisecond)
 }
 
 type exponentialBackoff struct {
@@ -60,5 +60,13 @@ func (eb *exponentialBackoff) Next(retry int) time.Duration {
 		return 0 * time.Millisecond
 	}
 
-	return time.Duration(math.Min(eb.initialTimeout+math.Pow(eb.exponentFactor, float64(retry)), eb.maxTimeout)+float64(rand.Int63n(eb.maximumJitterInterval))) * time.Millisecond
+	return time.Duration(math.Min(eb.initialTimeout+math.Pow(eb.exponentFactor, float64(retry)), eb.maxTimeout)+float64(jitter(eb.maximumJitterInterval))) * time.Millisecond
+}
+
+// jitter returns the random jitter that is less than max, there is no jitter if max is not positive
+func jitter(max int64) int64 {
+	if max <= 0 {
+		return 0
+	}
+	return rand.Int63n(max)
 }
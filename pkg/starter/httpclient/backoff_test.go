@@ -48,3 +48,8 @@ func TestConstantBackoffWhenRetryIsZero(t *testing.T) {
 
 	assert.True(t, 0*time.Millisecond <= constantBackoff.Next(0))
 }
+
+func TestBackoffWithoutJitter(t *testing.T) {
+	assert.Equal(t, 100*time.Millisecond, NewConstantBackoff(100*time.Millisecond, 0).Next(1))
+	assert.Equal(t, 4*time.Millisecond, NewExponentialBackoff(2*time.Millisecond, 10*time.Millisecond, 2.0, 0).Next(1))
+}
@@ -2,13 +2,16 @@ package httpclient
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gojektech/valkyrie"
 	"github.com/pkg/errors"
+	"hidevops.io/hiboot/pkg/log"
 )
 
 // client is the http client implementation
@@ -18,6 +21,8 @@ type client struct {
 	timeout    time.Duration
 	retryCount int
 	retrier    Retriable
+	observers  []Observer
+	shared     *Observers
 }
 
 const (
@@ -28,6 +33,33 @@ const (
 var _ Client = (*client)(nil)
 var defaultClient *client
 
+// Observers is the observers shared by the clients that are created with WithObservers,
+// e.g. the clients of the application observed by the metrics and tracing starters
+type Observers struct {
+	lock      sync.RWMutex
+	observers []Observer
+}
+
+// Add adds the observer that observes the requests of the clients sharing the observers
+func (o *Observers) Add(observer Observer) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.observers = append(o.observers, observer)
+}
+
+func (o *Observers) list() []Observer {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.observers
+}
+
+func (c *client) allObservers() (observers []Observer) {
+	if c.shared != nil {
+		observers = append(observers, c.shared.list()...)
+	}
+	return append(observers, c.observers...)
+}
+
 func init() {
 	defaultClient = NewClient()
 }
@@ -168,10 +200,29 @@ func (c *client) Delete(url string, headers http.Header, callbacks ...func(req *
 	return c.Do(request)
 }
 
+// WithContext returns the callback that sets ctx to the request, e.g. httpclient.Get(url, nil, httpclient.WithContext(ctx)),
+// the request id carried by ctx is passed to the server by the header X-Request-ID
+func WithContext(ctx context.Context) func(req *http.Request) {
+	return func(req *http.Request) {
+		*req = *req.WithContext(ctx)
+	}
+}
+
 // Do makes an HTTP request with the native `http.Do` interface
 func (c *client) Do(request *http.Request) (*http.Response, error) {
 	request.Close = true
 
+	if id := log.RequestID(request.Context()); id != "" && request.Header.Get(log.RequestIDHeader) == "" {
+		request.Header.Set(log.RequestIDHeader, id)
+	}
+
+	observers := c.allObservers()
+	for _, observer := range observers {
+		if preparer, ok := observer.(Preparer); ok {
+			preparer.Prepare(request)
+		}
+	}
+
 	var bodyReader *bytes.Reader
 
 	if request.Body != nil {
@@ -186,10 +237,17 @@ func (c *client) Do(request *http.Request) (*http.Response, error) {
 	multiErr := &valkyrie.MultiError{}
 	var response *http.Response
 
+	start := time.Now()
+
 	for i := 0; i <= c.retryCount; i++ {
 		if response != nil {
 			response.Body.Close()
 		}
+		if i > 0 {
+			for _, observer := range observers {
+				observer.Retried(request, i)
+			}
+		}
 
 		var err error
 		response, err = c.client.Do(request)
@@ -217,5 +275,9 @@ func (c *client) Do(request *http.Request) (*http.Response, error) {
 		break
 	}
 
-	return response, multiErr.HasError()
+	err := multiErr.HasError()
+	for _, observer := range observers {
+		observer.Done(request, response, err, time.Since(start))
+	}
+	return response, err
 }
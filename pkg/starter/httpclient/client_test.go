@@ -2,9 +2,11 @@ package httpclient
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/mock"
+	"hidevops.io/hiboot/pkg/log"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -259,6 +261,70 @@ func TestHTTPClientGetRetriesOnFailure(t *testing.T) {
 	assert.Equal(t, noOfCalls, count)
 }
 
+type fakeObserver struct {
+	retries int
+	done    int
+	status  int
+	err     error
+}
+
+func (o *fakeObserver) Retried(request *http.Request, attempt int) {
+	o.retries = attempt
+}
+
+func (o *fakeObserver) Done(request *http.Request, response *http.Response, err error, elapsed time.Duration) {
+	o.done++
+	o.err = err
+	if response != nil {
+		o.status = response.StatusCode
+	}
+}
+
+func TestHTTPClientObserver(t *testing.T) {
+	noOfRetries := 2
+	observer := new(fakeObserver)
+	observers := new(Observers)
+
+	client := NewClient(
+		WithHTTPTimeout(100*time.Millisecond),
+		WithRetryCount(noOfRetries),
+		WithRetrier(NewRetrier(NewConstantBackoff(time.Millisecond, time.Millisecond))),
+		WithObserver(observer),
+		WithObservers(observers),
+	)
+	// the shared observer is added after the client is created
+	sharedObserver := new(fakeObserver)
+	observers.Add(sharedObserver)
+
+	dummyHandler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	server := httptest.NewServer(http.HandlerFunc(dummyHandler))
+	defer server.Close()
+
+	_, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+
+	t.Run("should observe retries and the final response", func(t *testing.T) {
+		assert.Equal(t, noOfRetries, observer.retries)
+		assert.Equal(t, 1, observer.done)
+		assert.Equal(t, http.StatusInternalServerError, observer.status)
+	})
+
+	t.Run("should observe by shared observers", func(t *testing.T) {
+		assert.Equal(t, noOfRetries, sharedObserver.retries)
+		assert.Equal(t, 1, sharedObserver.done)
+	})
+
+	t.Run("should observe failure", func(t *testing.T) {
+		server.Close()
+		_, err := client.Get(server.URL, http.Header{})
+		assert.NotEqual(t, nil, err)
+		assert.NotEqual(t, nil, observer.err)
+		assert.Equal(t, 2, observer.done)
+	})
+}
+
 func BenchmarkHTTPClientGetRetriesOnFailure(b *testing.B) {
 	noOfRetries := 3
 	backoffInterval := 1 * time.Millisecond
@@ -283,6 +349,36 @@ func BenchmarkHTTPClientGetRetriesOnFailure(b *testing.B) {
 	}
 }
 
+func TestHTTPClientRequestID(t *testing.T) {
+	var requestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = r.Header.Get(log.RequestIDHeader)
+	}))
+	defer server.Close()
+
+	ctx := log.NewContext(context.Background(), log.Fields{log.RequestIDKey: "1234"})
+
+	t.Run("should pass the request id carried by context", func(t *testing.T) {
+		_, err := NewClient().Get(server.URL, nil, WithContext(ctx))
+		require.NoError(t, err)
+		assert.Equal(t, "1234", requestID)
+	})
+
+	t.Run("should not override the request id header", func(t *testing.T) {
+		headers := http.Header{}
+		headers.Set(log.RequestIDHeader, "5678")
+		_, err := NewClient().Get(server.URL, headers, WithContext(ctx))
+		require.NoError(t, err)
+		assert.Equal(t, "5678", requestID)
+	})
+
+	t.Run("should not pass request id without context", func(t *testing.T) {
+		_, err := NewClient().Get(server.URL, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "", requestID)
+	})
+}
+
 func TestHTTPClientPostRetriesOnFailure(t *testing.T) {
 	count := 0
 	noOfRetries := 3
@@ -0,0 +1,394 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/reflector"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidDeclarativeClient the declarative client should be the pointer of struct
+var ErrInvalidDeclarativeClient = errors.New("[httpclient] invalid declarative client")
+
+var (
+	contextType  = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	responseType = reflect.TypeOf((*http.Response)(nil))
+	bytesType    = reflect.TypeOf([]byte(nil))
+
+	pathVariable = regexp.MustCompile(`{([^{}]+)}`)
+)
+
+// StatusError is the error of the declarative client that the response status is not 2xx
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+// Error returns the status and the body of the response
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("[httpclient] %v: %s", e.Status, e.Body)
+}
+
+// ClientFactory implements the declarative clients, the components annotated with at.HttpClient are implemented
+// once the application is initialized
+type ClientFactory interface {
+	// Implement implements the function fields of the declarative client, each field is declared as
+	// func(ctx context.Context[, request]) ([response, ]error), and tagged by the method and the path, e.g.
+	//
+	//	type getUserRequest struct {
+	//		ID     string   `path:"id"`
+	//		Fields []string `query:"fields"`
+	//		Token  string   `header:"Authorization"`
+	//	}
+	//
+	//	GetUser func(ctx context.Context, req *getUserRequest) (*User, error) `method:"GET" path:"/users/{id}"`
+	//
+	// the fields of the request are bound to the path variables, the query and the headers by the tags,
+	// the field tagged by body is encoded as the json body, and the request is the json body itself if none of
+	// its fields is tagged, the response is decoded from json, or it is the raw body if it is string or []byte,
+	// or *http.Response that should be closed by the caller, the response status other than 2xx is StatusError.
+	// The requests of GET, HEAD, OPTIONS and TRACE are retried, the other methods are retried only if the field
+	// is tagged by retry:"true", e.g.
+	//
+	//	UpdateUser func(ctx context.Context, req *updateUserRequest) (*User, error) `method:"PUT" path:"/users/{id}" retry:"true"`
+	Implement(declarativeClient interface{}) error
+}
+
+type clientFactory struct {
+	instantiateFactory factory.InstantiateFactory
+	properties         *properties
+	observers          *Observers
+}
+
+func newClientFactory(instantiateFactory factory.InstantiateFactory, properties *properties, observers *Observers) ClientFactory {
+	return &clientFactory{
+		instantiateFactory: instantiateFactory,
+		properties:         properties,
+		observers:          observers,
+	}
+}
+
+// replace resolves the property placeholders of the source
+func (f *clientFactory) replace(source string) interface{} {
+	if f.instantiateFactory == nil {
+		return source
+	}
+	return f.instantiateFactory.Replace(source)
+}
+
+// tag returns the tag of the embedded at.HttpClient, the property placeholders are resolved
+func (f *clientFactory) tag(declarativeClient interface{}, name string) string {
+	value, _ := reflector.FindEmbeddedFieldTag(declarativeClient, "HttpClient", name)
+	return fmt.Sprintf("%v", f.replace(value))
+}
+
+// Implement implements the function fields of the declarative client by the properties httpclient.clients.<name>
+func (f *clientFactory) Implement(declarativeClient interface{}) (err error) {
+	v := reflect.ValueOf(declarativeClient)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return ErrInvalidDeclarativeClient
+	}
+	name := f.tag(declarativeClient, "value")
+	if name == "" {
+		name = reflector.GetLowerCamelName(declarativeClient)
+	}
+	var from interface{}
+	if f.properties != nil {
+		from = f.properties.Clients[name]
+	}
+	prop, err := decodeClientProperties(f.replace, from)
+	if err != nil {
+		return
+	}
+	if prop.URL == "" {
+		prop.URL = f.tag(declarativeClient, "url")
+	}
+	opts, err := prop.options()
+	if err != nil {
+		return
+	}
+	cli := NewClient(append(opts, WithObservers(f.observers))...)
+	// the requests that are not retryable are sent once
+	once := NewClient(append(opts, WithRetryCount(0), WithObservers(f.observers))...)
+
+	elem := v.Elem()
+	typ := elem.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		path, ok := field.Tag.Lookup("path")
+		if !ok || field.Type.Kind() != reflect.Func {
+			continue
+		}
+		if !elem.Field(i).CanSet() {
+			return fmt.Errorf("[httpclient] %v.%v should be exported", typ.Name(), field.Name)
+		}
+		method := strings.ToUpper(fmt.Sprintf("%v", f.replace(field.Tag.Get("method"))))
+		var m *declarativeMethod
+		m, err = newDeclarativeMethod(field.Type, method, fmt.Sprintf("%v", f.replace(path)))
+		if err != nil {
+			return fmt.Errorf("[httpclient] %v.%v: %v", typ.Name(), field.Name, err)
+		}
+		var retry bool
+		retry, err = retryable(m.method, field.Tag)
+		if err != nil {
+			return fmt.Errorf("[httpclient] %v.%v: %v", typ.Name(), field.Name, err)
+		}
+		c := once
+		if retry {
+			c = cli
+		}
+		elem.Field(i).Set(reflect.MakeFunc(field.Type, m.caller(c, prop.URL)))
+	}
+	log.Infof("Implemented http client %v of %v", name, prop.URL)
+	return
+}
+
+// retryable returns true if the request of the method is retried by httpclient.clients.<name>.retryCount,
+// only the safe methods GET, HEAD, OPTIONS and TRACE are retried unless the field is tagged by retry:"true",
+// and the field tagged by retry:"false" is never retried
+func retryable(method string, tag reflect.StructTag) (bool, error) {
+	if value, ok := tag.Lookup("retry"); ok {
+		retry, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("invalid retry tag %v", value)
+		}
+		return retry, nil
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true, nil
+	}
+	return false, nil
+}
+
+// declarativeBinding binds the field of the request to the path variable, the query, the header or the body
+type declarativeBinding struct {
+	kind  string
+	name  string
+	index []int
+}
+
+// declarativeMethod is the request of the function field of the declarative client
+type declarativeMethod struct {
+	method   string
+	path     string
+	request  reflect.Type
+	bindings []declarativeBinding
+	response reflect.Type
+}
+
+func newDeclarativeMethod(fn reflect.Type, method, path string) (m *declarativeMethod, err error) {
+	if fn.IsVariadic() || fn.NumIn() < 1 || fn.NumIn() > 2 || fn.In(0) != contextType ||
+		fn.NumOut() < 1 || fn.NumOut() > 2 || fn.Out(fn.NumOut()-1) != errorType {
+		return nil, fmt.Errorf("it should be func(context.Context[, request]) ([response, ]error)")
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+	m = &declarativeMethod{method: method, path: path}
+	if fn.NumOut() == 2 {
+		m.response = fn.Out(0)
+	}
+	if fn.NumIn() == 2 {
+		m.request = fn.In(1)
+		if typ := reflector.IndirectType(m.request); typ.Kind() == reflect.Struct {
+			for i := 0; i < typ.NumField(); i++ {
+				field := typ.Field(i)
+				for _, kind := range []string{"path", "query", "header", "body"} {
+					if name, ok := field.Tag.Lookup(kind); ok {
+						m.bindings = append(m.bindings, declarativeBinding{kind: kind, name: name, index: field.Index})
+					}
+				}
+			}
+		}
+	}
+	for _, match := range pathVariable.FindAllStringSubmatch(path, -1) {
+		if !m.bound("path", match[1]) {
+			return nil, fmt.Errorf("path variable {%v} is not bound", match[1])
+		}
+	}
+	return
+}
+
+// bound returns true if the name is bound by the kind
+func (m *declarativeMethod) bound(kind, name string) bool {
+	for _, b := range m.bindings {
+		if b.kind == kind && b.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// caller returns the implementation of the function field that sends the request to the base url
+func (m *declarativeMethod) caller(cli Client, baseURL string) func(args []reflect.Value) []reflect.Value {
+	return func(args []reflect.Value) []reflect.Value {
+		ctx, _ := args[0].Interface().(context.Context)
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var request reflect.Value
+		if len(args) == 2 {
+			request = args[1]
+		}
+		req, err := m.newRequest(ctx, baseURL, request)
+		if err != nil {
+			return m.results(reflect.Value{}, err)
+		}
+		return m.results(m.decode(cli.Do(req)))
+	}
+}
+
+// newRequest returns the http request that the request is bound to
+func (m *declarativeMethod) newRequest(ctx context.Context, baseURL string, request reflect.Value) (req *http.Request, err error) {
+	path := m.path
+	query := url.Values{}
+	header := http.Header{}
+	var body interface{}
+	if request.IsValid() {
+		if len(m.bindings) == 0 {
+			if !isNil(request) {
+				body = request.Interface()
+			}
+		} else {
+			v := reflect.Indirect(request)
+			if !v.IsValid() {
+				v = reflect.New(reflector.IndirectType(m.request)).Elem()
+			}
+			for _, b := range m.bindings {
+				field := v.FieldByIndex(b.index)
+				switch b.kind {
+				case "path":
+					var value string
+					if field = reflect.Indirect(field); field.IsValid() {
+						value = fmt.Sprint(field.Interface())
+					}
+					path = strings.Replace(path, "{"+b.name+"}", url.PathEscape(value), -1)
+				case "query":
+					eachValue(field, func(value string) { query.Add(b.name, value) })
+				case "header":
+					eachValue(field, func(value string) { header.Add(b.name, value) })
+				case "body":
+					if !isNil(field) {
+						body = field.Interface()
+					}
+				}
+			}
+		}
+	}
+
+	target := strings.TrimSuffix(baseURL, "/") + path
+	if len(query) != 0 {
+		separator := "?"
+		if strings.Contains(target, "?") {
+			separator = "&"
+		}
+		target += separator + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		var data []byte
+		data, err = json.Marshal(body)
+		if err != nil {
+			return
+		}
+		reader = bytes.NewReader(data)
+		header.Set("Content-Type", "application/json")
+	}
+	if m.response != nil && header.Get("Accept") == "" {
+		header.Set("Accept", "application/json")
+	}
+	req, err = http.NewRequest(m.method, target, reader)
+	if err != nil {
+		return
+	}
+	req.Header = header
+	return req.WithContext(ctx), nil
+}
+
+// decode decodes the response to the result of the function field
+func (m *declarativeMethod) decode(resp *http.Response, err error) (result reflect.Value, retErr error) {
+	if m.response == responseType {
+		return reflect.ValueOf(resp), err
+	}
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return result, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return result, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return result, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}
+	}
+	if m.response == nil || len(data) == 0 {
+		return
+	}
+	switch {
+	case m.response.Kind() == reflect.String:
+		return reflect.ValueOf(string(data)).Convert(m.response), nil
+	case m.response == bytesType:
+		return reflect.ValueOf(data), nil
+	}
+	ptr := reflect.New(m.response)
+	if err = json.Unmarshal(data, ptr.Interface()); err != nil {
+		return result, err
+	}
+	return ptr.Elem(), nil
+}
+
+// results returns the results of the function field
+func (m *declarativeMethod) results(result reflect.Value, err error) []reflect.Value {
+	errValue := reflect.Zero(errorType)
+	if err != nil {
+		errValue = reflect.ValueOf(&err).Elem()
+	}
+	if m.response == nil {
+		return []reflect.Value{errValue}
+	}
+	if !result.IsValid() {
+		result = reflect.Zero(m.response)
+	}
+	return []reflect.Value{result, errValue}
+}
+
+// eachValue calls cb with each value of the slice, or the value that is not zero
+func eachValue(v reflect.Value, cb func(value string)) {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			cb(fmt.Sprint(reflect.Indirect(v.Index(i)).Interface()))
+		}
+	default:
+		if !isNil(v) && !reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface()) {
+			cb(fmt.Sprint(reflect.Indirect(v).Interface()))
+		}
+	}
+}
+
+// isNil returns true if the value is nil
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
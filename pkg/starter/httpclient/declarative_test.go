@@ -0,0 +1,278 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/at"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type user struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type getUserRequest struct {
+	ID     string   `path:"id"`
+	Fields []string `query:"fields"`
+	Page   int      `query:"page"`
+	Token  string   `header:"Authorization"`
+}
+
+type updateUserRequest struct {
+	ID   string `path:"id"`
+	User *user  `body:""`
+}
+
+type userClient struct {
+	at.HttpClient `value:"user-service"`
+
+	GetUser    func(ctx context.Context, req *getUserRequest) (*user, error)   `method:"GET" path:"/users/{id}"`
+	CreateUser func(ctx context.Context, u *user) (*user, error)               `method:"POST" path:"/users"`
+	UpdateUser func(ctx context.Context, req *updateUserRequest) (user, error) `method:"put" path:"/users/{id}"`
+	DeleteUser func(ctx context.Context, req *getUserRequest) error            `method:"DELETE" path:"/users/{id}"`
+	Ping       func(ctx context.Context) (string, error)                       `path:"/ping"`
+	Raw        func(ctx context.Context) (*http.Response, error)               `path:"/ping"`
+	Flaky      func(ctx context.Context) ([]byte, error)                       `path:"/flaky"`
+	PostFlaky  func(ctx context.Context) ([]byte, error)                       `method:"POST" path:"/flaky"`
+	RetryFlaky func(ctx context.Context) ([]byte, error)                       `method:"POST" path:"/flaky" retry:"true"`
+	Missing    func(ctx context.Context, req *getUserRequest) (*user, error)   `path:"/missing/{id}"`
+	Ignored    func() string
+}
+
+func newUserServer(t *testing.T, flaky *int32) *httptest.Server {
+	r := mux.NewRouter()
+	r.HandleFunc("/api/users/{id}", func(w http.ResponseWriter, req *http.Request) {
+		id := mux.Vars(req)["id"]
+		switch req.Method {
+		case http.MethodGet:
+			assert.Equal(t, "application/json", req.Header.Get("Accept"))
+			json.NewEncoder(w).Encode(&user{
+				ID:   id,
+				Name: req.URL.Query().Get("page") + ":" + req.URL.RawQuery + ":" + req.Header.Get("Authorization"),
+			})
+		case http.MethodPut:
+			u := new(user)
+			json.NewDecoder(req.Body).Decode(u)
+			u.ID = id
+			json.NewEncoder(w).Encode(u)
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	r.HandleFunc("/api/users", func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		u := new(user)
+		json.NewDecoder(req.Body).Decode(u)
+		u.ID = "1"
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(u)
+	})
+	r.HandleFunc("/api/ping", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("pong"))
+	})
+	r.HandleFunc("/api/flaky", func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(flaky, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	r.HandleFunc("/api/missing/{id}", func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "user not found", http.StatusNotFound)
+	})
+	return httptest.NewServer(r)
+}
+
+func TestClientFactory(t *testing.T) {
+	var flaky int32
+	server := newUserServer(t, &flaky)
+	defer server.Close()
+
+	f := newClientFactory(nil, &properties{Clients: map[string]interface{}{
+		"user-service": map[string]interface{}{
+			"url":        server.URL + "/api/",
+			"retryCount": 2,
+			"backoff":    map[string]interface{}{"interval": "1ms", "jitter": "0s"},
+		},
+	}}, nil)
+	cli := new(userClient)
+	assert.Equal(t, nil, f.Implement(cli))
+	ctx := context.Background()
+
+	t.Run("should bind the path variables, the query and the headers", func(t *testing.T) {
+		u, err := cli.GetUser(ctx, &getUserRequest{ID: "a b", Fields: []string{"id", "name"}, Page: 2, Token: "Bearer t"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "a b", u.ID)
+		assert.Equal(t, "2:fields=id&fields=name&page=2:Bearer t", u.Name)
+	})
+
+	t.Run("should omit the zero query values", func(t *testing.T) {
+		u, err := cli.GetUser(ctx, &getUserRequest{ID: "1"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "::", u.Name)
+	})
+
+	t.Run("should encode the request as the body", func(t *testing.T) {
+		u, err := cli.CreateUser(ctx, &user{Name: "Steve"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, &user{ID: "1", Name: "Steve"}, u)
+	})
+
+	t.Run("should encode the field tagged by body", func(t *testing.T) {
+		u, err := cli.UpdateUser(ctx, &updateUserRequest{ID: "2", User: &user{Name: "Bill"}})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, user{ID: "2", Name: "Bill"}, u)
+	})
+
+	t.Run("should return the error only", func(t *testing.T) {
+		assert.Equal(t, nil, cli.DeleteUser(ctx, &getUserRequest{ID: "1"}))
+	})
+
+	t.Run("should return the raw body", func(t *testing.T) {
+		pong, err := cli.Ping(nil)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "pong", pong)
+	})
+
+	t.Run("should return the response", func(t *testing.T) {
+		resp, err := cli.Raw(ctx)
+		assert.Equal(t, nil, err)
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, "pong", string(body))
+	})
+
+	t.Run("should retry by the properties", func(t *testing.T) {
+		body, err := cli.Flaky(ctx)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "ok", string(body))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&flaky))
+	})
+
+	t.Run("should not retry the request that is not safe", func(t *testing.T) {
+		atomic.StoreInt32(&flaky, 0)
+		_, err := cli.PostFlaky(ctx)
+		statusErr, ok := err.(*StatusError)
+		assert.Equal(t, true, ok)
+		if ok {
+			assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&flaky))
+	})
+
+	t.Run("should retry the request that is tagged by retry", func(t *testing.T) {
+		atomic.StoreInt32(&flaky, 0)
+		body, err := cli.RetryFlaky(ctx)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "ok", string(body))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&flaky))
+	})
+
+	t.Run("should return the status error", func(t *testing.T) {
+		u, err := cli.Missing(ctx, &getUserRequest{ID: "1"})
+		assert.Equal(t, (*user)(nil), u)
+		statusErr, ok := err.(*StatusError)
+		assert.Equal(t, true, ok)
+		if ok {
+			assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+			assert.Equal(t, "user not found\n", string(statusErr.Body))
+		}
+	})
+
+	t.Run("should not implement the function field without path", func(t *testing.T) {
+		assert.Nil(t, cli.Ignored)
+	})
+}
+
+func TestClientFactoryErrors(t *testing.T) {
+	f := newClientFactory(nil, nil, nil)
+
+	t.Run("should report the invalid declarative client", func(t *testing.T) {
+		assert.Equal(t, ErrInvalidDeclarativeClient, f.Implement(userClient{}))
+	})
+
+	t.Run("should report the invalid signature", func(t *testing.T) {
+		err := f.Implement(&struct {
+			Get func(id string) (*user, error) `path:"/users"`
+		}{})
+		assert.Contains(t, err.Error(), "it should be func(context.Context[, request]) ([response, ]error)")
+	})
+
+	t.Run("should report the path variable that is not bound", func(t *testing.T) {
+		err := f.Implement(&struct {
+			Get func(ctx context.Context, u *user) (*user, error) `path:"/users/{id}"`
+		}{})
+		assert.Contains(t, err.Error(), "path variable {id} is not bound")
+	})
+
+	t.Run("should report the unexported function field", func(t *testing.T) {
+		err := f.Implement(&struct {
+			get func(ctx context.Context) error `path:"/users"`
+		}{})
+		assert.Contains(t, err.Error(), "should be exported")
+	})
+
+	t.Run("should report the invalid retry tag", func(t *testing.T) {
+		err := f.Implement(&struct {
+			Create func(ctx context.Context) error `method:"POST" path:"/users" retry:"yes"`
+		}{})
+		assert.Contains(t, err.Error(), "invalid retry tag yes")
+	})
+
+	t.Run("should report the invalid backoff policy", func(t *testing.T) {
+		f := newClientFactory(nil, &properties{Clients: map[string]interface{}{
+			"user-service": map[string]interface{}{"retryCount": 1, "backoff": map[string]interface{}{"policy": "linear"}},
+		}}, nil)
+		assert.Contains(t, f.Implement(new(userClient)).Error(), "invalid backoff policy: linear")
+	})
+
+	t.Run("should report the connection error", func(t *testing.T) {
+		cli := &struct {
+			at.HttpClient `url:"http://127.0.0.1:0"`
+			Get           func(ctx context.Context) (*user, error) `path:"/users"`
+		}{}
+		assert.Equal(t, nil, f.Implement(cli))
+		_, err := cli.Get(context.Background())
+		assert.NotEqual(t, nil, err)
+	})
+}
+
+func TestDecodeClientProperties(t *testing.T) {
+	t.Run("should apply the default values", func(t *testing.T) {
+		prop, err := decodeClientProperties(func(source string) interface{} { return source }, nil)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 30*time.Second, prop.Timeout)
+		assert.Equal(t, 0, prop.RetryCount)
+		assert.Equal(t, ConstantBackoff, prop.Backoff.Policy)
+		assert.Equal(t, 100*time.Millisecond, prop.Backoff.Interval)
+		assert.Equal(t, 10*time.Second, prop.Backoff.MaxInterval)
+		assert.Equal(t, 2.0, prop.Backoff.Factor)
+		assert.Equal(t, 10*time.Millisecond, prop.Backoff.Jitter)
+	})
+
+	t.Run("should bind the keys in relaxed way", func(t *testing.T) {
+		prop, err := decodeClientProperties(func(source string) interface{} { return source }, map[string]interface{}{
+			"url":         "http://localhost:8080",
+			"retry-count": 3,
+			"backoff":     map[string]interface{}{"policy": "exponential", "max_interval": "1s"},
+		})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "http://localhost:8080", prop.URL)
+		assert.Equal(t, 3, prop.RetryCount)
+		assert.Equal(t, ExponentialBackoff, prop.Backoff.Policy)
+		assert.Equal(t, time.Second, prop.Backoff.MaxInterval)
+
+		opts, err := prop.options()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 3, len(opts))
+	})
+}
@@ -3,6 +3,7 @@ package httpclient
 import (
 	"io"
 	"net/http"
+	"time"
 )
 
 // Doer interface has the method required to use a type as custom http client.
@@ -20,3 +21,16 @@ type Client interface {
 	Delete(url string, headers http.Header, callbacks ...func(req *http.Request)) (*http.Response, error)
 	Do(req *http.Request) (*http.Response, error)
 }
+
+// Observer observes the requests of http client, e.g. for metrics
+type Observer interface {
+	// Retried is called before each retry of the request, attempt starts from 1
+	Retried(request *http.Request, attempt int)
+	// Done is called when the request is done with the final response or error
+	Done(request *http.Request, response *http.Response, err error, elapsed time.Duration)
+}
+
+// Preparer is the optional interface of Observer that prepares the request once before it is sent, e.g. to set the tracing headers
+type Preparer interface {
+	Prepare(request *http.Request)
+}
@@ -34,3 +34,17 @@ func WithHTTPClient(doer Doer) Option {
 		c.client = doer
 	}
 }
+
+// WithObserver adds the observer that observes the requests of the client
+func WithObserver(observer Observer) Option {
+	return func(c *client) {
+		c.observers = append(c.observers, observer)
+	}
+}
+
+// WithObservers sets the observers that are shared with the other clients, the observers added later are applied too
+func WithObservers(observers *Observers) Option {
+	return func(c *client) {
+		c.shared = observers
+	}
+}
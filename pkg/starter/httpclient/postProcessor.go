@@ -0,0 +1,38 @@
+package httpclient
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/log"
+)
+
+// postProcessor implements the declarative clients, as they are instantiated once the application is initialized
+type postProcessor struct {
+	instantiateFactory factory.InstantiateFactory
+	clientFactory      ClientFactory
+}
+
+func init() {
+	app.RegisterPostProcessor(newPostProcessor)
+}
+
+func newPostProcessor(instantiateFactory factory.InstantiateFactory, clientFactory ClientFactory) *postProcessor {
+	return &postProcessor{
+		instantiateFactory: instantiateFactory,
+		clientFactory:      clientFactory,
+	}
+}
+
+// AfterInitialization implements the components that are annotated with at.HttpClient
+func (p *postProcessor) AfterInitialization() {
+	if p.instantiateFactory == nil || p.clientFactory == nil {
+		return
+	}
+	for _, md := range p.instantiateFactory.GetInstances(new(at.HttpClient)) {
+		metaData := factory.CastMetaData(md)
+		if err := p.clientFactory.Implement(metaData.Instance); err != nil {
+			log.Errorf("failed to implement http client %v: %v", metaData.Name, err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package httpclient
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/at"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type pingClient struct {
+	at.HttpClient `value:"ping-service"`
+
+	Ping func(ctx context.Context) (string, error) `path:"/ping"`
+}
+
+func TestPostProcessor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("pong"))
+	}))
+	defer server.Close()
+
+	app.Register(new(pingClient))
+	testApp := web.NewTestApp().
+		SetProperty("httpclient.clients.ping-service.url", server.URL).
+		Run(t)
+
+	t.Run("should implement the declarative client", func(t *testing.T) {
+		cli, ok := testApp.(app.ApplicationContext).GetInstance(pingClient{}).(*pingClient)
+		assert.Equal(t, true, ok)
+		if ok {
+			pong, err := cli.Ping(context.Background())
+			assert.Equal(t, nil, err)
+			assert.Equal(t, "pong", pong)
+		}
+	})
+}
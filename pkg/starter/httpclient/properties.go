@@ -0,0 +1,83 @@
+package httpclient
+
+import (
+	"fmt"
+	"hidevops.io/hiboot/pkg/utils/mapstruct"
+	"time"
+)
+
+const (
+	// ConstantBackoff is the backoff policy that waits the same interval between the retries
+	ConstantBackoff = "constant"
+
+	// ExponentialBackoff is the backoff policy that waits exponentially longer between the retries
+	ExponentialBackoff = "exponential"
+)
+
+// properties is the properties of httpclient
+type properties struct {
+	// the properties of the declarative clients by name, e.g. httpclient.clients.user-service.url
+	Clients map[string]interface{} `json:"clients"`
+}
+
+// BackoffProperties is the backoff between the retries of the declarative client
+type BackoffProperties struct {
+	// constant or exponential
+	Policy string `json:"policy" default:"constant"`
+	// the interval of the constant backoff, or the initial interval of the exponential backoff
+	Interval time.Duration `json:"interval" default:"100ms"`
+	// the maximum interval of the exponential backoff
+	MaxInterval time.Duration `json:"max_interval" mapstructure:"maxInterval" default:"10s"`
+	// the exponent factor of the exponential backoff
+	Factor float64 `json:"factor" default:"2"`
+	// the maximum random jitter that is added to the interval
+	Jitter time.Duration `json:"jitter" default:"10ms"`
+}
+
+// ClientProperties is the properties of the declarative client httpclient.clients.<name>, e.g.
+//
+//	httpclient:
+//	  clients:
+//	    user-service:
+//	      url: http://user-service:8080/api
+//	      retryCount: 3
+//	      backoff:
+//	        policy: exponential
+type ClientProperties struct {
+	// the base url of the requests, the url tag of at.HttpClient is used if it is empty
+	URL string `json:"url"`
+	// the timeout of each request
+	Timeout time.Duration `json:"timeout" default:"30s"`
+	// the number of the retries of the request that fails or is responded 5xx, the requests of the methods
+	// other than GET, HEAD, OPTIONS and TRACE are retried only if the field is tagged by retry:"true"
+	RetryCount int `json:"retry_count" mapstructure:"retryCount"`
+	// the backoff between the retries
+	Backoff BackoffProperties `json:"backoff"`
+}
+
+// decodeClientProperties decodes the properties of the client, the default values are applied,
+// and the keys are bound in relaxed way
+func decodeClientProperties(replace func(source string) interface{}, from interface{}) (prop *ClientProperties, err error) {
+	prop = new(ClientProperties)
+	err = mapstruct.DecodeWithDefaults(prop, from, replace)
+	return
+}
+
+// options returns the options of the client with the timeout, the retries and the backoff of the properties
+func (p *ClientProperties) options() (opts []Option, err error) {
+	opts = append(opts, WithHTTPTimeout(p.Timeout))
+	if p.RetryCount <= 0 {
+		return
+	}
+	var backoff Backoff
+	switch p.Backoff.Policy {
+	case ConstantBackoff:
+		backoff = NewConstantBackoff(p.Backoff.Interval, p.Backoff.Jitter)
+	case ExponentialBackoff:
+		backoff = NewExponentialBackoff(p.Backoff.Interval, p.Backoff.MaxInterval, p.Backoff.Factor, p.Backoff.Jitter)
+	default:
+		return nil, fmt.Errorf("[httpclient] invalid backoff policy: %v", p.Backoff.Policy)
+	}
+	opts = append(opts, WithRetryCount(p.RetryCount), WithRetrier(NewRetrier(backoff)))
+	return
+}
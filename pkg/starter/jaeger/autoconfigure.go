@@ -13,6 +13,9 @@
 // limitations under the License.
 
 // Package jaeger provides the hiboot starter for injectable jaeger dependency
+//
+// Deprecated: use the tracing starter instead, it traces every route and propagates the spans
+// through httpclient and grpc automatically.
 package jaeger
 
 import (
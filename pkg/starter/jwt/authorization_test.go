@@ -0,0 +1,131 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+	"net/http"
+	"testing"
+)
+
+type orderController struct {
+	at.JwtRestController
+	at.RequiresRoles `value:"admin,ops" logical:"or"`
+}
+
+func newOrderController() *orderController {
+	return &orderController{}
+}
+
+func (c *orderController) Get() string {
+	return "orders"
+}
+
+func (c *orderController) DeleteById(id int) string {
+	return "deleted"
+}
+
+type reportController struct {
+	at.JwtRestController
+	at.RequiresPermissions `value:"report:read"`
+}
+
+func newReportController() *reportController {
+	return &reportController{}
+}
+
+func (c *reportController) Get() string {
+	return "reports"
+}
+
+func TestAuthorization(t *testing.T) {
+	testApp := web.NewTestApp(newOrderController, newReportController).
+		SetProperty("web.authorization.rules", []map[string]interface{}{
+			{"method": "DELETE", "path": "/order/**", "roles": "admin"},
+		}).
+		Run(t)
+	appContext := testApp.(app.ApplicationContext)
+
+	admin, err := jwt.GenerateTestToken(appContext, "admin", []string{"admin"}, "report:*")
+	assert.Equal(t, nil, err)
+	ops, err := jwt.GenerateTestToken(appContext, "ops", []string{"ops"}, "report:write")
+	assert.Equal(t, nil, err)
+	guest, err := jwt.GenerateTestToken(appContext, "guest", nil)
+	assert.Equal(t, nil, err)
+
+	t.Run("should authorize any of the roles", func(t *testing.T) {
+		testApp.Get("/order").WithHeader("Authorization", admin).
+			Expect().Status(http.StatusOK).Body().Equal("orders")
+		testApp.Get("/order").WithHeader("Authorization", ops).
+			Expect().Status(http.StatusOK).Body().Equal("orders")
+	})
+
+	t.Run("should respond 403 in the shape of model.BaseResponse", func(t *testing.T) {
+		testApp.Get("/order").WithHeader("Authorization", guest).
+			Expect().Status(http.StatusForbidden).
+			JSON().Object().ValueEqual("code", http.StatusForbidden)
+	})
+
+	t.Run("should authorize by the rule of the route", func(t *testing.T) {
+		testApp.Delete("/order/id/{id}", 1).WithHeader("Authorization", ops).
+			Expect().Status(http.StatusForbidden)
+		testApp.Delete("/order/id/{id}", 1).WithHeader("Authorization", admin).
+			Expect().Status(http.StatusOK).Body().Equal("deleted")
+	})
+
+	t.Run("should authorize the implied permission", func(t *testing.T) {
+		testApp.Get("/report").WithHeader("Authorization", admin).
+			Expect().Status(http.StatusOK).Body().Equal("reports")
+		testApp.Get("/report").WithHeader("Authorization", ops).
+			Expect().Status(http.StatusForbidden)
+	})
+
+	t.Run("should respond 401 without token", func(t *testing.T) {
+		testApp.Get("/report").Expect().Status(http.StatusUnauthorized)
+	})
+}
+
+func TestAuthorizationOfHandledRoute(t *testing.T) {
+	testApp := web.NewTestApp(newOrderController).
+		SetProperty("web.authorization.rules", []map[string]interface{}{
+			{"method": "GET", "path": "/handled/**", "roles": "admin"},
+		})
+	appContext := testApp.(app.ApplicationContext)
+	appContext.Handle(http.MethodGet, "/handled/{name}", func(ctx context.Context) {
+		ctx.WriteString("hello " + ctx.Params().Get("name"))
+	})
+	testApp.Run(t)
+
+	admin, err := jwt.GenerateTestToken(appContext, "admin", []string{"admin"})
+	assert.Equal(t, nil, err)
+	ops, err := jwt.GenerateTestToken(appContext, "ops", []string{"ops"})
+	assert.Equal(t, nil, err)
+
+	t.Run("should respond 401 without token as the handled route is served through jwt", func(t *testing.T) {
+		testApp.Get("/handled/foo").Expect().Status(http.StatusUnauthorized)
+	})
+
+	t.Run("should authorize the handled route by the rule of the route", func(t *testing.T) {
+		testApp.Get("/handled/foo").WithHeader("Authorization", ops).
+			Expect().Status(http.StatusForbidden)
+		testApp.Get("/handled/foo").WithHeader("Authorization", admin).
+			Expect().Status(http.StatusOK).Body().Equal("hello foo")
+	})
+}
@@ -16,11 +16,12 @@
 package jwt
 
 import (
-	"github.com/dgrijalva/jwt-go"
 	mw "github.com/iris-contrib/middleware/jwt"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/app/web/context"
 	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/log"
 )
 
 const (
@@ -34,37 +35,54 @@ type configuration struct {
 	Properties Properties `mapstructure:"jwt"`
 	middleware *Middleware
 	token      Token
+
+	instantiateFactory factory.InstantiateFactory
 }
 
 func init() {
 	app.Register(newConfiguration)
 }
 
-func newConfiguration() *configuration {
-	return &configuration{}
+func newConfiguration(instantiateFactory factory.InstantiateFactory) *configuration {
+	return &configuration{instantiateFactory: instantiateFactory}
 }
 
 func (c *configuration) Middleware(jwtToken Token) *Middleware {
-	return NewJwtMiddleware(mw.Config{
-		ValidationKeyGetter: func(token *jwt.Token) (interface{}, error) {
-			//log.Debug(token)
-			return jwtToken.VerifyKey(), nil
-		},
-		// When set, the middleware verifies that tokens are signed with the specific signing algorithm
-		// If the signing method is not constant the ValidationKeyGetter callback can be used to implement additional checks
-		// Important to avoid security issues described here: https://auth0.com/blog/2015/03/31/critical-vulnerabilities-in-json-web-token-libraries/
-		SigningMethod: jwt.SigningMethodRS256,
+	m := NewJwtMiddleware(mw.Config{
+		// the key is selected by the kid header, and the token must be signed with the algorithm of the key,
+		// which avoids the security issues described here: https://auth0.com/blog/2015/03/31/critical-vulnerabilities-in-json-web-token-libraries/
+		ValidationKeyGetter: jwtToken.ValidationKey,
 	})
+	m.token = jwtToken
+	return m
 }
 
 // JwtToken
 func (c *configuration) Token() Token {
-	t := new(jwtToken)
-	t.Initialize(&c.Properties)
+	t := &jwtToken{store: &componentTokenStore{instantiateFactory: c.instantiateFactory}}
+	err := t.Initialize(&c.Properties)
+	if err != nil {
+		log.Warnf("failed to initialize jwt token: %v", err)
+	}
 	return t
 }
 
+// Jwks is the properties of JSON Web Key Set
+func (c *configuration) Jwks() *Jwks {
+	return &c.Properties.Jwks
+}
+
 // TokenProperties is the token properties parser
 func (c *configuration) TokenProperties(context context.Context) *TokenProperties {
 	return newTokenProperties(context)
 }
+
+// AuditPrincipal is the principal resolver of the audited request
+func (c *configuration) AuditPrincipal() *AuditPrincipal {
+	return &AuditPrincipal{claim: c.Properties.PrincipalClaim}
+}
+
+// PrincipalProvider provides the principal of the request for at.RequiresRoles and at.RequiresPermissions
+func (c *configuration) PrincipalProvider() *PrincipalProvider {
+	return &PrincipalProvider{properties: &c.Properties}
+}
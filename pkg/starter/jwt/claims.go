@@ -0,0 +1,174 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+	"time"
+)
+
+const (
+	claimExpiresAt = "exp"
+	claimIssuedAt  = "iat"
+	claimNotBefore = "nbf"
+	claimIssuer    = "iss"
+	claimAudience  = "aud"
+	claimID        = "jti"
+	// claimTokenType distinguishes the refresh token from the access token
+	claimTokenType = "typ"
+
+	tokenTypeRefresh = "refresh"
+)
+
+var (
+	// ErrInvalidClaims the claims of the token is not jwt.MapClaims
+	ErrInvalidClaims = errors.New("[jwt] invalid claims")
+	// ErrTokenExpired the token is expired
+	ErrTokenExpired = errors.New("[jwt] token is expired")
+	// ErrTokenNotValidYet the token is used before nbf
+	ErrTokenNotValidYet = errors.New("[jwt] token is not valid yet")
+	// ErrTokenUsedBeforeIssued the token is used before iat
+	ErrTokenUsedBeforeIssued = errors.New("[jwt] token is used before issued")
+	// ErrInvalidIssuer the token is not issued by jwt.issuer
+	ErrInvalidIssuer = errors.New("[jwt] invalid issuer")
+	// ErrInvalidAudience the token is not for any of jwt.audience
+	ErrInvalidAudience = errors.New("[jwt] invalid audience")
+	// ErrTokenRevoked the token is revoked
+	ErrTokenRevoked = errors.New("[jwt] token is revoked")
+	// ErrInvalidTokenType the refresh token is used as the access token, or vice versa
+	ErrInvalidTokenType = errors.New("[jwt] invalid token type")
+	// ErrMissingTokenID the token without jti can not be revoked
+	ErrMissingTokenID = errors.New("[jwt] token id is missing")
+)
+
+// registeredClaims are the claims that are not copied from the refresh token to the refreshed tokens
+var registeredClaims = []string{claimExpiresAt, claimIssuedAt, claimNotBefore, claimIssuer, claimAudience, claimID, claimTokenType}
+
+// ParseClaims parses the claims of the token into the user-defined struct by the json tags, e.g.
+//
+//	type UserClaims struct {
+//		jwt.StandardClaims
+//		Username string   `json:"username"`
+//		Roles    []string `json:"roles"`
+//	}
+func ParseClaims(token *jwt.Token, claims interface{}) (err error) {
+	if token == nil || token.Claims == nil {
+		return ErrInvalidClaims
+	}
+	var b []byte
+	b, err = json.Marshal(token.Claims)
+	if err == nil {
+		err = json.Unmarshal(b, claims)
+	}
+	return
+}
+
+// numericClaim returns the NumericDate claim in unix seconds
+func numericClaim(claims jwt.MapClaims, name string) (retVal int64, ok bool) {
+	switch v := claims[name].(type) {
+	case float64:
+		retVal, ok = int64(v), true
+	case int64:
+		retVal, ok = v, true
+	case json.Number:
+		n, err := v.Int64()
+		retVal, ok = n, err == nil
+	}
+	return
+}
+
+// expiresAt returns the time of exp claim, zero if it is absent
+func expiresAt(claims jwt.MapClaims) (retVal time.Time) {
+	if exp, ok := numericClaim(claims, claimExpiresAt); ok {
+		retVal = time.Unix(exp, 0)
+	}
+	return
+}
+
+// hasAudience returns true if the aud claim, a string or an array of strings, contains any of the audience
+func hasAudience(aud interface{}, audience []string) bool {
+	var values []string
+	switch v := aud.(type) {
+	case string:
+		values = []string{v}
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				values = append(values, s)
+			}
+		}
+	case []string:
+		values = v
+	}
+	for _, value := range values {
+		for _, expected := range audience {
+			if value == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validate validates the token type, the standard claims with leeway and the revocation
+func (t *jwtToken) validate(token *jwt.Token, tokenType string) (err error) {
+	var claims jwt.MapClaims
+	claims, err = t.validateClaims(token, tokenType)
+	if err != nil {
+		return
+	}
+
+	if jti, _ := claims[claimID].(string); jti != "" && t.store != nil {
+		var revoked bool
+		revoked, err = t.store.IsRevoked(jti)
+		if err == nil && revoked {
+			err = ErrTokenRevoked
+		}
+	}
+	return
+}
+
+// validateClaims validates the token type and the registered claims except the revocation
+func (t *jwtToken) validateClaims(token *jwt.Token, tokenType string) (claims jwt.MapClaims, err error) {
+	var ok bool
+	claims, ok = token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidClaims
+	}
+	if typ, _ := claims[claimTokenType].(string); typ != tokenType {
+		return nil, ErrInvalidTokenType
+	}
+
+	now := time.Now().Unix()
+	leeway := int64(t.leeway / time.Second)
+	if exp, ok := numericClaim(claims, claimExpiresAt); ok && now > exp+leeway {
+		return nil, ErrTokenExpired
+	}
+	if nbf, ok := numericClaim(claims, claimNotBefore); ok && now+leeway < nbf {
+		return nil, ErrTokenNotValidYet
+	}
+	if iat, ok := numericClaim(claims, claimIssuedAt); ok && now+leeway < iat {
+		return nil, ErrTokenUsedBeforeIssued
+	}
+	if t.issuer != "" && claims[claimIssuer] != t.issuer {
+		return nil, ErrInvalidIssuer
+	}
+	if len(t.audience) != 0 && !hasAudience(claims[claimAudience], t.audience) {
+		return nil, ErrInvalidAudience
+	}
+	return
+}
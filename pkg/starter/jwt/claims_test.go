@@ -0,0 +1,264 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt_test
+
+import (
+	"fmt"
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newToken(p jwt.Properties) jwt.Token {
+	p.PrivateKeyPath = "config/ssl/app.rsa"
+	p.PublicKeyPath = "config/ssl/app.rsa.pub"
+	if p.Refresh.Expiration == 0 {
+		p.Refresh = jwt.Refresh{Expiration: time.Hour, AccessTokenExpiration: time.Minute}
+	}
+	return jwt.NewJwtToken(&p)
+}
+
+func sign(t *testing.T, claims jwtgo.MapClaims) string {
+	token := newToken(jwt.Properties{})
+	tokenString, err := jwtgo.NewWithClaims(jwtgo.SigningMethodRS256, claims).
+		SignedString(token.KeySet().SigningKey().SignKey)
+	assert.Equal(t, nil, err)
+	return tokenString
+}
+
+func TestClaimsValidation(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name       string
+		properties jwt.Properties
+		claims     jwtgo.MapClaims
+		err        error
+	}{
+		{"should accept the valid token", jwt.Properties{Issuer: "hiboot", Audience: []string{"api"}},
+			jwtgo.MapClaims{"iss": "hiboot", "aud": "api", "exp": now.Add(time.Minute).Unix()}, nil},
+		{"should accept the audience array", jwt.Properties{Audience: []string{"api"}},
+			jwtgo.MapClaims{"aud": []string{"web", "api"}}, nil},
+		{"should reject the expired token", jwt.Properties{},
+			jwtgo.MapClaims{"exp": now.Add(-time.Minute).Unix()}, jwt.ErrTokenExpired},
+		{"should accept the expired token within leeway", jwt.Properties{Leeway: 2 * time.Minute},
+			jwtgo.MapClaims{"exp": now.Add(-time.Minute).Unix()}, nil},
+		{"should reject the token before nbf", jwt.Properties{},
+			jwtgo.MapClaims{"nbf": now.Add(time.Minute).Unix()}, jwt.ErrTokenNotValidYet},
+		{"should accept the token before nbf within leeway", jwt.Properties{Leeway: 2 * time.Minute},
+			jwtgo.MapClaims{"nbf": now.Add(time.Minute).Unix()}, nil},
+		{"should reject the token used before issued", jwt.Properties{},
+			jwtgo.MapClaims{"iat": now.Add(time.Minute).Unix()}, jwt.ErrTokenUsedBeforeIssued},
+		{"should reject the token of other issuer", jwt.Properties{Issuer: "hiboot"},
+			jwtgo.MapClaims{"iss": "other"}, jwt.ErrInvalidIssuer},
+		{"should reject the token of other audience", jwt.Properties{Audience: []string{"api"}},
+			jwtgo.MapClaims{"aud": "web"}, jwt.ErrInvalidAudience},
+		{"should reject the refresh token as access token", jwt.Properties{},
+			jwtgo.MapClaims{"typ": "refresh"}, jwt.ErrInvalidTokenType},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			token := newToken(testCase.properties)
+			_, err := token.Parse(sign(t, testCase.claims))
+			assert.Equal(t, testCase.err, err)
+		})
+	}
+
+	t.Run("should generate token with issuer, audience and jti", func(t *testing.T) {
+		token := newToken(jwt.Properties{Issuer: "hiboot", Audience: []string{"api"}})
+		tokenString, err := token.Generate(jwt.Map{"username": "johndoe"}, 1, time.Minute)
+		assert.Equal(t, nil, err)
+		parsed, err := token.Parse(tokenString)
+		assert.Equal(t, nil, err)
+		claims := parsed.Claims.(jwtgo.MapClaims)
+		assert.Equal(t, "hiboot", claims["iss"])
+		assert.NotEqual(t, "", claims["jti"])
+	})
+}
+
+func TestRefreshToken(t *testing.T) {
+	token := newToken(jwt.Properties{})
+	refreshToken, err := token.GenerateRefreshToken(jwt.Map{"username": "johndoe"})
+	assert.Equal(t, nil, err)
+
+	t.Run("should not use refresh token as access token", func(t *testing.T) {
+		_, err := token.Parse(refreshToken)
+		assert.Equal(t, jwt.ErrInvalidTokenType, err)
+	})
+
+	t.Run("should not refresh with access token", func(t *testing.T) {
+		accessToken, _ := token.Generate(jwt.Map{"username": "johndoe"}, 1, time.Minute)
+		_, _, err := token.Refresh(accessToken)
+		assert.Equal(t, jwt.ErrInvalidTokenType, err)
+	})
+
+	var newRefreshToken string
+	t.Run("should refresh and rotate the refresh token", func(t *testing.T) {
+		var accessToken string
+		accessToken, newRefreshToken, err = token.Refresh(refreshToken)
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, refreshToken, newRefreshToken)
+
+		parsed, err := token.Parse(accessToken)
+		assert.Equal(t, nil, err)
+		claims := parsed.Claims.(jwtgo.MapClaims)
+		assert.Equal(t, "johndoe", claims["username"])
+		assert.Equal(t, nil, claims["typ"])
+	})
+
+	t.Run("should not reuse the rotated refresh token", func(t *testing.T) {
+		_, _, err := token.Refresh(refreshToken)
+		assert.Equal(t, jwt.ErrTokenRevoked, err)
+
+		_, _, err = token.Refresh(newRefreshToken)
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("should refresh only once concurrently", func(t *testing.T) {
+		refreshToken, err := token.GenerateRefreshToken(jwt.Map{"username": "johndoe"})
+		assert.Equal(t, nil, err)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < cap(errs); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, err := token.Refresh(refreshToken)
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		refreshed := 0
+		for err := range errs {
+			if err == nil {
+				refreshed++
+			} else {
+				assert.Equal(t, jwt.ErrTokenRevoked, err)
+			}
+		}
+		assert.Equal(t, 1, refreshed)
+	})
+}
+
+type userClaims struct {
+	jwtgo.StandardClaims
+	Username string   `json:"username"`
+	Roles    []string `json:"roles"`
+}
+
+type tokenStore struct {
+	at.JwtTokenStore
+	sync.Mutex
+	revoked map[string]time.Time
+}
+
+func newTokenStore() *tokenStore {
+	return &tokenStore{revoked: make(map[string]time.Time)}
+}
+
+func (s *tokenStore) Revoke(jti string, expiresAt time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+func (s *tokenStore) IsRevoked(jti string) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	_, ok := s.revoked[jti]
+	return ok, nil
+}
+
+func (s *tokenStore) TryRevoke(jti string, expiresAt time.Time) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.revoked[jti]; ok {
+		return false, nil
+	}
+	s.revoked[jti] = expiresAt
+	return true, nil
+}
+
+type userController struct {
+	at.JwtRestController
+	token jwt.Token
+}
+
+func newUserController(token jwt.Token) *userController {
+	return &userController{token: token}
+}
+
+func (c *userController) Get(properties *jwt.TokenProperties) (response map[string]interface{}, err error) {
+	var claims userClaims
+	err = properties.Claims(&claims)
+	response = map[string]interface{}{
+		"username": claims.Username,
+		"roles":    claims.Roles,
+		"jti":      claims.Id,
+	}
+	return
+}
+
+func (c *userController) PostLogout(ctx context.Context) string {
+	token := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if err := c.token.Revoke(token); err != nil {
+		return err.Error()
+	}
+	return "logged out"
+}
+
+func TestRevocation(t *testing.T) {
+	testApp := web.NewTestApp(newUserController).Run(t)
+	appContext := testApp.(app.ApplicationContext)
+	token := appContext.GetInstance("jwt.token").(jwt.Token)
+	store := appContext.GetInstance(tokenStore{}).(*tokenStore)
+
+	tokenString, err := token.Generate(jwt.Map{"username": "johndoe", "roles": []string{"admin"}}, 1, time.Minute)
+	assert.Equal(t, nil, err)
+	bearer := fmt.Sprintf("Bearer %v", tokenString)
+
+	t.Run("should parse typed claims", func(t *testing.T) {
+		testApp.Get("/user").
+			WithHeader("Authorization", bearer).
+			Expect().Status(http.StatusOK).
+			JSON().Object().
+			ValueEqual("username", "johndoe").
+			ValueEqual("roles", []string{"admin"})
+	})
+
+	t.Run("should reject the revoked token", func(t *testing.T) {
+		testApp.Post("/user/logout").
+			WithHeader("Authorization", bearer).
+			Expect().Status(http.StatusOK).Body().Equal("logged out")
+		assert.Equal(t, 1, len(store.revoked))
+
+		testApp.Get("/user").
+			WithHeader("Authorization", bearer).
+			Expect().Status(http.StatusUnauthorized)
+	})
+}
@@ -17,8 +17,10 @@ package jwt
 import (
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
+	"hidevops.io/hiboot/pkg/app/web"
 	"hidevops.io/hiboot/pkg/app/web/context"
 	"hidevops.io/hiboot/pkg/at"
+	"strings"
 )
 
 // Controller is the base controller for jwt.RestController
@@ -26,6 +28,56 @@ type Controller struct {
 	at.JwtRestController
 }
 
+// AuditPrincipal resolves the principal of the audited request from the claim of jwt token
+type AuditPrincipal struct {
+	at.AuditPrincipal
+	claim string
+}
+
+// Principal returns the claim of jwt token, e.g. username
+func (p *AuditPrincipal) Principal(context context.Context) string {
+	return newTokenProperties(context).Get(p.claim)
+}
+
+// PrincipalProvider provides the principal of the request for authorization from the claims of jwt token
+type PrincipalProvider struct {
+	at.PrincipalProvider
+	properties *Properties
+}
+
+// GetPrincipal returns the principal with the roles and permissions of the claims, nil if the token is absent
+func (p *PrincipalProvider) GetPrincipal(context context.Context) *web.Principal {
+	claims, ok := newTokenProperties(context).GetAll()
+	if !ok {
+		return nil
+	}
+	principal := &web.Principal{
+		Roles:       StringsClaim(claims[p.properties.RolesClaim]),
+		Permissions: StringsClaim(claims[p.properties.PermissionsClaim]),
+	}
+	if name, ok := claims[p.properties.PrincipalClaim]; ok {
+		principal.Name = fmt.Sprintf("%v", name)
+	}
+	return principal
+}
+
+// StringsClaim converts the claim of an array, or a comma or space separated string to strings, e.g. roles or scope
+func StringsClaim(claim interface{}) (retVal []string) {
+	switch v := claim.(type) {
+	case []interface{}:
+		for _, item := range v {
+			retVal = append(retVal, fmt.Sprintf("%v", item))
+		}
+	case []string:
+		retVal = v
+	case string:
+		retVal = strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+	}
+	return
+}
+
 // TokenProperties is the struct for parse jwt token properties
 type TokenProperties struct {
 	at.ContextAware
@@ -73,6 +125,18 @@ func (p *TokenProperties) GetAll() (propMap map[string]interface{}, ok bool) {
 	return
 }
 
+// Claims parses the claims of the verified token into the user-defined struct, see ParseClaims
+func (p *TokenProperties) Claims(claims interface{}) error {
+	var token *jwt.Token
+	if p.context != nil {
+		token, _ = p.context.Values().Get("jwt").(*jwt.Token)
+	}
+	if token == nil || !token.Valid {
+		return ErrInvalidClaims
+	}
+	return ParseClaims(token, claims)
+}
+
 // Items is an util that parsing JWT token and return all properties in map from jwt.MapClaims
 func (p *TokenProperties) Items() (propMap map[string]string, ok bool) {
 	propMap = make(map[string]string)
@@ -48,6 +48,7 @@ var tokenExpiredSecond = int64(1)
 
 func init() {
 	log.SetLevel(log.DebugLevel)
+	app.Register(newAuditSink, newTokenStore)
 }
 
 func newFooController(token jwt.Token) *fooController {
@@ -203,3 +204,92 @@ func TestAppWithoutJwtController(t *testing.T) {
 			Expect().Status(http.StatusOK)
 	})
 }
+
+// statusController is served by the management server
+type statusController struct {
+	at.ManagementController
+}
+
+func newStatusController() *statusController {
+	return &statusController{}
+}
+
+func (c *statusController) Get() string {
+	return "UP"
+}
+
+func TestManagementServerWithJwt(t *testing.T) {
+	testApp := web.NewTestApp(newFooController, newStatusController).
+		SetProperty("management.server.port", "8081").
+		SetProperty("management.security.jwt", true).
+		Run(t)
+	fooCtrl := testApp.(app.ApplicationContext).GetInstance(fooController{}).(*fooController)
+
+	testApp.Post("/foo/login").
+		WithJSON(&userRequest{Username: "johndoe", Password: "iHop91#15"}).
+		Expect().Status(http.StatusOK)
+
+	t.Run("should protect management server by jwt", func(t *testing.T) {
+		testApp.Management(http.MethodGet, "/status").
+			Expect().Status(http.StatusUnauthorized)
+	})
+
+	t.Run("should access management server with jwt token", func(t *testing.T) {
+		testApp.Management(http.MethodGet, "/status").
+			WithHeader("Authorization", fmt.Sprintf("Bearer %v", fooCtrl.tokenStr)).
+			Expect().Status(http.StatusOK).Body().Equal("UP")
+	})
+
+	t.Run("should not serve management controller on main server", func(t *testing.T) {
+		testApp.Get("/status").Expect().Status(http.StatusNotFound)
+	})
+}
+
+type auditSink struct {
+	at.AuditSink
+	records []*web.AuditRecord
+}
+
+func newAuditSink() *auditSink {
+	return &auditSink{}
+}
+
+func (s *auditSink) Write(record *web.AuditRecord) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+type auditController struct {
+	at.JwtRestController
+	at.Audit
+}
+
+func newAuditController() *auditController {
+	return &auditController{}
+}
+
+func (c *auditController) Get() string {
+	return "audited"
+}
+
+func TestAuditPrincipal(t *testing.T) {
+	testApp := web.NewTestApp(newFooController, newAuditController).
+		SetProperty("web.audit.sinks", "").
+		Run(t)
+	appContext := testApp.(app.ApplicationContext)
+	fooCtrl := appContext.GetInstance(fooController{}).(*fooController)
+	sink := appContext.GetInstance(auditSink{}).(*auditSink)
+
+	testApp.Post("/foo/login").
+		WithJSON(&userRequest{Username: "johndoe", Password: "iHop91#15"}).
+		Expect().Status(http.StatusOK)
+
+	t.Run("should audit the principal from jwt token", func(t *testing.T) {
+		testApp.Get("/audit").
+			WithHeader("Authorization", fmt.Sprintf("Bearer %v", fooCtrl.tokenStr)).
+			Expect().Status(http.StatusOK)
+		assert.Equal(t, 1, len(sink.records))
+		assert.Equal(t, "johndoe", sink.records[0].Principal)
+		assert.Equal(t, "/audit", sink.records[0].Route)
+	})
+}
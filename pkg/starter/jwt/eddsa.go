@@ -0,0 +1,114 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/crypto/ed25519"
+)
+
+var (
+	// ErrEdDSAVerification the signature of EdDSA is invalid
+	ErrEdDSAVerification = errors.New("[jwt] EdDSA verification error")
+
+	// SigningMethodEdDSA is the signing method of EdDSA with Ed25519 keys
+	SigningMethodEdDSA = new(signingMethodEdDSA)
+
+	// oidEd25519 is the algorithm identifier of the Ed25519 keys in PKCS8 and PKIX, see RFC 8410
+	oidEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+// pkcs8 is the PKCS8 private key, see RFC 5208
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// publicKeyInfo is the PKIX public key, see RFC 5280
+type publicKeyInfo struct {
+	Algo      pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// signingMethodEdDSA implements the EdDSA family, which is not shipped by github.com/dgrijalva/jwt-go
+type signingMethodEdDSA struct{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+// Alg returns the name of the algorithm
+func (m *signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Verify verifies the signature with the ed25519.PublicKey
+func (m *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) (err error) {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	var sig []byte
+	sig, err = jwt.DecodeSegment(signature)
+	if err == nil && !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		err = ErrEdDSAVerification
+	}
+	return
+}
+
+// Sign signs the string with the ed25519.PrivateKey
+func (m *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
+
+// parseEd25519PrivateKey parses the DER encoded PKCS8 Ed25519 private key, which crypto/x509 does not support before go 1.13
+func parseEd25519PrivateKey(der []byte) (key ed25519.PrivateKey, err error) {
+	var p pkcs8
+	if _, err = asn1.Unmarshal(der, &p); err != nil {
+		return
+	}
+	var seed []byte
+	if !p.Algo.Algorithm.Equal(oidEd25519) {
+		err = ErrInvalidKey
+	} else if _, err = asn1.Unmarshal(p.PrivateKey, &seed); err == nil && len(seed) != ed25519.SeedSize {
+		err = ErrInvalidKey
+	}
+	if err == nil {
+		key = ed25519.NewKeyFromSeed(seed)
+	}
+	return
+}
+
+// parseEd25519PublicKey parses the DER encoded PKIX Ed25519 public key, which crypto/x509 does not support before go 1.13
+func parseEd25519PublicKey(der []byte) (key ed25519.PublicKey, err error) {
+	var p publicKeyInfo
+	if _, err = asn1.Unmarshal(der, &p); err != nil {
+		return
+	}
+	if !p.Algo.Algorithm.Equal(oidEd25519) || len(p.PublicKey.Bytes) != ed25519.PublicKeySize {
+		return nil, ErrInvalidKey
+	}
+	return ed25519.PublicKey(p.PublicKey.Bytes), nil
+}
@@ -0,0 +1,295 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/crypto/ed25519"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	keyTypeRSA   = "RSA"
+	keyTypeEC    = "EC"
+	keyTypeOKP   = "OKP"
+	curveEd25519 = "Ed25519"
+
+	// jwksMinRefresh is the min interval that the remote JWKS is refreshed on an unknown kid
+	jwksMinRefresh = 10 * time.Second
+	jwksTimeout    = 10 * time.Second
+)
+
+// JWK is the JSON Web Key of the public key, see RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is the JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK creates the JWK of the public key, the secret of HMAC is never published
+func NewJWK(key *Key) (jwk JWK, ok bool) {
+	jwk = JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+	ok = true
+	switch k := key.VerifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = keyTypeRSA
+		jwk.N = encode(k.N.Bytes())
+		jwk.E = encode(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = keyTypeEC
+		jwk.Crv = k.Curve.Params().Name
+		jwk.X = encode(pad(k.X.Bytes(), size))
+		jwk.Y = encode(pad(k.Y.Bytes(), size))
+	case ed25519.PublicKey:
+		jwk.Kty = keyTypeOKP
+		jwk.Crv = curveEd25519
+		jwk.X = encode(k)
+	default:
+		ok = false
+	}
+	return
+}
+
+// Key converts the JWK to the verification key, the algorithm is derived from the key type if alg is absent
+func (j JWK) Key() (key *Key, err error) {
+	key = &Key{ID: j.Kid}
+	alg := j.Alg
+	switch j.Kty {
+	case keyTypeRSA:
+		var n, e []byte
+		if n, err = decode(j.N); err == nil {
+			e, err = decode(j.E)
+		}
+		if err == nil {
+			key.VerifyKey = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		}
+		if alg == "" {
+			alg = "RS256"
+		}
+	case keyTypeEC:
+		var x, y []byte
+		if x, err = decode(j.X); err == nil {
+			y, err = decode(j.Y)
+		}
+		c := map[string]int{"P-256": 256, "P-384": 384, "P-521": 521}[j.Crv]
+		if c == 0 {
+			err = fmt.Errorf("%v: unsupported curve %v", ErrInvalidKey, j.Crv)
+		}
+		if err == nil {
+			key.VerifyKey = &ecdsa.PublicKey{Curve: curve(c), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+		if alg == "" {
+			alg = map[int]string{256: "ES256", 384: "ES384", 521: "ES512"}[c]
+		}
+	case keyTypeOKP:
+		var x []byte
+		x, err = decode(j.X)
+		if err == nil && (j.Crv != curveEd25519 || len(x) != ed25519.PublicKeySize) {
+			err = fmt.Errorf("%v: unsupported curve %v", ErrInvalidKey, j.Crv)
+		}
+		key.VerifyKey = ed25519.PublicKey(x)
+		if alg == "" {
+			alg = SigningMethodEdDSA.Alg()
+		}
+	default:
+		err = fmt.Errorf("%v: unsupported key type %v", ErrInvalidKey, j.Kty)
+	}
+	if err == nil {
+		key.Method, err = signingMethod(alg)
+	}
+	if err == nil && !compatible(key.Method, key.VerifyKey) {
+		err = fmt.Errorf("%v: %v key is expected by %v", ErrInvalidKey, alg, j.Kid)
+	}
+	if err != nil {
+		key = nil
+	}
+	return
+}
+
+// Thumbprint returns the JWK thumbprint, see RFC 7638
+func (j JWK) Thumbprint() string {
+	var members string
+	switch j.Kty {
+	case keyTypeRSA:
+		members = fmt.Sprintf(`{"e":%q,"kty":%q,"n":%q}`, j.E, j.Kty, j.N)
+	case keyTypeEC:
+		members = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q,"y":%q}`, j.Crv, j.Kty, j.X, j.Y)
+	default:
+		members = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q}`, j.Crv, j.Kty, j.X)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return encode(sum[:])
+}
+
+// NewJWKS creates the JWKS of the public keys
+func NewJWKS(keys []*Key) (jwks *JWKS) {
+	jwks = &JWKS{Keys: []JWK{}}
+	for _, key := range keys {
+		if jwk, ok := NewJWK(key); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	return
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+func pad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	p := make([]byte, size)
+	copy(p[size-len(b):], b)
+	return p
+}
+
+// remoteKeySet is the cached JWKS that is fetched from the url
+type remoteKeySet struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mutex     sync.Mutex
+	keys      map[string]*Key
+	fetchedAt time.Time
+}
+
+func newRemoteKeySet(url string, ttl time.Duration) *remoteKeySet {
+	return &remoteKeySet{
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: jwksTimeout},
+	}
+}
+
+// Lookup returns the key of kid, the JWKS is fetched again if the cache is expired,
+// or if the kid is not found, e.g. the remote key is rotated
+func (r *remoteKeySet) Lookup(kid string) (key *Key, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	age := time.Since(r.fetchedAt)
+	key = r.keys[kid]
+	if r.keys == nil || age >= r.ttl || (key == nil && age >= jwksMinRefresh) {
+		err = r.fetch()
+		if err != nil && r.keys != nil {
+			// keep using the cached keys if the remote JWKS is unavailable
+			log.Warnf("failed to refresh JWKS from %v: %v", r.url, err)
+			err = nil
+		}
+		key = r.keys[kid]
+	}
+	if err == nil && key == nil {
+		err = fmt.Errorf("%v: %v", ErrKeyNotFound, kid)
+	}
+	return
+}
+
+func (r *remoteKeySet) fetch() (err error) {
+	var resp *http.Response
+	resp, err = r.client.Get(r.url)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %v", resp.StatusCode)
+	}
+
+	var jwks JWKS
+	err = json.NewDecoder(resp.Body).Decode(&jwks)
+	if err != nil {
+		return
+	}
+	keys := make(map[string]*Key, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		key, e := jwk.Key()
+		if e != nil {
+			log.Warnf("ignore the key %v of JWKS %v: %v", jwk.Kid, r.url, e)
+			continue
+		}
+		keys[key.ID] = key
+	}
+	r.keys, r.fetchedAt = keys, time.Now()
+	return
+}
+
+// jwksController publishes the public keys on jwt.jwks.path if jwt.jwks.enabled is true
+type jwksController struct {
+	at.RestController
+	at.ContextPath `value:"${jwt.jwks.path:/.well-known/jwks.json}"`
+
+	token Token
+	jwks  *Jwks
+}
+
+func init() {
+	app.Register(newJwksController)
+}
+
+func newJwksController(token Token, jwks *Jwks) *jwksController {
+	return &jwksController{
+		token: token,
+		jwks:  jwks,
+	}
+}
+
+// Before responds 404 if the JWKS is not published
+func (c *jwksController) Before(ctx context.Context) {
+	if c.token == nil || c.jwks == nil || !c.jwks.Enabled {
+		ctx.ResponseError("JWKS is not published", http.StatusNotFound)
+		return
+	}
+	ctx.Next()
+}
+
+// GET /.well-known/jwks.json returns the JWKS of the public keys
+func (c *jwksController) Get() map[string]interface{} {
+	return map[string]interface{}{
+		"keys": NewJWKS(c.token.KeySet().Keys()).Keys,
+	}
+}
@@ -28,6 +28,9 @@ import (
 // Middleware derived from github.com/iris-contrib/middleware/jwt/Middleware
 type Middleware struct {
 	mwjwt.Middleware
+
+	// validates the standard claims and the revocation if it is set
+	token Token
 }
 
 // Serve the middleware's action
@@ -81,8 +84,12 @@ func (m *Middleware) CheckJWT(ctx ictx.Context) error {
 		return fmt.Errorf(errorMsg)
 	}
 
-	// Now parse the token
-	parsedToken, err := jwt.Parse(token, m.Config.ValidationKeyGetter)
+	// Now parse the token, the claims are validated by the token if it is set
+	parser := &jwt.Parser{SkipClaimsValidation: m.token != nil}
+	parsedToken, err := parser.Parse(token, m.Config.ValidationKeyGetter)
+	if err == nil && m.token != nil {
+		err = m.token.Validate(parsedToken)
+	}
 	// Check if there was an error in parsing...
 	if err != nil || !parsedToken.Valid {
 		log.Debugf("Error parsing token: %v", err)
@@ -126,5 +133,5 @@ func NewJwtMiddleware(cfg ...mwjwt.Config) *Middleware {
 		c.Extractor = mwjwt.FromAuthHeader
 	}
 
-	return &Middleware{mwjwt.Middleware{Config: c}}
+	return &Middleware{Middleware: mwjwt.Middleware{Config: c}}
 }
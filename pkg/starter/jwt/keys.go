@@ -0,0 +1,365 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/crypto/ed25519"
+	"hidevops.io/hiboot/pkg/utils/io"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+const rsaKeyBits = 2048
+
+var (
+	// ErrUnsupportedAlgorithm the algorithm is not supported
+	ErrUnsupportedAlgorithm = errors.New("[jwt] unsupported algorithm")
+	// ErrKeyNotFound the verification key of the kid is not found
+	ErrKeyNotFound = errors.New("[jwt] verification key is not found")
+	// ErrUnexpectedAlgorithm the token is not signed with the algorithm of the verification key
+	ErrUnexpectedAlgorithm = errors.New("[jwt] unexpected signing algorithm")
+	// ErrInvalidKey the key can not be parsed
+	ErrInvalidKey = errors.New("[jwt] invalid key")
+)
+
+// Key is the signing and verification key of jwt
+type Key struct {
+	// the key id, it is set to the kid header of the token
+	ID string
+	// the signing method of the key
+	Method jwt.SigningMethod
+	// the private key or the secret that signs the token, nil for the verification only key
+	SignKey interface{}
+	// the public key or the secret that verifies the token
+	VerifyKey interface{}
+	// the time after that the key does not verify any more, zero for never
+	ExpiresAt time.Time
+}
+
+// Expired returns true if the key does not verify any more
+func (k *Key) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// KeySet is the signing key and the verification keys by key id
+type KeySet struct {
+	mutex      sync.RWMutex
+	signingKey *Key
+	keys       []*Key
+}
+
+// NewKeySet is the constructor of KeySet, the signing key verifies as well
+func NewKeySet(signingKey *Key, keys ...*Key) *KeySet {
+	s := new(KeySet)
+	if signingKey != nil {
+		s.signingKey = signingKey
+		s.keys = append(s.keys, signingKey)
+	}
+	s.Add(keys...)
+	return s
+}
+
+// SigningKey returns the current signing key
+func (s *KeySet) SigningKey() *Key {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.signingKey
+}
+
+// Add adds the verification keys, the key of the same id is replaced
+func (s *KeySet) Add(keys ...*Key) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, key := range keys {
+		replaced := false
+		for i, k := range s.keys {
+			if k.ID == key.ID {
+				s.keys[i], replaced = key, true
+				break
+			}
+		}
+		if !replaced {
+			s.keys = append(s.keys, key)
+		}
+	}
+}
+
+// Lookup returns the key of kid, the signing key is returned if kid is empty, nil if it is not found or expired
+func (s *KeySet) Lookup(kid string) *Key {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if kid == "" {
+		return s.signingKey
+	}
+	now := time.Now()
+	for _, k := range s.keys {
+		if k.ID == kid && !k.Expired(now) {
+			return k
+		}
+	}
+	return nil
+}
+
+// Keys returns the keys that are not expired
+func (s *KeySet) Keys() (keys []*Key) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	now := time.Now()
+	for _, k := range s.keys {
+		if !k.Expired(now) {
+			keys = append(keys, k)
+		}
+	}
+	return
+}
+
+// Rotate replaces the signing key, the previous one keeps verifying until the grace period is over,
+// the expired keys are removed
+func (s *KeySet) Rotate(key *Key, grace time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	if s.signingKey != nil {
+		s.signingKey.ExpiresAt = now.Add(grace)
+	}
+	keys := []*Key{key}
+	for _, k := range s.keys {
+		if !k.Expired(now) && k.ID != key.ID {
+			keys = append(keys, k)
+		}
+	}
+	s.signingKey, s.keys = key, keys
+}
+
+// signingMethod returns the signing method of the algorithm, RS256 is the default one
+func signingMethod(alg string) (method jwt.SigningMethod, err error) {
+	if alg == "" {
+		alg = jwt.SigningMethodRS256.Alg()
+	}
+	method = jwt.GetSigningMethod(alg)
+	if method == nil || method == jwt.SigningMethodNone {
+		err = fmt.Errorf("%v: %v", ErrUnsupportedAlgorithm, alg)
+	}
+	return
+}
+
+// loadSigningKey loads the signing key from the PEM file of private key, or the secret of HMAC,
+// the public key is derived from the private key if its file does not exist
+func loadSigningKey(p *Properties) (key *Key, err error) {
+	key = new(Key)
+	key.Method, err = signingMethod(p.Algorithm)
+	if err != nil {
+		return
+	}
+
+	if _, ok := key.Method.(*jwt.SigningMethodHMAC); ok {
+		if p.Secret == "" {
+			return nil, fmt.Errorf("secret of %v is not set", key.Method.Alg())
+		}
+		key.SignKey, key.VerifyKey = []byte(p.Secret), []byte(p.Secret)
+	} else {
+		if io.IsPathNotExist(p.PrivateKeyPath) {
+			return nil, fmt.Errorf("private key file %v does not exist", p.PrivateKeyPath)
+		}
+		key.SignKey, err = readKey(p.PrivateKeyPath, parsePrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		if p.PublicKeyPath != "" && !io.IsPathNotExist(p.PublicKeyPath) {
+			key.VerifyKey, err = readKey(p.PublicKeyPath, parsePublicKey)
+		} else {
+			key.VerifyKey = publicKey(key.SignKey)
+		}
+		if err == nil && !compatible(key.Method, key.VerifyKey) {
+			err = fmt.Errorf("%v: %v key is expected", ErrInvalidKey, key.Method.Alg())
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	key.ID = p.KeyID
+	if key.ID == "" {
+		if jwk, ok := NewJWK(key); ok {
+			key.ID = jwk.Thumbprint()
+		}
+	}
+	return
+}
+
+// loadVerifyKey loads the verification key from the PEM file of public key, or the secret of HMAC
+func loadVerifyKey(p *VerifyKey) (key *Key, err error) {
+	key = &Key{ID: p.ID}
+	key.Method, err = signingMethod(p.Algorithm)
+	if err != nil {
+		return
+	}
+	if _, ok := key.Method.(*jwt.SigningMethodHMAC); ok {
+		if p.Secret == "" {
+			return nil, fmt.Errorf("secret of verify key %v is not set", p.ID)
+		}
+		key.VerifyKey = []byte(p.Secret)
+		return
+	}
+	key.VerifyKey, err = readKey(p.PublicKeyPath, parsePublicKey)
+	if err == nil && !compatible(key.Method, key.VerifyKey) {
+		err = fmt.Errorf("%v: %v key is expected by %v", ErrInvalidKey, key.Method.Alg(), p.ID)
+	}
+	return
+}
+
+// generateKey generates the key of the signing method, it is used by key rotation
+func generateKey(method jwt.SigningMethod) (key *Key, err error) {
+	key = &Key{Method: method}
+	switch m := method.(type) {
+	case *jwt.SigningMethodHMAC:
+		secret := make([]byte, m.Hash.Size())
+		_, err = rand.Read(secret)
+		key.SignKey, key.VerifyKey = secret, secret
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		var k *rsa.PrivateKey
+		k, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err == nil {
+			key.SignKey, key.VerifyKey = k, &k.PublicKey
+		}
+	case *jwt.SigningMethodECDSA:
+		var k *ecdsa.PrivateKey
+		k, err = ecdsa.GenerateKey(curve(m.CurveBits), rand.Reader)
+		if err == nil {
+			key.SignKey, key.VerifyKey = k, &k.PublicKey
+		}
+	case *signingMethodEdDSA:
+		var pub ed25519.PublicKey
+		var priv ed25519.PrivateKey
+		pub, priv, err = ed25519.GenerateKey(rand.Reader)
+		key.SignKey, key.VerifyKey = priv, pub
+	default:
+		err = fmt.Errorf("%v: %v", ErrUnsupportedAlgorithm, method.Alg())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if jwk, ok := NewJWK(key); ok {
+		key.ID = jwk.Thumbprint()
+	} else {
+		id := make([]byte, 8)
+		_, err = rand.Read(id)
+		key.ID = hex.EncodeToString(id)
+	}
+	return
+}
+
+func readKey(path string, parse func(b []byte) (interface{}, error)) (key interface{}, err error) {
+	var b []byte
+	b, err = ioutil.ReadFile(path)
+	if err == nil {
+		key, err = parse(b)
+	}
+	return
+}
+
+// parsePrivateKey parses the PEM encoded PKCS1, PKCS8 or EC private key
+func parsePrivateKey(b []byte) (key interface{}, err error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+	if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return
+	}
+	if key, err = parseEd25519PrivateKey(block.Bytes); err == nil {
+		return
+	}
+	if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return
+	}
+	if key, err = x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return
+	}
+	return nil, ErrInvalidKey
+}
+
+// parsePublicKey parses the PEM encoded PKIX or PKCS1 public key, or the certificate
+func parsePublicKey(b []byte) (key interface{}, err error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+	if key, err = parseEd25519PublicKey(block.Bytes); err == nil {
+		return
+	}
+	if key, err = x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return
+	}
+	if key, err = x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return
+	}
+	var cert *x509.Certificate
+	if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+		if key, err = parseEd25519PublicKey(cert.RawSubjectPublicKeyInfo); err == nil {
+			return
+		}
+		return cert.PublicKey, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+// publicKey returns the public key of the private key
+func publicKey(privateKey interface{}) interface{} {
+	if k, ok := privateKey.(crypto.Signer); ok {
+		return k.Public()
+	}
+	return nil
+}
+
+// compatible returns true if the verification key is of the type expected by the signing method
+func compatible(method jwt.SigningMethod, key interface{}) (ok bool) {
+	switch m := method.(type) {
+	case *jwt.SigningMethodHMAC:
+		_, ok = key.([]byte)
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok = key.(*rsa.PublicKey)
+	case *jwt.SigningMethodECDSA:
+		var k *ecdsa.PublicKey
+		k, ok = key.(*ecdsa.PublicKey)
+		ok = ok && k.Curve.Params().BitSize == m.CurveBits
+	case *signingMethodEdDSA:
+		_, ok = key.(ed25519.PublicKey)
+	}
+	return
+}
+
+func curve(bits int) elliptic.Curve {
+	switch bits {
+	case 384:
+		return elliptic.P384()
+	case 521:
+		return elliptic.P521()
+	}
+	return elliptic.P256()
+}
@@ -0,0 +1,259 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeKeys writes the PEM files of the private and public key into dir
+func writeKeys(t *testing.T, dir, name string, privateKey interface{}) (privateKeyPath, publicKeyPath string) {
+	var priv, pub []byte
+	var err error
+	switch k := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		priv, err = x509.MarshalPKCS8PrivateKey(k)
+		assert.Equal(t, nil, err)
+		pub, err = x509.MarshalPKIXPublicKey(&k.PublicKey)
+	case ed25519.PrivateKey:
+		// the DER encoded PKCS8 and PKIX Ed25519 keys of RFC 8410, crypto/x509 does not marshal them before go 1.13
+		priv = append([]byte{0x30, 0x2e, 0x02, 0x01, 0x00, 0x30, 0x05, 0x06, 0x03, 0x2b, 0x65, 0x70, 0x04, 0x22, 0x04, 0x20}, k[:ed25519.SeedSize]...)
+		pub = append([]byte{0x30, 0x2a, 0x30, 0x05, 0x06, 0x03, 0x2b, 0x65, 0x70, 0x03, 0x21, 0x00}, k[ed25519.SeedSize:]...)
+	}
+	assert.Equal(t, nil, err)
+
+	privateKeyPath = filepath.Join(dir, name)
+	publicKeyPath = privateKeyPath + ".pub"
+	err = ioutil.WriteFile(privateKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: priv}), 0600)
+	assert.Equal(t, nil, err)
+	err = ioutil.WriteFile(publicKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}), 0644)
+	assert.Equal(t, nil, err)
+	return
+}
+
+func verify(token jwt.Token, tokenString string) (*jwtgo.Token, error) {
+	return jwtgo.Parse(tokenString, token.ValidationKey)
+}
+
+func TestAlgorithms(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecPrivateKeyPath, ecPublicKeyPath := writeKeys(t, dir, "ec", ecKey)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	edPrivateKeyPath, _ := writeKeys(t, dir, "ed", edKey)
+
+	testCases := []struct {
+		name       string
+		properties *jwt.Properties
+	}{
+		{"should sign and verify with RS256", &jwt.Properties{
+			PrivateKeyPath: "config/ssl/app.rsa", PublicKeyPath: "config/ssl/app.rsa.pub"}},
+		{"should sign and verify with PS384", &jwt.Properties{
+			Algorithm: "PS384", PrivateKeyPath: "config/ssl/app.rsa", PublicKeyPath: "config/ssl/app.rsa.pub"}},
+		{"should sign and verify with HS256", &jwt.Properties{Algorithm: "HS256", Secret: "s3cr3t", KeyID: "hmac"}},
+		{"should sign and verify with ES256", &jwt.Properties{
+			Algorithm: "ES256", PrivateKeyPath: ecPrivateKeyPath, PublicKeyPath: ecPublicKeyPath}},
+		{"should sign and verify with EdDSA and derived public key", &jwt.Properties{
+			Algorithm: "EdDSA", PrivateKeyPath: edPrivateKeyPath}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			token := jwt.NewJwtToken(testCase.properties)
+			assert.NotEqual(t, nil, token)
+			tokenString, err := token.Generate(jwt.Map{"username": "johndoe"}, 1, time.Minute)
+			assert.Equal(t, nil, err)
+
+			parsed, err := verify(token, tokenString)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, true, parsed.Valid)
+			assert.Equal(t, token.KeySet().SigningKey().ID, parsed.Header["kid"])
+			assert.Equal(t, "johndoe", parsed.Claims.(jwtgo.MapClaims)["username"])
+		})
+	}
+
+	t.Run("should report unsupported algorithm and missing secret", func(t *testing.T) {
+		assert.Equal(t, nil, jwt.NewJwtToken(&jwt.Properties{Algorithm: "none"}))
+		assert.Equal(t, nil, jwt.NewJwtToken(&jwt.Properties{Algorithm: "HS256"}))
+		assert.Equal(t, nil, jwt.NewJwtToken(&jwt.Properties{
+			PrivateKeyPath: "config/ssl/app.rsa",
+			VerifyKeys:     []jwt.VerifyKey{{ID: "hmac", Algorithm: "HS256"}},
+		}))
+	})
+
+	t.Run("should report the key that does not match the algorithm", func(t *testing.T) {
+		assert.Equal(t, nil, jwt.NewJwtToken(&jwt.Properties{Algorithm: "ES384", PrivateKeyPath: ecPrivateKeyPath}))
+		assert.Equal(t, nil, jwt.NewJwtToken(&jwt.Properties{Algorithm: "RS256", PrivateKeyPath: edPrivateKeyPath}))
+	})
+
+	t.Run("should reject the token signed with other algorithm", func(t *testing.T) {
+		hmacToken := jwt.NewJwtToken(&jwt.Properties{Algorithm: "HS256", Secret: "s3cr3t"})
+		rsaToken := jwt.NewJwtToken(&jwt.Properties{PrivateKeyPath: "config/ssl/app.rsa"})
+		tokenString, err := hmacToken.Generate(jwt.Map{}, 1, time.Minute)
+		assert.Equal(t, nil, err)
+		_, err = verify(rsaToken, tokenString)
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should verify the token by kid with the verification keys", func(t *testing.T) {
+		edToken := jwt.NewJwtToken(&jwt.Properties{Algorithm: "EdDSA", PrivateKeyPath: edPrivateKeyPath, KeyID: "ed"})
+		hmacToken := jwt.NewJwtToken(&jwt.Properties{Algorithm: "HS512", Secret: "s3cr3t", KeyID: "hmac"})
+		token := jwt.NewJwtToken(&jwt.Properties{
+			PrivateKeyPath: "config/ssl/app.rsa",
+			VerifyKeys: []jwt.VerifyKey{
+				{ID: "ed", Algorithm: "EdDSA", PublicKeyPath: edPrivateKeyPath + ".pub"},
+				{ID: "hmac", Algorithm: "HS512", Secret: "s3cr3t"},
+			},
+		})
+		for _, tk := range []jwt.Token{edToken, hmacToken} {
+			tokenString, err := tk.Generate(jwt.Map{}, 1, time.Minute)
+			assert.Equal(t, nil, err)
+			_, err = verify(token, tokenString)
+			assert.Equal(t, nil, err)
+		}
+
+		unknownToken := jwt.NewJwtToken(&jwt.Properties{Algorithm: "HS512", Secret: "s3cr3t", KeyID: "unknown"})
+		tokenString, _ := unknownToken.Generate(jwt.Map{}, 1, time.Minute)
+		_, err = verify(token, tokenString)
+		assert.Contains(t, err.Error(), jwt.ErrKeyNotFound.Error())
+	})
+}
+
+func TestRotation(t *testing.T) {
+	token := jwt.NewJwtToken(&jwt.Properties{
+		Algorithm: "ES256",
+		Secret:    "",
+		PrivateKeyPath: func() string {
+			dir, _ := ioutil.TempDir("", "jwt")
+			key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			path, _ := writeKeys(t, dir, "ec", key)
+			return path
+		}(),
+		Rotation: jwt.Rotation{Grace: 100 * time.Millisecond},
+	})
+	oldToken, err := token.Generate(jwt.Map{}, 1, time.Minute)
+	assert.Equal(t, nil, err)
+	oldKeyID := token.KeySet().SigningKey().ID
+
+	t.Run("should sign with the rotated key and keep verifying with the old key", func(t *testing.T) {
+		err := token.Rotate()
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, oldKeyID, token.KeySet().SigningKey().ID)
+		assert.Equal(t, 2, len(token.KeySet().Keys()))
+
+		newToken, err := token.Generate(jwt.Map{}, 1, time.Minute)
+		assert.Equal(t, nil, err)
+		_, err = verify(token, newToken)
+		assert.Equal(t, nil, err)
+		_, err = verify(token, oldToken)
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("should not verify with the old key after the grace period", func(t *testing.T) {
+		time.Sleep(150 * time.Millisecond)
+		_, err := verify(token, oldToken)
+		assert.NotEqual(t, nil, err)
+		assert.Equal(t, 1, len(token.KeySet().Keys()))
+	})
+
+	t.Run("should rotate the signing key by the generation after the interval", func(t *testing.T) {
+		token := jwt.NewJwtToken(&jwt.Properties{
+			Algorithm: "HS256",
+			Secret:    "s3cr3t",
+			Rotation:  jwt.Rotation{Interval: 50 * time.Millisecond, Grace: time.Minute},
+		})
+		_, err := token.Generate(jwt.Map{}, 1, time.Minute)
+		assert.Equal(t, nil, err)
+		keyID := token.KeySet().SigningKey().ID
+
+		time.Sleep(60 * time.Millisecond)
+		assert.Equal(t, keyID, token.KeySet().SigningKey().ID)
+		_, err = token.Generate(jwt.Map{}, 1, time.Minute)
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, keyID, token.KeySet().SigningKey().ID)
+	})
+}
+
+func TestJWKS(t *testing.T) {
+	testApp := web.NewTestApp(newFooController).
+		SetProperty("jwt.jwks.enabled", true).
+		Run(t)
+	token := testApp.(app.ApplicationContext).GetInstance("jwt.token").(jwt.Token)
+
+	var jwks jwt.JWKS
+	t.Run("should publish the public keys", func(t *testing.T) {
+		body := testApp.Get("/.well-known/jwks.json").
+			Expect().Status(http.StatusOK).Body().Raw()
+		err := json.Unmarshal([]byte(body), &jwks)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(jwks.Keys))
+		assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+		assert.Equal(t, "RS256", jwks.Keys[0].Alg)
+		assert.Equal(t, token.KeySet().SigningKey().ID, jwks.Keys[0].Kid)
+	})
+
+	t.Run("should verify against remote JWKS", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			json.NewEncoder(w).Encode(&jwks)
+		}))
+		defer server.Close()
+
+		_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+		dir, _ := ioutil.TempDir("", "jwt")
+		defer os.RemoveAll(dir)
+		edPrivateKeyPath, _ := writeKeys(t, dir, "ed", edKey)
+		verifier := jwt.NewJwtToken(&jwt.Properties{
+			Algorithm:      "EdDSA",
+			PrivateKeyPath: edPrivateKeyPath,
+			Jwks:           jwt.Jwks{URL: server.URL, CacheTTL: time.Minute},
+		})
+
+		for i := 0; i < 3; i++ {
+			tokenString, err := token.Generate(jwt.Map{"username": fmt.Sprintf("johndoe%v", i)}, 1, time.Minute)
+			assert.Equal(t, nil, err)
+			_, err = verify(verifier, tokenString)
+			assert.Equal(t, nil, err)
+		}
+		assert.Equal(t, 1, requests)
+	})
+}
+
+func TestJWKSDisabled(t *testing.T) {
+	testApp := web.NewTestApp(newFooController).Run(t)
+	testApp.Get("/.well-known/jwks.json").Expect().Status(http.StatusNotFound)
+}
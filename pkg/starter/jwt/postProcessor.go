@@ -17,11 +17,13 @@ package jwt
 import (
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/system"
 )
 
 type postProcessor struct {
 	applicationContext app.ApplicationContext
 	jwtMiddleware      *Middleware
+	configuration      *system.Configuration
 }
 
 func init() {
@@ -29,10 +31,11 @@ func init() {
 	app.RegisterPostProcessor(newPostProcessor)
 }
 
-func newPostProcessor(applicationContext app.ApplicationContext, jwtMiddleware *Middleware) *postProcessor {
+func newPostProcessor(applicationContext app.ApplicationContext, jwtMiddleware *Middleware, configuration *system.Configuration) *postProcessor {
 	return &postProcessor{
 		applicationContext: applicationContext,
 		jwtMiddleware:      jwtMiddleware,
+		configuration:      configuration,
 	}
 }
 
@@ -43,6 +46,11 @@ func (p *postProcessor) AfterInitialization() {
 	// use jwt
 	p.applicationContext.Use(p.jwtMiddleware.Serve)
 
+	// protect the management server by jwt if management.security.jwt is true
+	if p.configuration != nil && p.configuration.Management.Security.Jwt {
+		p.applicationContext.UseManagement(p.jwtMiddleware.Serve)
+	}
+
 	// finally register jwt controllers
 	p.applicationContext.RegisterController(new(at.JwtRestController))
 }
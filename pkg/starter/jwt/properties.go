@@ -14,8 +14,78 @@
 
 package jwt
 
+import "time"
+
+// VerifyKey is the additional key that verifies the tokens signed with the key id, e.g. the key of other services
+type VerifyKey struct {
+	// the key id that is matched with the kid header of the token
+	ID string `json:"id" mapstructure:"id"`
+	// the algorithm of the key, e.g. RS256, HS256, ES256 or EdDSA
+	Algorithm string `json:"algorithm"`
+	// the PEM file of the public key, it is required by the asymmetric algorithms
+	PublicKeyPath string `json:"public_key_path" mapstructure:"publicKeyPath"`
+	// the secret of HMAC algorithms
+	Secret string `json:"-"`
+}
+
+// Jwks is the properties of JSON Web Key Set
+type Jwks struct {
+	// publish the public keys on the jwks path
+	Enabled bool `json:"enabled"`
+	// the path that the public keys are published on
+	Path string `json:"path" default:"/.well-known/jwks.json"`
+	// the url of the remote JWKS that the tokens are verified against if the kid is not found locally
+	URL string `json:"url" mapstructure:"url"`
+	// the time to live of the cached remote JWKS
+	CacheTTL time.Duration `json:"cache_ttl" mapstructure:"cacheTTL" default:"10m"`
+}
+
+// Rotation is the properties of signing key rotation
+type Rotation struct {
+	// the interval that a new signing key is generated, 0 for no rotation, the key is rotated by the first token
+	// generation after the interval, the generated keys are kept in memory
+	Interval time.Duration `json:"interval"`
+	// the time that the rotated key keeps verifying, it should be longer than the expiration of the tokens
+	Grace time.Duration `json:"grace" default:"24h"`
+}
+
+// Refresh is the properties of refresh token
+type Refresh struct {
+	// the expiration of the refresh token
+	Expiration time.Duration `json:"expiration" default:"168h"`
+	// the expiration of the access token that is issued by refresh
+	AccessTokenExpiration time.Duration `json:"access_token_expiration" mapstructure:"accessTokenExpiration" default:"15m"`
+}
+
 // Properties the jwt properties
 type Properties struct {
+	// the signing algorithm, HS256/384/512, RS256/384/512, PS256/384/512, ES256/384/512 or EdDSA
+	Algorithm      string `json:"algorithm" default:"RS256"`
 	PrivateKeyPath string `json:"private_key_path" default:"config/ssl/app.rsa"`
 	PublicKeyPath  string `json:"public_key_path" default:"config/ssl/app.rsa.pub"`
+	// the secret of HMAC algorithms, e.g. ${file:config/ssl/jwt.secret}
+	Secret string `json:"-"`
+	// the key id of the signing key, it is set to the kid header of the generated token,
+	// the thumbprint of the public key is used if it is empty
+	KeyID string `json:"key_id" mapstructure:"keyId"`
+	// the additional verification keys by key id
+	VerifyKeys []VerifyKey `json:"verify_keys" mapstructure:"verifyKeys"`
+	// the JSON Web Key Set
+	Jwks Jwks `json:"jwks"`
+	// the rotation of the signing key
+	Rotation Rotation `json:"rotation"`
+	// the iss claim of the generated token, the verified token must be issued by it if it is not empty
+	Issuer string `json:"issuer"`
+	// the aud claim of the generated token, the verified token must have one of them if it is not empty
+	Audience []string `json:"audience"`
+	// the clock skew that is tolerated by the exp, nbf and iat checks, e.g. 30s
+	Leeway time.Duration `json:"leeway"`
+	// the refresh token
+	Refresh Refresh `json:"refresh"`
+	// the claim of the token that is used as the name of the principal, e.g. of the audited request
+	PrincipalClaim string `json:"principal_claim" mapstructure:"principalClaim" default:"username"`
+	// the claim of the roles that are checked by at.RequiresRoles, an array or a comma or space separated string
+	RolesClaim string `json:"roles_claim" mapstructure:"rolesClaim" default:"roles"`
+	// the claim of the permissions that are checked by at.RequiresPermissions
+	PermissionsClaim string `json:"permissions_claim" mapstructure:"permissionsClaim" default:"permissions"`
 }
@@ -0,0 +1,113 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"sync"
+	"time"
+)
+
+// TokenStore stores the revoked tokens by jti, the middleware rejects the revoked tokens,
+// the component that embedded at.JwtTokenStore and implemented TokenStore replaces the in-memory store
+type TokenStore interface {
+	// Revoke revokes the token of jti, the record can be removed after the token expires
+	Revoke(jti string, expiresAt time.Time) error
+	// IsRevoked returns true if the token of jti is revoked
+	IsRevoked(jti string) (bool, error)
+	// TryRevoke revokes the token of jti atomically if it is not revoked yet, false is returned if it is already revoked,
+	// e.g. the refresh token is used only once even if it is refreshed concurrently
+	TryRevoke(jti string, expiresAt time.Time) (bool, error)
+}
+
+// MemoryTokenStore is the in-memory TokenStore, the expired records are purged when a token is revoked
+type MemoryTokenStore struct {
+	mutex   sync.RWMutex
+	revoked map[string]time.Time
+}
+
+// NewMemoryTokenStore is the constructor of MemoryTokenStore
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{revoked: make(map[string]time.Time)}
+}
+
+// Revoke revokes the token of jti
+func (s *MemoryTokenStore) Revoke(jti string, expiresAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.revoke(jti, expiresAt)
+	return nil
+}
+
+// TryRevoke revokes the token of jti if it is not revoked yet
+func (s *MemoryTokenStore) TryRevoke(jti string, expiresAt time.Time) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.revoked[jti]; ok {
+		return false, nil
+	}
+	s.revoke(jti, expiresAt)
+	return true, nil
+}
+
+func (s *MemoryTokenStore) revoke(jti string, expiresAt time.Time) {
+	now := time.Now()
+	for id, exp := range s.revoked {
+		if now.After(exp) {
+			delete(s.revoked, id)
+		}
+	}
+	s.revoked[jti] = expiresAt
+}
+
+// IsRevoked returns true if the token of jti is revoked
+func (s *MemoryTokenStore) IsRevoked(jti string) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	_, ok := s.revoked[jti]
+	return ok, nil
+}
+
+// componentTokenStore delegates to the component that embedded at.JwtTokenStore, or to the in-memory store
+type componentTokenStore struct {
+	instantiateFactory factory.InstantiateFactory
+	once               sync.Once
+	store              TokenStore
+}
+
+func (s *componentTokenStore) load() {
+	if !factory.FindInstance(s.instantiateFactory, new(at.JwtTokenStore), &s.store) {
+		s.store = NewMemoryTokenStore()
+	}
+}
+
+// Revoke revokes the token of jti
+func (s *componentTokenStore) Revoke(jti string, expiresAt time.Time) error {
+	s.once.Do(s.load)
+	return s.store.Revoke(jti, expiresAt)
+}
+
+// IsRevoked returns true if the token of jti is revoked
+func (s *componentTokenStore) IsRevoked(jti string) (bool, error) {
+	s.once.Do(s.load)
+	return s.store.IsRevoked(jti)
+}
+
+// TryRevoke revokes the token of jti if it is not revoked yet
+func (s *componentTokenStore) TryRevoke(jti string, expiresAt time.Time) (bool, error) {
+	s.once.Do(s.load)
+	return s.store.TryRevoke(jti, expiresAt)
+}
@@ -0,0 +1,49 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"hidevops.io/hiboot/pkg/app"
+	"time"
+)
+
+// ErrTokenNotConfigured the jwt starter is not configured in the application
+var ErrTokenNotConfigured = errors.New("[jwt] token is not configured")
+
+// GenerateTestToken generates the bearer token of the user with the roles and the permissions for testing,
+// the claims are named by jwt.principalClaim, jwt.rolesClaim and jwt.permissionsClaim, and it expires in an hour, e.g.
+//
+//	bearer, _ := jwt.GenerateTestToken(testApp.(app.ApplicationContext), "johndoe", []string{"admin"}, "order:read")
+//	testApp.Get("/order").WithHeader("Authorization", bearer).Expect().Status(http.StatusOK)
+func GenerateTestToken(applicationContext app.ApplicationContext, username string, roles []string, permissions ...string) (bearer string, err error) {
+	token, _ := applicationContext.GetInstance("jwt.token").(Token)
+	provider, _ := applicationContext.GetInstance(PrincipalProvider{}).(*PrincipalProvider)
+	if token == nil || provider == nil {
+		return "", ErrTokenNotConfigured
+	}
+
+	var tokenString string
+	tokenString, err = token.Generate(Map{
+		provider.properties.PrincipalClaim:   username,
+		provider.properties.RolesClaim:       roles,
+		provider.properties.PermissionsClaim: permissions,
+	}, 1, time.Hour)
+	if err == nil {
+		bearer = fmt.Sprintf("Bearer %v", tokenString)
+	}
+	return
+}
@@ -16,12 +16,13 @@ package jwt
 
 import (
 	"crypto/rsa"
+	"fmt"
+	"sync"
 	"time"
 
-	"fmt"
 	"github.com/dgrijalva/jwt-go"
-	"hidevops.io/hiboot/pkg/utils/io"
-	"io/ioutil"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/idgen"
 )
 
 // Map is the JWT map
@@ -30,14 +31,43 @@ type Map map[string]interface{}
 // Token is the token interface
 type Token interface {
 	Generate(payload Map, expired int64, unit time.Duration) (string, error)
+	// Deprecated: VerifyKey returns the public key of the RS256 signing key only, use ValidationKey instead
 	VerifyKey() *rsa.PublicKey
+	// ValidationKey returns the key that verifies the token by its kid header, it is the jwt-go Keyfunc
+	ValidationKey(token *jwt.Token) (interface{}, error)
+	// KeySet returns the signing key and the local verification keys
+	KeySet() *KeySet
+	// Rotate replaces the signing key with a generated one, the previous one keeps verifying for jwt.rotation.grace
+	Rotate() error
+	// Parse verifies the access token, then validates it by Validate
+	Parse(tokenString string) (*jwt.Token, error)
+	// Validate validates the exp, nbf, iat, iss and aud claims of the verified access token, and checks if it is revoked
+	Validate(token *jwt.Token) error
+	// GenerateRefreshToken generates the refresh token that expires in jwt.refresh.expiration
+	GenerateRefreshToken(payload Map) (string, error)
+	// Refresh issues the access token and the rotated refresh token with the payload of the refresh token,
+	// the refresh token is revoked, so it can be used only once
+	Refresh(refreshToken string) (accessToken string, newRefreshToken string, err error)
+	// Revoke revokes the access token or the refresh token by its jti, e.g. on logout
+	Revoke(tokenString string) error
 }
 
 type jwtToken struct {
-	verifyKey *rsa.PublicKey
-	signKey   *rsa.PrivateKey
+	keySet *KeySet
+	remote *remoteKeySet
+	grace  time.Duration
+	// the signing key is rotated by the token generation that is due, no goroutine is left running
+	rotationInterval time.Duration
+	rotatedAt        time.Time
+	rotationMutex    sync.Mutex
 	//jwtMiddleware *JwtMiddleware
 	jwtEnabled bool
+
+	issuer   string
+	audience []string
+	leeway   time.Duration
+	refresh  Refresh
+	store    TokenStore
 }
 
 // NewJwtToken create new jwt token
@@ -51,46 +81,234 @@ func NewJwtToken(p *Properties) (token Token) {
 }
 
 func (t *jwtToken) Initialize(p *Properties) error {
-	if io.IsPathNotExist(p.PrivateKeyPath) {
-		return fmt.Errorf("private key file %v does not exist", p.PrivateKeyPath)
+	signingKey, err := loadSigningKey(p)
+	if err != nil {
+		return err
 	}
-	var verifyBytes []byte
-	signBytes, err := ioutil.ReadFile(p.PrivateKeyPath)
-	if err == nil {
-		t.signKey, err = jwt.ParseRSAPrivateKeyFromPEM(signBytes)
-		if err == nil {
-			verifyBytes, err = ioutil.ReadFile(p.PublicKeyPath)
-			if err == nil {
-				t.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(verifyBytes)
-				if err == nil {
-					t.jwtEnabled = true
-				}
-			}
+	t.keySet = NewKeySet(signingKey)
+	for i := range p.VerifyKeys {
+		var key *Key
+		key, err = loadVerifyKey(&p.VerifyKeys[i])
+		if err != nil {
+			return err
+		}
+		t.keySet.Add(key)
+	}
+	if p.Jwks.URL != "" {
+		t.remote = newRemoteKeySet(p.Jwks.URL, p.Jwks.CacheTTL)
+	}
+	t.grace = p.Rotation.Grace
+	t.rotationInterval = p.Rotation.Interval
+	t.rotatedAt = time.Now()
+	t.issuer = p.Issuer
+	t.audience = p.Audience
+	t.leeway = p.Leeway
+	t.refresh = p.Refresh
+	if t.store == nil {
+		t.store = NewMemoryTokenStore()
+	}
+	t.jwtEnabled = true
+	return nil
+}
+
+func (t *jwtToken) VerifyKey() (key *rsa.PublicKey) {
+	if t.keySet != nil {
+		key, _ = t.keySet.SigningKey().VerifyKey.(*rsa.PublicKey)
+	}
+	return
+}
+
+// ValidationKey returns the key of the kid, the remote JWKS is looked up if the kid is not found locally,
+// the token must be signed with the algorithm of the key
+func (t *jwtToken) ValidationKey(token *jwt.Token) (retVal interface{}, err error) {
+	if t.keySet == nil {
+		return nil, ErrKeyNotFound
+	}
+	kid, _ := token.Header["kid"].(string)
+	key := t.keySet.Lookup(kid)
+	if key == nil {
+		if t.remote == nil {
+			return nil, fmt.Errorf("%v: %v", ErrKeyNotFound, kid)
+		}
+		key, err = t.remote.Lookup(kid)
+		if err != nil {
+			return
 		}
 	}
-	return err
+	if token.Method == nil || token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("%v: %v is expected", ErrUnexpectedAlgorithm, key.Method.Alg())
+	}
+	return key.VerifyKey, nil
+}
+
+// KeySet returns the signing key and the local verification keys
+func (t *jwtToken) KeySet() *KeySet {
+	return t.keySet
+}
+
+// Rotate replaces the signing key with a generated one of the same algorithm
+func (t *jwtToken) Rotate() (err error) {
+	if !t.jwtEnabled {
+		return
+	}
+	var key *Key
+	key, err = generateKey(t.keySet.SigningKey().Method)
+	if err == nil {
+		t.keySet.Rotate(key, t.grace)
+		log.Infof("rotated jwt signing key to %v", key.ID)
+	}
+	return
 }
 
-func (t *jwtToken) VerifyKey() *rsa.PublicKey {
-	return t.verifyKey
+// rotateIfDue rotates the signing key if it is older than jwt.rotation.interval
+func (t *jwtToken) rotateIfDue() {
+	if t.rotationInterval <= 0 {
+		return
+	}
+	t.rotationMutex.Lock()
+	defer t.rotationMutex.Unlock()
+	if time.Since(t.rotatedAt) < t.rotationInterval {
+		return
+	}
+	if err := t.Rotate(); err != nil {
+		log.Errorf("failed to rotate jwt signing key: %v", err)
+		return
+	}
+	t.rotatedAt = time.Now()
 }
 
 // Generate generates JWT token with specified exired time
 func (t *jwtToken) Generate(payload Map, expired int64, unit time.Duration) (tokenString string, err error) {
+	return t.generate(payload, unit*time.Duration(expired), "")
+}
+
+// GenerateRefreshToken generates the refresh token that expires in jwt.refresh.expiration
+func (t *jwtToken) GenerateRefreshToken(payload Map) (tokenString string, err error) {
+	return t.generate(payload, t.refresh.Expiration, tokenTypeRefresh)
+}
+
+func (t *jwtToken) generate(payload Map, expiresIn time.Duration, tokenType string) (tokenString string, err error) {
 	if t.jwtEnabled {
+		now := time.Now()
 		claim := jwt.MapClaims{
-			"exp": time.Now().Add(unit * time.Duration(expired)).Unix(),
-			"iat": time.Now().Unix(),
+			claimExpiresAt: now.Add(expiresIn).Unix(),
+			claimIssuedAt:  now.Unix(),
+		}
+		claim[claimID], err = idgen.NextString()
+		if err != nil {
+			return
+		}
+		if t.issuer != "" {
+			claim[claimIssuer] = t.issuer
+		}
+		if len(t.audience) != 0 {
+			claim[claimAudience] = t.audience
 		}
 
 		for k, v := range payload {
 			claim[k] = v
 		}
+		if tokenType != "" {
+			claim[claimTokenType] = tokenType
+		} else {
+			delete(claim, claimTokenType)
+		}
 
-		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claim)
+		t.rotateIfDue()
+		key := t.keySet.SigningKey()
+		token := jwt.NewWithClaims(key.Method, claim)
+		if key.ID != "" {
+			token.Header["kid"] = key.ID
+		}
 
 		// Sign and get the complete encoded token as a string using the secret
-		tokenString, err = token.SignedString(t.signKey)
+		tokenString, err = token.SignedString(key.SignKey)
+	}
+	return
+}
+
+// verify verifies the signature of the token, the claims are validated by validate
+func (t *jwtToken) verify(tokenString string) (*jwt.Token, error) {
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	return parser.Parse(tokenString, t.ValidationKey)
+}
+
+// Parse verifies and validates the access token
+func (t *jwtToken) Parse(tokenString string) (token *jwt.Token, err error) {
+	token, err = t.verify(tokenString)
+	if err == nil {
+		err = t.Validate(token)
+	}
+	if err != nil {
+		token = nil
 	}
 	return
 }
+
+// Validate validates the verified access token
+func (t *jwtToken) Validate(token *jwt.Token) error {
+	return t.validate(token, "")
+}
+
+// Refresh issues the access token and the rotated refresh token
+func (t *jwtToken) Refresh(refreshToken string) (accessToken string, newRefreshToken string, err error) {
+	var token *jwt.Token
+	var claims jwt.MapClaims
+	token, err = t.verify(refreshToken)
+	if err == nil {
+		claims, err = t.validateClaims(token, tokenTypeRefresh)
+	}
+	if err != nil {
+		return
+	}
+
+	// the refresh token is revoked atomically, so it is used only once even if it is refreshed concurrently
+	jti, _ := claims[claimID].(string)
+	if jti == "" {
+		err = ErrMissingTokenID
+		return
+	}
+	var ok bool
+	ok, err = t.store.TryRevoke(jti, expiresAt(claims))
+	if err == nil && !ok {
+		err = ErrTokenRevoked
+	}
+	if err != nil {
+		return
+	}
+	payload := make(Map, len(claims))
+	for k, v := range claims {
+		payload[k] = v
+	}
+	for _, k := range registeredClaims {
+		delete(payload, k)
+	}
+
+	accessToken, err = t.generate(payload, t.refresh.AccessTokenExpiration, "")
+	if err == nil {
+		newRefreshToken, err = t.GenerateRefreshToken(payload)
+	}
+	return
+}
+
+// Revoke revokes the token by its jti until it expires
+func (t *jwtToken) Revoke(tokenString string) (err error) {
+	var token *jwt.Token
+	token, err = t.verify(tokenString)
+	if err == nil {
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			return ErrInvalidClaims
+		}
+		err = t.revoke(claims)
+	}
+	return
+}
+
+func (t *jwtToken) revoke(claims jwt.MapClaims) error {
+	jti, _ := claims[claimID].(string)
+	if jti == "" {
+		return ErrMissingTokenID
+	}
+	return t.store.Revoke(jti, expiresAt(claims))
+}
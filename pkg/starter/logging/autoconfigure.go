@@ -19,6 +19,9 @@ import (
 	"github.com/kataras/iris/middleware/logger"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/idgen"
+	"time"
 )
 
 const (
@@ -56,8 +59,14 @@ func init() {
 	app.Register(newConfiguration)
 }
 
-// LoggerHandler config logger handler
+// LoggerHandler is the access log middleware, the access log is written with fields if the logging format is json or logfmt
 func (c *configuration) LoggerHandler() context.Handler {
+	if log.GetFormat() != log.TextFormat {
+		lh := c.structuredLoggerHandler
+		c.applicationContext.Use(lh)
+		return lh
+	}
+
 	loggerHandler := logger.New(logger.Config{
 		// Status displays status code
 		Status: c.Properties.Status,
@@ -86,3 +95,63 @@ func (c *configuration) LoggerHandler() context.Handler {
 
 	return lh
 }
+
+func (c *configuration) structuredLoggerHandler(ctx context.Context) {
+	start := time.Now()
+	ctx.Next()
+
+	fields := log.FromContext(ctx.Request().Context())
+	entry := log.WithFields(fields)
+	if c.Properties.Status {
+		entry = entry.WithField("status", ctx.GetStatusCode())
+	}
+	if c.Properties.IP {
+		entry = entry.WithField("ip", ctx.RemoteAddr())
+	}
+	if c.Properties.Method {
+		entry = entry.WithField("method", ctx.Method())
+	}
+	if c.Properties.Path {
+		if c.Properties.Query {
+			entry = entry.WithField("path", ctx.Request().URL.RequestURI())
+		} else {
+			entry = entry.WithField("path", ctx.Path())
+		}
+	}
+	for _, key := range c.Properties.ContextKeys {
+		if val := ctx.Values().Get(key); val != nil {
+			entry = entry.WithField(key, val)
+		}
+	}
+	for _, key := range c.Properties.HeaderKeys {
+		if val := ctx.GetHeader(key); val != "" {
+			entry = entry.WithField(key, val)
+		}
+	}
+	entry.WithField("latency", time.Since(start).String()).Info("access")
+}
+
+// RequestIDHandler is the middleware that takes the request id from the header X-Request-ID or generates a new one,
+// the request id is written to the response header, stored in ctx.Values() and carried by the context.Context of the request,
+// so that log.WithContext(ctx.Request().Context()) writes it with each log line of the request,
+// and httpclient and gRPC clients pass it to the downstream services
+func (c *configuration) RequestIDHandler() context.Handler {
+	rh := func(ctx context.Context) {
+		id := ctx.GetHeader(log.RequestIDHeader)
+		if id == "" {
+			id, _ = idgen.NextString()
+		}
+		ctx.Values().Set(log.RequestIDKey, id)
+		ctx.Header(log.RequestIDHeader, id)
+
+		// the request is replaced in place as the handlers hold the same *http.Request
+		request := ctx.Request()
+		*request = *request.WithContext(log.NewContext(request.Context(), log.Fields{log.RequestIDKey: id}))
+
+		ctx.Next()
+	}
+
+	c.applicationContext.Use(rh)
+
+	return rh
+}
@@ -15,10 +15,19 @@
 package logging
 
 import (
+	"bytes"
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"hidevops.io/hiboot/pkg/app/fake"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
 	"hidevops.io/hiboot/pkg/utils/io"
+	"net/http"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -38,3 +47,59 @@ func TestConfiguration(t *testing.T) {
 		c.LoggerHandler()
 	})
 }
+
+type fooController struct {
+	at.RestController
+}
+
+func newFooController() *fooController {
+	return &fooController{}
+}
+
+func (c *fooController) Get(ctx context.Context) string {
+	log.WithContext(ctx.Request().Context()).Info("get foo")
+	return log.RequestID(ctx.Request().Context())
+}
+
+func TestRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	testApp := web.NewTestApp(newFooController).
+		SetProperty("logging.format", log.JSONFormat).
+		Run(t)
+	// the test server disables logging
+	log.SetLevel(log.InfoLevel)
+	log.SetOutput(&buf)
+	defer func() {
+		log.SetFormat(log.TextFormat)
+		log.SetOutput(os.Stdout)
+	}()
+
+	t.Run("should take request id from header", func(t *testing.T) {
+		buf.Reset()
+		testApp.Get("/foo").
+			WithHeader(log.RequestIDHeader, "1234").
+			Expect().Status(http.StatusOK).
+			Header(log.RequestIDHeader).Equal("1234")
+
+		var lines []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var fields map[string]interface{}
+			if json.Unmarshal([]byte(line), &fields) == nil && fields[log.RequestIDKey] == "1234" {
+				lines = append(lines, fields)
+			}
+		}
+		assert.Equal(t, 2, len(lines))
+		if len(lines) == 2 {
+			assert.Equal(t, "get foo", lines[0]["msg"])
+			assert.Equal(t, "access", lines[1]["msg"])
+			assert.Equal(t, "/foo", lines[1]["path"])
+			assert.Equal(t, float64(http.StatusOK), lines[1]["status"])
+		}
+	})
+
+	t.Run("should generate request id", func(t *testing.T) {
+		resp := testApp.Get("/foo").Expect().Status(http.StatusOK)
+		id := resp.Header(log.RequestIDHeader).NotEmpty().Raw()
+		resp.Body().Equal(id)
+	})
+}
@@ -23,6 +23,6 @@ type Properties struct {
 	Path        bool     `json:"path" default:"true"`
 	Query       bool     `json:"query" default:"false"`
 	Columns     bool     `json:"columns" default:"false"`
-	ContextKeys []string `json:"context_keys" default:"logger_message"`
+	ContextKeys []string `json:"context_keys" default:"logger_message,request_id"`
 	HeaderKeys  []string `json:"header_keys" default:"User-Agent"`
 }
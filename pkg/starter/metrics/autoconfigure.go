@@ -0,0 +1,97 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics provides the hiboot starter for injectable prometheus metrics,
+// the http server, grpc server and client, and httpclient are instrumented automatically
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/starter/httpclient"
+	"strconv"
+	"time"
+)
+
+const (
+	// Profile is the profile of metrics, it should be as same as the package name
+	Profile = "metrics"
+)
+
+type configuration struct {
+	at.AutoConfiguration
+
+	Properties         Properties `mapstructure:"metrics"`
+	applicationContext app.ApplicationContext
+}
+
+func newConfiguration(applicationContext app.ApplicationContext) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+	}
+}
+
+func init() {
+	app.Register(newConfiguration)
+}
+
+// Registry is the metrics registry that can be injected to create custom metrics
+func (c *configuration) Registry() *Registry {
+	registry := NewRegistry(c.Properties.Namespace, c.Properties.Buckets)
+	if c.Properties.Runtime {
+		registry.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	}
+	return registry
+}
+
+// ServerHandler is the middleware that records the requests of each route by the route template and status
+func (c *configuration) ServerHandler(registry *Registry) context.Handler {
+	requests := registry.Counter("http_server_requests_total",
+		"The total number of http requests.", "method", "route", "status")
+	duration := registry.Histogram("http_server_request_duration_seconds",
+		"The http request latencies in seconds.", "method", "route", "status")
+	inFlight := registry.Gauge("http_server_requests_in_flight",
+		"The number of http requests being served.", "method", "route")
+
+	handler := func(ctx context.Context) {
+		route := ""
+		if currentRoute := ctx.GetCurrentRoute(); currentRoute != nil {
+			route = currentRoute.Path()
+		}
+		method := ctx.Method()
+		gauge := inFlight.WithLabelValues(method, route)
+		gauge.Inc()
+		start := time.Now()
+
+		ctx.Next()
+
+		gauge.Dec()
+		status := strconv.Itoa(ctx.GetStatusCode())
+		requests.WithLabelValues(method, route, status).Inc()
+		duration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+	}
+
+	c.applicationContext.Use(handler)
+
+	return handler
+}
+
+// ClientObserver is the httpclient observer that records the requests, retries and failures of the clients of the application
+func (c *configuration) ClientObserver(registry *Registry, observers *httpclient.Observers) *ClientObserver {
+	observer := newClientObserver(registry)
+	observers.Add(observer)
+	return observer
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/starter/httpclient"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fooController struct {
+	at.RestController
+
+	registry *Registry
+}
+
+func newFooController(registry *Registry) *fooController {
+	return &fooController{registry: registry}
+}
+
+func (c *fooController) GetByName(name string) string {
+	c.registry.Counter("foo_total", "The total number of foo.", "name").WithLabelValues(name).Inc()
+	return "hello " + name
+}
+
+func TestMetrics(t *testing.T) {
+	app.Register(newFooController)
+	testApp := web.NewTestApp().
+		SetProperty("metrics.namespace", "test").
+		Run(t)
+
+	t.Run("should count requests by route template", func(t *testing.T) {
+		testApp.Get("/foo/name/{name}").WithPath("name", "bar").Expect().Status(http.StatusOK)
+		testApp.Get("/foo/name/{name}").WithPath("name", "baz").Expect().Status(http.StatusOK)
+
+		body := testApp.Get("/metrics").Expect().Status(http.StatusOK).Body()
+		body.Contains(`test_http_server_requests_total{method="GET",route="/foo/name/{name}",status="200"} 2`)
+		body.Contains(`test_http_server_request_duration_seconds_count{method="GET",route="/foo/name/{name}",status="200"} 2`)
+		body.Contains(`test_http_server_requests_in_flight{method="GET",route="/foo/name/{name}"} 0`)
+	})
+
+	t.Run("should expose custom metrics", func(t *testing.T) {
+		body := testApp.Get("/metrics").Expect().Status(http.StatusOK).Body()
+		body.Contains(`test_foo_total{name="bar"} 1`)
+		body.Contains(`test_foo_total{name="baz"} 1`)
+	})
+
+	t.Run("should expose runtime metrics", func(t *testing.T) {
+		testApp.Get("/metrics").Expect().Status(http.StatusOK).Body().Contains("go_goroutines")
+	})
+
+	t.Run("should record httpclient requests, retries and failures", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+		host := strings.TrimPrefix(server.URL, "http://")
+
+		observers := testApp.(app.ApplicationContext).GetInstance(httpclient.Observers{}).(*httpclient.Observers)
+		client := httpclient.NewClient(httpclient.WithRetryCount(2), httpclient.WithObservers(observers))
+		resp, err := client.Get(server.URL, nil)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+		body := testApp.Get("/metrics").Expect().Status(http.StatusOK).Body()
+		body.Contains(`test_http_client_requests_total{host="` + host + `",method="GET",status="500"} 1`)
+		body.Contains(`test_http_client_retries_total{host="` + host + `",method="GET"} 2`)
+		body.Contains(`test_http_client_failures_total{host="` + host + `",method="GET"} 1`)
+	})
+}
+
+func TestGrpcInterceptor(t *testing.T) {
+	interceptor := newGrpcInterceptor(NewRegistry("", nil))
+
+	t.Run("should record unary server calls", func(t *testing.T) {
+		info := &grpc.UnaryServerInfo{FullMethod: "/helloworld.Greeter/SayHello"}
+		_, err := interceptor.UnaryServerInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "hello", nil
+		})
+		assert.Equal(t, nil, err)
+		_, err = interceptor.UnaryServerInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.NotFound, "not found")
+		})
+		assert.NotEqual(t, nil, err)
+
+		assert.Equal(t, float64(1), testutil.ToFloat64(interceptor.serverHandled.WithLabelValues("helloworld.Greeter", "SayHello", "OK")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(interceptor.serverHandled.WithLabelValues("helloworld.Greeter", "SayHello", "NotFound")))
+	})
+
+	t.Run("should record unary client calls", func(t *testing.T) {
+		err := interceptor.UnaryClientInterceptor(context.Background(), "/helloworld.Greeter/SayHello", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				return errors.New("unavailable")
+			})
+		assert.NotEqual(t, nil, err)
+		assert.Equal(t, float64(1), testutil.ToFloat64(interceptor.clientHandled.WithLabelValues("helloworld.Greeter", "SayHello", "Unknown")))
+	})
+
+	t.Run("should split method", func(t *testing.T) {
+		service, method := splitMethod("/helloworld.Greeter/SayHello")
+		assert.Equal(t, "helloworld.Greeter", service)
+		assert.Equal(t, "SayHello", method)
+	})
+}
@@ -0,0 +1,40 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+)
+
+type metricsController struct {
+	at.ManagementController
+
+	registry *Registry
+}
+
+func init() {
+	app.Register(newMetricsController)
+}
+
+func newMetricsController(registry *Registry) *metricsController {
+	return &metricsController{registry: registry}
+}
+
+// Get GET /metrics serves the metrics in prometheus text format
+func (c *metricsController) Get(ctx context.Context) {
+	c.registry.Handler().ServeHTTP(ctx.ResponseWriter(), ctx.Request())
+}
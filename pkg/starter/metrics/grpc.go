@@ -0,0 +1,128 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/at"
+	"io"
+	"strings"
+	"time"
+)
+
+// GrpcInterceptor records the handled grpc calls of both server and client by service, method and code
+type GrpcInterceptor struct {
+	at.GrpcServerInterceptor
+	at.GrpcClientInterceptor
+
+	serverHandled  *prometheus.CounterVec
+	serverDuration *prometheus.HistogramVec
+	clientHandled  *prometheus.CounterVec
+	clientDuration *prometheus.HistogramVec
+}
+
+func init() {
+	app.Register(newGrpcInterceptor)
+}
+
+func newGrpcInterceptor(registry *Registry) *GrpcInterceptor {
+	return &GrpcInterceptor{
+		serverHandled: registry.Counter("grpc_server_handled_total",
+			"The total number of grpc calls completed on the server.", "grpc_service", "grpc_method", "grpc_code"),
+		serverDuration: registry.Histogram("grpc_server_handling_seconds",
+			"The grpc call latencies in seconds on the server.", "grpc_service", "grpc_method"),
+		clientHandled: registry.Counter("grpc_client_handled_total",
+			"The total number of grpc calls completed by the client.", "grpc_service", "grpc_method", "grpc_code"),
+		clientDuration: registry.Histogram("grpc_client_handling_seconds",
+			"The grpc call latencies in seconds by the client.", "grpc_service", "grpc_method"),
+	}
+}
+
+// UnaryServerInterceptor records the unary calls on the server
+func (i *GrpcInterceptor) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	i.observe(i.serverHandled, i.serverDuration, info.FullMethod, err, start)
+	return resp, err
+}
+
+// StreamServerInterceptor records the stream calls on the server
+func (i *GrpcInterceptor) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	i.observe(i.serverHandled, i.serverDuration, info.FullMethod, err, start)
+	return err
+}
+
+// UnaryClientInterceptor records the unary calls by the client
+func (i *GrpcInterceptor) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	i.observe(i.clientHandled, i.clientDuration, method, err, start)
+	return err
+}
+
+// StreamClientInterceptor records the stream calls by the client, the call is completed when the stream ends
+func (i *GrpcInterceptor) StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	start := time.Now()
+	cs, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		i.observe(i.clientHandled, i.clientDuration, method, err, start)
+		return nil, err
+	}
+	return &clientStream{ClientStream: cs, done: func(err error) {
+		i.observe(i.clientHandled, i.clientDuration, method, err, start)
+	}}, nil
+}
+
+func (i *GrpcInterceptor) observe(handled *prometheus.CounterVec, duration *prometheus.HistogramVec, fullMethod string, err error, start time.Time) {
+	service, method := splitMethod(fullMethod)
+	handled.WithLabelValues(service, method, status.Code(err).String()).Inc()
+	duration.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+}
+
+// clientStream calls done once the stream ends, io.EOF is treated as OK
+type clientStream struct {
+	grpc.ClientStream
+
+	done     func(err error)
+	finished bool
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil && !s.finished {
+		s.finished = true
+		if err == io.EOF {
+			s.done(nil)
+		} else {
+			s.done(err)
+		}
+	}
+	return err
+}
+
+// splitMethod splits /package.service/method to package.service and method
+func splitMethod(fullMethod string) (service, method string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
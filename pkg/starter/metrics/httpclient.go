@@ -0,0 +1,62 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ClientObserver records the requests, retries and failures of httpclient by method and host
+type ClientObserver struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	retries  *prometheus.CounterVec
+	failures *prometheus.CounterVec
+}
+
+func newClientObserver(registry *Registry) *ClientObserver {
+	return &ClientObserver{
+		requests: registry.Counter("http_client_requests_total",
+			"The total number of httpclient requests.", "method", "host", "status"),
+		duration: registry.Histogram("http_client_request_duration_seconds",
+			"The httpclient request latencies in seconds, including the retries.", "method", "host"),
+		retries: registry.Counter("http_client_retries_total",
+			"The total number of httpclient retries.", "method", "host"),
+		failures: registry.Counter("http_client_failures_total",
+			"The total number of httpclient requests that failed or got server errors after all the retries.", "method", "host"),
+	}
+}
+
+// Retried records the retry of request
+func (o *ClientObserver) Retried(request *http.Request, attempt int) {
+	o.retries.WithLabelValues(request.Method, request.URL.Host).Inc()
+}
+
+// Done records the request when it is done, the status is empty if there is no response,
+// the request is failed if there is an error or a server error after all the retries
+func (o *ClientObserver) Done(request *http.Request, response *http.Response, err error, elapsed time.Duration) {
+	status := ""
+	if response != nil {
+		status = strconv.Itoa(response.StatusCode)
+	}
+	o.requests.WithLabelValues(request.Method, request.URL.Host, status).Inc()
+	o.duration.WithLabelValues(request.Method, request.URL.Host).Observe(elapsed.Seconds())
+	if err != nil || response == nil || response.StatusCode >= http.StatusInternalServerError {
+		o.failures.WithLabelValues(request.Method, request.URL.Host).Inc()
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+// Properties is the properties of metrics
+type Properties struct {
+	// the namespace that is prefixed to the names of all metrics, e.g. myapp_http_server_requests_total
+	Namespace string `json:"namespace"`
+	// the buckets of the latency histograms in seconds, prometheus.DefBuckets is used if it is empty
+	Buckets []float64 `json:"buckets"`
+	// collect the go runtime and process metrics
+	Runtime bool `json:"runtime" default:"true"`
+}
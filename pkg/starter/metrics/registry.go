@@ -0,0 +1,82 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"hidevops.io/hiboot/pkg/log"
+	"net/http"
+)
+
+// Registry is the injectable metrics registry, it creates the metrics on demand,
+// the metric that is already registered with the same name and labels is reused, e.g.
+//
+//	registry.Counter("orders_total", "The total number of orders.", "type").WithLabelValues("online").Inc()
+type Registry struct {
+	*prometheus.Registry
+
+	namespace string
+	buckets   []float64
+}
+
+// NewRegistry is the constructor of Registry, the namespace is prefixed to the names of all metrics,
+// prometheus.DefBuckets is used if buckets is empty
+func NewRegistry(namespace string, buckets []float64) *Registry {
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	return &Registry{
+		Registry:  prometheus.NewRegistry(),
+		namespace: namespace,
+		buckets:   buckets,
+	}
+}
+
+// Counter returns the counter vector of name with the label names
+func (r *Registry) Counter(name, help string, labelNames ...string) *prometheus.CounterVec {
+	opts := prometheus.CounterOpts{Namespace: r.namespace, Name: name, Help: help}
+	return r.register(prometheus.NewCounterVec(opts, labelNames)).(*prometheus.CounterVec)
+}
+
+// Gauge returns the gauge vector of name with the label names
+func (r *Registry) Gauge(name, help string, labelNames ...string) *prometheus.GaugeVec {
+	opts := prometheus.GaugeOpts{Namespace: r.namespace, Name: name, Help: help}
+	return r.register(prometheus.NewGaugeVec(opts, labelNames)).(*prometheus.GaugeVec)
+}
+
+// Histogram returns the histogram vector of name with the label names, the buckets of the registry are used
+func (r *Registry) Histogram(name, help string, labelNames ...string) *prometheus.HistogramVec {
+	opts := prometheus.HistogramOpts{Namespace: r.namespace, Name: name, Help: help, Buckets: r.buckets}
+	return r.register(prometheus.NewHistogramVec(opts, labelNames)).(*prometheus.HistogramVec)
+}
+
+// Handler returns the http handler that serves the metrics in prometheus text format
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r.Registry, promhttp.HandlerOpts{})
+}
+
+// register registers the collector, or returns the existing one with the same descriptor,
+// the collector is returned unregistered if it conflicts with another one
+func (r *Registry) register(collector prometheus.Collector) prometheus.Collector {
+	err := r.Register(collector)
+	if err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector
+		}
+		log.Error(err)
+	}
+	return collector
+}
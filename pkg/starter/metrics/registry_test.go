@@ -0,0 +1,63 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry("test", nil)
+
+	t.Run("should reuse the registered counter", func(t *testing.T) {
+		registry.Counter("orders_total", "The total number of orders.", "type").WithLabelValues("online").Inc()
+		registry.Counter("orders_total", "The total number of orders.", "type").WithLabelValues("online").Inc()
+
+		expected := `
+# HELP test_orders_total The total number of orders.
+# TYPE test_orders_total counter
+test_orders_total{type="online"} 2
+`
+		err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "test_orders_total")
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("should set gauge", func(t *testing.T) {
+		gauge := registry.Gauge("queue_size", "The size of the queue.")
+		gauge.WithLabelValues().Set(3)
+		assert.Equal(t, float64(3), testutil.ToFloat64(gauge))
+	})
+
+	t.Run("should observe histogram", func(t *testing.T) {
+		registry.Histogram("latency_seconds", "The latency.", "name").WithLabelValues("foo").Observe(0.1)
+		families, err := registry.Gather()
+		assert.Equal(t, nil, err)
+		var count uint64
+		for _, family := range families {
+			if family.GetName() == "test_latency_seconds" {
+				count = family.Metric[0].Histogram.GetSampleCount()
+			}
+		}
+		assert.Equal(t, uint64(1), count)
+	})
+
+	t.Run("should not panic on conflicting metric", func(t *testing.T) {
+		counter := registry.Counter("orders_total", "The total number of orders.", "channel")
+		assert.NotEqual(t, nil, counter)
+	})
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit provides the hiboot starter for rate limiting and request throttling,
+// the requests are limited by the token bucket or the sliding window algorithm, keyed by the client ip,
+// the jwt subject, the api key or the route, and the in-flight requests of a route can be limited as well, e.g.
+//
+//	ratelimit:
+//	  limits:
+//	  - path: /orders/**
+//	    rate: 100/1m
+//	    key: subject
+//	    concurrency: 10
+//
+// or by the annotation of the controller, e.g. at.RateLimit `value:"100/1m" key:"subject"`,
+// the request that exceeds the limit is responded 429 with the Retry-After header
+package ratelimit
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+)
+
+const (
+	// Profile is the profile of ratelimit, it should be as same as the package name
+	Profile = "ratelimit"
+)
+
+type configuration struct {
+	at.AutoConfiguration
+
+	Properties         Properties `mapstructure:"ratelimit"`
+	applicationContext app.ApplicationContext
+	instantiateFactory factory.InstantiateFactory
+	limiter            *limiter
+}
+
+func newConfiguration(applicationContext app.ApplicationContext, instantiateFactory factory.InstantiateFactory) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+		instantiateFactory: instantiateFactory,
+	}
+}
+
+func init() {
+	app.Register(newConfiguration)
+}
+
+// Store is the rate limit store, it is the component that embeds at.RateLimitStore, or the in-memory store
+func (c *configuration) Store() Store {
+	return &componentStore{instantiateFactory: c.instantiateFactory}
+}
+
+// Handler is the middleware that limits the requests by ratelimit.limits and the at.RateLimit annotations
+func (c *configuration) Handler(store Store, token jwt.Token) context.Handler {
+	c.limiter = &limiter{
+		properties:         &c.Properties,
+		store:              store,
+		token:              token,
+		instantiateFactory: c.instantiateFactory,
+	}
+	handler := c.limiter.handler
+
+	if c.Properties.Enabled {
+		c.applicationContext.Use(handler)
+	}
+
+	return handler
+}
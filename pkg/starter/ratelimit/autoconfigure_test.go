@@ -0,0 +1,136 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+	"hidevops.io/hiboot/pkg/starter/ratelimit"
+	"hidevops.io/hiboot/pkg/utils/io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type quotaController struct {
+	at.RestController
+	at.RateLimit `value:"${quota.rate:2/1m}" key:"subject"`
+}
+
+func newQuotaController() *quotaController {
+	return &quotaController{}
+}
+
+func (c *quotaController) Get() string {
+	return "quota"
+}
+
+type loginController struct {
+	at.RestController
+}
+
+func newLoginController() *loginController {
+	return &loginController{}
+}
+
+func (c *loginController) Post() string {
+	return "login"
+}
+
+type slowController struct {
+	at.RestController
+	release chan bool
+}
+
+func newSlowController() *slowController {
+	return &slowController{release: make(chan bool)}
+}
+
+func (c *slowController) Get() string {
+	<-c.release
+	return "slow"
+}
+
+func init() {
+	log.SetLevel(log.DebugLevel)
+	io.EnsureWorkDir(1, "config/application.yml")
+}
+
+func TestRateLimit(t *testing.T) {
+	testApp := web.NewTestApp(newQuotaController, newLoginController, newSlowController).
+		SetProperty("ratelimit.limits", []map[string]interface{}{
+			{"method": "POST", "path": "/login", "rate": "1/1m", "key": "apikey", "algorithm": "sliding-window"},
+			{"path": "/slow/**", "concurrency": 1},
+		}).
+		Run(t)
+	appContext := testApp.(app.ApplicationContext)
+
+	alice, err := jwt.GenerateTestToken(appContext, "alice", nil)
+	assert.Equal(t, nil, err)
+	bob, err := jwt.GenerateTestToken(appContext, "bob", nil)
+	assert.Equal(t, nil, err)
+
+	t.Run("should limit the requests by the annotation", func(t *testing.T) {
+		testApp.Get("/quota").WithHeader("Authorization", alice).
+			Expect().Status(http.StatusOK).
+			Header(ratelimit.HeaderLimit).Equal("2")
+		testApp.Get("/quota").WithHeader("Authorization", alice).
+			Expect().Status(http.StatusOK).
+			Header(ratelimit.HeaderRemaining).Equal("0")
+		res := testApp.Get("/quota").WithHeader("Authorization", alice).
+			Expect().Status(http.StatusTooManyRequests)
+		res.Header(ratelimit.HeaderRetryAfter).Equal("30")
+		res.JSON().Object().ValueEqual("code", http.StatusTooManyRequests)
+	})
+
+	t.Run("should count the requests by the jwt subject", func(t *testing.T) {
+		testApp.Get("/quota").WithHeader("Authorization", bob).
+			Expect().Status(http.StatusOK)
+	})
+
+	t.Run("should limit the requests by the properties", func(t *testing.T) {
+		testApp.Post("/login").WithHeader("X-API-Key", "key-1").
+			Expect().Status(http.StatusOK)
+		testApp.Post("/login").WithHeader("X-API-Key", "key-1").
+			Expect().Status(http.StatusTooManyRequests)
+		testApp.Post("/login").WithHeader("X-API-Key", "key-2").
+			Expect().Status(http.StatusOK)
+	})
+
+	t.Run("should limit the in-flight requests", func(t *testing.T) {
+		slow := appContext.GetInstance(slowController{}).(*slowController)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			testApp.Get("/slow").Expect().Status(http.StatusOK).Body().Equal("slow")
+		}()
+		// wait for the first request to be in flight
+		time.Sleep(100 * time.Millisecond)
+		testApp.Get("/slow").Expect().Status(http.StatusTooManyRequests)
+		slow.release <- true
+		wg.Wait()
+
+		go func() {
+			slow.release <- true
+		}()
+		testApp.Get("/slow").Expect().Status(http.StatusOK)
+	})
+}
@@ -0,0 +1,292 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+	"hidevops.io/hiboot/pkg/utils/pathmatch"
+	"hidevops.io/hiboot/pkg/utils/reflector"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// IPKey counts the requests by the client ip
+	IPKey = "ip"
+	// SubjectKey counts the requests by the subject of the jwt token, or by the client ip without token
+	SubjectKey = "subject"
+	// APIKeyKey counts the requests by the api key header, or by the client ip without api key
+	APIKeyKey = "apikey"
+	// RouteKey counts the requests of the route from all clients
+	RouteKey = "route"
+
+	// HeaderLimit is the header of the maximum number of requests
+	HeaderLimit = "X-RateLimit-Limit"
+	// HeaderRemaining is the header of the number of requests that are still allowed
+	HeaderRemaining = "X-RateLimit-Remaining"
+	// HeaderReset is the header of the seconds until the rate limit is fully reset
+	HeaderReset = "X-RateLimit-Reset"
+	// HeaderRetryAfter is the header of the seconds until the next request is allowed
+	HeaderRetryAfter = "Retry-After"
+
+	jwtKey       = "jwt"
+	bearerPrefix = "Bearer "
+)
+
+// ErrInvalidRate the rate is not in the form of count/period, e.g. 100/1m
+var ErrInvalidRate = errors.New("[ratelimit] invalid rate")
+
+// rule is the limit with the parsed policy
+type rule struct {
+	id          string
+	limit       Limit
+	policy      *Policy
+	concurrency int
+}
+
+// limiter limits the requests by the rules of the properties and the at.RateLimit annotations of the controllers
+type limiter struct {
+	properties         *Properties
+	store              Store
+	token              jwt.Token
+	instantiateFactory factory.InstantiateFactory
+
+	rules       []*rule
+	controllers map[string]*rule
+}
+
+// load parses the rules of ratelimit.limits and the at.RateLimit annotations of the controllers
+func (l *limiter) load() {
+	for i, limit := range l.properties.Limits {
+		id := fmt.Sprintf("%v:%v %v", i, limit.Method, limit.Path)
+		if r, err := l.newRule(id, limit); err == nil {
+			l.rules = append(l.rules, r)
+		} else {
+			log.Errorf("ratelimit.limits[%v]: %v", i, err)
+		}
+	}
+
+	l.controllers = make(map[string]*rule)
+	if l.instantiateFactory == nil {
+		return
+	}
+	for _, md := range l.instantiateFactory.GetInstances(new(at.RateLimit)) {
+		controller := factory.CastMetaData(md).Instance
+		typ := reflect.TypeOf(controller)
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		// the routes of the controller methods are named by pkgPath/Type.Method
+		name := typ.PkgPath() + "/" + typ.Name()
+		limit := Limit{
+			Rate:        l.tag(controller, "value"),
+			Algorithm:   l.tag(controller, "algorithm"),
+			Key:         l.tag(controller, "key"),
+			Burst:       l.intTag(controller, "burst"),
+			Concurrency: l.intTag(controller, "concurrency"),
+		}
+		if r, err := l.newRule(name, limit); err == nil {
+			l.controllers[name] = r
+		} else {
+			log.Errorf("at.RateLimit of %v: %v", name, err)
+		}
+	}
+}
+
+// tag returns the tag of the embedded at.RateLimit, the property placeholders are resolved
+func (l *limiter) tag(controller interface{}, name string) string {
+	value, _ := reflector.FindEmbeddedFieldTag(controller, "RateLimit", name)
+	return fmt.Sprintf("%v", l.instantiateFactory.Replace(value))
+}
+
+func (l *limiter) intTag(controller interface{}, name string) (retVal int) {
+	if value := l.tag(controller, name); value != "" {
+		retVal, _ = strconv.Atoi(value)
+	}
+	return
+}
+
+// newRule parses the limit with the defaults of the properties
+func (l *limiter) newRule(id string, limit Limit) (r *rule, err error) {
+	if limit.Algorithm == "" {
+		limit.Algorithm = l.properties.Algorithm
+	}
+	if limit.Key == "" {
+		limit.Key = l.properties.Key
+	}
+	r = &rule{id: id, limit: limit, concurrency: limit.Concurrency}
+	if limit.Rate == "" {
+		return
+	}
+	r.policy = &Policy{Algorithm: limit.Algorithm, Burst: limit.Burst}
+	r.policy.Limit, r.policy.Period, err = ParseRate(limit.Rate)
+	if r.policy.Burst <= 0 {
+		r.policy.Burst = r.policy.Limit
+	}
+	return
+}
+
+// match returns the rule of the request, the first matched limit of the properties,
+// or the annotation of the controller of the route
+func (l *limiter) match(ctx context.Context) *rule {
+	method := ctx.Method()
+	p := ctx.Path()
+	for _, r := range l.rules {
+		if (r.limit.Method == "" || strings.EqualFold(r.limit.Method, method)) && pathmatch.Match(r.limit.Path, p) {
+			return r
+		}
+	}
+	if route := ctx.GetCurrentRoute(); route != nil {
+		name := route.MainHandlerName()
+		if i := strings.LastIndex(name, "."); i > 0 {
+			return l.controllers[name[:i]]
+		}
+	}
+	return nil
+}
+
+// handler responds 429 if the request exceeds the rate limit or the concurrency limit of the matched rule
+func (l *limiter) handler(ctx context.Context) {
+	r := l.match(ctx)
+	if r == nil {
+		ctx.Next()
+		return
+	}
+
+	if r.concurrency > 0 {
+		key := "ratelimit:concurrency:" + r.id + ":" + l.routeKey(ctx)
+		acquired, err := l.store.Acquire(key, r.concurrency)
+		if err != nil {
+			// fail open, the service is still available if the store is not
+			log.Warnf("failed to acquire the concurrency limit: %v", err)
+		} else if !acquired {
+			ctx.Header(HeaderRetryAfter, "1")
+			ctx.ResponseError(http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		} else {
+			defer func() {
+				if err := l.store.Release(key); err != nil {
+					log.Warnf("failed to release the concurrency limit: %v", err)
+				}
+			}()
+		}
+	}
+
+	if r.policy != nil {
+		key := "ratelimit:" + r.id + ":" + l.key(ctx, r.limit.Key)
+		result, err := l.store.Take(key, r.policy)
+		if err != nil {
+			log.Warnf("failed to take the rate limit: %v", err)
+		} else {
+			if l.properties.Headers {
+				ctx.Header(HeaderLimit, strconv.Itoa(result.Limit))
+				ctx.Header(HeaderRemaining, strconv.Itoa(result.Remaining))
+				ctx.Header(HeaderReset, strconv.Itoa(seconds(result.Reset)))
+			}
+			if !result.Allowed {
+				ctx.Header(HeaderRetryAfter, strconv.Itoa(seconds(result.RetryAfter)))
+				ctx.ResponseError(http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+		}
+	}
+
+	ctx.Next()
+}
+
+// key returns the key that the requests are counted by
+func (l *limiter) key(ctx context.Context, key string) string {
+	switch key {
+	case RouteKey:
+		return RouteKey + ":" + l.routeKey(ctx)
+	case SubjectKey:
+		if subject := l.subject(ctx); subject != "" {
+			return SubjectKey + ":" + subject
+		}
+	case APIKeyKey:
+		if apiKey := ctx.GetHeader(l.properties.APIKeyHeader); apiKey != "" {
+			// the api key is hashed so that it is not kept in the store
+			sum := sha256.Sum256([]byte(apiKey))
+			return APIKeyKey + ":" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return IPKey + ":" + ctx.RemoteAddr()
+}
+
+// routeKey returns the route template of the request, or the path if there is no route
+func (l *limiter) routeKey(ctx context.Context) string {
+	if route := ctx.GetCurrentRoute(); route != nil {
+		return route.Method() + " " + route.Path()
+	}
+	return ctx.Method() + " " + ctx.Path()
+}
+
+// subject returns the subject claim of the verified jwt token, the unverified token is not trusted
+func (l *limiter) subject(ctx context.Context) string {
+	token, _ := ctx.Values().Get(jwtKey).(*jwtgo.Token)
+	if token == nil && l.token != nil {
+		header := ctx.GetHeader("Authorization")
+		if len(header) > len(bearerPrefix) && strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+			token, _ = l.token.Parse(strings.TrimSpace(header[len(bearerPrefix):]))
+		}
+	}
+	if token != nil {
+		if claims, ok := token.Claims.(jwtgo.MapClaims); ok && token.Valid {
+			if subject, ok := claims[l.properties.SubjectClaim]; ok {
+				return fmt.Sprintf("%v", subject)
+			}
+		}
+	}
+	return ""
+}
+
+// ParseRate parses the rate in the form of count/period, e.g. 100/1m, 10/s, or 1000/1h
+func ParseRate(rate string) (limit int, period time.Duration, err error) {
+	parts := strings.Split(strings.TrimSpace(rate), "/")
+	if len(parts) != 2 {
+		err = fmt.Errorf("%v: %v", ErrInvalidRate, rate)
+		return
+	}
+	limit, err = strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err == nil {
+		p := strings.TrimSpace(parts[1])
+		if p != "" && (p[0] < '0' || p[0] > '9') {
+			p = "1" + p
+		}
+		period, err = time.ParseDuration(p)
+	}
+	if err != nil || limit <= 0 || period <= 0 {
+		err = fmt.Errorf("%v: %v", ErrInvalidRate, rate)
+	}
+	return
+}
+
+// seconds returns the seconds of the duration rounded up
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
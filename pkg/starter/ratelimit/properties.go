@@ -0,0 +1,61 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+// Limit is the rate limit of the requests whose method and path match, e.g.
+//
+//	ratelimit:
+//	  limits:
+//	  - path: /orders/**
+//	    rate: 100/1m
+//	    key: subject
+//	  - method: POST
+//	    path: /login
+//	    rate: 5/1m
+//	    algorithm: sliding-window
+type Limit struct {
+	// the http method, any method if it is empty
+	Method string `json:"method"`
+	// the path pattern, e.g. /orders/*, or /orders/** for /orders and all of its sub paths
+	Path string `json:"path"`
+	// the number of requests per period, e.g. 100/1m, 10/s or 1000/1h, no rate limit if it is empty
+	Rate string `json:"rate"`
+	// the capacity of the token bucket, it is the number of requests of the rate by default
+	Burst int `json:"burst"`
+	// token-bucket or sliding-window, ratelimit.algorithm by default
+	Algorithm string `json:"algorithm"`
+	// the key that the requests are counted by, ip, subject, apikey or route, ratelimit.key by default
+	Key string `json:"key"`
+	// the maximum number of the in-flight requests of the route, no limit if it is 0
+	Concurrency int `json:"concurrency"`
+}
+
+// Properties is the properties of rate limit
+type Properties struct {
+	// enable the rate limit
+	Enabled bool `json:"enabled" default:"true"`
+	// the default algorithm, token-bucket or sliding-window
+	Algorithm string `json:"algorithm" default:"token-bucket"`
+	// the default key, ip, subject, apikey or route
+	Key string `json:"key" default:"ip"`
+	// the claim of the jwt token that the subject key is resolved from
+	SubjectClaim string `json:"subject_claim" mapstructure:"subjectClaim" default:"${jwt.principalClaim:username}"`
+	// the header that the apikey key is resolved from
+	APIKeyHeader string `json:"api_key_header" mapstructure:"apiKeyHeader" default:"X-API-Key"`
+	// send the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers
+	Headers bool `json:"headers" default:"true"`
+	// the limits by route, the first matched limit is applied, it takes precedence over the at.RateLimit annotation
+	Limits []Limit `json:"limits"`
+}
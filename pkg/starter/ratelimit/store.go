@@ -0,0 +1,245 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// TokenBucket is the algorithm that refills the bucket of burst tokens at the rate, each request takes a token
+	TokenBucket = "token-bucket"
+	// SlidingWindow is the algorithm that counts the requests of the sliding period,
+	// the count of the previous window is weighted by its overlap with the sliding period
+	SlidingWindow = "sliding-window"
+
+	pruneInterval = time.Minute
+)
+
+// Policy is the parsed rate limit of a route
+type Policy struct {
+	// TokenBucket or SlidingWindow
+	Algorithm string
+	// the number of requests per period
+	Limit int
+	// the period of the rate
+	Period time.Duration
+	// the capacity of the token bucket
+	Burst int
+}
+
+// Result is the result of taking a request from the rate limit
+type Result struct {
+	// the request is allowed
+	Allowed bool
+	// the maximum number of requests
+	Limit int
+	// the number of requests that are still allowed
+	Remaining int
+	// the duration until the rate limit is fully reset
+	Reset time.Duration
+	// the duration until the next request is allowed if it is not allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the rate limit state by key, a shared backend implements it to limit the requests across the instances,
+// the component that embeds at.RateLimitStore and implements Store replaces the in-memory store
+type Store interface {
+	// Take takes a request from the rate limit of the key by the policy
+	Take(key string, policy *Policy) (*Result, error)
+	// Acquire acquires an in-flight request of the key, it returns false if there are max in-flight requests already
+	Acquire(key string, max int) (bool, error)
+	// Release releases the in-flight request of the key acquired by Acquire
+	Release(key string) error
+}
+
+type bucket struct {
+	// the tokens of the token bucket
+	tokens float64
+	// the time of the last refill, or the start of the current window
+	last time.Time
+	// the counts of the current and the previous window
+	current  int
+	previous int
+
+	expiresAt time.Time
+}
+
+// MemoryStore is the in-memory Store, the state is not shared across the instances
+type MemoryStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	inFlight  map[string]int
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore is the constructor of MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]*bucket),
+		inFlight: make(map[string]int),
+		now:      time.Now,
+	}
+}
+
+// Take takes a request from the rate limit of the key by the policy
+func (s *MemoryStore) Take(key string, policy *Policy) (result *Result, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	s.prune(now)
+	b, ok := s.buckets[key]
+	if !ok || now.After(b.expiresAt) {
+		b = &bucket{tokens: float64(policy.Burst), last: now}
+		s.buckets[key] = b
+	}
+	if policy.Algorithm == SlidingWindow {
+		result = b.slidingWindow(policy, now)
+	} else {
+		result = b.tokenBucket(policy, now)
+	}
+	return
+}
+
+// Acquire acquires an in-flight request of the key
+func (s *MemoryStore) Acquire(key string, max int) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.inFlight[key] >= max {
+		return false, nil
+	}
+	s.inFlight[key]++
+	return true, nil
+}
+
+// Release releases the in-flight request of the key
+func (s *MemoryStore) Release(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.inFlight[key] <= 1 {
+		delete(s.inFlight, key)
+	} else {
+		s.inFlight[key]--
+	}
+	return nil
+}
+
+// prune removes the expired buckets once in a while
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+	for key, b := range s.buckets {
+		if now.After(b.expiresAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// tokenBucket refills the tokens since the last request and takes one
+func (b *bucket) tokenBucket(policy *Policy, now time.Time) *Result {
+	rate := float64(policy.Limit) / float64(policy.Period)
+	capacity := float64(policy.Burst)
+	b.tokens = math.Min(capacity, b.tokens+float64(now.Sub(b.last))*rate)
+	b.last = now
+
+	result := &Result{Limit: policy.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - b.tokens) / rate))
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration(math.Ceil((capacity - b.tokens) / rate))
+	// the bucket that is full again is the same as a new one
+	b.expiresAt = now.Add(result.Reset)
+	return result
+}
+
+// slidingWindow counts the request in the current window if the weighted count of the sliding period is under the limit
+func (b *bucket) slidingWindow(policy *Policy, now time.Time) *Result {
+	elapsed := now.Sub(b.last)
+	if elapsed >= policy.Period {
+		windows := elapsed / policy.Period
+		if windows == 1 {
+			b.previous = b.current
+		} else {
+			b.previous = 0
+		}
+		b.current = 0
+		b.last = b.last.Add(windows * policy.Period)
+		elapsed -= windows * policy.Period
+	}
+
+	weight := 1 - float64(elapsed)/float64(policy.Period)
+	count := float64(b.previous)*weight + float64(b.current)
+
+	result := &Result{Limit: policy.Limit, Reset: policy.Period - elapsed}
+	if count+1 <= float64(policy.Limit) {
+		b.current++
+		count++
+		result.Allowed = true
+	} else if b.current+1 > policy.Limit || b.previous == 0 {
+		// the current window is full, wait for the next one
+		result.RetryAfter = policy.Period - elapsed
+	} else {
+		// wait until the weight of the previous window decreases enough
+		w := (float64(policy.Limit-b.current) - 1) / float64(b.previous)
+		result.RetryAfter = time.Duration((1-w)*float64(policy.Period)) - elapsed
+	}
+	result.Remaining = int(math.Max(0, float64(policy.Limit)-count))
+	// the counts are all expired two periods after the current window
+	b.expiresAt = b.last.Add(2 * policy.Period)
+	return result
+}
+
+// componentStore takes the component that embeds at.RateLimitStore, or the in-memory store if there is none
+type componentStore struct {
+	instantiateFactory factory.InstantiateFactory
+	once               sync.Once
+	store              Store
+}
+
+func (s *componentStore) load() {
+	if !factory.FindInstance(s.instantiateFactory, new(at.RateLimitStore), &s.store) {
+		s.store = NewMemoryStore()
+	}
+}
+
+// Take takes a request from the rate limit of the key by the policy
+func (s *componentStore) Take(key string, policy *Policy) (*Result, error) {
+	s.once.Do(s.load)
+	return s.store.Take(key, policy)
+}
+
+// Acquire acquires an in-flight request of the key
+func (s *componentStore) Acquire(key string, max int) (bool, error) {
+	s.once.Do(s.load)
+	return s.store.Acquire(key, max)
+}
+
+// Release releases the in-flight request of the key
+func (s *componentStore) Release(key string) error {
+	s.once.Do(s.load)
+	return s.store.Release(key)
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newTestStore() (*MemoryStore, *time.Time) {
+	now := time.Unix(1500000000, 0)
+	s := NewMemoryStore()
+	s.now = func() time.Time {
+		return now
+	}
+	return s, &now
+}
+
+func TestTokenBucket(t *testing.T) {
+	s, now := newTestStore()
+	policy := &Policy{Algorithm: TokenBucket, Limit: 10, Period: 10 * time.Second, Burst: 3}
+
+	t.Run("should allow the burst", func(t *testing.T) {
+		for i := 2; i >= 0; i-- {
+			res, err := s.Take("k", policy)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, true, res.Allowed)
+			assert.Equal(t, i, res.Remaining)
+			assert.Equal(t, 3, res.Limit)
+		}
+	})
+
+	t.Run("should deny the request of the empty bucket", func(t *testing.T) {
+		res, err := s.Take("k", policy)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, res.Allowed)
+		assert.Equal(t, time.Second, res.RetryAfter)
+		assert.Equal(t, 3*time.Second, res.Reset)
+	})
+
+	t.Run("should keep the buckets by key", func(t *testing.T) {
+		res, _ := s.Take("other", policy)
+		assert.Equal(t, true, res.Allowed)
+	})
+
+	t.Run("should refill the tokens at the rate", func(t *testing.T) {
+		*now = now.Add(time.Second)
+		res, _ := s.Take("k", policy)
+		assert.Equal(t, true, res.Allowed)
+		res, _ = s.Take("k", policy)
+		assert.Equal(t, false, res.Allowed)
+
+		*now = now.Add(time.Minute)
+		res, _ = s.Take("k", policy)
+		assert.Equal(t, true, res.Allowed)
+		assert.Equal(t, 2, res.Remaining)
+	})
+}
+
+func TestSlidingWindow(t *testing.T) {
+	s, now := newTestStore()
+	policy := &Policy{Algorithm: SlidingWindow, Limit: 4, Period: 10 * time.Second}
+
+	t.Run("should allow the limit of the window", func(t *testing.T) {
+		for i := 3; i >= 0; i-- {
+			res, _ := s.Take("k", policy)
+			assert.Equal(t, true, res.Allowed)
+			assert.Equal(t, i, res.Remaining)
+		}
+		res, _ := s.Take("k", policy)
+		assert.Equal(t, false, res.Allowed)
+		assert.Equal(t, 10*time.Second, res.RetryAfter)
+	})
+
+	t.Run("should weight the count of the previous window", func(t *testing.T) {
+		// 25% into the next window, the previous window counts 4 * 0.75 = 3
+		*now = now.Add(12500 * time.Millisecond)
+		res, _ := s.Take("k", policy)
+		assert.Equal(t, true, res.Allowed)
+		assert.Equal(t, 0, res.Remaining)
+
+		res, _ = s.Take("k", policy)
+		assert.Equal(t, false, res.Allowed)
+		// the previous window counts 4 * 0.5 = 2 when 50% into the window
+		assert.Equal(t, 2500*time.Millisecond, res.RetryAfter)
+	})
+
+	t.Run("should reset after two periods", func(t *testing.T) {
+		*now = now.Add(30 * time.Second)
+		res, _ := s.Take("k", policy)
+		assert.Equal(t, true, res.Allowed)
+		assert.Equal(t, 3, res.Remaining)
+	})
+}
+
+func TestConcurrency(t *testing.T) {
+	s, _ := newTestStore()
+
+	ok, _ := s.Acquire("k", 2)
+	assert.Equal(t, true, ok)
+	ok, _ = s.Acquire("k", 2)
+	assert.Equal(t, true, ok)
+	ok, _ = s.Acquire("k", 2)
+	assert.Equal(t, false, ok)
+
+	assert.Equal(t, nil, s.Release("k"))
+	ok, _ = s.Acquire("k", 2)
+	assert.Equal(t, true, ok)
+}
+
+func TestParseRate(t *testing.T) {
+	testCases := []struct {
+		rate   string
+		limit  int
+		period time.Duration
+	}{
+		{"100/1m", 100, time.Minute},
+		{"10/s", 10, time.Second},
+		{" 5 / 30s ", 5, 30 * time.Second},
+	}
+	for _, testCase := range testCases {
+		t.Run("should parse "+testCase.rate, func(t *testing.T) {
+			limit, period, err := ParseRate(testCase.rate)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, testCase.limit, limit)
+			assert.Equal(t, testCase.period, period)
+		})
+	}
+
+	t.Run("should report invalid rate", func(t *testing.T) {
+		for _, rate := range []string{"", "100", "a/1m", "0/1m", "10/x"} {
+			_, _, err := ParseRate(rate)
+			assert.Contains(t, err.Error(), ErrInvalidRate.Error())
+		}
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"crypto/subtle"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/app/web/context"
+)
+
+const (
+	// APIKeyAuthenticatorName is the name of the api key authenticator
+	APIKeyAuthenticatorName = "apikey"
+)
+
+// APIKeyAuthenticator authenticates the request by the api key header, e.g. X-API-Key
+type APIKeyAuthenticator struct {
+	properties *APIKeyProperties
+}
+
+// newAPIKeyAuthenticator is the constructor of APIKeyAuthenticator
+func newAPIKeyAuthenticator(properties *APIKeyProperties) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{properties: properties}
+}
+
+// Name returns apikey
+func (a *APIKeyAuthenticator) Name() string {
+	return APIKeyAuthenticatorName
+}
+
+// Authenticate returns the principal of the api key, nil if the header is absent
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context) (principal *Principal, err error) {
+	key := ctx.GetHeader(a.properties.Header)
+	if key == "" {
+		return
+	}
+	for i := range a.properties.Keys {
+		k := &a.properties.Keys[i]
+		if k.Key != "" && subtle.ConstantTimeCompare([]byte(k.Key), []byte(key)) == 1 {
+			principal = newPrincipal(&k.Credential)
+			return
+		}
+	}
+	err = ErrInvalidCredentials
+	return
+}
+
+// newPrincipal returns the principal of the credential
+func newPrincipal(credential *Credential) *Principal {
+	return &Principal{
+		Principal: web.Principal{
+			Name:        credential.Name,
+			Roles:       credential.Roles,
+			Permissions: credential.Permissions,
+		},
+	}
+}
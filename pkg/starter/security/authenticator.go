@@ -0,0 +1,199 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"errors"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/pathmatch"
+	"net/http"
+	"strings"
+)
+
+const (
+	// PrincipalKey is the key of the authenticated principal in the values of the context
+	PrincipalKey = "security.principal"
+
+	bearerPrefix = "Bearer "
+)
+
+var (
+	// ErrInvalidCredentials the credentials are present but are not accepted
+	ErrInvalidCredentials = errors.New("[security] invalid credentials")
+	// ErrUnauthorized the request requires authentication but no authenticator applies
+	ErrUnauthorized = errors.New("[security] unauthorized")
+)
+
+// Authenticator authenticates the request, the components that embed at.Authenticator and implement it
+// are added to the chain by their names, e.g.
+//
+//	type tokenAuthenticator struct {
+//		at.Authenticator
+//	}
+//
+//	func (a *tokenAuthenticator) Name() string { return "token" }
+type Authenticator interface {
+	// Name is the name of the authenticator in security.authenticators and security.paths
+	Name() string
+	// Authenticate returns the principal of the request, or nil principal and nil error if the request does not
+	// carry the credentials of the authenticator, so that the next authenticator is tried,
+	// the error is responded as 401 if the credentials are present but invalid
+	Authenticate(ctx context.Context) (*Principal, error)
+}
+
+// Principal is the authenticated principal of the request, it can be injected into the controller method, e.g.
+//
+//	func (c *orderController) Get(principal *security.Principal) string {
+//		return principal.Name
+//	}
+//
+// the principal is anonymous if the request is not authenticated
+type Principal struct {
+	at.ContextAware
+	web.Principal
+
+	// the name of the authenticator that authenticated the request
+	Authenticator string `json:"authenticator"`
+	// the attributes of the principal, e.g. the claims of the token
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Authenticated returns true if the principal is authenticated by an authenticator
+func (p *Principal) Authenticated() bool {
+	return p != nil && p.Authenticator != ""
+}
+
+// GetPrincipal returns the principal authenticated by the security middleware, or nil
+func GetPrincipal(ctx context.Context) *Principal {
+	principal, _ := ctx.Values().Get(PrincipalKey).(*Principal)
+	return principal
+}
+
+// PrincipalProvider provides the principal authenticated by the security middleware
+// for at.RequiresRoles and at.RequiresPermissions
+type PrincipalProvider struct {
+	at.PrincipalProvider
+}
+
+// GetPrincipal returns the authenticated principal, nil if the request is not authenticated
+func (p *PrincipalProvider) GetPrincipal(ctx context.Context) *web.Principal {
+	if principal := GetPrincipal(ctx); principal.Authenticated() {
+		return &principal.Principal
+	}
+	return nil
+}
+
+// chain selects and runs the authenticators of the request
+type chain struct {
+	properties         *Properties
+	builtIn            []Authenticator
+	instantiateFactory factory.InstantiateFactory
+
+	authenticators map[string]Authenticator
+}
+
+// load loads the built-in authenticators and the components that embed at.Authenticator by their names
+func (c *chain) load() {
+	c.authenticators = make(map[string]Authenticator)
+	for _, a := range c.builtIn {
+		c.authenticators[a.Name()] = a
+	}
+	if c.instantiateFactory == nil {
+		return
+	}
+	for _, md := range c.instantiateFactory.GetInstances(new(at.Authenticator)) {
+		if a, ok := factory.CastMetaData(md).Instance.(Authenticator); ok {
+			c.authenticators[a.Name()] = a
+		}
+	}
+}
+
+// rule returns the authenticators and whether the anonymous request is allowed for the path
+func (c *chain) rule(p string) (names []string, anonymous bool) {
+	for _, rule := range c.properties.Paths {
+		if pathmatch.Match(rule.Pattern, p) {
+			return rule.Authenticators, rule.Anonymous
+		}
+	}
+	return c.properties.Authenticators, c.properties.Anonymous
+}
+
+// authenticate returns the principal of the first authenticator that applies, or an error if the credentials
+// are invalid or the request is not authenticated but the anonymous request is not allowed
+func (c *chain) authenticate(ctx context.Context) (principal *Principal, err error) {
+	names, anonymous := c.rule(ctx.Path())
+	for _, name := range names {
+		a, ok := c.authenticators[strings.TrimSpace(name)]
+		if !ok {
+			log.Debugf("authenticator %v is not found", name)
+			continue
+		}
+		principal, err = a.Authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if principal != nil {
+			principal.Authenticator = a.Name()
+			return
+		}
+	}
+	if !anonymous {
+		err = ErrUnauthorized
+	}
+	return
+}
+
+// handler authenticates the request and keeps the principal in the values of the context,
+// it responds 401 if the authentication fails
+func (c *chain) handler(ctx context.Context) {
+	principal, err := c.authenticate(ctx)
+	if err != nil {
+		log.Debugf("authentication of %v failed: %v", ctx.Path(), err)
+		if c.challenge(ctx) {
+			ctx.Header("WWW-Authenticate", `Basic realm="`+c.properties.Basic.Realm+`"`)
+		}
+		ctx.ResponseError(err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if principal == nil {
+		principal = &Principal{}
+	}
+	ctx.Values().Set(PrincipalKey, principal)
+	ctx.Next()
+}
+
+// challenge returns true if http basic authentication applies to the request
+func (c *chain) challenge(ctx context.Context) bool {
+	names, _ := c.rule(ctx.Path())
+	for _, name := range names {
+		if strings.TrimSpace(name) == BasicAuthenticatorName {
+			return true
+		}
+	}
+	return false
+}
+
+// bearerToken returns the bearer token of the authorization header
+func bearerToken(ctx context.Context) (token string, ok bool) {
+	header := ctx.GetHeader("Authorization")
+	if len(header) > len(bearerPrefix) && strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+		return strings.TrimSpace(header[len(bearerPrefix):]), true
+	}
+	return
+}
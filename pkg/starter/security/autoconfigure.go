@@ -0,0 +1,164 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package security provides the hiboot starter for request authentication by a chain of authenticators,
+// the built-in authenticators are jwt, oauth2 token introspection, api key and http basic,
+// the components that embed at.Authenticator are added to the chain as well, e.g.
+//
+//	security:
+//	  authenticators: [jwt, oauth2]
+//	  paths:
+//	  - pattern: /internal/**
+//	    authenticators: [apikey]
+//	  - pattern: /legacy/**
+//	    authenticators: [basic]
+//	  - pattern: /public/**
+//	    anonymous: true
+//
+// the authenticated principal can be injected into the controller method as *security.Principal,
+// and it is provided for at.RequiresRoles and at.RequiresPermissions.
+//
+// The password encoder of security.password and the secret cipher of security.secrets are injectable as well.
+package security
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+	"hidevops.io/hiboot/pkg/utils/crypto/aes"
+	"hidevops.io/hiboot/pkg/utils/crypto/password"
+	"hidevops.io/hiboot/pkg/utils/validator"
+)
+
+const (
+	// Profile is the profile of security, it should be as same as the package name
+	Profile = "security"
+)
+
+type configuration struct {
+	at.AutoConfiguration
+
+	Properties         Properties `mapstructure:"security"`
+	applicationContext app.ApplicationContext
+	instantiateFactory factory.InstantiateFactory
+	chain              *chain
+}
+
+func newConfiguration(applicationContext app.ApplicationContext, instantiateFactory factory.InstantiateFactory) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+		instantiateFactory: instantiateFactory,
+	}
+}
+
+func newSecretsProperties() *SecretsProperties {
+	return &SecretsProperties{}
+}
+
+func init() {
+	validator.Validate.RegisterStructValidation(validateSecrets, SecretsProperties{})
+	app.Register(newConfiguration)
+	app.Register(newSecretsProperties)
+}
+
+// APIKeyAuthenticator is the authenticator of the api keys of security.apiKey.keys
+func (c *configuration) APIKeyAuthenticator() *APIKeyAuthenticator {
+	return newAPIKeyAuthenticator(&c.Properties.APIKey)
+}
+
+// BasicAuthenticator is the authenticator of the users of security.basic.users
+func (c *configuration) BasicAuthenticator(passwordEncoder password.PasswordEncoder) *BasicAuthenticator {
+	return newBasicAuthenticator(&c.Properties.Basic, passwordEncoder)
+}
+
+// OAuth2Authenticator is the authenticator of the opaque tokens introspected by security.oauth2.introspectionUrl
+func (c *configuration) OAuth2Authenticator() *OAuth2Authenticator {
+	return newOAuth2Authenticator(&c.Properties.OAuth2)
+}
+
+// JwtAuthenticator is the authenticator of the jwt tokens of the jwt starter
+func (c *configuration) JwtAuthenticator(token jwt.Token, principalProvider *jwt.PrincipalProvider) *JwtAuthenticator {
+	return newJwtAuthenticator(token, principalProvider)
+}
+
+// Handler is the middleware that authenticates the request by the authenticators selected by the path,
+// it responds 401 if the credentials are invalid or the path does not allow the anonymous request
+func (c *configuration) Handler(apiKeyAuthenticator *APIKeyAuthenticator,
+	basicAuthenticator *BasicAuthenticator,
+	oAuth2Authenticator *OAuth2Authenticator,
+	jwtAuthenticator *JwtAuthenticator) context.Handler {
+	c.chain = &chain{
+		properties:         &c.Properties,
+		builtIn:            []Authenticator{jwtAuthenticator, oAuth2Authenticator, apiKeyAuthenticator, basicAuthenticator},
+		instantiateFactory: c.instantiateFactory,
+	}
+	handler := c.chain.handler
+
+	c.applicationContext.Use(handler)
+
+	return handler
+}
+
+// Principal is the principal of the request, it is anonymous if the request is not authenticated
+func (c *configuration) Principal(ctx context.Context) *Principal {
+	if principal := GetPrincipal(ctx); principal != nil {
+		return principal
+	}
+	return &Principal{}
+}
+
+// PasswordEncoder is the delegating password encoder that encodes the passwords by security.password.encoder,
+// and matches the {id} prefixed hashes of bcrypt, scrypt, argon2id, and the legacy md5 and noop
+func (c *configuration) PasswordEncoder() password.PasswordEncoder {
+	p := &c.Properties.Password
+	encoders := map[string]password.PasswordEncoder{
+		password.BCrypt:   password.NewBCryptPasswordEncoder(p.BCrypt.Cost),
+		password.SCrypt:   password.NewSCryptPasswordEncoder(p.SCrypt.N, p.SCrypt.R, p.SCrypt.P),
+		password.Argon2id: password.NewArgon2PasswordEncoder(uint32(p.Argon2.Time), uint32(p.Argon2.Memory), uint8(p.Argon2.Threads)),
+		password.MD5:      new(password.MD5PasswordEncoder),
+		password.Noop:     new(password.NoopPasswordEncoder),
+	}
+	switch p.Encoder {
+	case password.BCrypt, password.SCrypt, password.Argon2id:
+	default:
+		log.Warnf("security.password.encoder %v is not recommended for the new passwords", p.Encoder)
+	}
+	encoder := password.NewDelegatingPasswordEncoder(p.Encoder, encoders)
+	if p.DefaultForMatches != "" {
+		encoder.SetDefaultForMatches(encoders[p.DefaultForMatches])
+	}
+	return encoder
+}
+
+// SecretCipher is the AES-GCM cipher of the application secrets by the versioned keys of security.secrets.keys,
+// the keys are validated as the properties are bound
+func (c *configuration) SecretCipher(secrets *SecretsProperties) *aes.GCMCipher {
+	keys, err := secrets.decodeKeys()
+	var cipher *aes.GCMCipher
+	if err == nil {
+		cipher, err = aes.NewGCMCipher(secrets.Primary, keys)
+	}
+	if err != nil {
+		log.Errorf("failed to create the secret cipher: %v", err)
+	}
+	return cipher
+}
+
+// PrincipalProvider provides the authenticated principal for at.RequiresRoles and at.RequiresPermissions
+func (c *configuration) PrincipalProvider() *PrincipalProvider {
+	return &PrincipalProvider{}
+}
@@ -0,0 +1,285 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security_test
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+	"hidevops.io/hiboot/pkg/starter/security"
+	"hidevops.io/hiboot/pkg/utils/crypto/aes"
+	"hidevops.io/hiboot/pkg/utils/crypto/password"
+	"hidevops.io/hiboot/pkg/utils/io"
+	"hidevops.io/hiboot/pkg/utils/validator"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+type tenantAuthenticator struct {
+	at.Authenticator
+}
+
+func newTenantAuthenticator() *tenantAuthenticator {
+	return &tenantAuthenticator{}
+}
+
+func (a *tenantAuthenticator) Name() string {
+	return "tenant"
+}
+
+func (a *tenantAuthenticator) Authenticate(ctx context.Context) (principal *security.Principal, err error) {
+	if tenant := ctx.GetHeader("X-Tenant"); tenant != "" {
+		principal = &security.Principal{}
+		principal.Name = tenant
+	}
+	return
+}
+
+type principalController struct {
+	at.RestController
+}
+
+func newPrincipalController() *principalController {
+	return &principalController{}
+}
+
+func (c *principalController) Get(principal *security.Principal) string {
+	if !principal.Authenticated() {
+		return "anonymous"
+	}
+	return principal.Name + "@" + principal.Authenticator
+}
+
+type internalController struct {
+	at.RestController
+}
+
+func newInternalController() *internalController {
+	return &internalController{}
+}
+
+func (c *internalController) Get(principal *security.Principal) string {
+	return principal.Name + "@" + principal.Authenticator
+}
+
+type adminController struct {
+	at.RestController
+	at.RequiresRoles `value:"admin"`
+}
+
+func newAdminController() *adminController {
+	return &adminController{}
+}
+
+func (c *adminController) Get(principal *security.Principal) string {
+	return principal.Name
+}
+
+func init() {
+	log.SetLevel(log.DebugLevel)
+	io.EnsureWorkDir(1, "config/application.yml")
+	app.Register(newTenantAuthenticator)
+}
+
+func newIntrospectionServer(calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok || clientID != "gateway" || clientSecret != "s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		res := map[string]interface{}{"active": false}
+		if r.PostFormValue("token") == "opaque-token" {
+			res = map[string]interface{}{
+				"active":   true,
+				"username": "johndoe",
+				"scope":    "order:read order:write",
+				"roles":    []string{"admin"},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	}))
+}
+
+func TestAuthenticators(t *testing.T) {
+	var calls int32
+	server := newIntrospectionServer(&calls)
+	defer server.Close()
+
+	testApp := web.NewTestApp(newPrincipalController, newInternalController, newAdminController).
+		SetProperty("security.apiKey.keys", []map[string]interface{}{
+			{"key": "order-key", "name": "order-service", "roles": []string{"service"}},
+		}).
+		SetProperty("security.basic.users", []map[string]interface{}{
+			{"name": "legacy", "password": "legacy-password", "roles": []string{"admin"}},
+		}).
+		SetProperty("security.password.defaultForMatches", password.Noop).
+		SetProperty("security.oauth2.introspectionUrl", server.URL).
+		SetProperty("security.oauth2.clientId", "gateway").
+		SetProperty("security.oauth2.clientSecret", "s3cr3t").
+		SetProperty("security.paths", []map[string]interface{}{
+			{"pattern": "/internal/**", "authenticators": []string{"apikey", "tenant"}},
+			{"pattern": "/admin/**", "authenticators": []string{"basic", "oauth2"}},
+		}).
+		Run(t)
+
+	bearer, err := jwt.GenerateTestToken(testApp.(app.ApplicationContext), "jwt-user", []string{"user"})
+	assert.Equal(t, nil, err)
+
+	t.Run("should allow the anonymous request of the unmatched path", func(t *testing.T) {
+		testApp.Get("/principal").Expect().Status(http.StatusOK).Body().Equal("anonymous")
+	})
+
+	t.Run("should authenticate by jwt", func(t *testing.T) {
+		testApp.Get("/principal").WithHeader("Authorization", bearer).
+			Expect().Status(http.StatusOK).Body().Equal("jwt-user@jwt")
+	})
+
+	t.Run("should authenticate the opaque token by oauth2 introspection", func(t *testing.T) {
+		testApp.Get("/principal").WithHeader("Authorization", "Bearer opaque-token").
+			Expect().Status(http.StatusOK).Body().Equal("johndoe@oauth2")
+		testApp.Get("/principal").WithHeader("Authorization", "Bearer opaque-token").
+			Expect().Status(http.StatusOK).Body().Equal("johndoe@oauth2")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("should respond 401 for the invalid credentials", func(t *testing.T) {
+		testApp.Get("/principal").WithHeader("Authorization", "Bearer inactive-token").
+			Expect().Status(http.StatusUnauthorized)
+		testApp.Get("/principal").WithHeader("Authorization", "Bearer a.b.c").
+			Expect().Status(http.StatusUnauthorized)
+		testApp.Get("/principal").WithHeader("X-API-Key", "unknown-key").
+			Expect().Status(http.StatusUnauthorized)
+	})
+
+	t.Run("should select the authenticators by path", func(t *testing.T) {
+		testApp.Get("/internal").WithHeader("X-API-Key", "order-key").
+			Expect().Status(http.StatusOK).Body().Equal("order-service@apikey")
+		testApp.Get("/internal").WithHeader("X-Tenant", "acme").
+			Expect().Status(http.StatusOK).Body().Equal("acme@tenant")
+		testApp.Get("/internal").WithHeader("Authorization", bearer).
+			Expect().Status(http.StatusUnauthorized)
+		testApp.Get("/internal").Expect().Status(http.StatusUnauthorized)
+	})
+
+	t.Run("should authenticate by http basic", func(t *testing.T) {
+		testApp.Get("/admin").WithBasicAuth("legacy", "legacy-password").
+			Expect().Status(http.StatusOK).Body().Equal("legacy")
+		testApp.Get("/admin").WithBasicAuth("legacy", "wrong-password").
+			Expect().Status(http.StatusUnauthorized).
+			Header("WWW-Authenticate").Contains("Basic realm=")
+	})
+
+	t.Run("should authorize the principal of any authenticator", func(t *testing.T) {
+		testApp.Get("/admin").WithHeader("Authorization", "Bearer opaque-token").
+			Expect().Status(http.StatusOK).Body().Equal("johndoe")
+		testApp.Get("/principal").WithHeader("Authorization", bearer).
+			Expect().Status(http.StatusOK)
+	})
+}
+
+func TestPasswordEncoderAndSecretCipher(t *testing.T) {
+	hash, err := password.NewBCryptPasswordEncoder(4).Encode("legacy-password")
+	assert.Equal(t, nil, err)
+
+	testApp := web.NewTestApp(newAdminController).
+		SetProperty("security.basic.users", []map[string]interface{}{
+			{"name": "legacy", "password": "{bcrypt}" + hash, "roles": []string{"admin"}},
+			{"name": "plain", "password": "plain-password", "roles": []string{"admin"}},
+		}).
+		SetProperty("security.password.encoder", password.Argon2id).
+		SetProperty("security.password.argon2.memory", 1024).
+		SetProperty("security.secrets.primary", "v2").
+		SetProperty("security.secrets.keys.v1", "MDEyMzQ1Njc4OWFiY2RlZg==").
+		SetProperty("security.secrets.keys.v2", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=").
+		Run(t)
+	appContext := testApp.(app.ApplicationContext)
+
+	t.Run("should match the hashed password of http basic", func(t *testing.T) {
+		testApp.Get("/admin").WithBasicAuth("legacy", "legacy-password").
+			Expect().Status(http.StatusOK).Body().Equal("legacy")
+		testApp.Get("/admin").WithBasicAuth("legacy", "{bcrypt}"+hash).
+			Expect().Status(http.StatusUnauthorized)
+	})
+
+	t.Run("should not match the plain password unless noop is the default for matches", func(t *testing.T) {
+		testApp.Get("/admin").WithBasicAuth("plain", "plain-password").
+			Expect().Status(http.StatusUnauthorized)
+	})
+
+	t.Run("should inject the password encoder", func(t *testing.T) {
+		encoder, ok := appContext.GetInstance("password.passwordEncoder").(password.PasswordEncoder)
+		assert.Equal(t, true, ok)
+		encoded, err := encoder.Encode("s3cr3t")
+		assert.Equal(t, nil, err)
+		assert.Contains(t, encoded, "{argon2id}$argon2id$v=19$m=1024,t=1,p=4$")
+		assert.Equal(t, true, encoder.Matches("s3cr3t", encoded))
+		assert.Equal(t, true, encoder.UpgradeEncoding("{bcrypt}"+hash))
+	})
+
+	t.Run("should inject the secret cipher", func(t *testing.T) {
+		cipher, ok := appContext.GetInstance(aes.GCMCipher{}).(*aes.GCMCipher)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "v2", cipher.Primary())
+		secret, err := cipher.EncryptString("s3cr3t")
+		assert.Equal(t, nil, err)
+		plain, err := cipher.DecryptString(secret)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "s3cr3t", plain)
+	})
+}
+
+func TestSecretsProperties(t *testing.T) {
+	t.Run("should validate the secret keys", func(t *testing.T) {
+		err := validator.Validate.Struct(&security.SecretsProperties{
+			Primary: "v1",
+			Keys:    map[string]string{"v1": "MDEyMzQ1Njc4OWFiY2RlZg=="},
+		})
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("should report the key that is not base64 encoded", func(t *testing.T) {
+		err := validator.Validate.Struct(&security.SecretsProperties{
+			Primary: "v1",
+			Keys:    map[string]string{"v1": "not base64"},
+		})
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should report the key of invalid size", func(t *testing.T) {
+		err := validator.Validate.Struct(&security.SecretsProperties{
+			Primary: "v1",
+			Keys:    map[string]string{"v1": "MDEyMzQ1Njc4OQ=="},
+		})
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should report the primary key that is not found", func(t *testing.T) {
+		err := validator.Validate.Struct(&security.SecretsProperties{
+			Primary: "v2",
+			Keys:    map[string]string{"v1": "MDEyMzQ1Njc4OWFiY2RlZg=="},
+		})
+		assert.NotEqual(t, nil, err)
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"crypto/subtle"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/utils/crypto/password"
+)
+
+const (
+	// BasicAuthenticatorName is the name of the http basic authenticator
+	BasicAuthenticatorName = "basic"
+
+	dummyPassword = "dummy-password"
+)
+
+// BasicAuthenticator authenticates the request by http basic authentication for the legacy clients
+type BasicAuthenticator struct {
+	properties      *BasicProperties
+	passwordEncoder password.PasswordEncoder
+	// the hash that the password of the unknown user is matched against, so that the username is not revealed by timing
+	dummyPassword string
+}
+
+// newBasicAuthenticator is the constructor of BasicAuthenticator
+func newBasicAuthenticator(properties *BasicProperties, passwordEncoder password.PasswordEncoder) *BasicAuthenticator {
+	a := &BasicAuthenticator{properties: properties, passwordEncoder: passwordEncoder}
+	if passwordEncoder != nil && len(properties.Users) != 0 {
+		a.dummyPassword, _ = passwordEncoder.Encode(dummyPassword)
+	}
+	return a
+}
+
+// Name returns basic
+func (a *BasicAuthenticator) Name() string {
+	return BasicAuthenticatorName
+}
+
+// Authenticate returns the principal of the user, nil if the request does not carry basic credentials
+func (a *BasicAuthenticator) Authenticate(ctx context.Context) (principal *Principal, err error) {
+	username, rawPassword, ok := ctx.Request().BasicAuth()
+	if !ok {
+		return
+	}
+	var user *User
+	for i := range a.properties.Users {
+		if subtle.ConstantTimeCompare([]byte(a.properties.Users[i].Name), []byte(username)) == 1 && user == nil {
+			user = &a.properties.Users[i]
+		}
+	}
+	// the password of the unknown user is matched against the dummy hash as well, it takes the same time
+	if user == nil {
+		a.matches(rawPassword, a.dummyPassword)
+	} else if a.matches(rawPassword, user.Password) {
+		principal = newPrincipal(&user.Credential)
+		return
+	}
+	err = ErrInvalidCredentials
+	return
+}
+
+// matches matches the {id} prefixed hash by the password encoder, the hash without the prefix never matches
+// unless security.password.defaultForMatches is set, e.g. noop for the plain passwords
+func (a *BasicAuthenticator) matches(rawPassword, encodedPassword string) bool {
+	return a.passwordEncoder != nil && a.passwordEncoder.Matches(rawPassword, encodedPassword)
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"github.com/kataras/iris"
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/utils/crypto/password"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// countingEncoder counts the hashes that are matched
+type countingEncoder struct {
+	password.PasswordEncoder
+	matched []string
+}
+
+func (e *countingEncoder) Matches(rawPassword, encodedPassword string) bool {
+	e.matched = append(e.matched, encodedPassword)
+	return e.PasswordEncoder.Matches(rawPassword, encodedPassword)
+}
+
+func TestBasicAuthenticator(t *testing.T) {
+	bcrypt := password.NewBCryptPasswordEncoder(4)
+	hash, err := bcrypt.Encode("s3cr3t")
+	assert.Equal(t, nil, err)
+	encoder := &countingEncoder{PasswordEncoder: bcrypt}
+	authenticator := newBasicAuthenticator(&BasicProperties{Users: []User{
+		{Credential: Credential{Name: "johndoe"}, Password: hash},
+	}}, encoder)
+
+	authenticate := func(username, rawPassword string) (*Principal, error) {
+		ctx := web.NewContext(iris.New())
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(username, rawPassword)
+		ctx.BeginRequest(httptest.NewRecorder(), req)
+		return authenticator.Authenticate(ctx)
+	}
+
+	t.Run("should authenticate the user", func(t *testing.T) {
+		encoder.matched = nil
+		principal, err := authenticate("johndoe", "s3cr3t")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "johndoe", principal.Name)
+		assert.Equal(t, []string{hash}, encoder.matched)
+	})
+
+	t.Run("should match the password of the unknown user against the dummy hash", func(t *testing.T) {
+		encoder.matched = nil
+		principal, err := authenticate("unknown", "s3cr3t")
+		assert.Equal(t, ErrInvalidCredentials, err)
+		assert.Nil(t, principal)
+		assert.Equal(t, 1, len(encoder.matched))
+		assert.NotEqual(t, "", encoder.matched[0])
+	})
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+	"strings"
+)
+
+const (
+	// JwtAuthenticatorName is the name of the jwt authenticator
+	JwtAuthenticatorName = "jwt"
+
+	jwtKey = "jwt"
+)
+
+// JwtAuthenticator authenticates the bearer jwt token signed by the keys of the jwt starter,
+// the opaque bearer token is left to the next authenticator, e.g. oauth2
+type JwtAuthenticator struct {
+	token             jwt.Token
+	principalProvider *jwt.PrincipalProvider
+}
+
+// newJwtAuthenticator is the constructor of JwtAuthenticator
+func newJwtAuthenticator(token jwt.Token, principalProvider *jwt.PrincipalProvider) *JwtAuthenticator {
+	return &JwtAuthenticator{token: token, principalProvider: principalProvider}
+}
+
+// Name returns jwt
+func (a *JwtAuthenticator) Name() string {
+	return JwtAuthenticatorName
+}
+
+// Authenticate returns the principal of the claims of the bearer jwt token, nil if the bearer token is absent
+// or it is not a jwt, the verified token is kept in the context so that jwt.TokenProperties works as well
+func (a *JwtAuthenticator) Authenticate(ctx context.Context) (principal *Principal, err error) {
+	tokenString, ok := bearerToken(ctx)
+	if !ok || strings.Count(tokenString, ".") != 2 || a.token == nil {
+		return
+	}
+	token, e := a.token.Parse(tokenString)
+	if e != nil {
+		log.Debugf("jwt authentication failed: %v", e)
+		err = ErrInvalidCredentials
+		return
+	}
+	ctx.Values().Set(jwtKey, token)
+
+	principal = &Principal{}
+	if p := a.principalProvider.GetPrincipal(ctx); p != nil {
+		principal.Principal = *p
+	}
+	if claims, ok := token.Claims.(jwtgo.MapClaims); ok {
+		principal.Attributes = claims
+	}
+	return
+}
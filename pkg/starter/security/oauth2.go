@@ -0,0 +1,172 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/starter/httpclient"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// OAuth2AuthenticatorName is the name of the oauth2 token introspection authenticator
+	OAuth2AuthenticatorName = "oauth2"
+
+	introspectionActive = "active"
+	introspectionScope  = "scope"
+	introspectionExpiry = "exp"
+)
+
+// OAuth2Authenticator authenticates the opaque bearer token by the introspection endpoint of the identity server,
+// the active tokens are cached for security.oauth2.cacheTTL but not beyond their expiry
+type OAuth2Authenticator struct {
+	properties *OAuth2Properties
+	client     httpclient.Client
+
+	mutex sync.Mutex
+	cache map[string]*introspection
+}
+
+type introspection struct {
+	principal *Principal
+	expiresAt time.Time
+}
+
+// newOAuth2Authenticator is the constructor of OAuth2Authenticator
+func newOAuth2Authenticator(properties *OAuth2Properties) *OAuth2Authenticator {
+	return &OAuth2Authenticator{
+		properties: properties,
+		client:     httpclient.NewClient(httpclient.WithHTTPTimeout(properties.Timeout)),
+		cache:      make(map[string]*introspection),
+	}
+}
+
+// Name returns oauth2
+func (a *OAuth2Authenticator) Name() string {
+	return OAuth2AuthenticatorName
+}
+
+// Authenticate returns the principal of the active bearer token, nil if the bearer token is absent
+// or the introspection endpoint is not configured
+func (a *OAuth2Authenticator) Authenticate(ctx context.Context) (principal *Principal, err error) {
+	token, ok := bearerToken(ctx)
+	if !ok || a.properties.IntrospectionURL == "" {
+		return
+	}
+	if principal = a.cached(token); principal != nil {
+		return
+	}
+
+	var claims map[string]interface{}
+	claims, err = a.introspect(ctx, token)
+	if err != nil {
+		log.Debugf("oauth2 token introspection failed: %v", err)
+		err = ErrInvalidCredentials
+		return
+	}
+	if active, _ := claims[introspectionActive].(bool); !active {
+		err = ErrInvalidCredentials
+		return
+	}
+
+	principal = &Principal{
+		Principal: web.Principal{
+			Roles:       jwt.StringsClaim(claims[a.properties.RolesClaim]),
+			Permissions: append(jwt.StringsClaim(claims[a.properties.PermissionsClaim]), jwt.StringsClaim(claims[introspectionScope])...),
+		},
+		Attributes: claims,
+	}
+	if name, ok := claims[a.properties.PrincipalClaim]; ok {
+		principal.Name = fmt.Sprintf("%v", name)
+	}
+	a.store(token, principal, claims)
+	return
+}
+
+// introspect posts the token to the introspection endpoint and returns the introspection response
+func (a *OAuth2Authenticator) introspect(ctx context.Context, token string) (claims map[string]interface{}, err error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/x-www-form-urlencoded")
+	headers.Set("Accept", "application/json")
+
+	var resp *http.Response
+	resp, err = a.client.Post(a.properties.IntrospectionURL, strings.NewReader(form.Encode()), headers,
+		httpclient.WithContext(ctx.Request().Context()),
+		func(req *http.Request) {
+			if a.properties.ClientID != "" {
+				req.SetBasicAuth(url.QueryEscape(a.properties.ClientID), url.QueryEscape(a.properties.ClientSecret))
+			}
+		})
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status %v", resp.StatusCode)
+		return
+	}
+	err = json.NewDecoder(resp.Body).Decode(&claims)
+	return
+}
+
+// cached returns the copy of the cached principal of the token, nil if it is not cached or expired
+func (a *OAuth2Authenticator) cached(token string) *Principal {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	entry, ok := a.cache[token]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(a.cache, token)
+		return nil
+	}
+	principal := *entry.principal
+	return &principal
+}
+
+// store caches the principal until the cache ttl or the expiry of the token, whichever comes first
+func (a *OAuth2Authenticator) store(token string, principal *Principal, claims map[string]interface{}) {
+	if a.properties.CacheTTL <= 0 {
+		return
+	}
+	expiresAt := time.Now().Add(a.properties.CacheTTL)
+	if exp, ok := claims[introspectionExpiry].(float64); ok {
+		if expiry := time.Unix(int64(exp), 0); expiry.Before(expiresAt) {
+			expiresAt = expiry
+		}
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	now := time.Now()
+	for t, entry := range a.cache {
+		if now.After(entry.expiresAt) {
+			delete(a.cache, t)
+		}
+	}
+	cached := *principal
+	a.cache[token] = &introspection{principal: &cached, expiresAt: expiresAt}
+}
@@ -0,0 +1,45 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/factory"
+)
+
+// postProcessor loads the authenticator chain, as the components that embed at.Authenticator are all instantiated then
+type postProcessor struct {
+	configurableFactory factory.ConfigurableFactory
+}
+
+func init() {
+	app.RegisterPostProcessor(newPostProcessor)
+}
+
+func newPostProcessor(configurableFactory factory.ConfigurableFactory) *postProcessor {
+	return &postProcessor{
+		configurableFactory: configurableFactory,
+	}
+}
+
+// AfterInitialization loads the authenticators of the chain
+func (p *postProcessor) AfterInitialization() {
+	if p.configurableFactory == nil {
+		return
+	}
+	if c, ok := p.configurableFactory.Configuration(Profile).(*configuration); ok && c.chain != nil {
+		c.chain.load()
+	}
+}
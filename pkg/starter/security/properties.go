@@ -0,0 +1,192 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"encoding/base64"
+	"fmt"
+	"gopkg.in/go-playground/validator.v8"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/crypto/aes"
+	"reflect"
+	"time"
+)
+
+// Credential is the credential of an api key or a user of http basic authentication
+type Credential struct {
+	// the name of the principal, it is the username of http basic authentication
+	Name string `json:"name"`
+	// the roles granted to the principal
+	Roles []string `json:"roles"`
+	// the permissions granted to the principal
+	Permissions []string `json:"permissions"`
+}
+
+// APIKey is the api key of a client, e.g. security.apiKey.keys: [{key: ${ORDER_API_KEY}, name: order-service}]
+type APIKey struct {
+	Credential `mapstructure:",squash"`
+	// the api key
+	Key string `json:"-"`
+}
+
+// APIKeyProperties is the properties of api key authentication
+type APIKeyProperties struct {
+	// the header that carries the api key
+	Header string `json:"header" default:"X-API-Key"`
+	// the accepted api keys
+	Keys []APIKey `json:"keys"`
+}
+
+// User is the user of http basic authentication
+type User struct {
+	Credential `mapstructure:",squash"`
+	// the password of the user, the {id} prefixed hash is matched by the password encoder, e.g. {bcrypt}$2a$10$...,
+	// the password without the prefix is matched by security.password.defaultForMatches only
+	Password string `json:"-"`
+}
+
+// BasicProperties is the properties of http basic authentication
+type BasicProperties struct {
+	// the realm of the WWW-Authenticate challenge
+	Realm string `json:"realm" default:"${app.name}"`
+	// the accepted users
+	Users []User `json:"users"`
+}
+
+// OAuth2Properties is the properties of oauth2 token introspection (RFC 7662)
+type OAuth2Properties struct {
+	// the introspection endpoint of the identity server, the oauth2 authenticator is skipped if it is empty
+	IntrospectionURL string `json:"introspection_url" mapstructure:"introspectionUrl"`
+	// the client id to authenticate to the introspection endpoint
+	ClientID string `json:"client_id" mapstructure:"clientId"`
+	// the client secret to authenticate to the introspection endpoint
+	ClientSecret string `json:"-" mapstructure:"clientSecret"`
+	// the claim of the principal name in the introspection response
+	PrincipalClaim string `json:"principal_claim" mapstructure:"principalClaim" default:"username"`
+	// the claim of the roles in the introspection response
+	RolesClaim string `json:"roles_claim" mapstructure:"rolesClaim" default:"roles"`
+	// the claim of the permissions in the introspection response, the scopes are granted as permissions as well
+	PermissionsClaim string `json:"permissions_claim" mapstructure:"permissionsClaim" default:"permissions"`
+	// the time to live of the cached introspection result of an active token, 0 for no cache
+	CacheTTL time.Duration `json:"cache_ttl" mapstructure:"cacheTTL" default:"1m"`
+	// the timeout of the introspection request
+	Timeout time.Duration `json:"timeout" default:"5s"`
+}
+
+// BCryptProperties is the cost parameters of bcrypt
+type BCryptProperties struct {
+	// the cost in [4, 31]
+	Cost int `json:"cost" default:"10"`
+}
+
+// SCryptProperties is the cost parameters of scrypt
+type SCryptProperties struct {
+	// the cpu/memory cost, it must be a power of 2
+	N int `json:"n" default:"32768"`
+	// the block size
+	R int `json:"r" default:"8"`
+	// the parallelization
+	P int `json:"p" default:"1"`
+}
+
+// Argon2Properties is the cost parameters of argon2id
+type Argon2Properties struct {
+	// the number of passes over the memory
+	Time int `json:"time" default:"1"`
+	// the memory in KiB
+	Memory int `json:"memory" default:"65536"`
+	// the degree of parallelism
+	Threads int `json:"threads" default:"4"`
+}
+
+// PasswordProperties is the properties of the password encoder
+type PasswordProperties struct {
+	// the id of the encoder that encodes the new passwords, bcrypt, scrypt or argon2id
+	Encoder string `json:"encoder" default:"bcrypt"`
+	// the id of the encoder that matches the hashes without the {id} prefix, e.g. md5 for the legacy hashes,
+	// or noop for the plain passwords, the hashes without the prefix never match if it is empty
+	DefaultForMatches string `json:"default_for_matches" mapstructure:"defaultForMatches"`
+
+	BCrypt BCryptProperties `json:"bcrypt"`
+	SCrypt SCryptProperties `json:"scrypt"`
+	Argon2 Argon2Properties `json:"argon2"`
+}
+
+// SecretsProperties is the properties of the authenticated encryption of the application secrets,
+// the application fails to start if the keys are invalid
+type SecretsProperties struct {
+	at.ConfigurationProperties `value:"security.secrets"`
+
+	// the version of the key that encrypts the new secrets
+	Primary string `json:"primary"`
+	// the base64 encoded AES keys of 16, 24 or 32 bytes by version, e.g. v1: ${SECRETS_KEY_V1}
+	Keys map[string]string `json:"-"`
+}
+
+// decodeKeys returns the AES keys by version
+func (p *SecretsProperties) decodeKeys() (keys map[string][]byte, err error) {
+	keys = make(map[string][]byte)
+	for version, key := range p.Keys {
+		var b []byte
+		b, err = base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("security.secrets.keys.%v is not base64 encoded: %v", version, err)
+		}
+		keys[version] = b
+	}
+	return
+}
+
+// validateSecrets reports the keys that can not make the cipher, e.g. the key of invalid size or the primary key
+// that is not found, so that the properties fail to bind
+func validateSecrets(v *validator.Validate, structLevel *validator.StructLevel) {
+	p := structLevel.CurrentStruct.Interface().(SecretsProperties)
+	keys, err := p.decodeKeys()
+	if err == nil {
+		_, err = aes.NewGCMCipher(p.Primary, keys)
+	}
+	if err != nil {
+		log.Errorf("invalid security.secrets: %v", err)
+		structLevel.ReportError(reflect.ValueOf(p.Keys), "Keys", "keys", "secrets")
+	}
+}
+
+// PathRule selects the authenticators of the requests whose path matches the pattern
+type PathRule struct {
+	// the path pattern, e.g. /orders/*, or /internal/** for /internal and all of its sub paths
+	Pattern string `json:"pattern"`
+	// the names of the authenticators that are tried in order
+	Authenticators []string `json:"authenticators"`
+	// allow the request without credentials, the principal is resolved if the credentials are present
+	Anonymous bool `json:"anonymous"`
+}
+
+// Properties is the properties of security
+type Properties struct {
+	// the names of the authenticators that are tried in order for the paths that do not match any rule,
+	// the components that embed at.Authenticator are named by their Name method
+	Authenticators []string `json:"authenticators" default:"jwt,oauth2,apikey,basic"`
+	// allow the request without credentials for the paths that do not match any rule
+	Anonymous bool `json:"anonymous" default:"true"`
+	// the path rules, the first matched rule is applied
+	Paths []PathRule `json:"paths"`
+
+	APIKey APIKeyProperties `json:"api_key" mapstructure:"apiKey"`
+	Basic  BasicProperties  `json:"basic"`
+	OAuth2 OAuth2Properties `json:"oauth2"`
+
+	Password PasswordProperties `json:"password"`
+}
@@ -0,0 +1,158 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing provides the hiboot starter for distributed tracing with W3C trace context propagation,
+// a server span is started for each dispatched route, and the spans are propagated through httpclient,
+// grpc clients and servers automatically
+package tracing
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/starter/httpclient"
+	"net/http"
+	"sync"
+)
+
+const (
+	// Profile is the profile of tracing, it should be as same as the package name
+	Profile = "tracing"
+
+	// LogExporterName is the name of the built-in exporter that writes the spans to the log
+	LogExporterName = "log"
+	// InMemoryExporterName is the name of the built-in exporter that keeps the spans in memory
+	InMemoryExporterName = "memory"
+
+	// TraceIDKey is the log field name of the trace id
+	TraceIDKey = "trace_id"
+	// SpanIDKey is the log field name of the span id
+	SpanIDKey = "span_id"
+)
+
+type configuration struct {
+	at.AutoConfiguration
+
+	Properties         Properties `mapstructure:"tracing"`
+	applicationContext app.ApplicationContext
+	instantiateFactory factory.InstantiateFactory
+}
+
+func newConfiguration(applicationContext app.ApplicationContext, instantiateFactory factory.InstantiateFactory) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+		instantiateFactory: instantiateFactory,
+	}
+}
+
+func init() {
+	app.Register(newConfiguration)
+}
+
+// InMemoryExporter is the exporter that keeps the spans in memory if it is enabled by tracing.exporters
+func (c *configuration) InMemoryExporter() *InMemoryExporter {
+	return NewInMemoryExporter()
+}
+
+// Tracer is the tracer that can be injected to start custom spans
+func (c *configuration) Tracer(inMemoryExporter *InMemoryExporter) *Tracer {
+	tracer := NewTracer(c.Properties.ServiceName, c.Properties.SampleRatio)
+	for _, name := range c.Properties.Exporters {
+		switch name {
+		case LogExporterName:
+			tracer.AddExporter(new(LogExporter))
+		case InMemoryExporterName:
+			tracer.AddExporter(inMemoryExporter)
+		case "":
+		default:
+			log.Warnf("unknown tracing exporter %v", name)
+		}
+	}
+	// the exporter components may be instantiated after the tracer, so they are looked up at the first export
+	tracer.AddExporter(&componentExporter{instantiateFactory: c.instantiateFactory})
+	return tracer
+}
+
+// ServerHandler is the middleware that starts a server span for each dispatched route,
+// the parent span is extracted from the traceparent header, the span is carried by the context of the request,
+// and the trace id and span id are written with the log lines by log.WithContext(ctx.Request().Context())
+func (c *configuration) ServerHandler(tracer *Tracer) context.Handler {
+	handler := func(ctx context.Context) {
+		request := ctx.Request()
+		route := request.URL.Path
+		if currentRoute := ctx.GetCurrentRoute(); currentRoute != nil {
+			route = currentRoute.Path()
+		}
+
+		spanCtx := Extract(request.Context(), HeaderCarrier(request.Header))
+		spanCtx, span := tracer.Start(spanCtx, request.Method+" "+route, SpanKindServer)
+		span.SetAttribute("http.method", request.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.target", request.URL.RequestURI())
+		spanCtx = log.NewContext(spanCtx, log.Fields{
+			TraceIDKey: span.SpanContext.TraceID.String(),
+			SpanIDKey:  span.SpanContext.SpanID.String(),
+		})
+		// the request is replaced in place as the handlers hold the same *http.Request
+		*request = *request.WithContext(spanCtx)
+
+		ctx.Next()
+
+		status := ctx.GetStatusCode()
+		span.SetAttribute("http.status_code", status)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(StatusError, http.StatusText(status))
+		}
+		span.End()
+	}
+
+	c.applicationContext.Use(handler)
+
+	return handler
+}
+
+// ClientObserver is the httpclient observer that starts a client span for each request of the clients of the application
+func (c *configuration) ClientObserver(tracer *Tracer, observers *httpclient.Observers) *ClientObserver {
+	observer := newClientObserver(tracer)
+	observers.Add(observer)
+	return observer
+}
+
+// componentExporter exports the spans to the components that embed at.TraceExporter and implement Exporter
+type componentExporter struct {
+	instantiateFactory factory.InstantiateFactory
+	once               sync.Once
+	exporters          []Exporter
+}
+
+func (e *componentExporter) load() {
+	if e.instantiateFactory == nil {
+		return
+	}
+	for _, md := range e.instantiateFactory.GetInstances(new(at.TraceExporter)) {
+		if exporter, ok := factory.CastMetaData(md).Instance.(Exporter); ok {
+			e.exporters = append(e.exporters, exporter)
+		}
+	}
+}
+
+// Export exports the span to the exporter components
+func (e *componentExporter) Export(span *Span) {
+	e.once.Do(e.load)
+	for _, exporter := range e.exporters {
+		exporter.Export(span)
+	}
+}
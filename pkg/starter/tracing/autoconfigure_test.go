@@ -0,0 +1,158 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web"
+	webctx "hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/starter/httpclient"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fooController struct {
+	at.RestController
+
+	url    string
+	client httpclient.Client
+}
+
+func newFooController(client httpclient.Client) *fooController {
+	return &fooController{client: client}
+}
+
+func (c *fooController) GetByName(name string, ctx webctx.Context) string {
+	resp, err := c.client.Get(c.url, nil, httpclient.WithContext(ctx.Request().Context()))
+	if err == nil {
+		resp.Body.Close()
+	}
+	return "hello " + name
+}
+
+type fakeExporter struct {
+	at.TraceExporter
+
+	names []string
+}
+
+func newFakeExporter() *fakeExporter {
+	return &fakeExporter{}
+}
+
+func (e *fakeExporter) Export(span *Span) {
+	e.names = append(e.names, span.Name)
+}
+
+func TestTracing(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get(TraceparentHeader)
+	}))
+	defer server.Close()
+
+	app.Register(newFooController, newFakeExporter)
+	testApp := web.NewTestApp().
+		SetProperty("tracing.exporters", "log,memory").
+		Run(t)
+	applicationContext := testApp.(app.ApplicationContext)
+	applicationContext.GetInstance(fooController{}).(*fooController).url = server.URL
+	exporter := applicationContext.GetInstance(InMemoryExporter{}).(*InMemoryExporter)
+
+	t.Run("should trace the route and the httpclient request", func(t *testing.T) {
+		exporter.Reset()
+		testApp.Get("/foo/name/{name}").WithPath("name", "bar").
+			WithHeader(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01").
+			Expect().Status(http.StatusOK)
+
+		spans := exporter.Spans()
+		assert.Equal(t, 2, len(spans))
+		if len(spans) == 2 {
+			client, server := spans[0], spans[1]
+			assert.Equal(t, "GET /foo/name/{name}", server.Name)
+			assert.Equal(t, SpanKindServer, server.Kind)
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID.String())
+			assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID.String())
+			assert.Equal(t, http.StatusOK, server.Attributes["http.status_code"])
+			assert.NotEqual(t, "", server.ServiceName)
+			assert.NotContains(t, server.ServiceName, "${")
+
+			assert.Equal(t, "HTTP GET", client.Name)
+			assert.Equal(t, SpanKindClient, client.Kind)
+			assert.Equal(t, server.SpanContext.SpanID, client.Parent.SpanID)
+			assert.Equal(t, client.SpanContext.Traceparent(), traceparent)
+		}
+	})
+
+	t.Run("should start a new trace without traceparent", func(t *testing.T) {
+		exporter.Reset()
+		testApp.Get("/foo/name/{name}").WithPath("name", "baz").Expect().Status(http.StatusOK)
+
+		spans := exporter.Spans()
+		assert.Equal(t, 2, len(spans))
+		if len(spans) == 2 {
+			assert.Equal(t, false, spans[1].Parent.IsValid())
+			assert.Equal(t, spans[1].SpanContext.TraceID, spans[0].SpanContext.TraceID)
+		}
+	})
+
+	t.Run("should export to exporter components", func(t *testing.T) {
+		fake := applicationContext.GetInstance(fakeExporter{}).(*fakeExporter)
+		assert.Contains(t, fake.names, "GET /foo/name/{name}")
+	})
+}
+
+func TestGrpcInterceptor(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	interceptor := newGrpcInterceptor(NewTracer("test", 1, exporter))
+
+	t.Run("should pass span from client to server by metadata", func(t *testing.T) {
+		var outgoing metadata.MD
+		err := interceptor.UnaryClientInterceptor(context.Background(), "/helloworld.Greeter/SayHello", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				outgoing, _ = metadata.FromOutgoingContext(ctx)
+				return nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(outgoing.Get(TraceparentHeader)))
+
+		info := &grpc.UnaryServerInfo{FullMethod: "/helloworld.Greeter/SayHello"}
+		incoming := metadata.NewIncomingContext(context.Background(), outgoing)
+		_, err = interceptor.UnaryServerInterceptor(incoming, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			assert.NotEqual(t, nil, SpanFromContext(ctx))
+			return nil, status.Error(codes.NotFound, "not found")
+		})
+		assert.NotEqual(t, nil, err)
+
+		spans := exporter.Spans()
+		assert.Equal(t, 2, len(spans))
+		client, server := spans[0], spans[1]
+		assert.Equal(t, SpanKindClient, client.Kind)
+		assert.Equal(t, StatusUnset, client.Status)
+		assert.Equal(t, SpanKindServer, server.Kind)
+		assert.Equal(t, client.SpanContext.SpanID, server.Parent.SpanID)
+		assert.Equal(t, client.SpanContext.TraceID, server.SpanContext.TraceID)
+		assert.Equal(t, StatusError, server.Status)
+		assert.Equal(t, "NotFound", server.Attributes["rpc.grpc.status_code"])
+	})
+}
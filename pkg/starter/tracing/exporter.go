@@ -0,0 +1,83 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"hidevops.io/hiboot/pkg/log"
+	"sync"
+)
+
+// Exporter exports the sampled spans when they end, the component that embeds at.TraceExporter
+// and implements Exporter is added to the tracer automatically
+type Exporter interface {
+	Export(span *Span)
+}
+
+// LogExporter writes the spans to the log at debug level
+type LogExporter struct{}
+
+// Export writes the span to the log
+func (e *LogExporter) Export(span *Span) {
+	fields := log.Fields{
+		"trace_id": span.SpanContext.TraceID.String(),
+		"span_id":  span.SpanContext.SpanID.String(),
+		"kind":     span.Kind.String(),
+		"service":  span.ServiceName,
+		"duration": span.Duration().String(),
+	}
+	if span.Parent.IsValid() {
+		fields["parent_id"] = span.Parent.SpanID.String()
+	}
+	if span.Status == StatusError {
+		fields["error"] = span.StatusMessage
+	}
+	for k, v := range span.Attributes {
+		fields[k] = v
+	}
+	log.WithFields(fields).Debug(span.Name)
+}
+
+// InMemoryExporter keeps the spans in memory, so that the tests can assert the spans without a collector,
+// it is enabled by tracing.exporters: memory
+type InMemoryExporter struct {
+	mu    sync.RWMutex
+	spans []*Span
+}
+
+// NewInMemoryExporter is the constructor of InMemoryExporter
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// Export keeps the span
+func (e *InMemoryExporter) Export(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans in the order they end
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]*Span{}, e.spans...)
+}
+
+// Reset removes the exported spans
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/at"
+	"io"
+)
+
+// GrpcInterceptor starts a server span for each grpc call with the parent span of the traceparent metadata,
+// and a client span for each grpc call that is passed to the server by the traceparent metadata
+type GrpcInterceptor struct {
+	at.GrpcServerInterceptor
+	at.GrpcClientInterceptor
+
+	tracer *Tracer
+}
+
+func init() {
+	app.Register(newGrpcInterceptor)
+}
+
+func newGrpcInterceptor(tracer *Tracer) *GrpcInterceptor {
+	return &GrpcInterceptor{tracer: tracer}
+}
+
+// metadataCarrier is the Carrier of grpc metadata
+type metadataCarrier metadata.MD
+
+// Get returns the first value of the metadata
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Set sets the metadata
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// UnaryServerInterceptor traces the unary calls on the server
+func (i *GrpcInterceptor) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := i.startServerSpan(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	i.end(span, err)
+	return resp, err
+}
+
+// StreamServerInterceptor traces the stream calls on the server
+func (i *GrpcInterceptor) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := i.startServerSpan(ss.Context(), info.FullMethod)
+	err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	i.end(span, err)
+	return err
+}
+
+// UnaryClientInterceptor traces the unary calls by the client
+func (i *GrpcInterceptor) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, span := i.startClientSpan(ctx, method)
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	i.end(span, err)
+	return err
+}
+
+// StreamClientInterceptor traces the stream calls by the client, the span ends when the stream ends
+func (i *GrpcInterceptor) StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, span := i.startClientSpan(ctx, method)
+	cs, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		i.end(span, err)
+		return nil, err
+	}
+	return &clientStream{ClientStream: cs, span: span, end: i.end}, nil
+}
+
+func (i *GrpcInterceptor) startServerSpan(ctx context.Context, fullMethod string) (context.Context, *Span) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = Extract(ctx, metadataCarrier(md))
+	}
+	ctx, span := i.tracer.Start(ctx, fullMethod, SpanKindServer)
+	span.SetAttribute("rpc.system", "grpc")
+	span.SetAttribute("rpc.method", fullMethod)
+	return ctx, span
+}
+
+func (i *GrpcInterceptor) startClientSpan(ctx context.Context, fullMethod string) (context.Context, *Span) {
+	ctx, span := i.tracer.Start(ctx, fullMethod, SpanKindClient)
+	span.SetAttribute("rpc.system", "grpc")
+	span.SetAttribute("rpc.method", fullMethod)
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md), span
+}
+
+func (i *GrpcInterceptor) end(span *Span, err error) {
+	code := status.Code(err)
+	span.SetAttribute("rpc.grpc.status_code", code.String())
+	span.RecordError(err)
+	span.End()
+}
+
+// serverStream overrides the context of the server stream with the server span
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context with the server span
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// clientStream ends the span once the stream ends, io.EOF is treated as OK
+type clientStream struct {
+	grpc.ClientStream
+
+	span     *Span
+	end      func(span *Span, err error)
+	finished bool
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil && !s.finished {
+		s.finished = true
+		if err == io.EOF {
+			s.end(s.span, nil)
+		} else {
+			s.end(s.span, err)
+		}
+	}
+	return err
+}
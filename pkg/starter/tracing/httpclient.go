@@ -0,0 +1,68 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ClientObserver starts a client span for each httpclient request and passes it to the server by the traceparent header,
+// the span is the child of the span carried by the context of the request
+type ClientObserver struct {
+	tracer *Tracer
+	spans  sync.Map
+}
+
+func newClientObserver(tracer *Tracer) *ClientObserver {
+	return &ClientObserver{tracer: tracer}
+}
+
+// Prepare starts the client span and sets the traceparent header
+func (o *ClientObserver) Prepare(request *http.Request) {
+	ctx, span := o.tracer.Start(request.Context(), "HTTP "+request.Method, SpanKindClient)
+	span.SetAttribute("http.method", request.Method)
+	span.SetAttribute("http.url", request.URL.String())
+	span.SetAttribute("net.peer.name", request.URL.Host)
+	Inject(ctx, HeaderCarrier(request.Header))
+	o.spans.Store(request, span)
+}
+
+// Retried records the retry count of the span
+func (o *ClientObserver) Retried(request *http.Request, attempt int) {
+	if span, ok := o.spans.Load(request); ok {
+		span.(*Span).SetAttribute("http.retry_count", attempt)
+	}
+}
+
+// Done ends the span with the status of the response
+func (o *ClientObserver) Done(request *http.Request, response *http.Response, err error, elapsed time.Duration) {
+	value, ok := o.spans.Load(request)
+	if !ok {
+		return
+	}
+	o.spans.Delete(request)
+
+	span := value.(*Span)
+	if response != nil {
+		span.SetAttribute("http.status_code", response.StatusCode)
+		if response.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(StatusError, response.Status)
+		}
+	}
+	span.RecordError(err)
+	span.End()
+}
@@ -0,0 +1,105 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// TraceparentHeader is the W3C trace context header, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+	TraceparentHeader = "traceparent"
+
+	traceparentVersion = "00"
+	sampledFlag        = 0x01
+)
+
+// ErrInvalidTraceparent the traceparent header is malformed
+var ErrInvalidTraceparent = errors.New("[tracing] invalid traceparent")
+
+// Carrier carries the span context across the process boundary, e.g. http headers or grpc metadata
+type Carrier interface {
+	Get(key string) string
+	Set(key string, value string)
+}
+
+// HeaderCarrier is the Carrier of http headers
+type HeaderCarrier http.Header
+
+// Get returns the first value of the header
+func (c HeaderCarrier) Get(key string) string {
+	return http.Header(c).Get(key)
+}
+
+// Set sets the header
+func (c HeaderCarrier) Set(key string, value string) {
+	http.Header(c).Set(key, value)
+}
+
+// Traceparent formats the span context as the W3C traceparent header
+func (sc SpanContext) Traceparent() string {
+	flags := 0
+	if sc.Sampled {
+		flags |= sampledFlag
+	}
+	return fmt.Sprintf("%v-%v-%v-%02x", traceparentVersion, sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent parses the W3C traceparent header to the remote span context
+func ParseTraceparent(traceparent string) (sc SpanContext, err error) {
+	invalid := fmt.Errorf("%v: %q", ErrInvalidTraceparent, traceparent)
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	// the future versions may append more parts, version ff is forbidden
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == traceparentVersion && len(parts) != 4) ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, invalid
+	}
+
+	_, e1 := hex.Decode(sc.TraceID[:], []byte(parts[1]))
+	_, e2 := hex.Decode(sc.SpanID[:], []byte(parts[2]))
+	flags, e3 := hex.DecodeString(parts[3])
+	if e1 != nil || e2 != nil || e3 != nil || !sc.IsValid() {
+		return SpanContext{}, invalid
+	}
+	sc.Sampled = flags[0]&sampledFlag != 0
+	sc.Remote = true
+	return
+}
+
+// Inject sets the traceparent of the span carried by ctx to the carrier
+func Inject(ctx context.Context, carrier Carrier) {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		carrier.Set(TraceparentHeader, sc.Traceparent())
+	}
+}
+
+// Extract returns a copy of ctx that carries the remote span context of the traceparent of the carrier,
+// ctx is returned as it is if the traceparent is missing or malformed
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	traceparent := carrier.Get(TraceparentHeader)
+	if traceparent == "" {
+		return ctx
+	}
+	sc, err := ParseTraceparent(traceparent)
+	if err != nil {
+		return ctx
+	}
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
@@ -0,0 +1,130 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestPropagation(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	t.Run("should parse traceparent", func(t *testing.T) {
+		sc, err := ParseTraceparent(traceparent)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+		assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+		assert.Equal(t, true, sc.Sampled)
+		assert.Equal(t, true, sc.Remote)
+		assert.Equal(t, traceparent, sc.Traceparent())
+	})
+
+	t.Run("should report invalid traceparent", func(t *testing.T) {
+		for _, tp := range []string{
+			"",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+		} {
+			_, err := ParseTraceparent(tp)
+			assert.Contains(t, err.Error(), ErrInvalidTraceparent.Error())
+		}
+	})
+
+	t.Run("should accept future version with more parts", func(t *testing.T) {
+		sc, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, sc.Sampled)
+	})
+
+	t.Run("should inject and extract span context", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(TraceparentHeader, traceparent)
+		ctx := Extract(context.Background(), HeaderCarrier(header))
+
+		tracer := NewTracer("test", 0)
+		ctx, span := tracer.Start(ctx, "child", SpanKindInternal)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID.String())
+		assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID.String())
+		assert.Equal(t, true, span.SpanContext.Sampled)
+
+		out := http.Header{}
+		Inject(ctx, HeaderCarrier(out))
+		assert.Equal(t, span.SpanContext.Traceparent(), out.Get(TraceparentHeader))
+	})
+
+	t.Run("should ignore malformed traceparent", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(TraceparentHeader, "invalid")
+		ctx := Extract(context.Background(), HeaderCarrier(header))
+		assert.Equal(t, false, SpanContextFromContext(ctx).IsValid())
+
+		out := http.Header{}
+		Inject(ctx, HeaderCarrier(out))
+		assert.Equal(t, "", out.Get(TraceparentHeader))
+	})
+}
+
+func TestTracer(t *testing.T) {
+	exporter := NewInMemoryExporter()
+
+	t.Run("should export sampled spans", func(t *testing.T) {
+		tracer := NewTracer("test", 1, exporter)
+		ctx, parent := tracer.Start(context.Background(), "parent", SpanKindServer)
+		_, child := tracer.Start(ctx, "child", SpanKindInternal)
+		child.SetAttribute("foo", "bar")
+		child.End()
+		child.End()
+		parent.End()
+
+		spans := exporter.Spans()
+		assert.Equal(t, 2, len(spans))
+		assert.Equal(t, "child", spans[0].Name)
+		assert.Equal(t, "bar", spans[0].Attributes["foo"])
+		assert.Equal(t, parent.SpanContext.TraceID, spans[0].SpanContext.TraceID)
+		assert.Equal(t, parent.SpanContext.SpanID, spans[0].Parent.SpanID)
+		assert.Equal(t, "parent", spans[1].Name)
+		assert.Equal(t, false, spans[1].Parent.IsValid())
+		assert.Equal(t, "test", spans[1].ServiceName)
+	})
+
+	t.Run("should not export spans that are not sampled", func(t *testing.T) {
+		exporter.Reset()
+		tracer := NewTracer("test", 0, exporter)
+		ctx, parent := tracer.Start(context.Background(), "parent", SpanKindServer)
+		_, child := tracer.Start(ctx, "child", SpanKindInternal)
+		child.End()
+		parent.End()
+		assert.Equal(t, 0, len(exporter.Spans()))
+		assert.Equal(t, true, child.SpanContext.IsValid())
+	})
+
+	t.Run("should sample by ratio", func(t *testing.T) {
+		tracer := NewTracer("test", 0.5)
+		sampled := 0
+		for i := 0; i < 1000; i++ {
+			if _, span := tracer.Start(context.Background(), "span", SpanKindInternal); span.SpanContext.Sampled {
+				sampled++
+			}
+		}
+		assert.True(t, sampled > 400 && sampled < 600)
+	})
+}
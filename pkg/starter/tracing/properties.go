@@ -0,0 +1,26 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+// Properties is the properties of tracing
+type Properties struct {
+	// the service name of the spans
+	ServiceName string `json:"service_name" mapstructure:"serviceName" default:"${app.name}"`
+	// the ratio of the root spans to be sampled in [0, 1], the span that has a parent follows the decision of its parent
+	SampleRatio float64 `json:"sample_ratio" mapstructure:"sampleRatio" default:"1"`
+	// the built-in exporters, log writes the spans to the log at debug level, memory keeps the spans in InMemoryExporter,
+	// the components that embed at.TraceExporter are added as well
+	Exporters []string `json:"exporters" default:"log"`
+}
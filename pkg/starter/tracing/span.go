@@ -0,0 +1,186 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID is the 16 bytes id of the trace
+type TraceID [16]byte
+
+// String returns the hex string of the trace id
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// IsValid returns true if the trace id is not all zeros
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// SpanID is the 8 bytes id of the span
+type SpanID [8]byte
+
+// String returns the hex string of the span id
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// IsValid returns true if the span id is not all zeros
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// SpanContext is the part of the span that is propagated to the remote services
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+	// Remote is true if the span context is extracted from the incoming request
+	Remote bool
+}
+
+// IsValid returns true if both the trace id and the span id are valid
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// SpanKind is the role of the span in the trace
+type SpanKind int
+
+// Available span kinds
+const (
+	SpanKindInternal SpanKind = iota
+	SpanKindServer
+	SpanKindClient
+)
+
+var spanKindNames = map[SpanKind]string{
+	SpanKindInternal: "internal",
+	SpanKindServer:   "server",
+	SpanKindClient:   "client",
+}
+
+// String returns the name of the span kind
+func (k SpanKind) String() string {
+	return spanKindNames[k]
+}
+
+// StatusCode is the status of the span
+type StatusCode int
+
+// Available status codes
+const (
+	StatusUnset StatusCode = iota
+	StatusOK
+	StatusError
+)
+
+// Span is the unit of work in the trace, it is exported when it ends if it is sampled
+type Span struct {
+	Name          string
+	Kind          SpanKind
+	ServiceName   string
+	SpanContext   SpanContext
+	Parent        SpanContext
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    map[string]interface{}
+	Status        StatusCode
+	StatusMessage string
+
+	mu     sync.Mutex
+	ended  bool
+	tracer *Tracer
+}
+
+// SetAttribute sets the attribute of the span, e.g. http.method
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes[key] = value
+}
+
+// SetStatus sets the status of the span
+func (s *Span) SetStatus(code StatusCode, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Status, s.StatusMessage = code, message
+}
+
+// RecordError sets the error status of the span if err is not nil
+func (s *Span) RecordError(err error) {
+	if err != nil {
+		s.SetStatus(StatusError, err.Error())
+	}
+}
+
+// End ends the span and exports it if it is sampled, the span is ended only once
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+
+	if s.SpanContext.Sampled && s.tracer != nil {
+		s.tracer.export(s)
+	}
+}
+
+// Duration returns the duration of the ended span
+func (s *Span) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+type spanKey struct{}
+
+type remoteSpanContextKey struct{}
+
+// ContextWithSpan returns a copy of ctx that carries the span as the parent of the spans started with it
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span carried by ctx, or nil
+func SpanFromContext(ctx context.Context) (span *Span) {
+	if ctx != nil {
+		span, _ = ctx.Value(spanKey{}).(*Span)
+	}
+	return
+}
+
+// ContextWithRemoteSpanContext returns a copy of ctx that carries the span context extracted from the incoming request
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the span carried by ctx, or the remote span context
+func SpanContextFromContext(ctx context.Context) (sc SpanContext) {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext
+	}
+	if ctx != nil {
+		sc, _ = ctx.Value(remoteSpanContextKey{}).(SpanContext)
+	}
+	return
+}
@@ -0,0 +1,111 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+)
+
+// Tracer starts the spans and exports the sampled spans when they end
+type Tracer struct {
+	serviceName string
+	sampleRatio float64
+
+	mu        sync.RWMutex
+	exporters []Exporter
+}
+
+// NewTracer is the constructor of Tracer, sampleRatio is the ratio of the root spans to be sampled in [0, 1],
+// the span that has a parent is sampled if its parent is sampled
+func NewTracer(serviceName string, sampleRatio float64, exporters ...Exporter) *Tracer {
+	return &Tracer{
+		serviceName: serviceName,
+		sampleRatio: sampleRatio,
+		exporters:   exporters,
+	}
+}
+
+// AddExporter adds the exporter that the sampled spans are exported to
+func (t *Tracer) AddExporter(exporters ...Exporter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exporters = append(t.exporters, exporters...)
+}
+
+// Start starts the span as the child of the span or the remote span context carried by ctx,
+// it returns the copy of ctx that carries the new span
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.sample(sc.TraceID)
+	}
+
+	span := &Span{
+		Name:        name,
+		Kind:        kind,
+		ServiceName: t.serviceName,
+		SpanContext: sc,
+		Parent:      parent,
+		StartTime:   time.Now(),
+		Attributes:  make(map[string]interface{}),
+		tracer:      t,
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// sample decides by the trace id, so that the decision is the same for the same trace
+func (t *Tracer) sample(traceID TraceID) bool {
+	switch {
+	case t.sampleRatio >= 1:
+		return true
+	case t.sampleRatio <= 0:
+		return false
+	}
+	return binary.BigEndian.Uint64(traceID[8:])>>1 < uint64(t.sampleRatio*math.MaxInt64)
+}
+
+func (t *Tracer) export(span *Span) {
+	t.mu.RLock()
+	exporters := t.exporters
+	t.mu.RUnlock()
+	for _, exporter := range exporters {
+		exporter.Export(span)
+	}
+}
+
+func newTraceID() (id TraceID) {
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return
+}
+
+func newSpanID() (id SpanID) {
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return
+}
@@ -16,7 +16,8 @@ package system
 
 // Configuration is the system configuration
 type Configuration struct {
-	App     App     `mapstructure:"app"`
-	Server  Server  `mapstructure:"server"`
-	Logging Logging `mapstructure:"logging"`
+	App        App        `mapstructure:"app"`
+	Server     Server     `mapstructure:"server"`
+	Management Management `mapstructure:"management"`
+	Logging    Logging    `mapstructure:"logging"`
 }
@@ -19,20 +19,31 @@ package system
 import (
 	"bytes"
 	"fmt"
+	props "github.com/magiconair/properties"
+	"github.com/mitchellh/mapstructure"
 	"gopkg.in/yaml.v2"
 	"hidevops.io/hiboot/pkg/log"
 	"hidevops.io/hiboot/pkg/utils/io"
+	"hidevops.io/hiboot/pkg/utils/mapstruct"
 	"hidevops.io/hiboot/pkg/utils/reflector"
 	"hidevops.io/hiboot/pkg/utils/replacer"
 	"hidevops.io/hiboot/pkg/utils/str"
 	"hidevops.io/viper"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 )
 
-// Builder is the config file (yaml, json) builder
+const yamlType = "yaml"
+
+// MergeOrder is the order of the config file formats, the config files of the same name but different formats are
+// all merged in this order, the latter overrides the former, e.g. application.yml overrides application.json
+var MergeOrder = []string{"hcl", "toml", "properties", "props", "prop", "json", "yml", "yaml"}
+
+// Builder is the config file (yaml, yml, json, toml, hcl, properties) builder
 type Builder interface {
 	Init() error
 	Build(profiles ...string) (interface{}, error)
@@ -40,6 +51,7 @@ type Builder interface {
 	Save(p interface{}) error
 	Replace(source string) (retVal interface{})
 	GetProperty(name string) (retVal interface{})
+	GetProperties(prefix string) (retVal map[string]interface{})
 	SetProperty(name string, val interface{}) Builder
 	SetDefaultProperty(name string, val interface{}) Builder
 	SetConfiguration(in interface{})
@@ -53,25 +65,24 @@ type builder struct {
 	configuration    interface{}
 	customProperties map[string]interface{}
 	profiles         []string
+	resolver         replacer.Resolver
+	resolved         map[string]string
+	configFile       string
 }
 
 // NewBuilder is the constructor of system.Builder
 func NewBuilder(configuration interface{}, path, name, fileType string, customProperties map[string]interface{}) Builder {
-	return &builder{
+	b := &builder{
 		Viper:            viper.New(),
 		path:             path,
 		name:             name,
 		fileType:         fileType,
 		configuration:    configuration,
 		customProperties: customProperties,
+		resolved:         make(map[string]string),
 	}
-}
-
-// New create new viper instance
-func (b *builder) config(name string) {
-	b.AddConfigPath(b.path)
-	b.SetConfigName(name)
-	b.SetConfigType(b.fileType)
+	b.resolver = replacer.NewResolver(b.lookup)
+	return b
 }
 
 func (b *builder) SetConfiguration(in interface{}) {
@@ -137,14 +148,68 @@ func (b *builder) BuildWithProfile(profile string) (interface{}, error) {
 	return b.load(name, profile)
 }
 
-// Read single file
-func (b *builder) read(fullName string) {
+// Read and merge all config files of the name in MergeOrder
+func (b *builder) read(name string) {
+	for _, ext := range MergeOrder {
+		file := filepath.Join(b.path, name+"."+ext)
+		if io.IsPathNotExist(file) {
+			continue
+		}
+		settings, err := readFile(file, ext)
+		if err == nil {
+			err = b.MergeConfigMap(settings)
+		}
+		if err != nil {
+			log.Errorf("failed to read %v, err: %v", file, err)
+			continue
+		}
+		// the latest config file of the base name is the one to save
+		if name == b.name {
+			b.configFile = file
+		}
+	}
+}
 
-	// config
-	b.config(fullName)
+// readFile reads the config file of the type into a map, the empty file is read as empty map
+func readFile(file, ext string) (settings map[string]interface{}, err error) {
+	var in []byte
+	in, err = ioutil.ReadFile(file)
+	if err != nil || len(bytes.TrimSpace(in)) == 0 {
+		return
+	}
+	v := viper.New()
+	v.SetConfigType(ext)
+	err = v.ReadConfig(bytes.NewReader(in))
+	if err == nil {
+		settings = v.AllSettings()
+		if ext == "hcl" {
+			settings = flattenBlocks(settings).(map[string]interface{})
+		}
+	}
+	return
+}
 
-	// read config
-	b.MergeInConfig()
+// flattenBlocks converts the hcl blocks, which are decoded as single element list of map, to map,
+// so that they can be merged with the other formats
+func flattenBlocks(in interface{}) interface{} {
+	switch val := in.(type) {
+	case map[string]interface{}:
+		for k, v := range val {
+			val[k] = flattenBlocks(v)
+		}
+	case []map[string]interface{}:
+		if len(val) == 1 {
+			return flattenBlocks(val[0])
+		}
+		for i, v := range val {
+			val[i] = flattenBlocks(v).(map[string]interface{})
+		}
+	case []interface{}:
+		for i, v := range val {
+			val[i] = flattenBlocks(v)
+		}
+	}
+	return in
 }
 
 // Read single file
@@ -170,24 +235,40 @@ func (b *builder) load(fullName, profile string) (interface{}, error) {
 	allKeys := b.AllKeys()
 	for _, key := range allKeys {
 		val := b.GetString(key)
+		// skip the value that is already resolved, so that the escaped $${...} is not resolved again
+		if resolved, ok := b.resolved[key]; ok && resolved == val {
+			continue
+		}
 		if strings.Contains(val, "${") {
-			newVal := b.Replace(val)
+			newVal, literal := b.resolve(val)
+			if literal {
+				b.resolved[key] = newVal.(string)
+			}
 			b.SetConfig(key, newVal)
 			log.Debugf(">>> replaced key: %v, value: %v, newVal: %v", key, val, newVal)
 		}
 	}
 
-	err := b.Unmarshal(conf)
+	// the bare numbers of the durations tagged by unit:"s" are of seconds, e.g. grpc.timeoutSecond: 5
+	err := b.Unmarshal(conf, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstruct.SecondsHook(),
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)))
 	return conf, err
 }
 
-// Save configurations to file
+// Save configurations to the config file in the same format it was read,
+// or in the file type of the builder if there is no config file read yet
 func (b *builder) Save(p interface{}) error {
-
-	b.config(b.name)
+	file := b.configFile
+	if file == "" {
+		file = filepath.Join(b.path, b.name+"."+b.fileType)
+	}
 
 	y, err := yaml.Marshal(p)
 	if err == nil {
+		b.SetConfigType(yamlType)
 		err = b.ReadConfig(bytes.NewBuffer(y))
 		if err != nil {
 			fmt.Printf("err: %v\n", err)
@@ -195,51 +276,69 @@ func (b *builder) Save(p interface{}) error {
 		}
 	}
 
-	return b.WriteConfig()
+	switch strings.TrimPrefix(filepath.Ext(file), ".") {
+	case "properties", "props", "prop":
+		// write properties by ourselves, as the slices are not written by viper
+		return b.writeProperties(file)
+	}
+	return b.WriteConfigAs(file)
 }
 
-// Replace replace reference and
-func (b *builder) Replace(source string) (retVal interface{}) {
-	result := source
-	matches := replacer.GetMatches(source)
-	if len(matches) != 0 {
-		for _, m := range matches {
-			varFullName, varName := m[0], m[1]
-			// check if it contains default value
-			var defaultValue string
-			n := strings.Index(varName, ":")
-			if n > 0 {
-				defaultValue = varName[n+1:]
-				varName = varName[:n]
-				//log.Debugf("name: %v, default value: %v", varName, defaultValue)
-			}
-			prop := b.Get(varName)
-
-			var newVal string
-			if prop != nil {
-				switch prop.(type) {
-				case string:
-					newVal = prop.(string)
-					result = strings.Replace(result, varFullName, newVal, -1)
-				default:
-					retVal = prop
-					return
-				}
+// writeProperties writes all settings as sorted key = value lines, the slices are joined by comma
+func (b *builder) writeProperties(file string) error {
+	p := props.NewProperties()
+	keys := b.AllKeys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		val := b.Get(key)
+		if v := reflect.ValueOf(val); v.Kind() == reflect.Slice {
+			items := make([]string, v.Len())
+			for i := range items {
+				items[i] = fmt.Sprintf("%v", v.Index(i).Interface())
 			}
+			val = strings.Join(items, ",")
+		}
+		if _, _, err := p.Set(key, fmt.Sprintf("%v", val)); err != nil {
+			return err
+		}
+	}
 
-			envValue := os.Getenv(varName)
-			// check if  varName == strings.ToUpper(varName), the assume that varName is environment variable
-			if envValue != "" || (varName == strings.ToUpper(varName) && defaultValue == "") {
-				result = strings.Replace(result, varFullName, envValue, -1)
-			}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = p.Write(f, props.UTF8)
+	return err
+}
 
-			if envValue == "" && newVal == "" && defaultValue != "" {
-				result = strings.Replace(result, varFullName, defaultValue, -1)
-			}
-			log.Debugf("replaced %v to %v", varName, result)
+// Replace resolves the placeholders of source, e.g. ${name:default}, the source is returned as it is if it fails
+func (b *builder) Replace(source string) (retVal interface{}) {
+	retVal, _ = b.resolve(source)
+	return
+}
+
+// resolve resolves the placeholders of source, literal reports if the result keeps the escaped placeholders
+func (b *builder) resolve(source string) (retVal interface{}, literal bool) {
+	retVal, err := b.resolver.Resolve(source)
+	if err != nil {
+		log.Errorf("failed to resolve %v, err: %v", source, err)
+		return source, false
+	}
+	if l, ok := retVal.(replacer.Literal); ok {
+		retVal, literal = string(l), true
+	}
+	return
+}
+
+// lookup returns the property to the resolver, the resolved value that keeps the escaped placeholders is not resolved again
+func (b *builder) lookup(name string) (retVal interface{}) {
+	retVal = b.Get(name)
+	if s, ok := retVal.(string); ok {
+		if resolved, ok := b.resolved[strings.ToLower(name)]; ok && resolved == s {
+			retVal = replacer.Literal(s)
 		}
 	}
-	retVal = result
 	return
 }
 
@@ -248,6 +347,32 @@ func (b *builder) GetProperty(name string) (retVal interface{}) {
 	return
 }
 
+// GetProperties get all properties under the prefix as a nested map, the properties from
+// config files, custom properties and default properties are all merged, all properties are returned if prefix is empty
+func (b *builder) GetProperties(prefix string) (retVal map[string]interface{}) {
+	retVal = make(map[string]interface{})
+	if prefix != "" {
+		prefix = strings.ToLower(prefix) + "."
+	}
+	for _, key := range b.AllKeys() {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		path := strings.Split(key[len(prefix):], ".")
+		m := retVal
+		for _, k := range path[:len(path)-1] {
+			child, ok := m[k].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				m[k] = child
+			}
+			m = child
+		}
+		m[path[len(path)-1]] = b.Get(key)
+	}
+	return
+}
+
 func (b *builder) SetProperty(name string, val interface{}) Builder {
 	b.Set(name, val)
 	return b
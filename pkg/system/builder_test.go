@@ -136,6 +136,44 @@ func TestBuilderBuild(t *testing.T) {
 		home := os.Getenv("HOME")
 		assert.Equal(t, "this is "+home, res)
 	})
+
+	t.Run("should replace nested and escaped property", func(t *testing.T) {
+		b.SetProperty("app.env", "dev").
+			SetProperty("db.dev.url", "jdbc://${app.name}-dev")
+
+		res := b.Replace("${db.${app.env}.url}")
+		assert.Equal(t, "jdbc://foo-dev", res)
+
+		res = b.Replace("${db.${app.unknown:dev}.url} and $${app.name}")
+		assert.Equal(t, "jdbc://foo-dev and ${app.name}", res)
+	})
+
+	t.Run("should keep the source if there is circular reference", func(t *testing.T) {
+		b.SetProperty("circular.a", "${circular.b}").
+			SetProperty("circular.b", "${circular.a}")
+
+		res := b.Replace("${circular.a}")
+		assert.Equal(t, "${circular.a}", res)
+	})
+
+	t.Run("should keep the escaped placeholder through chained references", func(t *testing.T) {
+		assert.Equal(t, "${app.name}", b.GetProperty("mock.escaped"))
+		assert.Equal(t, "${app.name}", b.GetProperty("mock.chained"))
+		assert.Equal(t, "${app.name} is foo", b.Replace("${mock.chained} is ${app.name}"))
+	})
+
+	t.Run("should get properties with prefix", func(t *testing.T) {
+		b.SetProperty("mock.pets.cat", "tom")
+		props := b.GetProperties("mock")
+		assert.Equal(t, "hiboot-mock-local", props["name"])
+		assert.Equal(t, "tom", props["pets"].(map[string]interface{})["cat"])
+
+		props = b.GetProperties("unknown")
+		assert.Equal(t, 0, len(props))
+
+		props = b.GetProperties("")
+		assert.Equal(t, "tom", props["mock"].(map[string]interface{})["pets"].(map[string]interface{})["cat"])
+	})
 }
 
 func TestBuilderBuildWithError(t *testing.T) {
@@ -275,3 +313,97 @@ func TestBuilderSave(t *testing.T) {
 		assert.Contains(t, err.Error(), "wrong")
 	})
 }
+
+func TestBuilderFileFormats(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "hiboot-formats")
+	os.RemoveAll(path)
+	defer os.RemoveAll(path)
+
+	files := map[string]string{
+		"application.hcl": `app {
+  name = "hcl"
+  project = "hcl-project"
+}
+`,
+		"application.toml": `[app]
+name = "toml"
+version = "toml-version"
+`,
+		"application.properties": `app.name = properties
+server.port = 8082
+logging.level = info
+`,
+		"application.json": `{"app": {"name": "json", "profiles": {"include": ["foo", "bar"]}}}`,
+		"application.yml": `app:
+  name: yaml
+`,
+		"application-dev.toml": `[server]
+port = "8083"
+`,
+	}
+	for name, content := range files {
+		io.CreateFile(path, name)
+		io.WriterFile(path, name, []byte(content))
+	}
+
+	b := NewBuilder(&Configuration{}, path, "application", "yaml", nil)
+	cp, err := b.Build("default", "dev")
+
+	t.Run("should merge config files of all formats in order", func(t *testing.T) {
+		assert.Equal(t, nil, err)
+		c := cp.(*Configuration)
+		assert.Equal(t, "yaml", c.App.Name)
+		assert.Equal(t, "hcl-project", c.App.Project)
+		assert.Equal(t, "toml-version", c.App.Version)
+		assert.Equal(t, []string{"foo", "bar"}, c.App.Profiles.Include)
+		assert.Equal(t, "info", c.Logging.Level)
+	})
+
+	t.Run("should override by profile config file", func(t *testing.T) {
+		c := cp.(*Configuration)
+		assert.Equal(t, "8083", c.Server.Port)
+	})
+}
+
+func TestBuilderSaveFormats(t *testing.T) {
+	c := &Configuration{
+		App: App{
+			Name:    "foo",
+			Project: "bar",
+			Profiles: Profiles{
+				Include: []string{"baz", "qux"},
+			},
+		},
+		Server: Server{
+			Port: "8080",
+		},
+	}
+
+	for _, ext := range []string{"yml", "json", "toml", "hcl", "properties"} {
+		t.Run("should save and read back in "+ext, func(t *testing.T) {
+			path := filepath.Join(os.TempDir(), "hiboot-save-"+ext)
+			os.RemoveAll(path)
+			defer os.RemoveAll(path)
+
+			// the config file read decides the format to save
+			io.CreateFile(path, "application."+ext)
+			b := NewBuilder(&Configuration{}, path, "application", "yaml", nil)
+			_, err := b.Build("default")
+			assert.Equal(t, nil, err)
+
+			err = b.Save(c)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, false, io.IsPathNotExist(filepath.Join(path, "application."+ext)))
+			assert.Equal(t, true, io.IsPathNotExist(filepath.Join(path, "application.yaml")))
+
+			nb := NewBuilder(&Configuration{}, path, "application", "yaml", nil)
+			cp, err := nb.Build("default")
+			assert.Equal(t, nil, err)
+			res := cp.(*Configuration)
+			assert.Equal(t, c.App.Name, res.App.Name)
+			assert.Equal(t, c.App.Project, res.App.Project)
+			assert.Equal(t, c.App.Profiles.Include, res.App.Profiles.Include)
+			assert.Equal(t, c.Server.Port, res.Server.Port)
+		})
+	}
+}
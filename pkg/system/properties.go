@@ -14,6 +14,11 @@
 
 package system
 
+import (
+	"hidevops.io/hiboot/pkg/utils/crypto/tlsconfig"
+	"time"
+)
+
 // Profiles is app profiles
 // .include auto configuration starter should be included inside this slide
 // .active active profile
@@ -50,11 +55,98 @@ type App struct {
 // Server is the properties of http server
 type Server struct {
 	Port string `json:"port" default:"8080"`
+	// the https properties
+	SSL SSL `json:"ssl"`
+}
+
+// SSL is the properties of https and mutual tls, the grpc server applies them as well
+type SSL struct {
+	// serve https instead of http
+	Enabled bool `json:"enabled"`
+	// the pem certificate file, the chain of the certificate and the intermediates
+	CertFile string `json:"cert_file" mapstructure:"certFile"`
+	// the pem private key file
+	KeyFile string `json:"key_file" mapstructure:"keyFile"`
+	// the minimum tls version, 1.0, 1.1, 1.2, or 1.3 since go 1.12
+	MinVersion string `json:"min_version" mapstructure:"minVersion" default:"1.2"`
+	// the cipher suites of tls 1.2 and below by name, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, the defaults of go if it is empty
+	CipherSuites []string `json:"cipher_suites" mapstructure:"cipherSuites"`
+	// the pem certificate authorities that verify the client certificates
+	ClientCAFile string `json:"client_ca_file" mapstructure:"clientCaFile"`
+	// none, optional or required, the client certificate is verified by the client certificate authorities
+	ClientAuth string `json:"client_auth" mapstructure:"clientAuth" default:"none"`
+	// serve http/2 as well as http/1.1
+	HTTP2 bool `json:"http2" mapstructure:"http2" default:"true"`
+	// the interval of checking the changes of the certificate files, 0 for no reload
+	ReloadInterval time.Duration `json:"reload_interval" mapstructure:"reloadInterval" default:"10s"`
+}
+
+// Options returns the tls options of the server
+func (s *SSL) Options() *tlsconfig.Options {
+	return &tlsconfig.Options{
+		CertFile:     s.CertFile,
+		KeyFile:      s.KeyFile,
+		CAFile:       s.ClientCAFile,
+		MinVersion:   s.MinVersion,
+		CipherSuites: s.CipherSuites,
+		ClientAuth:   s.ClientAuth,
+	}
+}
+
+// ManagementServer is the properties of management server, it is served over tls by server.ssl as the main server
+type ManagementServer struct {
+	// the port of management server, the management controllers are served by the main server if it is empty
+	Port string `json:"port"`
+	// the address that management server binds to, e.g. 127.0.0.1, it binds to all addresses if it is empty
+	Address string `json:"address"`
+}
+
+// BasicAuth is the properties of basic authentication
+type BasicAuth struct {
+	// the basic authentication is enabled if username is not empty
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ManagementSecurity is the properties of management server security
+type ManagementSecurity struct {
+	// protect the management server by basic authentication
+	BasicAuth BasicAuth `json:"basic_auth" mapstructure:"basicAuth"`
+	// protect the management server by jwt, the jwt starter is required
+	Jwt bool `json:"jwt"`
+}
+
+// Management is the properties of management server, e.g. the actuator endpoints
+type Management struct {
+	Server   ManagementServer   `json:"server"`
+	Security ManagementSecurity `json:"security"`
 }
 
 // Logging is the properties of logging
 type Logging struct {
 	Level string `json:"level" default:"info"`
+	// the format of log lines, text, json or logfmt
+	Format string `json:"format" default:"text"`
+	// the level overrides by package or logger name, e.g. logging.levels.factory: debug
+	Levels map[string]string `json:"levels"`
+	// the log file, the log is written to the console only if the path is empty
+	File LoggingFile `json:"file"`
+}
+
+// LoggingFile is the properties of the rotating log file
+type LoggingFile struct {
+	// the path of the log file, e.g. logs/app.log
+	Path string `json:"path"`
+	// rotate the log file when its size would exceed the max size, e.g. 10MB, 0 for no size based rotation
+	MaxSize string `json:"max_size" mapstructure:"maxSize" default:"100MB"`
+	// rotate the log file by the interval, e.g. 24h, 0 for no time based rotation
+	Interval time.Duration `json:"interval"`
+	// the max number of rotated files to retain, 0 to retain all
+	MaxBackups int `json:"max_backups" mapstructure:"maxBackups"`
+	// the max age of rotated files to retain, e.g. 168h, 0 to retain all
+	MaxAge time.Duration `json:"max_age" mapstructure:"maxAge"`
+	// compress the rotated files with gzip
+	Compress bool `json:"compress"`
 }
 
 // Env is the name value pair of environment variable
@@ -0,0 +1,145 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hidevops.io/hiboot/pkg/utils/crypto"
+	"io"
+	"strings"
+)
+
+const versionSeparator = ":"
+
+var (
+	// ErrKeyNotFound the key of the version is not found
+	ErrKeyNotFound = errors.New("[crypto] key not found")
+	// ErrInvalidVersion the key version is empty or contains the separator
+	ErrInvalidVersion = errors.New("[crypto] invalid key version")
+)
+
+// GCMCipher is the authenticated encryption by AES-GCM with the versioned keys, the cipher text is prefixed with
+// the version of the key that encrypts it, e.g. v2:base64(nonce|cipherText), the new secrets are encrypted by the
+// primary key, and the secrets of the former keys are still decrypted, so that the keys can be rotated, e.g.
+//
+//	c, _ := aes.NewGCMCipher("v2", map[string][]byte{"v1": oldKey, "v2": newKey})
+//	secret, _ := c.EncryptString("s3cr3t")
+//	plain, _ := c.DecryptString(secret)
+//	if c.NeedsRotation(secret) {
+//		// encrypt and store the secret again
+//	}
+type GCMCipher struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewGCMCipher is the constructor of GCMCipher, the keys are 16, 24 or 32 bytes for AES-128, AES-192 or AES-256
+func NewGCMCipher(primary string, keys map[string][]byte) (c *GCMCipher, err error) {
+	c = &GCMCipher{primary: primary, aeads: make(map[string]cipher.AEAD)}
+	for version, key := range keys {
+		if version == "" || strings.Contains(version, versionSeparator) {
+			return nil, fmt.Errorf("%v: %v", ErrInvalidVersion, version)
+		}
+		var block cipher.Block
+		block, err = aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %v: %v", version, err)
+		}
+		c.aeads[version], err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := c.aeads[primary]; !ok && len(keys) != 0 {
+		return nil, fmt.Errorf("%v: %v", ErrKeyNotFound, primary)
+	}
+	return
+}
+
+// Primary returns the version of the key that encrypts the new secrets
+func (c *GCMCipher) Primary() string {
+	return c.primary
+}
+
+// Encrypt encrypts the plain text by the primary key, the additional data is authenticated but not encrypted,
+// it must be the same to decrypt, e.g. the id of the record that the secret belongs to
+func (c *GCMCipher) Encrypt(plainText, additionalData []byte) (retVal string, err error) {
+	aead, ok := c.aeads[c.primary]
+	if !ok {
+		return "", fmt.Errorf("%v: %v", ErrKeyNotFound, c.primary)
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plainText)+aead.Overhead())
+	if _, err = io.ReadFull(rand.Reader, nonce); err == nil {
+		sealed := aead.Seal(nonce, nonce, plainText, additionalData)
+		retVal = c.primary + versionSeparator + base64.RawURLEncoding.EncodeToString(sealed)
+	}
+	return
+}
+
+// Decrypt decrypts the cipher text by the key of its version, and authenticates the cipher text and the additional data
+func (c *GCMCipher) Decrypt(cipherText string, additionalData []byte) (plainText []byte, err error) {
+	version, text, ok := splitVersion(cipherText)
+	if !ok {
+		return nil, crypto.ErrInvalidInput
+	}
+	aead, ok := c.aeads[version]
+	if !ok {
+		return nil, fmt.Errorf("%v: %v", ErrKeyNotFound, version)
+	}
+	var sealed []byte
+	sealed, err = base64.RawURLEncoding.DecodeString(text)
+	if err != nil {
+		return nil, crypto.ErrInvalidInput
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, crypto.ErrCipherTooShort
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additionalData)
+}
+
+// EncryptString encrypts the string without additional data
+func (c *GCMCipher) EncryptString(plainText string) (string, error) {
+	return c.Encrypt([]byte(plainText), nil)
+}
+
+// DecryptString decrypts the string encrypted by EncryptString
+func (c *GCMCipher) DecryptString(cipherText string) (retVal string, err error) {
+	var plainText []byte
+	plainText, err = c.Decrypt(cipherText, nil)
+	if err == nil {
+		retVal = string(plainText)
+	}
+	return
+}
+
+// NeedsRotation returns true if the cipher text is not encrypted by the primary key
+func (c *GCMCipher) NeedsRotation(cipherText string) bool {
+	version, _, ok := splitVersion(cipherText)
+	return !ok || version != c.primary
+}
+
+// splitVersion splits the cipher text to the key version and the encoded text
+func splitVersion(cipherText string) (version, text string, ok bool) {
+	i := strings.Index(cipherText, versionSeparator)
+	if i <= 0 {
+		return
+	}
+	return cipherText[:i], cipherText[i+1:], true
+}
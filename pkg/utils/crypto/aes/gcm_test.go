@@ -0,0 +1,101 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aes
+
+import (
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/utils/crypto"
+	"strings"
+	"testing"
+)
+
+func TestGCMCipher(t *testing.T) {
+	v1 := []byte("0123456789abcdef")
+	v2 := []byte("0123456789abcdef0123456789abcdef")
+
+	old, err := NewGCMCipher("v1", map[string][]byte{"v1": v1})
+	assert.Equal(t, nil, err)
+	c, err := NewGCMCipher("v2", map[string][]byte{"v1": v1, "v2": v2})
+	assert.Equal(t, nil, err)
+
+	t.Run("should encrypt by the primary key", func(t *testing.T) {
+		secret, err := c.EncryptString("s3cr3t")
+		assert.Equal(t, nil, err)
+		assert.True(t, strings.HasPrefix(secret, "v2:"))
+		assert.Equal(t, false, c.NeedsRotation(secret))
+
+		another, _ := c.EncryptString("s3cr3t")
+		assert.NotEqual(t, secret, another)
+
+		plain, err := c.DecryptString(secret)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "s3cr3t", plain)
+	})
+
+	t.Run("should decrypt by the former key", func(t *testing.T) {
+		secret, _ := old.EncryptString("s3cr3t")
+		assert.Equal(t, true, c.NeedsRotation(secret))
+		plain, err := c.DecryptString(secret)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "s3cr3t", plain)
+	})
+
+	t.Run("should authenticate the additional data", func(t *testing.T) {
+		secret, _ := c.Encrypt([]byte("s3cr3t"), []byte("user-1"))
+		plain, err := c.Decrypt(secret, []byte("user-1"))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "s3cr3t", string(plain))
+
+		_, err = c.Decrypt(secret, []byte("user-2"))
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should reject the tampered cipher text", func(t *testing.T) {
+		secret, _ := c.EncryptString("s3cr3t")
+		tampered := []byte(secret)
+		i := len(tampered) - 2
+		if tampered[i] == 'A' {
+			tampered[i] = 'B'
+		} else {
+			tampered[i] = 'A'
+		}
+		_, err := c.DecryptString(string(tampered))
+		assert.NotEqual(t, nil, err)
+
+		_, err = c.DecryptString("v2:AAAA")
+		assert.Equal(t, crypto.ErrCipherTooShort, err)
+		_, err = c.DecryptString("s3cr3t")
+		assert.Equal(t, crypto.ErrInvalidInput, err)
+	})
+
+	t.Run("should report the unknown key", func(t *testing.T) {
+		secret, _ := c.EncryptString("s3cr3t")
+		_, err := old.DecryptString(secret)
+		assert.Contains(t, err.Error(), ErrKeyNotFound.Error())
+
+		_, err = NewGCMCipher("v3", map[string][]byte{"v1": v1})
+		assert.Contains(t, err.Error(), ErrKeyNotFound.Error())
+
+		_, err = new(GCMCipher).EncryptString("s3cr3t")
+		assert.Contains(t, err.Error(), ErrKeyNotFound.Error())
+	})
+
+	t.Run("should report the invalid key", func(t *testing.T) {
+		_, err := NewGCMCipher("v1", map[string][]byte{"v1": []byte("short")})
+		assert.NotEqual(t, nil, err)
+		_, err = NewGCMCipher("v:1", map[string][]byte{"v:1": v1})
+		assert.Contains(t, err.Error(), ErrInvalidVersion.Error())
+	})
+}
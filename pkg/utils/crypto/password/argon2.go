@@ -0,0 +1,91 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+// Argon2PasswordEncoder hashes the password by argon2id, the hash is in the PHC string format,
+// e.g. $argon2id$v=19$m=65536,t=1,p=4$salt$hash
+type Argon2PasswordEncoder struct {
+	time      uint32
+	memory    uint32
+	threads   uint8
+	keyLength uint32
+}
+
+// NewArgon2PasswordEncoder is the constructor of Argon2PasswordEncoder, time is the number of passes,
+// memory is the memory in KiB and threads is the parallelism
+func NewArgon2PasswordEncoder(time, memory uint32, threads uint8) *Argon2PasswordEncoder {
+	return &Argon2PasswordEncoder{time: time, memory: memory, threads: threads, keyLength: defaultKeyLength}
+}
+
+// Encode hashes the raw password with a random salt
+func (e *Argon2PasswordEncoder) Encode(rawPassword string) (retVal string, err error) {
+	var s []byte
+	s, err = salt(defaultSaltLength)
+	if err == nil {
+		key := argon2.IDKey([]byte(rawPassword), s, e.time, e.memory, e.threads, e.keyLength)
+		retVal = fmt.Sprintf("$%v$v=%d$m=%d,t=%d,p=%d$%v$%v", Argon2id, argon2.Version, e.memory, e.time, e.threads, encode(s), encode(key))
+	}
+	return
+}
+
+// Matches returns true if the raw password matches the argon2id hash
+func (e *Argon2PasswordEncoder) Matches(rawPassword, encodedPassword string) bool {
+	h, err := parseArgon2(encodedPassword)
+	if err != nil {
+		return false
+	}
+	key := argon2.IDKey([]byte(rawPassword), h.salt, h.time, h.memory, h.threads, uint32(len(h.key)))
+	return subtle.ConstantTimeCompare(key, h.key) == 1
+}
+
+// UpgradeEncoding returns true if any of the cost parameters of the hash is lower than the encoder
+func (e *Argon2PasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
+	h, err := parseArgon2(encodedPassword)
+	return err != nil || h.time < e.time || h.memory < e.memory || h.threads < e.threads
+}
+
+type argon2Hash struct {
+	time      uint32
+	memory    uint32
+	threads   uint8
+	salt, key []byte
+}
+
+func parseArgon2(encodedPassword string) (h *argon2Hash, err error) {
+	parts := strings.Split(encodedPassword, "$")
+	if len(parts) != 6 || parts[1] != Argon2id || parts[2] != fmt.Sprintf("v=%d", argon2.Version) {
+		return nil, ErrInvalidHash
+	}
+	var params map[string]int
+	params, err = parseParams(parts[3], "m", "t", "p")
+	if err != nil || params["p"] == 0 || params["p"] > 255 || params["t"] == 0 {
+		return nil, ErrInvalidHash
+	}
+	h = &argon2Hash{memory: uint32(params["m"]), time: uint32(params["t"]), threads: uint8(params["p"])}
+	if h.salt, err = decode(parts[4]); err == nil {
+		h.key, err = decode(parts[5])
+	}
+	if err != nil || len(h.key) == 0 {
+		return nil, ErrInvalidHash
+	}
+	return
+}
@@ -0,0 +1,47 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import "golang.org/x/crypto/bcrypt"
+
+// BCryptPasswordEncoder hashes the password by bcrypt
+type BCryptPasswordEncoder struct {
+	cost int
+}
+
+// NewBCryptPasswordEncoder is the constructor of BCryptPasswordEncoder, bcrypt.DefaultCost is used if cost is out of range
+func NewBCryptPasswordEncoder(cost int) *BCryptPasswordEncoder {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BCryptPasswordEncoder{cost: cost}
+}
+
+// Encode hashes the raw password, the hash is in the modular crypt format, e.g. $2a$10$...
+func (e *BCryptPasswordEncoder) Encode(rawPassword string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(rawPassword), e.cost)
+	return string(hash), err
+}
+
+// Matches returns true if the raw password matches the bcrypt hash
+func (e *BCryptPasswordEncoder) Matches(rawPassword, encodedPassword string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(encodedPassword), []byte(rawPassword)) == nil
+}
+
+// UpgradeEncoding returns true if the cost of the hash is lower than the cost of the encoder
+func (e *BCryptPasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(encodedPassword))
+	return err != nil || cost < e.cost
+}
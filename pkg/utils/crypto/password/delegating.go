@@ -0,0 +1,133 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"hidevops.io/hiboot/pkg/utils/crypto/md5"
+	"strings"
+)
+
+const (
+	idPrefix = "{"
+	idSuffix = "}"
+)
+
+// DelegatingPasswordEncoder encodes the password by the encoder of idForEncode and prefixes the hash with {id},
+// the hash is matched by the encoder of its {id} prefix, so that the hashes of the former encoders still match,
+// and UpgradeEncoding reports the hashes that are not encoded by the encoder of idForEncode
+type DelegatingPasswordEncoder struct {
+	idForEncode       string
+	encoders          map[string]PasswordEncoder
+	defaultForMatches PasswordEncoder
+}
+
+// NewDelegatingPasswordEncoder is the constructor of DelegatingPasswordEncoder, the encoder of idForEncode must be in encoders
+func NewDelegatingPasswordEncoder(idForEncode string, encoders map[string]PasswordEncoder) *DelegatingPasswordEncoder {
+	return &DelegatingPasswordEncoder{idForEncode: idForEncode, encoders: encoders}
+}
+
+// SetDefaultForMatches sets the encoder that matches the hashes without the {id} prefix, e.g. the legacy md5 hashes,
+// the hashes without prefix never match by default
+func (e *DelegatingPasswordEncoder) SetDefaultForMatches(encoder PasswordEncoder) {
+	e.defaultForMatches = encoder
+}
+
+// Encode hashes the raw password by the encoder of idForEncode, e.g. {bcrypt}$2a$10$...
+func (e *DelegatingPasswordEncoder) Encode(rawPassword string) (retVal string, err error) {
+	encoder, ok := e.encoders[e.idForEncode]
+	if !ok {
+		return "", fmt.Errorf("%v: %v", ErrUnknownEncoder, e.idForEncode)
+	}
+	retVal, err = encoder.Encode(rawPassword)
+	if err == nil {
+		retVal = idPrefix + e.idForEncode + idSuffix + retVal
+	}
+	return
+}
+
+// Matches returns true if the raw password matches the hash by the encoder of its {id} prefix
+func (e *DelegatingPasswordEncoder) Matches(rawPassword, encodedPassword string) bool {
+	encoder, hash := e.encoder(encodedPassword)
+	return encoder != nil && encoder.Matches(rawPassword, hash)
+}
+
+// UpgradeEncoding returns true if the hash is not encoded by the encoder of idForEncode, or the encoder reports it
+func (e *DelegatingPasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
+	id, hash, ok := ExtractID(encodedPassword)
+	encoder, found := e.encoders[id]
+	if !ok || id != e.idForEncode || !found {
+		return true
+	}
+	return encoder.UpgradeEncoding(hash)
+}
+
+// encoder returns the encoder of the {id} prefix of the hash and the hash without the prefix
+func (e *DelegatingPasswordEncoder) encoder(encodedPassword string) (encoder PasswordEncoder, hash string) {
+	id, hash, ok := ExtractID(encodedPassword)
+	if !ok {
+		return e.defaultForMatches, encodedPassword
+	}
+	return e.encoders[id], hash
+}
+
+// ExtractID returns the id of the {id} prefix and the hash without the prefix
+func ExtractID(encodedPassword string) (id, hash string, ok bool) {
+	if !strings.HasPrefix(encodedPassword, idPrefix) {
+		return "", encodedPassword, false
+	}
+	end := strings.Index(encodedPassword, idSuffix)
+	if end < 0 {
+		return "", encodedPassword, false
+	}
+	return encodedPassword[len(idPrefix):end], encodedPassword[end+len(idSuffix):], true
+}
+
+// NoopPasswordEncoder keeps the password as it is, it is for testing and the migration of the plain passwords only
+type NoopPasswordEncoder struct{}
+
+// Encode returns the raw password
+func (e *NoopPasswordEncoder) Encode(rawPassword string) (string, error) {
+	return rawPassword, nil
+}
+
+// Matches compares the passwords in constant time
+func (e *NoopPasswordEncoder) Matches(rawPassword, encodedPassword string) bool {
+	return subtle.ConstantTimeCompare([]byte(rawPassword), []byte(encodedPassword)) == 1
+}
+
+// UpgradeEncoding always returns true
+func (e *NoopPasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
+	return true
+}
+
+// MD5PasswordEncoder matches the legacy md5 hashes of md5.Encrypt, it is for the migration of the legacy hashes only
+type MD5PasswordEncoder struct{}
+
+// Encode returns the md5 hash of the raw password
+func (e *MD5PasswordEncoder) Encode(rawPassword string) (string, error) {
+	return md5.Encrypt(rawPassword), nil
+}
+
+// Matches compares the md5 hash of the raw password in constant time
+func (e *MD5PasswordEncoder) Matches(rawPassword, encodedPassword string) bool {
+	return subtle.ConstantTimeCompare([]byte(md5.Encrypt(rawPassword)), []byte(strings.ToLower(encodedPassword))) == 1
+}
+
+// UpgradeEncoding always returns true
+func (e *MD5PasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
+	return true
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package password provides the password encoders that hash the passwords for storing,
+// bcrypt, scrypt and argon2id are supported, and DelegatingPasswordEncoder reads the {id} prefixed hashes,
+// so that the hashes of the different encoders can be migrated gradually, e.g.
+//
+//	encoder := password.NewDelegatingPasswordEncoder(password.BCrypt, map[string]password.PasswordEncoder{
+//		password.BCrypt:   password.NewBCryptPasswordEncoder(10),
+//		password.Argon2id: password.NewArgon2PasswordEncoder(1, 64*1024, 4),
+//	})
+//	hash, _ := encoder.Encode("s3cr3t") // {bcrypt}$2a$10$...
+//	ok := encoder.Matches("s3cr3t", hash)
+//	if ok && encoder.UpgradeEncoding(hash) {
+//		// re-encode and store the hash
+//	}
+package password
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+const (
+	// BCrypt is the id of BCryptPasswordEncoder
+	BCrypt = "bcrypt"
+	// SCrypt is the id of SCryptPasswordEncoder
+	SCrypt = "scrypt"
+	// Argon2id is the id of Argon2PasswordEncoder
+	Argon2id = "argon2id"
+	// Noop is the id of NoopPasswordEncoder
+	Noop = "noop"
+	// MD5 is the id of MD5PasswordEncoder
+	MD5 = "md5"
+
+	defaultSaltLength = 16
+	defaultKeyLength  = 32
+)
+
+var (
+	// ErrInvalidHash the encoded password is not in the format of the encoder
+	ErrInvalidHash = errors.New("[password] invalid hash")
+	// ErrUnknownEncoder the encoder of the id is not found
+	ErrUnknownEncoder = errors.New("[password] unknown encoder")
+)
+
+// PasswordEncoder encodes the raw password to the hash for storing, and matches the raw password with the hash
+type PasswordEncoder interface {
+	// Encode hashes the raw password with a random salt
+	Encode(rawPassword string) (string, error)
+	// Matches returns true if the raw password matches the encoded password
+	Matches(rawPassword, encodedPassword string) bool
+	// UpgradeEncoding returns true if the encoded password should be encoded again, e.g. the cost is lower than the current one
+	UpgradeEncoding(encodedPassword string) bool
+}
+
+// salt returns n random bytes
+func salt(n int) (b []byte, err error) {
+	b = make([]byte, n)
+	_, err = io.ReadFull(rand.Reader, b)
+	return
+}
+
+// encode encodes the bytes in unpadded base64 as the PHC string format
+func encode(b []byte) string {
+	return base64.RawStdEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(s)
+}
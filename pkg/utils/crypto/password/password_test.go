@@ -0,0 +1,100 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/utils/crypto/md5"
+	"strings"
+	"testing"
+)
+
+func TestPasswordEncoders(t *testing.T) {
+	testCases := []struct {
+		name    string
+		encoder PasswordEncoder
+		prefix  string
+		weaker  PasswordEncoder
+	}{
+		{BCrypt, NewBCryptPasswordEncoder(5), "$2a$05$", NewBCryptPasswordEncoder(4)},
+		{SCrypt, NewSCryptPasswordEncoder(1024, 8, 1), "$scrypt$ln=10,r=8,p=1$", NewSCryptPasswordEncoder(512, 8, 1)},
+		{Argon2id, NewArgon2PasswordEncoder(1, 1024, 2), "$argon2id$v=19$m=1024,t=1,p=2$", NewArgon2PasswordEncoder(1, 512, 2)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run("should encode and match by "+testCase.name, func(t *testing.T) {
+			hash, err := testCase.encoder.Encode("s3cr3t")
+			assert.Equal(t, nil, err)
+			assert.True(t, strings.HasPrefix(hash, testCase.prefix), hash)
+			assert.Equal(t, true, testCase.encoder.Matches("s3cr3t", hash))
+			assert.Equal(t, false, testCase.encoder.Matches("wrong", hash))
+			assert.Equal(t, false, testCase.encoder.Matches("s3cr3t", "invalid"))
+
+			another, _ := testCase.encoder.Encode("s3cr3t")
+			assert.NotEqual(t, hash, another)
+		})
+
+		t.Run("should upgrade the weaker hash of "+testCase.name, func(t *testing.T) {
+			hash, _ := testCase.weaker.Encode("s3cr3t")
+			assert.Equal(t, true, testCase.encoder.Matches("s3cr3t", hash))
+			assert.Equal(t, true, testCase.encoder.UpgradeEncoding(hash))
+
+			hash, _ = testCase.encoder.Encode("s3cr3t")
+			assert.Equal(t, false, testCase.encoder.UpgradeEncoding(hash))
+		})
+	}
+}
+
+func TestDelegatingPasswordEncoder(t *testing.T) {
+	encoders := map[string]PasswordEncoder{
+		BCrypt:   NewBCryptPasswordEncoder(4),
+		Argon2id: NewArgon2PasswordEncoder(1, 1024, 1),
+		MD5:      new(MD5PasswordEncoder),
+		Noop:     new(NoopPasswordEncoder),
+	}
+	encoder := NewDelegatingPasswordEncoder(Argon2id, encoders)
+
+	t.Run("should encode with id prefix", func(t *testing.T) {
+		hash, err := encoder.Encode("s3cr3t")
+		assert.Equal(t, nil, err)
+		assert.True(t, strings.HasPrefix(hash, "{argon2id}$argon2id$"))
+		assert.Equal(t, true, encoder.Matches("s3cr3t", hash))
+		assert.Equal(t, false, encoder.UpgradeEncoding(hash))
+	})
+
+	t.Run("should match the hash of the former encoder and upgrade it", func(t *testing.T) {
+		hash, _ := encoders[BCrypt].Encode("s3cr3t")
+		assert.Equal(t, true, encoder.Matches("s3cr3t", "{bcrypt}"+hash))
+		assert.Equal(t, true, encoder.UpgradeEncoding("{bcrypt}"+hash))
+		assert.Equal(t, true, encoder.Matches("s3cr3t", "{md5}"+md5.Encrypt("s3cr3t")))
+		assert.Equal(t, true, encoder.Matches("s3cr3t", "{noop}s3cr3t"))
+	})
+
+	t.Run("should not match the unknown or missing id", func(t *testing.T) {
+		assert.Equal(t, false, encoder.Matches("s3cr3t", "{unknown}s3cr3t"))
+		assert.Equal(t, false, encoder.Matches("s3cr3t", md5.Encrypt("s3cr3t")))
+		assert.Equal(t, true, encoder.UpgradeEncoding(md5.Encrypt("s3cr3t")))
+	})
+
+	t.Run("should match the hash without id by the default encoder", func(t *testing.T) {
+		encoder.SetDefaultForMatches(encoders[MD5])
+		assert.Equal(t, true, encoder.Matches("s3cr3t", md5.Encrypt("s3cr3t")))
+	})
+
+	t.Run("should report unknown encoder", func(t *testing.T) {
+		_, err := NewDelegatingPasswordEncoder("unknown", encoders).Encode("s3cr3t")
+		assert.Contains(t, err.Error(), ErrUnknownEncoder.Error())
+	})
+}
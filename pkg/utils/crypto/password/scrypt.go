@@ -0,0 +1,116 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"golang.org/x/crypto/scrypt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// SCryptPasswordEncoder hashes the password by scrypt, the hash is in the PHC string format,
+// e.g. $scrypt$ln=15,r=8,p=1$salt$hash
+type SCryptPasswordEncoder struct {
+	n         int
+	r         int
+	p         int
+	keyLength int
+}
+
+// NewSCryptPasswordEncoder is the constructor of SCryptPasswordEncoder, n is the cpu/memory cost that must be a power of 2,
+// r is the block size and p is the parallelization
+func NewSCryptPasswordEncoder(n, r, p int) *SCryptPasswordEncoder {
+	return &SCryptPasswordEncoder{n: n, r: r, p: p, keyLength: defaultKeyLength}
+}
+
+// Encode hashes the raw password with a random salt
+func (e *SCryptPasswordEncoder) Encode(rawPassword string) (retVal string, err error) {
+	var s, key []byte
+	s, err = salt(defaultSaltLength)
+	if err == nil {
+		key, err = scrypt.Key([]byte(rawPassword), s, e.n, e.r, e.p, e.keyLength)
+	}
+	if err == nil {
+		retVal = fmt.Sprintf("$%v$ln=%d,r=%d,p=%d$%v$%v", SCrypt, bits.TrailingZeros(uint(e.n)), e.r, e.p, encode(s), encode(key))
+	}
+	return
+}
+
+// Matches returns true if the raw password matches the scrypt hash
+func (e *SCryptPasswordEncoder) Matches(rawPassword, encodedPassword string) bool {
+	h, err := parseSCrypt(encodedPassword)
+	if err != nil {
+		return false
+	}
+	key, err := scrypt.Key([]byte(rawPassword), h.salt, h.n, h.r, h.p, len(h.key))
+	return err == nil && subtle.ConstantTimeCompare(key, h.key) == 1
+}
+
+// UpgradeEncoding returns true if any of the cost parameters of the hash is lower than the encoder
+func (e *SCryptPasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
+	h, err := parseSCrypt(encodedPassword)
+	return err != nil || h.n < e.n || h.r < e.r || h.p < e.p
+}
+
+type scryptHash struct {
+	n, r, p   int
+	salt, key []byte
+}
+
+func parseSCrypt(encodedPassword string) (h *scryptHash, err error) {
+	parts := strings.Split(encodedPassword, "$")
+	if len(parts) != 5 || parts[1] != SCrypt {
+		return nil, ErrInvalidHash
+	}
+	var params map[string]int
+	params, err = parseParams(parts[2], "ln", "r", "p")
+	if err != nil || params["ln"] >= bits.UintSize-1 {
+		return nil, ErrInvalidHash
+	}
+	h = &scryptHash{n: 1 << uint(params["ln"]), r: params["r"], p: params["p"]}
+	if h.salt, err = decode(parts[3]); err == nil {
+		h.key, err = decode(parts[4])
+	}
+	if err != nil || len(h.key) == 0 {
+		return nil, ErrInvalidHash
+	}
+	return
+}
+
+// parseParams parses the comma separated name=value parameters of the PHC string, all names are required
+func parseParams(s string, names ...string) (params map[string]int, err error) {
+	params = make(map[string]int)
+	for _, param := range strings.Split(s, ",") {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return nil, ErrInvalidHash
+		}
+		var v int
+		v, err = strconv.Atoi(kv[1])
+		if err != nil || v < 0 {
+			return nil, ErrInvalidHash
+		}
+		params[kv[0]] = v
+	}
+	for _, name := range names {
+		if _, ok := params[name]; !ok {
+			return nil, ErrInvalidHash
+		}
+	}
+	return
+}
@@ -0,0 +1,321 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tlsconfig builds the tls configurations of the servers and the clients from the certificate files,
+// the certificates and the certificate authorities are reloaded when the files change, e.g.
+//
+//	cfg, reloader, err := tlsconfig.ServerConfig(&tlsconfig.Options{
+//		CertFile:   "config/ssl/server.crt",
+//		KeyFile:    "config/ssl/server.key",
+//		CAFile:     "config/ssl/ca.crt",
+//		ClientAuth: tlsconfig.ClientAuthRequired,
+//	})
+//	go reloader.Watch(10*time.Second, stop)
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ClientAuthNone does not request the client certificate
+	ClientAuthNone = "none"
+	// ClientAuthOptional verifies the client certificate if it is given
+	ClientAuthOptional = "optional"
+	// ClientAuthRequired requires and verifies the client certificate
+	ClientAuthRequired = "required"
+)
+
+var (
+	// ErrInvalidVersion the tls version is not supported
+	ErrInvalidVersion = errors.New("[tls] invalid tls version")
+	// ErrInvalidCipherSuite the cipher suite is not supported
+	ErrInvalidCipherSuite = errors.New("[tls] invalid cipher suite")
+	// ErrInvalidClientAuth the client auth mode is not none, optional or required
+	ErrInvalidClientAuth = errors.New("[tls] invalid client auth")
+	// ErrInvalidCA the certificate authority file does not contain any pem certificate
+	ErrInvalidCA = errors.New("[tls] invalid certificate authority")
+	// ErrCertificateRequired the server is configured without the certificate file
+	ErrCertificateRequired = errors.New("[tls] certificate file is required")
+	// ErrCARequired the client certificate is verified but the certificate authority file is not given
+	ErrCARequired = errors.New("[tls] certificate authority file is required to verify the client certificate")
+
+	// the versions by name, 1.3 is added since go 1.12
+	versions = map[string]uint16{
+		"1.0": tls.VersionTLS10,
+		"1.1": tls.VersionTLS11,
+		"1.2": tls.VersionTLS12,
+	}
+
+	// the forward secret cipher suites of tls 1.2 and below by name, the suites of the rsa key exchange are not supported,
+	// the ECDHE CBC suites are only for the legacy clients
+	suites = map[string]uint16{
+		"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+		"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+		"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+		"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+		"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384": tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384":   tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305":    tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+		"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305":  tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	}
+)
+
+// Options is the options of the tls configuration
+type Options struct {
+	// the pem certificate file, the chain of the certificate and the intermediates
+	CertFile string
+	// the pem private key file
+	KeyFile string
+	// the pem certificate authorities that verify the client certificates of the server,
+	// or the server certificates of the client, the system roots are used by the client if it is empty
+	CAFile string
+	// the minimum tls version, 1.0, 1.1, 1.2, or 1.3 since go 1.12
+	MinVersion string
+	// the cipher suites of tls 1.2 and below by name, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+	CipherSuites []string
+	// none, optional or required, the client auth mode of the server
+	ClientAuth string
+	// the server name that the client verifies, the host of the address is used if it is empty
+	ServerName string
+	// the client skips the verification of the server certificate, it is for testing only
+	InsecureSkipVerify bool
+}
+
+// Reloader keeps the key pair and the certificate authorities of the files, and reloads them when the files change
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mutex       sync.RWMutex
+	certificate *tls.Certificate
+	pool        *x509.CertPool
+	modTimes    map[string]time.Time
+}
+
+// NewReloader is the constructor of Reloader, the files are loaded at once, the empty file names are ignored
+func NewReloader(certFile, keyFile, caFile string) (r *Reloader, err error) {
+	r = &Reloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if _, err = r.Reload(); err != nil {
+		r = nil
+	}
+	return
+}
+
+// Certificate returns the loaded key pair, nil if the files are not given
+func (r *Reloader) Certificate() *tls.Certificate {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.certificate
+}
+
+// CertPool returns the loaded certificate authorities, nil if the file is not given
+func (r *Reloader) CertPool() *x509.CertPool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.pool
+}
+
+// Reload reloads the files if any of them changes, the former ones are kept if the files are invalid
+func (r *Reloader) Reload() (reloaded bool, err error) {
+	modTimes := make(map[string]time.Time)
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+		var info os.FileInfo
+		info, err = os.Stat(file)
+		if err != nil {
+			return
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	r.mutex.RLock()
+	changed := len(modTimes) != len(r.modTimes)
+	for file, modTime := range modTimes {
+		changed = changed || !modTime.Equal(r.modTimes[file])
+	}
+	r.mutex.RUnlock()
+	if !changed {
+		return
+	}
+
+	var certificate *tls.Certificate
+	var pool *x509.CertPool
+	if r.certFile != "" || r.keyFile != "" {
+		var cert tls.Certificate
+		cert, err = tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return
+		}
+		certificate = &cert
+	}
+	if r.caFile != "" {
+		pool, err = loadCertPool(r.caFile)
+		if err != nil {
+			return
+		}
+	}
+
+	r.mutex.Lock()
+	r.certificate, r.pool, r.modTimes = certificate, pool, modTimes
+	r.mutex.Unlock()
+	reloaded = true
+	return
+}
+
+// Watch reloads the files by the interval until stop is closed, the failures are reported by onError if it is not nil
+func (r *Reloader) Watch(interval time.Duration, stop <-chan struct{}, onError func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := r.Reload(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// ServerConfig returns the tls configuration of the server, the certificate and the client certificate authorities
+// of the handshake are always the latest ones of the reloader, the certificate authority file is required
+// if the client certificate is verified
+func ServerConfig(options *Options) (cfg *tls.Config, reloader *Reloader, err error) {
+	if options.CertFile == "" {
+		return nil, nil, ErrCertificateRequired
+	}
+	var clientAuth tls.ClientAuthType
+	switch strings.ToLower(options.ClientAuth) {
+	case "", ClientAuthNone:
+		clientAuth = tls.NoClientCert
+	case ClientAuthOptional:
+		clientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequired:
+		clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, nil, fmt.Errorf("%v: %v", ErrInvalidClientAuth, options.ClientAuth)
+	}
+	caFile := options.CAFile
+	if clientAuth == tls.NoClientCert {
+		caFile = ""
+	} else if caFile == "" {
+		return nil, nil, ErrCARequired
+	}
+	cfg, err = baseConfig(options)
+	if err != nil {
+		return
+	}
+	reloader, err = NewReloader(options.CertFile, options.KeyFile, caFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg.ClientAuth = clientAuth
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c := cfg.Clone()
+		c.GetConfigForClient = nil
+		if certificate := reloader.Certificate(); certificate != nil {
+			c.Certificates = []tls.Certificate{*certificate}
+		}
+		c.ClientCAs = reloader.CertPool()
+		return c, nil
+	}
+	return
+}
+
+// ClientConfig returns the tls configuration of the client, the client certificate is the latest one of the reloader,
+// but the certificate authorities of CAFile are loaded once as RootCAs, the client should be recreated to trust the
+// rotated certificate authorities
+func ClientConfig(options *Options) (cfg *tls.Config, reloader *Reloader, err error) {
+	cfg, err = baseConfig(options)
+	if err != nil {
+		return
+	}
+	reloader, err = NewReloader(options.CertFile, options.KeyFile, options.CAFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg.ServerName = options.ServerName
+	cfg.InsecureSkipVerify = options.InsecureSkipVerify
+	cfg.RootCAs = reloader.CertPool()
+	cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		if certificate := reloader.Certificate(); certificate != nil {
+			return certificate, nil
+		}
+		// no certificate is sent
+		return new(tls.Certificate), nil
+	}
+	return
+}
+
+// baseConfig returns the configuration of the minimum version and the cipher suites
+func baseConfig(options *Options) (cfg *tls.Config, err error) {
+	cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+	if options.MinVersion != "" {
+		version, ok := versions[options.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("%v: %v", ErrInvalidVersion, options.MinVersion)
+		}
+		cfg.MinVersion = version
+	}
+	if len(options.CipherSuites) != 0 {
+		cfg.CipherSuites, err = cipherSuites(options.CipherSuites)
+	}
+	return
+}
+
+// cipherSuites returns the ids of the cipher suites by name
+func cipherSuites(names []string) (ids []uint16, err error) {
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, ok := suites[name]
+		if !ok {
+			return nil, fmt.Errorf("%v: %v", ErrInvalidCipherSuite, name)
+		}
+		ids = append(ids, id)
+	}
+	return
+}
+
+func loadCertPool(file string) (pool *x509.CertPool, err error) {
+	var pem []byte
+	pem, err = ioutil.ReadFile(file)
+	if err != nil {
+		return
+	}
+	pool = x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%v: %v", ErrInvalidCA, file)
+	}
+	return
+}
@@ -0,0 +1,299 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, name string, parent *testCert, isCA bool) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Equal(t, nil, err)
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.Equal(t, nil, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Equal(t, nil, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, dir, name string) (certFile, keyFile string) {
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	der, err := x509.MarshalECPrivateKey(c.key)
+	assert.Equal(t, nil, err)
+	err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0644)
+	assert.Equal(t, nil, err)
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600)
+	assert.Equal(t, nil, err)
+	return
+}
+
+// handshake returns the client certificate that the server verified
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) (peer *x509.Certificate, err error) {
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	assert.Equal(t, nil, err)
+	defer ln.Close()
+
+	type result struct {
+		peer *x509.Certificate
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer conn.Close()
+		tlsConn := conn.(*tls.Conn)
+		err = tlsConn.Handshake()
+		var peer *x509.Certificate
+		if err == nil && len(tlsConn.ConnectionState().VerifiedChains) != 0 {
+			peer = tlsConn.ConnectionState().VerifiedChains[0][0]
+		}
+		done <- result{peer: peer, err: err}
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), clientConfig)
+	if err == nil {
+		// the client does not know the server rejects its certificate until it reads
+		conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	res := <-done
+	return res.peer, res.err
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsconfig")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "ca", nil, true)
+	caFile, _ := ca.write(t, dir, "ca")
+	serverCertFile, serverKeyFile := newTestCert(t, "localhost", ca, false).write(t, dir, "server")
+	clientCertFile, clientKeyFile := newTestCert(t, "client", ca, false).write(t, dir, "client")
+
+	serverOptions := func(clientAuth string) *Options {
+		return &Options{CertFile: serverCertFile, KeyFile: serverKeyFile, CAFile: caFile, ClientAuth: clientAuth}
+	}
+	clientOptions := &Options{CertFile: clientCertFile, KeyFile: clientKeyFile, CAFile: caFile, ServerName: "localhost"}
+	anonymousOptions := &Options{CAFile: caFile, ServerName: "localhost"}
+
+	t.Run("should verify the client certificate of mutual tls", func(t *testing.T) {
+		serverConfig, _, err := ServerConfig(serverOptions(ClientAuthRequired))
+		assert.Equal(t, nil, err)
+		clientConfig, _, err := ClientConfig(clientOptions)
+		assert.Equal(t, nil, err)
+		peer, err := handshake(t, serverConfig, clientConfig)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "client", peer.Subject.CommonName)
+	})
+
+	t.Run("should reject the client without certificate if client auth is required", func(t *testing.T) {
+		serverConfig, _, err := ServerConfig(serverOptions(ClientAuthRequired))
+		assert.Equal(t, nil, err)
+		clientConfig, _, err := ClientConfig(anonymousOptions)
+		assert.Equal(t, nil, err)
+		_, err = handshake(t, serverConfig, clientConfig)
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should accept the client without certificate if client auth is optional", func(t *testing.T) {
+		serverConfig, _, err := ServerConfig(serverOptions(ClientAuthOptional))
+		assert.Equal(t, nil, err)
+		clientConfig, _, err := ClientConfig(anonymousOptions)
+		assert.Equal(t, nil, err)
+		peer, err := handshake(t, serverConfig, clientConfig)
+		assert.Equal(t, nil, err)
+		assert.Nil(t, peer)
+
+		clientConfig, _, err = ClientConfig(clientOptions)
+		assert.Equal(t, nil, err)
+		peer, err = handshake(t, serverConfig, clientConfig)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "client", peer.Subject.CommonName)
+	})
+
+	t.Run("should not request the client certificate if client auth is none", func(t *testing.T) {
+		serverConfig, _, err := ServerConfig(serverOptions(ClientAuthNone))
+		assert.Equal(t, nil, err)
+		clientConfig, _, err := ClientConfig(clientOptions)
+		assert.Equal(t, nil, err)
+		peer, err := handshake(t, serverConfig, clientConfig)
+		assert.Equal(t, nil, err)
+		assert.Nil(t, peer)
+	})
+
+	t.Run("should reject the server certificate of unknown authority", func(t *testing.T) {
+		otherFile, _ := newTestCert(t, "other", nil, true).write(t, dir, "other")
+		serverConfig, _, err := ServerConfig(serverOptions(ClientAuthNone))
+		assert.Equal(t, nil, err)
+		clientConfig, _, err := ClientConfig(&Options{CAFile: otherFile, ServerName: "localhost"})
+		assert.Equal(t, nil, err)
+		_, err = handshake(t, serverConfig, clientConfig)
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should serve the reloaded certificate", func(t *testing.T) {
+		reloadDir, err := ioutil.TempDir(dir, "reload")
+		assert.Equal(t, nil, err)
+		certFile, keyFile := newTestCert(t, "localhost", ca, false).write(t, reloadDir, "server")
+		serverConfig, reloader, err := ServerConfig(&Options{CertFile: certFile, KeyFile: keyFile})
+		assert.Equal(t, nil, err)
+		clientConfig, _, err := ClientConfig(anonymousOptions)
+		assert.Equal(t, nil, err)
+
+		var served *x509.Certificate
+		clientConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			served, _ = x509.ParseCertificate(rawCerts[0])
+			return nil
+		}
+		_, err = handshake(t, serverConfig, clientConfig)
+		assert.Equal(t, nil, err)
+		first := served.SerialNumber
+
+		reloaded, err := reloader.Reload()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, reloaded)
+
+		renewed := newTestCert(t, "localhost", ca, false)
+		renewed.write(t, reloadDir, "server")
+		later := time.Now().Add(time.Second)
+		os.Chtimes(certFile, later, later)
+		os.Chtimes(keyFile, later, later)
+		reloaded, err = reloader.Reload()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, reloaded)
+
+		_, err = handshake(t, serverConfig, clientConfig)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, renewed.cert.SerialNumber, served.SerialNumber)
+		assert.NotEqual(t, first, served.SerialNumber)
+	})
+
+	t.Run("should keep the former certificate if the reloaded one is invalid", func(t *testing.T) {
+		reloadDir, err := ioutil.TempDir(dir, "invalid")
+		assert.Equal(t, nil, err)
+		certFile, keyFile := newTestCert(t, "localhost", ca, false).write(t, reloadDir, "server")
+		reloader, err := NewReloader(certFile, keyFile, "")
+		assert.Equal(t, nil, err)
+		former := reloader.Certificate()
+
+		err = ioutil.WriteFile(certFile, []byte("invalid"), 0644)
+		assert.Equal(t, nil, err)
+		later := time.Now().Add(time.Second)
+		os.Chtimes(certFile, later, later)
+		_, err = reloader.Reload()
+		assert.NotEqual(t, nil, err)
+		assert.Equal(t, former, reloader.Certificate())
+	})
+
+	t.Run("should report invalid options", func(t *testing.T) {
+		_, _, err := ServerConfig(&Options{CertFile: serverCertFile, KeyFile: serverKeyFile, ClientAuth: "always"})
+		assert.Contains(t, err.Error(), ErrInvalidClientAuth.Error())
+
+		_, _, err = ServerConfig(&Options{CertFile: serverCertFile, KeyFile: serverKeyFile, MinVersion: "2.0"})
+		assert.Contains(t, err.Error(), ErrInvalidVersion.Error())
+
+		_, _, err = ClientConfig(&Options{CipherSuites: []string{"TLS_UNKNOWN"}})
+		assert.Contains(t, err.Error(), ErrInvalidCipherSuite.Error())
+
+		_, _, err = ClientConfig(&Options{CipherSuites: []string{"TLS_RSA_WITH_AES_128_CBC_SHA"}})
+		assert.Contains(t, err.Error(), ErrInvalidCipherSuite.Error())
+
+		_, _, err = ClientConfig(&Options{CAFile: serverKeyFile})
+		assert.Contains(t, err.Error(), ErrInvalidCA.Error())
+
+		_, _, err = ServerConfig(&Options{CertFile: filepath.Join(dir, "not-exist.crt"), KeyFile: serverKeyFile})
+		assert.NotEqual(t, nil, err)
+
+		_, _, err = ServerConfig(&Options{KeyFile: serverKeyFile})
+		assert.Equal(t, ErrCertificateRequired, err)
+
+		_, _, err = ServerConfig(&Options{CertFile: serverCertFile, KeyFile: serverKeyFile, ClientAuth: ClientAuthOptional})
+		assert.Equal(t, ErrCARequired, err)
+
+		_, _, err = ServerConfig(&Options{CertFile: serverCertFile, KeyFile: serverKeyFile, ClientAuth: ClientAuthRequired})
+		assert.Equal(t, ErrCARequired, err)
+	})
+
+	t.Run("should apply the minimum version and the cipher suites", func(t *testing.T) {
+		cfg, _, err := ClientConfig(&Options{
+			MinVersion:   "1.1",
+			CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", " "},
+		})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, uint16(tls.VersionTLS11), cfg.MinVersion)
+		assert.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, cfg.CipherSuites)
+
+		cfg, _, err = ClientConfig(&Options{})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+	})
+
+	t.Run("should stop watching", func(t *testing.T) {
+		reloader, err := NewReloader(serverCertFile, serverKeyFile, caFile)
+		assert.Equal(t, nil, err)
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			reloader.Watch(time.Millisecond, stop, nil)
+			close(done)
+		}()
+		time.Sleep(5 * time.Millisecond)
+		close(stop)
+		<-done
+	})
+}
@@ -0,0 +1,239 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mapstruct
+
+import (
+	"fmt"
+	"github.com/mitchellh/mapstructure"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tagName        = "mapstructure"
+	defaultTagName = "default"
+	unitTagName    = "unit"
+)
+
+var (
+	sizeRegExp = regexp.MustCompile(`(?i)^\s*(\d+(?:\.\d+)?)\s*([KMGTP]?)I?B?\s*$`)
+	// the size of the decode hook requires the B or iB suffix for the lowercase units,
+	// so that the value like 1m of an integer field is not taken as 1 megabyte
+	sizeHookRegExp = regexp.MustCompile(`^\s*\d+(?:\.\d+)?\s*(?:[KMGTP][iI]?[bB]?|[kmgtp][iI]?[bB]|[bB])?\s*$`)
+
+	sizeUnits = map[string]float64{
+		"":  1,
+		"K": 1 << 10,
+		"M": 1 << 20,
+		"G": 1 << 30,
+		"T": 1 << 40,
+		"P": 1 << 50,
+	}
+)
+
+// RelaxedName returns the normalized name, e.g. max-size, max_size and maxSize are all normalized to maxsize
+func RelaxedName(name string) string {
+	name = strings.Replace(name, "-", "", -1)
+	name = strings.Replace(name, "_", "", -1)
+	return strings.ToLower(name)
+}
+
+// ParseSize parse size string to number of bytes, e.g. 10MB => 10485760
+func ParseSize(s string) (size int64, err error) {
+	m := sizeRegExp.FindStringSubmatch(s)
+	if m == nil {
+		err = fmt.Errorf("invalid size: %v", s)
+		return
+	}
+	var val float64
+	val, err = strconv.ParseFloat(m[1], 64)
+	if err == nil {
+		size = int64(val * sizeUnits[strings.ToUpper(m[2])])
+	}
+	return
+}
+
+func fieldName(field reflect.StructField) (name string) {
+	name = field.Name
+	tag := strings.SplitN(field.Tag.Get(tagName), ",", 2)[0]
+	if tag != "" {
+		name = tag
+	}
+	return
+}
+
+func toStringMap(data interface{}) (m map[string]interface{}, ok bool) {
+	switch data.(type) {
+	case map[string]interface{}:
+		m, ok = data.(map[string]interface{}), true
+	case map[interface{}]interface{}:
+		m, ok = make(map[string]interface{}), true
+		for k, v := range data.(map[interface{}]interface{}) {
+			m[fmt.Sprintf("%v", k)] = v
+		}
+	}
+	return
+}
+
+func exportedFields(typ reflect.Type) (fields []reflect.StructField) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath == "" && !(field.Anonymous && field.Type.Kind() == reflect.Interface) {
+			fields = append(fields, field)
+		}
+	}
+	return
+}
+
+// RelaxedNameHook returns the decode hook that maps kebab-case, snake_case or camelCase keys to the struct fields
+func RelaxedNameHook() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if to.Kind() != reflect.Struct {
+			return data, nil
+		}
+		src, ok := toStringMap(data)
+		if !ok {
+			return data, nil
+		}
+		names := make(map[string]string)
+		for _, field := range exportedFields(to) {
+			name := fieldName(field)
+			names[RelaxedName(name)] = name
+		}
+		dst := make(map[string]interface{}, len(src))
+		for key, val := range src {
+			if name, ok := names[RelaxedName(key)]; ok {
+				key = name
+			}
+			dst[key] = val
+		}
+		return dst, nil
+	}
+}
+
+// DefaultValueHook returns the decode hook that fills in the value of the `default` tag if the key is not present,
+// nested structs, slices of structs and maps of structs are all applied, the replace func is used for resolving
+// the references of the default value, e.g. `default:"${app.name}"`
+func DefaultValueHook(replace func(source string) interface{}) mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if to.Kind() != reflect.Struct {
+			return data, nil
+		}
+		src, ok := toStringMap(data)
+		if !ok {
+			return data, nil
+		}
+		keys := make(map[string]bool, len(src))
+		dst := make(map[string]interface{}, len(src))
+		for key, val := range src {
+			keys[RelaxedName(key)] = true
+			dst[key] = val
+		}
+		for _, field := range exportedFields(to) {
+			name := fieldName(field)
+			if keys[RelaxedName(name)] {
+				continue
+			}
+			if def, ok := field.Tag.Lookup(defaultTagName); ok {
+				var val interface{} = def
+				if replace != nil {
+					val = replace(def)
+				}
+				dst[name] = val
+			} else if field.Type.Kind() == reflect.Struct {
+				// make sure that the defaults of nested struct is applied
+				dst[name] = make(map[string]interface{})
+			}
+		}
+		return dst, nil
+	}
+}
+
+// StringToSizeHook returns the decode hook that converts size string to number of bytes, e.g. 10MB => 10485760,
+// the lowercase units must end with B or iB, e.g. 10mb or 10miB, 10m is left to the decoder and reported as invalid
+func StringToSizeHook() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String {
+			return data, nil
+		}
+		switch to.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+			s := data.(string)
+			if _, err := strconv.ParseInt(s, 10, 64); err == nil || !sizeHookRegExp.MatchString(s) {
+				return data, nil
+			}
+			return ParseSize(s)
+		}
+		return data, nil
+	}
+}
+
+// seconds returns the duration of the bare number of seconds, e.g. 5 or "1.5", ok is false if it is not a number
+func seconds(data interface{}) (d time.Duration, ok bool) {
+	var n float64
+	v := reflect.ValueOf(data)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	case reflect.String:
+		var err error
+		if n, err = strconv.ParseFloat(strings.TrimSpace(v.String()), 64); err != nil {
+			return
+		}
+	default:
+		return
+	}
+	return time.Duration(n * float64(time.Second)), true
+}
+
+// SecondsHook returns the decode hook that decodes the bare number of the duration field tagged by unit:"s"
+// as seconds, e.g. timeout: 5 is 5s, the duration string such as 500ms is decoded as it is
+func SecondsHook() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if to.Kind() != reflect.Struct {
+			return data, nil
+		}
+		src, ok := toStringMap(data)
+		if !ok {
+			return data, nil
+		}
+		units := make(map[string]bool)
+		for _, field := range exportedFields(to) {
+			if field.Tag.Get(unitTagName) == "s" {
+				units[RelaxedName(fieldName(field))] = true
+			}
+		}
+		if len(units) == 0 {
+			return data, nil
+		}
+		dst := make(map[string]interface{}, len(src))
+		for key, val := range src {
+			if units[RelaxedName(key)] {
+				if d, ok := seconds(val); ok {
+					val = d
+				}
+			}
+			dst[key] = val
+		}
+		return dst, nil
+	}
+}
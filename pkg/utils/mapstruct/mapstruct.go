@@ -20,14 +20,31 @@ import (
 	"github.com/mitchellh/mapstructure"
 )
 
+// Option is the option of the decoder config
+type Option func(config *mapstructure.DecoderConfig)
+
+// WithDecodeHook append decode hooks to the decoder config
+func WithDecodeHook(hooks ...mapstructure.DecodeHookFunc) Option {
+	return func(config *mapstructure.DecoderConfig) {
+		if config.DecodeHook != nil {
+			hooks = append([]mapstructure.DecodeHookFunc{config.DecodeHook}, hooks...)
+		}
+		config.DecodeHook = mapstructure.ComposeDecodeHookFunc(hooks...)
+	}
+}
+
 // Decode decode (convert) map to struct
-func Decode(to interface{}, from interface{}) error {
+func Decode(to interface{}, from interface{}, opts ...Option) error {
 
 	config := &mapstructure.DecoderConfig{
 		WeaklyTypedInput: true,
 		Result:           to,
 	}
 
+	for _, opt := range opts {
+		opt(config)
+	}
+
 	decoder, err := mapstructure.NewDecoder(config)
 	if err != nil {
 		return err
@@ -39,3 +56,21 @@ func Decode(to interface{}, from interface{}) error {
 
 	return decoder.Decode(from)
 }
+
+// DecodeWithDefaults decodes map to struct as the configuration properties, the values of the `default` tags are
+// applied, the references of the values are resolved by replace, the keys are bound in relaxed way, and the durations,
+// the comma separated slices and the sizes are converted from strings, the bare numbers of the durations tagged by
+// unit:"s" are of seconds, nil map is decoded as an empty one
+func DecodeWithDefaults(to interface{}, from interface{}, replace func(source string) interface{}) error {
+	if from == nil {
+		from = map[string]interface{}{}
+	}
+	return Decode(to, from, WithDecodeHook(
+		DefaultValueHook(replace),
+		RelaxedNameHook(),
+		SecondsHook(),
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		StringToSizeHook(),
+	))
+}
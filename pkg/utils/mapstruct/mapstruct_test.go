@@ -15,8 +15,11 @@
 package mapstruct
 
 import (
+	"github.com/mitchellh/mapstructure"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
+	"time"
 )
 
 type Foo struct {
@@ -46,3 +49,177 @@ func TestDecode(t *testing.T) {
 		assert.Equal(t, "parameters of mapstruct.Decode must not be nil", err.Error())
 	})
 }
+
+type server struct {
+	Host string `default:"localhost"`
+	Port int    `default:"8080"`
+}
+
+type bar struct {
+	Name           string
+	MaxSize        int64
+	ReadTimeout    time.Duration `default:"10s"`
+	LongName       string        `mapstructure:"long_name"`
+	Tags           []string      `default:"a,b"`
+	Server         server
+	Servers        []server
+	NamedServers   map[string]server
+	DefaultMessage string `default:"hello ${name}"`
+}
+
+func TestDecodeWithHooks(t *testing.T) {
+	replace := func(source string) interface{} {
+		return strings.Replace(source, "${name}", "hiboot", -1)
+	}
+	opts := []Option{
+		WithDecodeHook(
+			DefaultValueHook(replace),
+			RelaxedNameHook(),
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+			StringToSizeHook(),
+		),
+	}
+
+	t.Run("should decode with relaxed names", func(t *testing.T) {
+		b := new(bar)
+		err := Decode(b, map[string]interface{}{
+			"name":         "bar",
+			"max-size":     "10MB",
+			"read_timeout": "1m",
+			"long-name":    "long name",
+		}, opts...)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "bar", b.Name)
+		assert.Equal(t, int64(10<<20), b.MaxSize)
+		assert.Equal(t, time.Minute, b.ReadTimeout)
+		assert.Equal(t, "long name", b.LongName)
+	})
+
+	t.Run("should apply default values to nested struct, slice and map", func(t *testing.T) {
+		b := new(bar)
+		err := Decode(b, map[string]interface{}{
+			"servers": []interface{}{
+				map[interface{}]interface{}{"host": "foo"},
+				map[string]interface{}{"port": 9090},
+			},
+			"named-servers": map[string]interface{}{
+				"a": map[string]interface{}{"host": "a.com"},
+			},
+		}, opts...)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 10*time.Second, b.ReadTimeout)
+		assert.Equal(t, []string{"a", "b"}, b.Tags)
+		assert.Equal(t, "hello hiboot", b.DefaultMessage)
+		assert.Equal(t, server{Host: "localhost", Port: 8080}, b.Server)
+		assert.Equal(t, []server{{Host: "foo", Port: 8080}, {Host: "localhost", Port: 9090}}, b.Servers)
+		assert.Equal(t, server{Host: "a.com", Port: 8080}, b.NamedServers["a"])
+	})
+
+	t.Run("should report error with invalid size", func(t *testing.T) {
+		b := new(bar)
+		err := Decode(b, map[string]interface{}{"max-size": "10XB"}, opts...)
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should require the byte suffix for the lowercase size units", func(t *testing.T) {
+		testData := []struct {
+			src  string
+			size int64
+		}{
+			{"1M", 1 << 20},
+			{"1Mi", 1 << 20},
+			{"1mb", 1 << 20},
+			{"1miB", 1 << 20},
+			{"2 kb", 2 << 10},
+			{"512b", 512},
+		}
+		for _, d := range testData {
+			b := new(bar)
+			err := Decode(b, map[string]interface{}{"max-size": d.src}, opts...)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, d.size, b.MaxSize)
+		}
+
+		b := new(bar)
+		err := Decode(b, map[string]interface{}{"max-size": "1m"}, opts...)
+		assert.NotEqual(t, nil, err)
+	})
+}
+
+func TestDecodeWithDefaults(t *testing.T) {
+	replace := func(source string) interface{} {
+		return strings.Replace(source, "${name}", "hiboot", -1)
+	}
+
+	t.Run("should decode nil map with default values", func(t *testing.T) {
+		b := new(bar)
+		err := DecodeWithDefaults(b, nil, replace)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 10*time.Second, b.ReadTimeout)
+		assert.Equal(t, "hello hiboot", b.DefaultMessage)
+		assert.Equal(t, server{Host: "localhost", Port: 8080}, b.Server)
+	})
+
+	t.Run("should decode with relaxed names", func(t *testing.T) {
+		b := new(bar)
+		err := DecodeWithDefaults(b, map[string]interface{}{"max-size": "1KB", "read_timeout": "1m", "tags": "x,y"}, replace)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(1<<10), b.MaxSize)
+		assert.Equal(t, time.Minute, b.ReadTimeout)
+		assert.Equal(t, []string{"x", "y"}, b.Tags)
+	})
+}
+
+func TestSecondsHook(t *testing.T) {
+	type timeouts struct {
+		Timeout time.Duration `unit:"s"`
+		Delay   time.Duration
+	}
+	opts := []Option{WithDecodeHook(SecondsHook(), mapstructure.StringToTimeDurationHookFunc())}
+
+	t.Run("should decode the bare numbers of the field tagged by unit as seconds", func(t *testing.T) {
+		for _, value := range []interface{}{5, int64(5), 5.0, "5"} {
+			to := new(timeouts)
+			err := Decode(to, map[string]interface{}{"timeout": value, "delay": 5}, opts...)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, 5*time.Second, to.Timeout)
+			assert.Equal(t, time.Duration(5), to.Delay)
+		}
+	})
+
+	t.Run("should decode the duration string as it is", func(t *testing.T) {
+		to := new(timeouts)
+		err := Decode(to, map[string]interface{}{"timeout": "1.5s"}, opts...)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1500*time.Millisecond, to.Timeout)
+	})
+}
+
+func TestParseSize(t *testing.T) {
+	testData := []struct {
+		src  string
+		size int64
+	}{
+		{"100", 100},
+		{"1KB", 1 << 10},
+		{"1.5K", 1536},
+		{"10MB", 10 << 20},
+		{"2 GiB", 2 << 30},
+		{"1tb", 1 << 40},
+	}
+	for _, d := range testData {
+		size, err := ParseSize(d.src)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, d.size, size)
+	}
+
+	_, err := ParseSize("ten")
+	assert.NotEqual(t, nil, err)
+}
+
+func TestRelaxedName(t *testing.T) {
+	assert.Equal(t, "maxsize", RelaxedName("max-size"))
+	assert.Equal(t, "maxsize", RelaxedName("max_size"))
+	assert.Equal(t, "maxsize", RelaxedName("maxSize"))
+}
@@ -0,0 +1,34 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pathmatch provides the path pattern matching that is shared by the security, authorization and rate limit rules
+package pathmatch
+
+import (
+	"path"
+	"strings"
+)
+
+const anySubPath = "/**"
+
+// Match returns true if the path matches the pattern, the pattern ends with /** matches the prefix and its sub paths,
+// e.g. /api/** matches /api and /api/foo/bar, the other patterns are matched by path.Match, e.g. /user/*
+func Match(pattern, p string) bool {
+	if strings.HasSuffix(pattern, anySubPath) {
+		prefix := strings.TrimSuffix(pattern, anySubPath)
+		return p == prefix || strings.HasPrefix(p, prefix+"/")
+	}
+	matched, _ := path.Match(pattern, p)
+	return matched || pattern == p
+}
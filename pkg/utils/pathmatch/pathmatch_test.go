@@ -0,0 +1,43 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pathmatch
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"should match the prefix of /**", "/api/**", "/api", true},
+		{"should match the sub paths of /**", "/api/**", "/api/foo/bar", true},
+		{"should not match the path that only shares the prefix", "/api/**", "/apis", false},
+		{"should match all paths by /**", "/**", "/foo", true},
+		{"should match the wildcard", "/user/*", "/user/123", true},
+		{"should not match the wildcard across the separator", "/user/*", "/user/123/orders", false},
+		{"should match the route template", "/order/{id}", "/order/{id}", true},
+		{"should match the invalid pattern exactly", "/[", "/[", true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, Match(testCase.pattern, testCase.path))
+		})
+	}
+}
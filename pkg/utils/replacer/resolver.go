@@ -0,0 +1,345 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replacer
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"hidevops.io/hiboot/pkg/utils/str"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	placeholderPrefix = "${"
+	placeholderSuffix = "}"
+	escapeChar        = '$'
+	defaultSeparator  = ':'
+	randomPrefix      = "random."
+	fileExpression    = "file"
+)
+
+var (
+	// ErrCircularReference the placeholder refers to itself directly or indirectly
+	ErrCircularReference = errors.New("[replacer] circular reference")
+	// ErrInvalidExpression the expression is not supported
+	ErrInvalidExpression = errors.New("[replacer] invalid expression")
+
+	randomRegExp = regexp.MustCompile(`^(int|long)(?:\(\s*(-?\d+)\s*(?:,\s*(-?\d+)\s*)?\))?$`)
+)
+
+// Literal is the resolved string that keeps the escaped placeholders, e.g. $${name} is resolved to Literal("${name}"),
+// the Literal that lookup returns is not resolved again, so the escaped placeholders are kept through the references
+type Literal string
+
+// Resolver resolves the property placeholders like ${name:default}
+//
+// The placeholders are resolved recursively, e.g. ${a.${env}.url} or ${name:${nickname:anonymous}},
+// a placeholder that refers to itself directly or indirectly is reported as ErrCircularReference.
+// $${name} is escaped to the literal ${name}.
+//
+// The expressions below are supported as well:
+//
+//	${random.uuid}         random uuid
+//	${random.int}          random non-negative int
+//	${random.int(10)}      random int in [0, 10)
+//	${random.int(1,10)}    random int in [1, 10)
+//	${random.long}         random non-negative int64, random.long(max) and random.long(min,max) are supported too
+//	${file:path}           the trimmed content of the file
+type Resolver interface {
+	// Resolve resolves the placeholders of source, if source is exactly one placeholder,
+	// the value is returned as it is, e.g. an int or a slice, otherwise the resolved string is returned,
+	// or the Literal if it keeps the escaped placeholders
+	Resolve(source string) (retVal interface{}, err error)
+}
+
+type resolver struct {
+	lookup func(name string) interface{}
+}
+
+// NewResolver is the constructor of Resolver, lookup returns the property by name, or nil if it does not exist,
+// the environment variables are used if the property does not exist
+func NewResolver(lookup func(name string) interface{}) Resolver {
+	return &resolver{lookup: lookup}
+}
+
+// Resolve resolves the placeholders of source
+func (r *resolver) Resolve(source string) (retVal interface{}, err error) {
+	return r.resolve(source, nil)
+}
+
+func (r *resolver) resolve(source string, visiting []string) (retVal interface{}, err error) {
+	var buf strings.Builder
+	var escaped bool
+	i := 0
+	for {
+		n := strings.Index(source[i:], placeholderPrefix)
+		if n < 0 {
+			buf.WriteString(source[i:])
+			break
+		}
+		start := i + n
+		end := closingIndex(source, start+len(placeholderPrefix))
+		if end < 0 {
+			// unclosed placeholder is kept as it is
+			buf.WriteString(source[i:])
+			break
+		}
+
+		// $${name} is escaped to ${name}
+		if start > 0 && source[start-1] == escapeChar {
+			buf.WriteString(source[i : start-1])
+			buf.WriteString(source[start : end+1])
+			escaped = true
+			i = end + 1
+			continue
+		}
+
+		buf.WriteString(source[i:start])
+		var val interface{}
+		var ok bool
+		val, ok, err = r.placeholder(source[start+len(placeholderPrefix):end], visiting)
+		if err != nil {
+			return
+		}
+		switch {
+		case !ok:
+			// unresolvable placeholder is kept as it is
+			buf.WriteString(source[start : end+1])
+		case start == 0 && end == len(source)-1:
+			retVal = val
+			return
+		default:
+			_, isLiteral := val.(Literal)
+			escaped = escaped || isLiteral
+			buf.WriteString(fmt.Sprintf("%v", val))
+		}
+		i = end + 1
+	}
+	if escaped {
+		retVal = Literal(buf.String())
+	} else {
+		retVal = buf.String()
+	}
+	return
+}
+
+func (r *resolver) resolveString(source string, visiting []string) (retVal string, err error) {
+	var val interface{}
+	val, err = r.resolve(source, visiting)
+	if err == nil {
+		retVal = fmt.Sprintf("%v", val)
+	}
+	return
+}
+
+func (r *resolver) placeholder(expr string, visiting []string) (retVal interface{}, ok bool, err error) {
+	name, defaultValue, hasDefault := splitDefault(expr)
+	name, err = r.resolveString(name, visiting)
+	if err != nil {
+		return
+	}
+
+	switch {
+	case name == fileExpression && hasDefault:
+		var path string
+		path, err = r.resolveString(defaultValue, visiting)
+		if err == nil {
+			retVal, err = readFile(path)
+			ok = err == nil
+		}
+		return
+	case strings.HasPrefix(name, randomPrefix):
+		retVal, err = random(name[len(randomPrefix):])
+		ok = err == nil
+		return
+	}
+
+	for _, v := range visiting {
+		if v == name {
+			err = fmt.Errorf("%v: %v -> %v", ErrCircularReference, strings.Join(visiting, " -> "), name)
+			return
+		}
+	}
+
+	var prop interface{}
+	if r.lookup != nil {
+		prop = r.lookup(name)
+	}
+	if prop != nil {
+		ok = true
+		switch p := prop.(type) {
+		case Literal:
+			retVal = p
+		case string:
+			path := make([]string, len(visiting), len(visiting)+1)
+			copy(path, visiting)
+			retVal, err = r.resolve(p, append(path, name))
+		default:
+			retVal = prop
+		}
+		return
+	}
+
+	envValue := os.Getenv(name)
+	// check if name == strings.ToUpper(name), then assume that name is environment variable
+	if envValue != "" || (name == strings.ToUpper(name) && !hasDefault) {
+		retVal, ok = envValue, true
+		return
+	}
+
+	if hasDefault {
+		retVal, err = r.resolve(defaultValue, visiting)
+		ok = err == nil
+	}
+	return
+}
+
+// closingIndex returns the index of the suffix that closes the placeholder started before from, or -1
+func closingIndex(source string, from int) int {
+	depth := 1
+	for i := from; i < len(source); i++ {
+		if strings.HasPrefix(source[i:], placeholderPrefix) {
+			depth++
+			i += len(placeholderPrefix) - 1
+		} else if strings.HasPrefix(source[i:], placeholderSuffix) {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitDefault splits name:default on the first separator that is not inside a nested placeholder
+func splitDefault(expr string) (name, defaultValue string, ok bool) {
+	depth := 0
+	for i := 0; i < len(expr); i++ {
+		switch {
+		case strings.HasPrefix(expr[i:], placeholderPrefix):
+			depth++
+			i += len(placeholderPrefix) - 1
+		case strings.HasPrefix(expr[i:], placeholderSuffix):
+			depth--
+		case expr[i] == defaultSeparator && depth == 0:
+			return expr[:i], expr[i+1:], true
+		}
+	}
+	return expr, "", false
+}
+
+// Split splits source by sep, the separators inside the placeholders are ignored,
+// e.g. a=${random.int(1,10)},b=c is split to a=${random.int(1,10)} and b=c
+func Split(source string, sep byte) (retVal []string) {
+	depth := 0
+	start := 0
+	for i := 0; i < len(source); i++ {
+		switch {
+		case strings.HasPrefix(source[i:], placeholderPrefix):
+			depth++
+			i += len(placeholderPrefix) - 1
+		case strings.HasPrefix(source[i:], placeholderSuffix) && depth > 0:
+			depth--
+		case source[i] == sep && depth == 0:
+			retVal = append(retVal, source[start:i])
+			start = i + 1
+		}
+	}
+	retVal = append(retVal, source[start:])
+	return
+}
+
+// Convert converts the resolved value to the kind, only the string value is converted
+func Convert(val interface{}, kind reflect.Kind) (retVal interface{}) {
+	retVal = val
+	switch val.(type) {
+	case string:
+		retVal = str.Convert(val.(string), kind)
+	default:
+		if val != nil && kind == reflect.String {
+			retVal = fmt.Sprintf("%v", val)
+		}
+	}
+	return
+}
+
+func readFile(path string) (retVal string, err error) {
+	var b []byte
+	b, err = ioutil.ReadFile(path)
+	if err == nil {
+		retVal = strings.TrimSpace(string(b))
+	}
+	return
+}
+
+func random(expr string) (retVal interface{}, err error) {
+	if expr == "uuid" {
+		return uuid()
+	}
+
+	m := randomRegExp.FindStringSubmatch(expr)
+	if m == nil {
+		err = fmt.Errorf("%v: random.%v", ErrInvalidExpression, expr)
+		return
+	}
+
+	min, max := int64(0), int64(math.MaxInt64)
+	if m[1] == "int" {
+		max = math.MaxInt32
+	}
+	if m[3] != "" {
+		min, _ = strconv.ParseInt(m[2], 10, 64)
+		max, _ = strconv.ParseInt(m[3], 10, 64)
+	} else if m[2] != "" {
+		max, _ = strconv.ParseInt(m[2], 10, 64)
+	}
+	if max <= min {
+		err = fmt.Errorf("%v: random.%v, max must be greater than min", ErrInvalidExpression, expr)
+		return
+	}
+
+	var n *big.Int
+	n, err = rand.Int(rand.Reader, new(big.Int).Sub(big.NewInt(max), big.NewInt(min)))
+	if err != nil {
+		return
+	}
+	val := n.Int64() + min
+	if m[1] == "int" {
+		retVal = int(val)
+	} else {
+		retVal = val
+	}
+	return
+}
+
+func uuid() (retVal string, err error) {
+	b := make([]byte, 16)
+	_, err = rand.Read(b)
+	if err == nil {
+		// version 4, variant RFC 4122
+		b[6] = (b[6] & 0x0f) | 0x40
+		b[8] = (b[8] & 0x3f) | 0x80
+		retVal = fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	}
+	return
+}
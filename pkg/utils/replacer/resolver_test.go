@@ -0,0 +1,149 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replacer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestResolver(t *testing.T) {
+	props := map[string]interface{}{
+		"app.name":       "hiboot",
+		"app.env":        "dev",
+		"app.nickname":   "${app.name}-app",
+		"app.port":       8080,
+		"app.profiles":   []string{"foo", "bar"},
+		"db.dev.url":     "jdbc://${app.nickname}",
+		"circular.a":     "${circular.b}",
+		"circular.b":     "${circular.c}",
+		"circular.c":     "${circular.a}",
+		"self.reference": "${self.reference}",
+		"app.escaped":    "$${app.name}",
+		"app.chained":    "${app.escaped}",
+		"app.literal":    Literal("${app.name}"),
+	}
+	r := NewResolver(func(name string) interface{} {
+		return props[name]
+	})
+
+	os.Setenv("RESOLVER_TEST_ENV", "env-value")
+	defer os.Unsetenv("RESOLVER_TEST_ENV")
+
+	testCases := []struct {
+		name     string
+		source   string
+		expected interface{}
+	}{
+		{"should return source without placeholder", "hello", "hello"},
+		{"should resolve property", "this is ${app.name}", "this is hiboot"},
+		{"should resolve property recursively", "${db.dev.url}", "jdbc://hiboot-app"},
+		{"should resolve nested placeholder", "${db.${app.env}.url}", "jdbc://hiboot-app"},
+		{"should resolve default value", "${app.unknown:default}", "default"},
+		{"should resolve nested default value", "${app.unknown:${app.missing:${app.name}}}", "hiboot"},
+		{"should resolve empty default value", "[${app.unknown:}]", "[]"},
+		{"should return typed value", "${app.port}", 8080},
+		{"should return slice", "${app.profiles}", []string{"foo", "bar"}},
+		{"should format typed value in string", "port: ${app.port}", "port: 8080"},
+		{"should resolve environment variable", "${RESOLVER_TEST_ENV}", "env-value"},
+		{"should resolve undefined environment variable to empty", "[${RESOLVER_TEST_UNDEFINED}]", "[]"},
+		{"should keep unresolvable placeholder", "${app.unknown}", "${app.unknown}"},
+		{"should keep unclosed placeholder", "${app.name", "${app.name"},
+		{"should escape placeholder", "$${app.name} is ${app.name}", Literal("${app.name} is hiboot")},
+		{"should keep escaped placeholder through references", "${app.chained} is ${app.name}", Literal("${app.name} is hiboot")},
+		{"should not resolve literal again", "${app.literal}", Literal("${app.name}")},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			res, err := r.Resolve(testCase.source)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, testCase.expected, res)
+		})
+	}
+
+	t.Run("should report circular reference", func(t *testing.T) {
+		_, err := r.Resolve("${circular.a}")
+		assert.Contains(t, err.Error(), ErrCircularReference.Error())
+
+		_, err = r.Resolve("${self.reference}")
+		assert.Contains(t, err.Error(), ErrCircularReference.Error())
+	})
+
+	t.Run("should resolve random uuid", func(t *testing.T) {
+		res, err := r.Resolve("${random.uuid}")
+		assert.Equal(t, nil, err)
+		assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), res)
+	})
+
+	t.Run("should resolve random int in range", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			res, err := r.Resolve("${random.int(1,10)}")
+			assert.Equal(t, nil, err)
+			n := res.(int)
+			assert.True(t, n >= 1 && n < 10)
+		}
+
+		res, err := r.Resolve("${random.int(5)}")
+		assert.Equal(t, nil, err)
+		assert.True(t, res.(int) < 5)
+
+		res, err = r.Resolve("${random.long}")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, reflect.Int64, reflect.TypeOf(res).Kind())
+	})
+
+	t.Run("should report invalid random expression", func(t *testing.T) {
+		_, err := r.Resolve("${random.int(10,1)}")
+		assert.Contains(t, err.Error(), ErrInvalidExpression.Error())
+
+		_, err = r.Resolve("${random.float}")
+		assert.Contains(t, err.Error(), ErrInvalidExpression.Error())
+	})
+
+	t.Run("should resolve file content", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "resolver")
+		assert.Equal(t, nil, err)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "secret")
+		err = ioutil.WriteFile(path, []byte("s3cr3t\n"), 0644)
+		assert.Equal(t, nil, err)
+
+		res, err := r.Resolve("password: ${file:" + path + "}")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "password: s3cr3t", res)
+
+		_, err = r.Resolve("${file:" + filepath.Join(dir, "not-exist") + "}")
+		assert.NotEqual(t, nil, err)
+	})
+}
+
+func TestSplit(t *testing.T) {
+	assert.Equal(t, []string{"a=${random.int(1,10)}", "b=${c:d,e}", "f"}, Split("a=${random.int(1,10)},b=${c:d,e},f", ','))
+	assert.Equal(t, []string{""}, Split("", ','))
+}
+
+func TestConvert(t *testing.T) {
+	assert.Equal(t, 8080, Convert("8080", reflect.Int))
+	assert.Equal(t, true, Convert("true", reflect.Bool))
+	assert.Equal(t, "8080", Convert(8080, reflect.String))
+	assert.Equal(t, []string{"a", "b"}, Convert("a,b", reflect.Slice))
+	assert.Equal(t, []string{"a", "b"}, Convert([]string{"a", "b"}, reflect.Slice))
+}